@@ -1,11 +1,19 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -21,19 +29,49 @@ func main() {
 
 	// Parse optional flags (skeleton is default for better user experience)
 	useSkeleton := true
+	useLang2 := false
+	paletteIndex := fileio.TIM_PALETTE_AUTO
+	timOptions := fileio.TIMEncodeOptions{BPP: fileio.TIM_BPP_8}
 	if len(os.Args) > 4 {
 		for _, arg := range os.Args[4:] {
+			if strings.HasPrefix(arg, "--palette=") {
+				value, err := strconv.Atoi(strings.TrimPrefix(arg, "--palette="))
+				if err != nil {
+					fmt.Printf("Error: Invalid palette index '%s'\n", arg)
+					os.Exit(1)
+				}
+				paletteIndex = value
+				continue
+			}
+			if strings.HasPrefix(arg, "--bpp=") {
+				switch strings.TrimPrefix(arg, "--bpp=") {
+				case "4":
+					timOptions.BPP = fileio.TIM_BPP_4
+				case "8":
+					timOptions.BPP = fileio.TIM_BPP_8
+				case "16":
+					timOptions.BPP = fileio.TIM_BPP_16
+				default:
+					fmt.Printf("Error: Invalid BPP '%s', expected 4, 8 or 16\n", arg)
+					os.Exit(1)
+				}
+				continue
+			}
 			switch arg {
 			case "--skeleton", "-s":
 				useSkeleton = true
 			case "--raw", "-r":
 				useSkeleton = false
+			case "--lang2":
+				useLang2 = true
+			case "--stp":
+				timOptions.SemiTransparent = true
 			}
 		}
 	}
 
 	// Check if input file exists
-	if _, err := os.Stat(inputFilename); os.IsNotExist(err) {
+	if !resource.LocalFileExists(inputFilename) {
 		fmt.Printf("Error: Input file '%s' does not exist\n", inputFilename)
 		os.Exit(1)
 	}
@@ -47,18 +85,38 @@ func main() {
 
 	switch toolName {
 	case "tim2png":
-		convertTIMToPNG(inputFilename, outputFilename)
+		convertTIMToPNG(inputFilename, outputFilename, paletteIndex)
 	case "adt2png":
 		convertADTToPNG(inputFilename, outputFilename)
+	case "png2tim":
+		convertPNGToTIM(inputFilename, outputFilename, timOptions)
+	case "png2adt":
+		convertPNGToADT(inputFilename, outputFilename)
 	case "sap2wav":
 		convertSAPToWAV(inputFilename, outputFilename)
+	case "vab2wav":
+		convertVABToWAV(inputFilename, outputFilename)
+	case "vab2sf2":
+		convertVABToSF2(inputFilename, outputFilename)
+	case "msg2txt":
+		convertMSGToTXT(inputFilename, outputFilename, useLang2)
+	case "txt2msg":
+		convertTXTToMSG(inputFilename, outputFilename)
+	case "txt2scd":
+		convertTXTToSCD(inputFilename, outputFilename)
 	case "pld2obj":
 		convertPLDToOBJ(inputFilename, outputFilename, useSkeleton)
 	case "emd2obj":
 		convertEMDToOBJ(inputFilename, outputFilename, useSkeleton)
+	case "pld2gltf":
+		convertPLDToGLTF(inputFilename, outputFilename)
+	case "emd2gltf":
+		convertEMDToGLTF(inputFilename, outputFilename)
+	case "rdt2gltf":
+		convertRDTToGLTF(inputFilename, outputFilename)
 	default:
 		fmt.Printf("Error: Invalid tool name '%s'\n", toolName)
-		fmt.Println("Supported tools: tim2png, adt2png, sap2wav, pld2obj, emd2obj")
+		fmt.Println("Supported tools: tim2png, adt2png, png2tim, png2adt, sap2wav, vab2wav, vab2sf2, msg2txt, txt2msg, txt2scd, pld2obj, emd2obj, pld2gltf, emd2gltf, rdt2gltf")
 		os.Exit(1)
 	}
 }
@@ -71,27 +129,58 @@ func printUsage() {
 	fmt.Println("Supported tools:")
 	fmt.Println("  tim2png  - Convert TIM texture to PNG")
 	fmt.Println("  adt2png  - Convert ADT image to PNG") 
+	fmt.Println("  png2tim  - Convert PNG to TIM texture")
+	fmt.Println("  png2adt  - Convert 320x240 PNG to ADT image")
 	fmt.Println("  sap2wav  - Convert SAP audio to WAV")
+	fmt.Println("  vab2wav  - Convert VAB (.vh + .vb) waveforms to one WAV per waveform")
+	fmt.Println("  vab2sf2  - Convert VAB (.vh + .vb) to a SoundFont")
+	fmt.Println("  msg2txt  - Convert MSG messages (or the messages in an RDT) to text")
+	fmt.Println("  txt2msg  - Convert text back to MSG messages")
+	fmt.Println("  txt2scd  - Assemble script text into SCD bytecode")
 	fmt.Println("  pld2obj  - Convert PLD mesh to OBJ")
 	fmt.Println("  emd2obj  - Convert EMD mesh to OBJ")
+	fmt.Println("  pld2gltf - Convert PLD model with skeleton and animations to binary glTF (.glb)")
+	fmt.Println("  emd2gltf - Convert EMD model with skeleton and animations to binary glTF (.glb)")
+	fmt.Println("  rdt2gltf - Convert room collision, camera switches, triggers, cameras and item models to binary glTF (.glb)")
 	fmt.Println("")
 	fmt.Println("OBJ Export Flags:")
 	fmt.Println("  --skeleton, -s  - Use skeleton data for full character model (default)")
 	fmt.Println("  --raw, -r       - Export raw MD1 data without skeleton")
 	fmt.Println("")
+	fmt.Println("TIM Export Flags:")
+	fmt.Println("  --palette=N     - Use palette N for the whole image instead of one palette per column")
+	fmt.Println("")
+	fmt.Println("TIM Import Flags:")
+	fmt.Println("  --bpp=N         - Bits per pixel of the TIM: 4, 8 (default) or 16")
+	fmt.Println("  --stp           - Set the semi-transparency bit on every opaque color")
+	fmt.Println("")
+	fmt.Println("MSG Export Flags:")
+	fmt.Println("  --lang2         - Read the second language messages from an RDT")
+	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("  fileconv tim2png data/Pl0/Emd0/EM000.TIM em000.png")
+	fmt.Println("  fileconv tim2png data/Pl0/Emd0/EM000.TIM em000.png --palette=1")
 	fmt.Println("  fileconv adt2png data/Pl0/Emd0/EM000.ADT em000.png")
+	fmt.Println("  fileconv png2tim em000.png EM000.TIM --bpp=4")
+	fmt.Println("  fileconv png2adt title.png TITLE.ADT")
 	fmt.Println("  fileconv sap2wav data/Pl0/Voice/STAGE0/0000.SAP voice.wav")
+	fmt.Println("  fileconv vab2wav door00/door00.vh door00_wav")
+	fmt.Println("  fileconv vab2sf2 door00/door00.vh door00.sf2")
+	fmt.Println("  fileconv msg2txt data/Pl0/Rdt/ROOM1000.RDT room1000.txt")
+	fmt.Println("  fileconv txt2msg room1000.txt room1000.msg")
+	fmt.Println("  fileconv txt2scd room1000_init.txt room1000_init.scd")
 	fmt.Println("  fileconv pld2obj data/PL0/PLD/PL00.PLD leon.obj")
 	fmt.Println("  fileconv emd2obj data/PL0/EMD0/EM000.EMD enemy.obj --raw")
+	fmt.Println("  fileconv pld2gltf data/PL0/PLD/PL00.PLD leon.glb")
+	fmt.Println("  fileconv emd2gltf data/PL0/EMD0/EM010.EMD zombie.glb")
+	fmt.Println("  fileconv rdt2gltf data/Pl0/Rdt/ROOM1000.RDT room1000.glb")
 	fmt.Println("")
 	fmt.Printf("Error: You provided %d arguments, but 4 are required\n", len(os.Args))
 }
 
-func convertTIMToPNG(inputFilename, outputFilename string) {
+func convertTIMToPNG(inputFilename, outputFilename string, paletteIndex int) {
 	fmt.Println("Loading TIM file...")
-	timOutput, err := fileio.LoadTIMFile(inputFilename)
+	timOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadTIMStream)
 	if err != nil {
 		fmt.Printf("Error loading TIM file: %v\n", err)
 		os.Exit(1)
@@ -100,7 +189,7 @@ func convertTIMToPNG(inputFilename, outputFilename string) {
 		timOutput.ImageWidth, timOutput.ImageHeight, timOutput.NumPalettes)
 	
 	fmt.Println("Converting to PNG...")
-	if err := timOutput.ConvertToPNG(outputFilename); err != nil {
+	if err := timOutput.ConvertToPNGWithPalette(outputFilename, paletteIndex); err != nil {
 		fmt.Printf("Error converting to PNG: %v\n", err)
 		os.Exit(1)
 	}
@@ -109,7 +198,7 @@ func convertTIMToPNG(inputFilename, outputFilename string) {
 
 func convertADTToPNG(inputFilename, outputFilename string) {
 	fmt.Println("Loading ADT file...")
-	adtOutput, err := fileio.LoadADTFile(inputFilename)
+	adtOutput, err := resource.LoadLocalFile(inputFilename, loadADTStream)
 	if err != nil {
 		fmt.Printf("Error loading ADT file: %v\n", err)
 		os.Exit(1)
@@ -121,9 +210,55 @@ func convertADTToPNG(inputFilename, outputFilename string) {
 	fmt.Printf("Successfully converted to %s\n", outputFilename)
 }
 
+// loadADTStream adapts fileio.LoadADTStream to the loader signature used by resource.LoadLocalFile
+func loadADTStream(r io.ReaderAt, fileLength int64) (*fileio.ADTOutput, error) {
+	return fileio.LoadADTStream(io.NewSectionReader(r, 0, fileLength))
+}
+
+func convertPNGToTIM(inputFilename, outputFilename string, options fileio.TIMEncodeOptions) {
+	fmt.Println("Loading PNG file...")
+	img := loadPNGFile(inputFilename)
+
+	fmt.Println("Converting to TIM...")
+	if err := fileio.ConvertImageToTIMFile(img, options, outputFilename); err != nil {
+		fmt.Printf("Error converting to TIM: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully converted to %s\n", outputFilename)
+}
+
+func convertPNGToADT(inputFilename, outputFilename string) {
+	fmt.Println("Loading PNG file...")
+	img := loadPNGFile(inputFilename)
+
+	fmt.Println("Converting to ADT...")
+	if err := fileio.ConvertImageToADTFile(img, outputFilename); err != nil {
+		fmt.Printf("Error converting to ADT: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully converted to %s\n", outputFilename)
+}
+
+func loadPNGFile(inputFilename string) image.Image {
+	pngFile, err := os.Open(inputFilename)
+	if err != nil {
+		fmt.Printf("Error loading PNG file: %v\n", err)
+		os.Exit(1)
+	}
+	defer pngFile.Close()
+
+	img, err := png.Decode(pngFile)
+	if err != nil {
+		fmt.Printf("Error decoding PNG file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("PNG file loaded: %dx%d pixels\n", img.Bounds().Dx(), img.Bounds().Dy())
+	return img
+}
+
 func convertSAPToWAV(inputFilename, outputFilename string) {
 	fmt.Println("Loading SAP file...")
-	sapOutput, err := fileio.LoadSAPFile(inputFilename)
+	sapOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadSAPStream)
 	if err != nil {
 		fmt.Printf("Error loading SAP file: %v\n", err)
 		os.Exit(1)
@@ -135,9 +270,157 @@ func convertSAPToWAV(inputFilename, outputFilename string) {
 	fmt.Printf("Successfully converted to %s\n", outputFilename)
 }
 
+func convertVABToWAV(inputFilename, outputFolder string) {
+	fmt.Println("Loading VAB files...")
+	vabHeaderOutput, vabDataOutput := loadVABFiles(inputFilename)
+
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		fmt.Printf("Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Decoding waveforms...")
+	samples := vabDataOutput.DecodeWaveforms()
+	inputBase := strings.TrimSuffix(filepath.Base(inputFilename), filepath.Ext(inputFilename))
+	for i, sample := range samples {
+		wavFilename := filepath.Join(outputFolder, fmt.Sprintf("%s_%03d.wav", inputBase, i+1))
+		if err := sample.ConvertToWAV(wavFilename, fileio.SPU_SAMPLE_RATE); err != nil {
+			fmt.Printf("Error writing WAV: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("  Waveform %d: %d samples, looping: %v -> %s\n", i+1, len(sample.PCMData), sample.Looping, filepath.Base(wavFilename))
+	}
+	fmt.Printf("Successfully converted %d waveforms of %d programs to %s\n", len(samples), vabHeaderOutput.VABHeader.ProgramCount, outputFolder)
+}
+
+func convertVABToSF2(inputFilename, outputFilename string) {
+	fmt.Println("Loading VAB files...")
+	vabHeaderOutput, vabDataOutput := loadVABFiles(inputFilename)
+
+	fmt.Println("Converting to SoundFont...")
+	bankName := strings.TrimSuffix(filepath.Base(inputFilename), filepath.Ext(inputFilename))
+	if err := fileio.ConvertVABToSF2(outputFilename, bankName, vabHeaderOutput, vabDataOutput); err != nil {
+		fmt.Printf("Error converting to SF2: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully converted to %s\n", outputFilename)
+}
+
+// loadVABFiles loads a .vh file and the .vb file next to it with the same name
+func loadVABFiles(headerFilename string) (*fileio.VABHeaderOutput, *fileio.VABDataOutput) {
+	dataFilename := strings.TrimSuffix(headerFilename, filepath.Ext(headerFilename)) + ".vb"
+	if !resource.LocalFileExists(dataFilename) {
+		fmt.Printf("Error: VAB data file not found for %s\n", headerFilename)
+		os.Exit(1)
+	}
+
+	vabHeaderOutput, err := resource.LoadLocalFile(headerFilename, fileio.LoadVABHeaderStream)
+	if err != nil {
+		fmt.Printf("Error loading VAB header: %v\n", err)
+		os.Exit(1)
+	}
+	vabDataOutput, err := resource.LoadLocalFile(dataFilename, func(r io.ReaderAt, fileLength int64) (*fileio.VABDataOutput, error) {
+		return fileio.LoadVABDataStream(r, fileLength, vabHeaderOutput)
+	})
+	if err != nil {
+		fmt.Printf("Error loading VAB data: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("VAB files loaded: %d programs, %d tones, %d waveforms\n",
+		vabHeaderOutput.VABHeader.ProgramCount, vabHeaderOutput.VABHeader.ToneCount, len(vabDataOutput.RawADPCMData))
+	return vabHeaderOutput, vabDataOutput
+}
+
+func convertMSGToTXT(inputFilename, outputFilename string, useLang2 bool) {
+	var msgOutput *fileio.MSGOutput
+	if strings.EqualFold(filepath.Ext(inputFilename), ".rdt") {
+		fmt.Println("Loading RDT file...")
+		rdtOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadRDT)
+		if err != nil {
+			if rdtOutput == nil || !errors.Is(err, fileio.ErrUnknownOpcode) {
+				fmt.Printf("Error loading RDT file: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Warning: %v\n", err)
+		}
+		msgOutput = rdtOutput.Lang1MessageData
+		if useLang2 {
+			msgOutput = rdtOutput.Lang2MessageData
+		}
+	} else {
+		fmt.Println("Loading MSG file...")
+		var err error
+		msgOutput, err = resource.LoadLocalFile(inputFilename, fileio.LoadRDT_MSGStream)
+		if err != nil {
+			fmt.Printf("Error loading MSG file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if msgOutput == nil {
+		fmt.Println("Error: no messages found")
+		os.Exit(1)
+	}
+	fmt.Printf("Messages loaded: %d messages\n", len(msgOutput.Messages))
+
+	fmt.Println("Converting to text...")
+	if err := os.WriteFile(outputFilename, []byte(msgOutput.FormatText()), 0644); err != nil {
+		fmt.Printf("Error writing text: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully converted to %s\n", outputFilename)
+}
+
+func convertTXTToMSG(inputFilename, outputFilename string) {
+	fmt.Println("Loading text file...")
+	text, err := os.ReadFile(inputFilename)
+	if err != nil {
+		fmt.Printf("Error loading text file: %v\n", err)
+		os.Exit(1)
+	}
+	msgOutput, err := fileio.ParseMSGText(string(text))
+	if err != nil {
+		fmt.Printf("Error parsing messages: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Text file loaded: %d messages\n", len(msgOutput.Messages))
+
+	fmt.Println("Converting to MSG...")
+	msgData, err := msgOutput.Encode()
+	if err != nil {
+		fmt.Printf("Error encoding messages: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outputFilename, msgData, 0644); err != nil {
+		fmt.Printf("Error writing MSG: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully converted to %s\n", outputFilename)
+}
+
+func convertTXTToSCD(inputFilename, outputFilename string) {
+	fmt.Println("Loading script text...")
+	text, err := os.ReadFile(inputFilename)
+	if err != nil {
+		fmt.Printf("Error loading text file: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Assembling script...")
+	scdData, err := script.AssembleScript(string(text))
+	if err != nil {
+		fmt.Printf("Error assembling script: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outputFilename, scdData, 0644); err != nil {
+		fmt.Printf("Error writing SCD: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully assembled %d bytes to %s\n", len(scdData), outputFilename)
+}
+
 func convertPLDToOBJ(inputFilename, outputFilename string, useSkeleton bool) {
 	fmt.Println("Loading PLD file...")
-	pld, err := fileio.LoadPLDFile(inputFilename)
+	pld, err := resource.LoadLocalFile(inputFilename, fileio.LoadPLDStream)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -198,9 +481,9 @@ func convertPLDToOBJ(inputFilename, outputFilename string, useSkeleton bool) {
 
 func convertEMDToOBJ(inputFilename, outputFilename string, useSkeleton bool) {
 	fmt.Println("Loading EMD file...")
-	emd := fileio.LoadEMDFile(inputFilename)
-	if emd == nil {
-		fmt.Println("Error: failed to load EMD")
+	emd, err := resource.LoadLocalFile(inputFilename, fileio.LoadEMDStream)
+	if err != nil {
+		fmt.Printf("Error: failed to load EMD: %v\n", err)
 		os.Exit(1)
 	}
 	if emd.MeshData == nil {
@@ -213,33 +496,19 @@ func convertEMDToOBJ(inputFilename, outputFilename string, useSkeleton bool) {
 	// Export single texture file
 	texturePNG := outputFilename[:len(outputFilename)-4] + ".png"
 	
-	// For EMD files, we need to load the corresponding TIM file
-	// Assume TIM file is in the same directory with same base name
-	timPath := inputFilename[:len(inputFilename)-4] + ".TIM"
-	if _, err := os.Stat(timPath); err == nil {
-		fmt.Println("Loading TIM texture...")
-		timOutput, err := fileio.LoadTIMFile(timPath)
-		if err == nil {
-			fmt.Println("Exporting texture...")
-			// Ensure output directory exists
-			if err := os.MkdirAll(filepath.Dir(texturePNG), 0755); err != nil {
-				fmt.Printf("Warning: Failed to create output directory: %v\n", err)
-			} else if err := timOutput.ConvertToPNG(texturePNG); err != nil {
-				fmt.Printf("Warning: Failed to export texture %s: %v\n", texturePNG, err)
-			}
-		} else {
-			fmt.Printf("Warning: Failed to load TIM file %s: %v\n", timPath, err)
+	timData := loadEMDTexture(inputFilename)
+	if timData != nil {
+		fmt.Println("Exporting texture...")
+		// Ensure output directory exists
+		if err := os.MkdirAll(filepath.Dir(texturePNG), 0755); err != nil {
+			fmt.Printf("Warning: Failed to create output directory: %v\n", err)
+		} else if err := timData.ConvertToPNG(texturePNG); err != nil {
+			fmt.Printf("Warning: Failed to export texture %s: %v\n", texturePNG, err)
 		}
-	} else {
-		fmt.Printf("Warning: TIM file not found: %s\n", timPath)
 	}
 	
 	// Build meshes with single texture reference
 	textureBase := filepath.Base(texturePNG)
-	var timData *fileio.TIMOutput
-	if _, err := os.Stat(timPath); err == nil {
-		timData, _ = fileio.LoadTIMFile(timPath)
-	}
 	
 	var meshes []mesh
 	var materials map[MatKey]Material
@@ -273,3 +542,107 @@ func convertEMDToOBJ(inputFilename, outputFilename string, useSkeleton bool) {
 	}
 	fmt.Printf("Successfully converted to %s with %d materials\n", outputFilename, len(materials))
 }
+
+// For EMD files, the texture is in a TIM file next to the model
+// with the same base name
+func loadEMDTexture(inputFilename string) *fileio.TIMOutput {
+	timPath := inputFilename[:len(inputFilename)-4] + ".TIM"
+	if !resource.LocalFileExists(timPath) {
+		fmt.Printf("Warning: TIM file not found: %s\n", timPath)
+		return nil
+	}
+	fmt.Println("Loading TIM texture...")
+	timOutput, err := resource.LoadLocalFile(timPath, fileio.LoadTIMStream)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load TIM file %s: %v\n", timPath, err)
+		return nil
+	}
+	return timOutput
+}
+
+func convertPLDToGLTF(inputFilename, outputFilename string) {
+	fmt.Println("Loading PLD file...")
+	pld, err := resource.LoadLocalFile(inputFilename, fileio.LoadPLDStream)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if pld.MeshData == nil {
+		fmt.Println("Error: no mesh data")
+		os.Exit(1)
+	}
+
+	model := gltfModel{
+		meshData:     pld.MeshData,
+		textureData:  pld.TextureData,
+		skeletonData: pld.SkeletonData,
+	}
+	if pld.AnimationData != nil && pld.SkeletonData != nil {
+		model.animationSets = append(model.animationSets, gltfAnimationSet{name: "anim", animationData: pld.AnimationData, skeletonData: pld.SkeletonData})
+	}
+	exportGLTF(outputFilename, model)
+}
+
+func convertEMDToGLTF(inputFilename, outputFilename string) {
+	fmt.Println("Loading EMD file...")
+	emd, err := resource.LoadLocalFile(inputFilename, fileio.LoadEMDStream)
+	if err != nil {
+		fmt.Printf("Error: failed to load EMD: %v\n", err)
+		os.Exit(1)
+	}
+	if emd.MeshData == nil {
+		fmt.Println("Error: no mesh data")
+		os.Exit(1)
+	}
+
+	model := gltfModel{
+		meshData:     emd.MeshData,
+		textureData:  loadEMDTexture(inputFilename),
+		skeletonData: emd.SkeletonData1,
+	}
+	// All three animation sections move the bones of the first skeleton
+	animationSections := []struct {
+		animationData *fileio.EDDOutput
+		skeletonData  *fileio.EMROutput
+	}{
+		{emd.AnimationData1, emd.SkeletonData1},
+		{emd.AnimationData2, emd.SkeletonData2},
+		{emd.AnimationData3, emd.SkeletonData3},
+	}
+	for i, section := range animationSections {
+		if section.animationData == nil || section.skeletonData == nil || len(section.skeletonData.FrameData) == 0 {
+			continue
+		}
+		model.animationSets = append(model.animationSets, gltfAnimationSet{
+			name:          fmt.Sprintf("anim%d", i+1),
+			animationData: section.animationData,
+			skeletonData:  section.skeletonData,
+		})
+	}
+	exportGLTF(outputFilename, model)
+}
+
+func exportGLTF(outputFilename string, model gltfModel) {
+	fmt.Println("Converting to glTF...")
+	if model.skeletonData == nil || len(model.skeletonData.RelativePositionData) == 0 {
+		fmt.Println("Warning: no skeleton data found, exporting the meshes without bones")
+	}
+	if err := writeGLB(outputFilename, model); err != nil {
+		fmt.Printf("Error writing glTF: %v\n", err)
+		os.Exit(1)
+	}
+
+	numAnimations := 0
+	for _, animationSet := range model.animationSets {
+		for _, frames := range animationSet.animationData.AnimationIndexFrames {
+			if len(frames) > 0 {
+				numAnimations++
+			}
+		}
+	}
+	numJoints := 0
+	if model.skeletonData != nil {
+		numJoints = len(model.skeletonData.RelativePositionData)
+	}
+	fmt.Printf("Successfully converted to %s with %d meshes, %d joints and %d animations\n", outputFilename, len(model.meshData.Components), numJoints, numAnimations)
+}
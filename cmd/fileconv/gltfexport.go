@@ -0,0 +1,651 @@
+package main
+
+// Binary glTF 2.0 export for PLD and EMD models
+// Every mesh component is skinned to the bone with the same index and
+// every EDD animation becomes a glTF animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"math"
+	"os"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	gltfComponentUnsignedShort = 5123
+	gltfComponentFloat         = 5126
+	gltfTargetArrayBuffer      = 34962
+	gltfFilterNearest          = 9728
+
+	glbMagic     = 0x46546C67 // "glTF"
+	glbVersion   = 2
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+
+	gltfFrameRate = 30 // animation frames per second
+)
+
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes"`
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Skins       []gltfSkin       `json:"skins,omitempty"`
+	Cameras     []gltfCamera     `json:"cameras,omitempty"`
+	Animations  []gltfAnimation  `json:"animations,omitempty"`
+	Materials   []gltfMaterial   `json:"materials,omitempty"`
+	Textures    []gltfTexture    `json:"textures,omitempty"`
+	Images      []gltfImage      `json:"images,omitempty"`
+	Samplers    []gltfSampler    `json:"samplers,omitempty"`
+	Accessors   []gltfAccessor   `json:"accessors"`
+	BufferViews []gltfBufferView `json:"bufferViews"`
+	Buffers     []gltfBuffer     `json:"buffers"`
+}
+
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+type gltfScene struct {
+	Nodes []int `json:"nodes"`
+}
+
+type gltfNode struct {
+	Name        string    `json:"name,omitempty"`
+	Children    []int     `json:"children,omitempty"`
+	Translation []float32 `json:"translation,omitempty"`
+	Rotation    []float32 `json:"rotation,omitempty"`
+	Mesh        *int      `json:"mesh,omitempty"`
+	Skin        *int      `json:"skin,omitempty"`
+	Camera      *int      `json:"camera,omitempty"`
+}
+
+type gltfMesh struct {
+	Name       string          `json:"name,omitempty"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Material   *int           `json:"material,omitempty"`
+}
+
+type gltfSkin struct {
+	InverseBindMatrices int   `json:"inverseBindMatrices"`
+	Skeleton            int   `json:"skeleton"`
+	Joints              []int `json:"joints"`
+}
+
+type gltfCamera struct {
+	Name        string                `json:"name,omitempty"`
+	Type        string                `json:"type"`
+	Perspective gltfCameraPerspective `json:"perspective"`
+}
+
+type gltfCameraPerspective struct {
+	AspectRatio float32 `json:"aspectRatio"`
+	YFov        float32 `json:"yfov"`
+	ZNear       float32 `json:"znear"`
+	ZFar        float32 `json:"zfar"`
+}
+
+type gltfAnimation struct {
+	Name     string                 `json:"name,omitempty"`
+	Channels []gltfAnimationChannel `json:"channels"`
+	Samplers []gltfAnimationSampler `json:"samplers"`
+}
+
+type gltfAnimationChannel struct {
+	Sampler int                 `json:"sampler"`
+	Target  gltfAnimationTarget `json:"target"`
+}
+
+type gltfAnimationTarget struct {
+	Node int    `json:"node"`
+	Path string `json:"path"`
+}
+
+type gltfAnimationSampler struct {
+	Input         int    `json:"input"`
+	Output        int    `json:"output"`
+	Interpolation string `json:"interpolation"`
+}
+
+type gltfMaterial struct {
+	Name                 string               `json:"name,omitempty"`
+	PBRMetallicRoughness gltfPBRMetallicRough `json:"pbrMetallicRoughness"`
+	AlphaMode            string               `json:"alphaMode,omitempty"`
+	DoubleSided          bool                 `json:"doubleSided,omitempty"`
+}
+
+type gltfPBRMetallicRough struct {
+	BaseColorFactor  []float32        `json:"baseColorFactor,omitempty"`
+	BaseColorTexture *gltfTextureInfo `json:"baseColorTexture,omitempty"`
+	MetallicFactor   float32          `json:"metallicFactor"`
+	RoughnessFactor  float32          `json:"roughnessFactor"`
+}
+
+type gltfTextureInfo struct {
+	Index int `json:"index"`
+}
+
+type gltfTexture struct {
+	Sampler int `json:"sampler"`
+	Source  int `json:"source"`
+}
+
+type gltfImage struct {
+	BufferView int    `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type gltfSampler struct {
+	MagFilter int `json:"magFilter"`
+	MinFilter int `json:"minFilter"`
+}
+
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float32 `json:"min,omitempty"`
+	Max           []float32 `json:"max,omitempty"`
+}
+
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+type gltfBuffer struct {
+	ByteLength int `json:"byteLength"`
+}
+
+// Model data to export
+type gltfModel struct {
+	meshData      *fileio.MD1Output
+	textureData   *fileio.TIMOutput
+	skeletonData  *fileio.EMROutput
+	animationSets []gltfAnimationSet
+}
+
+// An EDD file and the EMR frames it indexes into
+type gltfAnimationSet struct {
+	name          string
+	animationData *fileio.EDDOutput
+	skeletonData  *fileio.EMROutput
+}
+
+// Collects the glTF document and the contents of the binary chunk
+type gltfBuilder struct {
+	document   gltfDocument
+	binaryData []byte
+}
+
+// Write a binary glTF file with the skeleton, skinned meshes and animations
+func writeGLB(outputFilename string, model gltfModel) error {
+	builder, err := buildGLTF(model)
+	if err != nil {
+		return err
+	}
+	return builder.writeGLB(outputFilename)
+}
+
+// The scene has a single root node that converts from game coordinates
+func newGLTFBuilder(rootName string) *gltfBuilder {
+	builder := &gltfBuilder{}
+	builder.document.Asset = gltfAsset{Version: "2.0", Generator: "OpenBiohazard2 fileconv"}
+
+	// The game uses Y down, so everything is turned upright around the X axis
+	builder.document.Nodes = append(builder.document.Nodes, gltfNode{Name: rootName, Rotation: []float32{1, 0, 0, 0}})
+	builder.document.Scenes = []gltfScene{{Nodes: []int{0}}}
+	return builder
+}
+
+func (builder *gltfBuilder) writeGLB(outputFilename string) error {
+	builder.document.Buffers = []gltfBuffer{{ByteLength: len(builder.binaryData)}}
+	jsonData, err := json.Marshal(builder.document)
+	if err != nil {
+		return err
+	}
+	// Chunks are padded to 4 bytes, JSON with spaces and binary data with zeros
+	for len(jsonData)%4 != 0 {
+		jsonData = append(jsonData, ' ')
+	}
+	binaryData := builder.binaryData
+	for len(binaryData)%4 != 0 {
+		binaryData = append(binaryData, 0)
+	}
+
+	output := new(bytes.Buffer)
+	totalLength := 12 + 8 + len(jsonData) + 8 + len(binaryData)
+	binary.Write(output, binary.LittleEndian, []uint32{glbMagic, glbVersion, uint32(totalLength)})
+	binary.Write(output, binary.LittleEndian, []uint32{uint32(len(jsonData)), glbChunkJSON})
+	output.Write(jsonData)
+	binary.Write(output, binary.LittleEndian, []uint32{uint32(len(binaryData)), glbChunkBIN})
+	output.Write(binaryData)
+
+	return os.WriteFile(outputFilename, output.Bytes(), 0644)
+}
+
+func buildGLTF(model gltfModel) (*gltfBuilder, error) {
+	builder := newGLTFBuilder("model")
+	materialIndex, err := builder.addTexture("texture", model.textureData)
+	if err != nil {
+		return nil, err
+	}
+
+	var jointNodes []int
+	var bindTransforms []mgl32.Mat4
+	skinIndex := -1
+	if model.skeletonData != nil && len(model.skeletonData.RelativePositionData) > 0 {
+		jointNodes, bindTransforms = builder.addJoints(model.skeletonData)
+		skinIndex = builder.addSkin(jointNodes, bindTransforms)
+	}
+
+	for componentId, component := range model.meshData.Components {
+		jointId := componentId
+		if jointId >= len(jointNodes) {
+			jointId = 0
+		}
+		transform := mgl32.Ident4()
+		if skinIndex != -1 {
+			transform = bindTransforms[jointId]
+		}
+
+		primitive, err := builder.addComponentPrimitive(&component, transform, model.textureData, jointId, skinIndex != -1)
+		if err != nil {
+			return nil, fmt.Errorf("component %d: %w", componentId, err)
+		}
+		if primitive == nil {
+			continue
+		}
+		if materialIndex != -1 {
+			primitive.Material = &materialIndex
+		}
+
+		meshIndex := len(builder.document.Meshes)
+		name := fmt.Sprintf("component_%02d", componentId)
+		builder.document.Meshes = append(builder.document.Meshes, gltfMesh{Name: name, Primitives: []gltfPrimitive{*primitive}})
+		node := gltfNode{Name: name, Mesh: &meshIndex}
+		if skinIndex != -1 {
+			node.Skin = &skinIndex
+		}
+		builder.addChildNode(0, node)
+	}
+
+	for _, animationSet := range model.animationSets {
+		if skinIndex == -1 {
+			break
+		}
+		for animationId, frames := range animationSet.animationData.AnimationIndexFrames {
+			if len(frames) == 0 {
+				continue
+			}
+			name := fmt.Sprintf("%s_%02d", animationSet.name, animationId)
+			if err := builder.addAnimation(name, frames, animationSet.skeletonData, model.skeletonData, jointNodes); err != nil {
+				return nil, fmt.Errorf("animation %s: %w", name, err)
+			}
+		}
+	}
+
+	return builder, nil
+}
+
+// Add the texture as an embedded PNG and return the material that uses it, or -1 without a texture
+func (builder *gltfBuilder) addTexture(name string, textureData *fileio.TIMOutput) (int, error) {
+	if textureData == nil {
+		return -1, nil
+	}
+	textureImage, err := textureData.ImageWithPalette(fileio.TIM_PALETTE_AUTO)
+	if err != nil {
+		return -1, fmt.Errorf("failed to decode texture: %w", err)
+	}
+	pngData := new(bytes.Buffer)
+	if err := png.Encode(pngData, textureImage); err != nil {
+		return -1, fmt.Errorf("failed to encode texture: %w", err)
+	}
+
+	document := &builder.document
+	document.Images = append(document.Images, gltfImage{BufferView: builder.addBufferView(pngData.Bytes(), 0), MimeType: "image/png"})
+	// Nearest filtering keeps the pixel art sharp
+	document.Samplers = append(document.Samplers, gltfSampler{MagFilter: gltfFilterNearest, MinFilter: gltfFilterNearest})
+	document.Textures = append(document.Textures, gltfTexture{Sampler: len(document.Samplers) - 1, Source: len(document.Images) - 1})
+	document.Materials = append(document.Materials, gltfMaterial{
+		Name: name,
+		PBRMetallicRoughness: gltfPBRMetallicRough{
+			BaseColorTexture: &gltfTextureInfo{Index: len(document.Textures) - 1},
+			MetallicFactor:   0,
+			RoughnessFactor:  1,
+		},
+		DoubleSided: true,
+	})
+	return len(document.Materials) - 1, nil
+}
+
+// Add one node per bone, following the hierarchy in ArmatureChildren.
+// Returns the node of each bone and the bone transforms in the bind pose.
+func (builder *gltfBuilder) addJoints(skeletonData *fileio.EMROutput) ([]int, []mgl32.Mat4) {
+	numJoints := len(skeletonData.RelativePositionData)
+	jointNodes := make([]int, numJoints)
+	for jointId, offsetFromParent := range skeletonData.RelativePositionData {
+		jointNodes[jointId] = len(builder.document.Nodes)
+		builder.document.Nodes = append(builder.document.Nodes, gltfNode{
+			Name:        fmt.Sprintf("joint_%02d", jointId),
+			Translation: []float32{float32(offsetFromParent.X), float32(offsetFromParent.Y), float32(offsetFromParent.Z)},
+		})
+	}
+
+	hasParent := make([]bool, numJoints)
+	for jointId := 0; jointId < numJoints && jointId < len(skeletonData.ArmatureChildren); jointId++ {
+		for _, childId := range skeletonData.ArmatureChildren[jointId] {
+			if int(childId) >= numJoints || int(childId) == jointId || hasParent[childId] {
+				continue
+			}
+			hasParent[childId] = true
+			node := &builder.document.Nodes[jointNodes[jointId]]
+			node.Children = append(node.Children, jointNodes[childId])
+		}
+	}
+	// Bones outside the hierarchy stay attached to the model
+	for jointId := 0; jointId < numJoints; jointId++ {
+		if !hasParent[jointId] {
+			builder.document.Nodes[0].Children = append(builder.document.Nodes[0].Children, jointNodes[jointId])
+		}
+	}
+
+	bindTransforms := make([]mgl32.Mat4, numJoints)
+	for i := range bindTransforms {
+		bindTransforms[i] = mgl32.Ident4()
+	}
+	buildComponentTransformsRecursive(skeletonData, 0, -1, bindTransforms)
+	return jointNodes, bindTransforms
+}
+
+func (builder *gltfBuilder) addSkin(jointNodes []int, bindTransforms []mgl32.Mat4) int {
+	inverseBindMatrices := make([]float32, 0, len(bindTransforms)*16)
+	for _, transform := range bindTransforms {
+		inverse := transform.Inv()
+		inverseBindMatrices = append(inverseBindMatrices, inverse[:]...)
+	}
+	accessor := builder.addFloatAccessor(inverseBindMatrices, "MAT4", 16, 0, false)
+	builder.document.Skins = append(builder.document.Skins, gltfSkin{
+		InverseBindMatrices: accessor,
+		Skeleton:            jointNodes[0],
+		Joints:              jointNodes,
+	})
+	return len(builder.document.Skins) - 1
+}
+
+func (builder *gltfBuilder) addChildNode(parent int, node gltfNode) int {
+	nodeIndex := len(builder.document.Nodes)
+	builder.document.Nodes = append(builder.document.Nodes, node)
+	builder.document.Nodes[parent].Children = append(builder.document.Nodes[parent].Children, nodeIndex)
+	return nodeIndex
+}
+
+// One corner of a polygon in a mesh component
+type gltfCorner struct {
+	vertex fileio.MD1Vertex
+	normal fileio.MD1Vertex
+	u, v   uint8
+}
+
+// Build the vertices of a component in the bind pose, bound to a single joint.
+// Returns nil if the component has no polygons.
+func (builder *gltfBuilder) addComponentPrimitive(
+	entityModel *fileio.MD1Object,
+	transform mgl32.Mat4,
+	textureData *fileio.TIMOutput,
+	jointId int,
+	skinned bool,
+) (*gltfPrimitive, error) {
+	var positions, normals, uvs []float32
+	var pages []uint16
+	addTriangle := func(corners [3]gltfCorner, page uint16) {
+		// Polygon winding isn't consistent in the game data,
+		// so each triangle is turned to face the same way as its vertex normals
+		var vertices, vertexNormals [3]mgl32.Vec3
+		for i, corner := range corners {
+			vertices[i] = transform.Mul4x1(mgl32.Vec3{float32(corner.vertex.X), float32(corner.vertex.Y), float32(corner.vertex.Z)}.Vec4(1)).Vec3()
+			normal := buildModelNormal(corner.normal)
+			vertexNormals[i] = transform.Mat3().Mul3x1(mgl32.Vec3{normal.x, normal.y, normal.z}).Normalize()
+		}
+		faceNormal := vertices[1].Sub(vertices[0]).Cross(vertices[2].Sub(vertices[0]))
+		order := [3]int{0, 1, 2}
+		if faceNormal.Dot(vertexNormals[0].Add(vertexNormals[1]).Add(vertexNormals[2])) < 0 {
+			order = [3]int{0, 2, 1}
+		}
+
+		for _, i := range order {
+			positions = append(positions, vertices[i][:]...)
+			normals = append(normals, vertexNormals[i][:]...)
+			// glTF puts the texture origin at the top left, so the V flip for OBJ is undone
+			uv := buildTextureUV(float32(corners[i].u), float32(corners[i].v), page, textureData)
+			uvs = append(uvs, uv.u, 1.0-uv.v)
+			pages = append(pages, page)
+		}
+	}
+
+	for j, triangleIndex := range entityModel.TriangleIndices {
+		if j >= len(entityModel.TriangleTextures) {
+			return nil, fmt.Errorf("triangle %d has no texture coordinates", j)
+		}
+		textureInfo := entityModel.TriangleTextures[j]
+		corners := [3]gltfCorner{}
+		indices := [3][2]uint16{
+			{triangleIndex.IndexVertex0, triangleIndex.IndexNormal0},
+			{triangleIndex.IndexVertex1, triangleIndex.IndexNormal1},
+			{triangleIndex.IndexVertex2, triangleIndex.IndexNormal2},
+		}
+		textureCoords := [3][2]uint8{{textureInfo.U0, textureInfo.V0}, {textureInfo.U1, textureInfo.V1}, {textureInfo.U2, textureInfo.V2}}
+		for i := range corners {
+			if int(indices[i][0]) >= len(entityModel.TriangleVertices) || int(indices[i][1]) >= len(entityModel.TriangleNormals) {
+				return nil, fmt.Errorf("triangle %d has an index out of range", j)
+			}
+			corners[i] = gltfCorner{
+				vertex: entityModel.TriangleVertices[indices[i][0]],
+				normal: entityModel.TriangleNormals[indices[i][1]],
+				u:      textureCoords[i][0],
+				v:      textureCoords[i][1],
+			}
+		}
+		addTriangle(corners, textureInfo.Page)
+	}
+
+	for j, quadIndex := range entityModel.QuadIndices {
+		if j >= len(entityModel.QuadTextures) {
+			return nil, fmt.Errorf("quad %d has no texture coordinates", j)
+		}
+		textureInfo := entityModel.QuadTextures[j]
+		corners := [4]gltfCorner{}
+		indices := [4][2]uint16{
+			{quadIndex.IndexVertex0, quadIndex.IndexNormal0},
+			{quadIndex.IndexVertex1, quadIndex.IndexNormal1},
+			{quadIndex.IndexVertex2, quadIndex.IndexNormal2},
+			{quadIndex.IndexVertex3, quadIndex.IndexNormal3},
+		}
+		textureCoords := [4][2]uint8{
+			{textureInfo.U0, textureInfo.V0},
+			{textureInfo.U1, textureInfo.V1},
+			{textureInfo.U2, textureInfo.V2},
+			{textureInfo.U3, textureInfo.V3},
+		}
+		for i := range corners {
+			if int(indices[i][0]) >= len(entityModel.QuadVertices) || int(indices[i][1]) >= len(entityModel.QuadNormals) {
+				return nil, fmt.Errorf("quad %d has an index out of range", j)
+			}
+			corners[i] = gltfCorner{
+				vertex: entityModel.QuadVertices[indices[i][0]],
+				normal: entityModel.QuadNormals[indices[i][1]],
+				u:      textureCoords[i][0],
+				v:      textureCoords[i][1],
+			}
+		}
+		// Quad corners are stored in Z order
+		addTriangle([3]gltfCorner{corners[0], corners[1], corners[2]}, textureInfo.Page)
+		addTriangle([3]gltfCorner{corners[1], corners[3], corners[2]}, textureInfo.Page)
+	}
+
+	vertexCount := len(positions) / 3
+	if vertexCount == 0 {
+		return nil, nil
+	}
+
+	primitive := &gltfPrimitive{Attributes: map[string]int{
+		"POSITION":   builder.addFloatAccessor(positions, "VEC3", 3, gltfTargetArrayBuffer, true),
+		"NORMAL":     builder.addFloatAccessor(normals, "VEC3", 3, gltfTargetArrayBuffer, false),
+		"TEXCOORD_0": builder.addFloatAccessor(uvs, "VEC2", 2, gltfTargetArrayBuffer, false),
+	}}
+	if skinned {
+		joints := make([]uint16, vertexCount*4)
+		weights := make([]float32, vertexCount*4)
+		for i := 0; i < vertexCount; i++ {
+			joints[i*4] = uint16(jointId)
+			weights[i*4] = 1
+		}
+		primitive.Attributes["JOINTS_0"] = builder.addUint16Accessor(joints, "VEC4", 4, gltfTargetArrayBuffer)
+		primitive.Attributes["WEIGHTS_0"] = builder.addFloatAccessor(weights, "VEC4", 4, gltfTargetArrayBuffer, false)
+	}
+	return primitive, nil
+}
+
+// Add an animation with a rotation for every bone and the root translation on each frame
+func (builder *gltfBuilder) addAnimation(
+	name string,
+	frames []fileio.EDDTableElement,
+	frameSkeleton *fileio.EMROutput,
+	skeletonData *fileio.EMROutput,
+	jointNodes []int,
+) error {
+	numJoints := len(jointNodes)
+	times := make([]float32, len(frames))
+	translations := make([]float32, 0, len(frames)*3)
+	rotations := make([][]float32, numJoints)
+	for frameIndex, frame := range frames {
+		if frame.FrameId >= len(frameSkeleton.FrameData) {
+			return fmt.Errorf("frame %d is out of range (%d frames)", frame.FrameId, len(frameSkeleton.FrameData))
+		}
+		frameData := frameSkeleton.FrameData[frame.FrameId]
+		if len(frameData.RotationAngles) < numJoints {
+			return fmt.Errorf("frame %d has %d rotations for %d bones", frame.FrameId, len(frameData.RotationAngles), numJoints)
+		}
+		times[frameIndex] = float32(frameIndex) / gltfFrameRate
+
+		rootPosition := skeletonData.RelativePositionData[0]
+		translations = append(translations,
+			float32(rootPosition.X)+float32(frameData.FrameHeader.XOffset),
+			float32(rootPosition.Y)+float32(frameData.FrameHeader.YOffset),
+			float32(rootPosition.Z)+float32(frameData.FrameHeader.ZOffset))
+
+		for jointId := 0; jointId < numJoints; jointId++ {
+			quat := frameRotationToQuat(frameData.RotationAngles[jointId])
+			// Keep the sign consistent with the previous frame so the rotation doesn't take the long way around
+			if previous := rotations[jointId]; len(previous) >= 4 {
+				last := mgl32.Quat{W: previous[len(previous)-1], V: mgl32.Vec3{previous[len(previous)-4], previous[len(previous)-3], previous[len(previous)-2]}}
+				if last.Dot(quat) < 0 {
+					quat = quat.Scale(-1)
+				}
+			}
+			rotations[jointId] = append(rotations[jointId], quat.V.X(), quat.V.Y(), quat.V.Z(), quat.W)
+		}
+	}
+
+	animation := gltfAnimation{Name: name}
+	timeAccessor := builder.addFloatAccessor(times, "SCALAR", 1, 0, true)
+	addChannel := func(node int, path string, output int) {
+		animation.Samplers = append(animation.Samplers, gltfAnimationSampler{Input: timeAccessor, Output: output, Interpolation: "LINEAR"})
+		animation.Channels = append(animation.Channels, gltfAnimationChannel{
+			Sampler: len(animation.Samplers) - 1,
+			Target:  gltfAnimationTarget{Node: node, Path: path},
+		})
+	}
+	addChannel(jointNodes[0], "translation", builder.addFloatAccessor(translations, "VEC3", 3, 0, false))
+	for jointId := 0; jointId < numJoints; jointId++ {
+		addChannel(jointNodes[jointId], "rotation", builder.addFloatAccessor(rotations[jointId], "VEC4", 4, 0, false))
+	}
+	builder.document.Animations = append(builder.document.Animations, animation)
+	return nil
+}
+
+// Same rotation order as the game renderer
+func frameRotationToQuat(frameRotation mgl32.Vec3) mgl32.Quat {
+	quat := mgl32.QuatIdent()
+	quat = quat.Mul(mgl32.QuatRotate(frameRotation.X(), mgl32.Vec3{1.0, 0.0, 0.0}))
+	quat = quat.Mul(mgl32.QuatRotate(frameRotation.Y(), mgl32.Vec3{0.0, 1.0, 0.0}))
+	quat = quat.Mul(mgl32.QuatRotate(frameRotation.Z(), mgl32.Vec3{0.0, 0.0, 1.0}))
+	return quat.Normalize()
+}
+
+func (builder *gltfBuilder) addBufferView(data []byte, target int) int {
+	// Accessor data has to be aligned to 4 bytes
+	for len(builder.binaryData)%4 != 0 {
+		builder.binaryData = append(builder.binaryData, 0)
+	}
+	builder.document.BufferViews = append(builder.document.BufferViews, gltfBufferView{
+		Buffer:     0,
+		ByteOffset: len(builder.binaryData),
+		ByteLength: len(data),
+		Target:     target,
+	})
+	builder.binaryData = append(builder.binaryData, data...)
+	return len(builder.document.BufferViews) - 1
+}
+
+func (builder *gltfBuilder) addFloatAccessor(values []float32, accessorType string, componentCount int, target int, withBounds bool) int {
+	data := make([]byte, len(values)*4)
+	for i, value := range values {
+		binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(value))
+	}
+	accessor := gltfAccessor{
+		BufferView:    builder.addBufferView(data, target),
+		ComponentType: gltfComponentFloat,
+		Count:         len(values) / componentCount,
+		Type:          accessorType,
+	}
+	if withBounds {
+		accessor.Min, accessor.Max = accessorBounds(values, componentCount)
+	}
+	builder.document.Accessors = append(builder.document.Accessors, accessor)
+	return len(builder.document.Accessors) - 1
+}
+
+func (builder *gltfBuilder) addUint16Accessor(values []uint16, accessorType string, componentCount int, target int) int {
+	data := make([]byte, len(values)*2)
+	for i, value := range values {
+		binary.LittleEndian.PutUint16(data[i*2:], value)
+	}
+	builder.document.Accessors = append(builder.document.Accessors, gltfAccessor{
+		BufferView:    builder.addBufferView(data, target),
+		ComponentType: gltfComponentUnsignedShort,
+		Count:         len(values) / componentCount,
+		Type:          accessorType,
+	})
+	return len(builder.document.Accessors) - 1
+}
+
+func accessorBounds(values []float32, componentCount int) ([]float32, []float32) {
+	minValues := make([]float32, componentCount)
+	maxValues := make([]float32, componentCount)
+	for i := 0; i < componentCount; i++ {
+		minValues[i] = float32(math.Inf(1))
+		maxValues[i] = float32(math.Inf(-1))
+	}
+	for i, value := range values {
+		component := i % componentCount
+		minValues[component] = min(minValues[component], value)
+		maxValues[component] = max(maxValues[component], value)
+	}
+	return minValues, maxValues
+}
@@ -0,0 +1,345 @@
+package main
+
+// Room export for level design
+// Writes the collision shapes, camera switches, triggers, cameras and item models
+// of a room as a binary glTF scene, using the same shapes as the debug view in the game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/geometry"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Same as the camera and debug view in the game.
+// The render package isn't used here, since it needs OpenGL.
+const (
+	cameraAspectRatio = 4.0 / 3.0
+	cameraNearPlane   = 16.0
+	cameraFarPlane    = 45000.0
+	md1VertexLength   = 8 // position, texture coordinates and normal
+)
+
+var (
+	collisionColor    = [4]float32{1.0, 0.0, 0.0, 0.3}
+	slopeColor        = [4]float32{1.0, 0.0, 1.0, 0.3}
+	cameraSwitchColor = [4]float32{0.0, 1.0, 0.0, 0.3}
+	doorColor         = [4]float32{0.0, 0.0, 1.0, 0.3}
+	triggerColor      = [4]float32{0.0, 1.0, 1.0, 0.3} // items and other AOTs
+	cameraColor       = [4]float32{1.0, 1.0, 0.0, 0.5}
+)
+
+// Shared materials for each kind of debug shape
+type roomMaterials struct {
+	collision, slope, cameraSwitch, door, item, trigger, camera int
+}
+
+func convertRDTToGLTF(inputFilename, outputFilename string) {
+	fmt.Println("Loading RDT file...")
+	rdtOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadRDT)
+	if err != nil {
+		if rdtOutput == nil || !errors.Is(err, fileio.ErrUnknownOpcode) {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	scriptObjects := world.CollectScriptObjects(rdtOutput.InitScriptData)
+	builder, err := buildRoomGLTF(rdtOutput, scriptObjects)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := builder.writeGLB(outputFilename); err != nil {
+		fmt.Printf("Error writing glTF: %v\n", err)
+		os.Exit(1)
+	}
+
+	aotManager := scriptObjects.AotManager
+	fmt.Printf("Collision shapes: %d\n", len(rdtOutput.CollisionData.CollisionEntities))
+	fmt.Printf("Camera switches: %d\n", len(rdtOutput.CameraSwitchData.CameraSwitches))
+	fmt.Printf("Cameras: %d\n", len(rdtOutput.RIDOutput.CameraPositions))
+	fmt.Printf("Doors: %d, items: %d, triggers: %d\n", len(aotManager.Doors), len(aotManager.Items), len(aotManager.AotTriggers))
+	fmt.Printf("Item models: %d\n", len(scriptObjects.ItemModels))
+	fmt.Printf("Successfully converted to %s\n", outputFilename)
+}
+
+func buildRoomGLTF(rdtOutput *fileio.RDTOutput, scriptObjects *world.ScriptObjects) (*gltfBuilder, error) {
+	builder := newGLTFBuilder("room")
+	materials := roomMaterials{
+		collision:    builder.addColorMaterial("collision", collisionColor),
+		slope:        builder.addColorMaterial("slope", slopeColor),
+		cameraSwitch: builder.addColorMaterial("camera_switch", cameraSwitchColor),
+		door:         builder.addColorMaterial("door", doorColor),
+		item:         builder.addColorMaterial("item", triggerColor),
+		trigger:      builder.addColorMaterial("trigger", triggerColor),
+		camera:       builder.addColorMaterial("camera", cameraColor),
+	}
+
+	collisionEntities := []fileio.CollisionEntity{}
+	if rdtOutput.CollisionData != nil {
+		collisionEntities = rdtOutput.CollisionData.CollisionEntities
+	}
+	cameraSwitches := []fileio.RVDHeader{}
+	if rdtOutput.CameraSwitchData != nil {
+		cameraSwitches = rdtOutput.CameraSwitchData.CameraSwitches
+	}
+	aotManager := scriptObjects.AotManager
+
+	// Each floor gets its own group at the height of the floor
+	floorNodes := make(map[int]int)
+	floorNode := func(floor int) int {
+		if nodeIndex, exists := floorNodes[floor]; exists {
+			return nodeIndex
+		}
+		node := gltfNode{Name: fmt.Sprintf("floor_%02d", floor)}
+		if floor == world.ALL_FLOORS {
+			node.Name = "all_floors"
+		} else {
+			node.Translation = []float32{0, float32(floor * fileio.FLOOR_HEIGHT_UNIT), 0}
+		}
+		floorNodes[floor] = builder.addChildNode(0, node)
+		return floorNodes[floor]
+	}
+
+	// Collision shapes can be on any of the 32 floors,
+	// but only the floors that the room uses are exported
+	roomFloors := map[int]bool{0: true}
+	addFloor := func(floor uint8) {
+		if floor != world.ALL_FLOORS {
+			roomFloors[int(floor)] = true
+		}
+	}
+	for _, cameraSwitch := range cameraSwitches {
+		addFloor(cameraSwitch.Floor)
+	}
+	for _, door := range aotManager.Doors {
+		addFloor(door.Header.Floor)
+	}
+	for _, item := range aotManager.Items {
+		addFloor(item.Header.Floor)
+	}
+	for _, aot := range aotManager.AotTriggers {
+		addFloor(aot.Header.Floor)
+	}
+	floors := make([]int, 0, len(roomFloors))
+	for floor := range roomFloors {
+		floors = append(floors, floor)
+	}
+	sort.Ints(floors)
+
+	for _, floor := range floors {
+		for _, entity := range collisionEntities {
+			if floor >= len(entity.FloorCheck) || !entity.FloorCheck[floor] {
+				continue
+			}
+			vertexBuffer := geometry.NewCollisionDebugEntity([]fileio.CollisionEntity{entity})
+			name := fmt.Sprintf("collision_%03d_shape%d", entity.ScaIndex, entity.Shape)
+			builder.addShapeMesh(floorNode(floor), name, vertexBuffer, materials.collision)
+		}
+	}
+
+	// Slopes already include their height
+	slopesNode := -1
+	for _, entity := range collisionEntities {
+		vertexBuffer := geometry.NewSlopedSurfacesDebugVertexBuffer([]fileio.CollisionEntity{entity})
+		if len(vertexBuffer) == 0 {
+			continue
+		}
+		if slopesNode == -1 {
+			slopesNode = builder.addChildNode(0, gltfNode{Name: "slopes"})
+		}
+		builder.addShapeMesh(slopesNode, fmt.Sprintf("slope_%03d", entity.ScaIndex), vertexBuffer, materials.slope)
+	}
+
+	for switchIndex, cameraSwitch := range cameraSwitches {
+		corners := [4][]float32{
+			{float32(cameraSwitch.X1), float32(cameraSwitch.Z1)},
+			{float32(cameraSwitch.X2), float32(cameraSwitch.Z2)},
+			{float32(cameraSwitch.X3), float32(cameraSwitch.Z3)},
+			{float32(cameraSwitch.X4), float32(cameraSwitch.Z4)},
+		}
+		rect := geometry.NewQuadFourPoints(corners)
+		name := fmt.Sprintf("camera_switch_%02d_cam%02d_to_cam%02d", switchIndex, cameraSwitch.Cam0, cameraSwitch.Cam1)
+		builder.addShapeMesh(floorNode(int(cameraSwitch.Floor)), name, rect.VertexBuffer, materials.cameraSwitch)
+	}
+
+	for _, door := range aotManager.Doors {
+		name := fmt.Sprintf("door_%02d_to_room%d%02x", door.Header.Aot, int(door.Stage)+1, door.Room)
+		builder.addShapeMesh(floorNode(int(door.Header.Floor)), name, door.Bounds.VertexBuffer, materials.door)
+	}
+	for _, item := range aotManager.Items {
+		name := fmt.Sprintf("item_%02d_id%d", item.Header.Aot, item.ItemId)
+		builder.addShapeMesh(floorNode(int(item.Header.Floor)), name, item.Bounds.VertexBuffer, materials.item)
+	}
+	for _, aot := range aotManager.AotTriggers {
+		name := fmt.Sprintf("aot_%02d_type%d", aot.Header.Aot, aot.Header.Id)
+		builder.addShapeMesh(floorNode(int(aot.Header.Floor)), name, aot.Bounds.VertexBuffer, materials.trigger)
+	}
+
+	if rdtOutput.RIDOutput != nil {
+		for cameraId, cameraInfo := range rdtOutput.RIDOutput.CameraPositions {
+			builder.addRoomCamera(cameraId, cameraInfo, materials.camera)
+		}
+	}
+
+	itemModelMaterials := make(map[int]int)
+	for _, itemModel := range scriptObjects.ItemModels {
+		modelIndex := int(itemModel.ObjectIndex)
+		if modelIndex == 255 {
+			continue
+		}
+		if modelIndex >= len(rdtOutput.ItemModelData) || modelIndex >= len(rdtOutput.ItemTextureData) ||
+			rdtOutput.ItemModelData[modelIndex] == nil || rdtOutput.ItemTextureData[modelIndex] == nil {
+			fmt.Printf("Warning: Item model %d is not in the room\n", modelIndex)
+			continue
+		}
+
+		materialIndex, exists := itemModelMaterials[modelIndex]
+		if !exists {
+			var err error
+			materialIndex, err = builder.addTexture(fmt.Sprintf("item_model_%02d", modelIndex), rdtOutput.ItemTextureData[modelIndex])
+			if err != nil {
+				return nil, fmt.Errorf("item model %d: %w", modelIndex, err)
+			}
+			itemModelMaterials[modelIndex] = materialIndex
+		}
+		builder.addItemModel(itemModel, rdtOutput.ItemModelData[modelIndex], rdtOutput.ItemTextureData[modelIndex], materialIndex)
+	}
+
+	return builder, nil
+}
+
+// Add a shape from a debug vertex buffer, which has 3 floats per vertex
+func (builder *gltfBuilder) addShapeMesh(parent int, name string, vertexBuffer []float32, materialIndex int) {
+	if len(vertexBuffer) < 9 {
+		return
+	}
+	primitive := gltfPrimitive{
+		Attributes: map[string]int{"POSITION": builder.addFloatAccessor(vertexBuffer, "VEC3", 3, gltfTargetArrayBuffer, true)},
+		Material:   &materialIndex,
+	}
+	meshIndex := len(builder.document.Meshes)
+	builder.document.Meshes = append(builder.document.Meshes, gltfMesh{Name: name, Primitives: []gltfPrimitive{primitive}})
+	builder.addChildNode(parent, gltfNode{Name: name, Mesh: &meshIndex})
+}
+
+// Add a camera that sees the same view as the game, with its frustum as a mesh
+func (builder *gltfBuilder) addRoomCamera(cameraId int, cameraInfo fileio.CameraInfo, materialIndex int) {
+	cameraUp := mgl32.Vec3{0, -1, 0}
+	viewMatrix := mgl32.LookAtV(cameraInfo.CameraFrom, cameraInfo.CameraTo, cameraUp)
+	cameraMatrix := viewMatrix.Inv()
+	rotation := mgl32.Mat4ToQuat(cameraMatrix).Normalize()
+
+	name := fmt.Sprintf("camera_%02d", cameraId)
+	yFov := mgl32.DegToRad(cameraInfo.CameraFov)
+	builder.document.Cameras = append(builder.document.Cameras, gltfCamera{
+		Name: name,
+		Type: "perspective",
+		Perspective: gltfCameraPerspective{
+			AspectRatio: cameraAspectRatio,
+			YFov:        yFov,
+			ZNear:       cameraNearPlane,
+			ZFar:        cameraFarPlane,
+		},
+	})
+	cameraIndex := len(builder.document.Cameras) - 1
+	cameraNode := builder.addChildNode(0, gltfNode{
+		Name:        name,
+		Translation: []float32{cameraInfo.CameraFrom.X(), cameraInfo.CameraFrom.Y(), cameraInfo.CameraFrom.Z()},
+		Rotation:    []float32{rotation.V.X(), rotation.V.Y(), rotation.V.Z(), rotation.W},
+		Camera:      &cameraIndex,
+	})
+
+	// The frustum ends at the point the camera looks at.
+	// Cameras look down -Z in their own space.
+	depth := cameraInfo.CameraTo.Sub(cameraInfo.CameraFrom).Len()
+	halfHeight := depth * float32(math.Tan(float64(yFov)/2))
+	halfWidth := halfHeight * cameraAspectRatio
+	origin := mgl32.Vec3{0, 0, 0}
+	corners := [4]mgl32.Vec3{
+		{-halfWidth, -halfHeight, -depth},
+		{halfWidth, -halfHeight, -depth},
+		{halfWidth, halfHeight, -depth},
+		{-halfWidth, halfHeight, -depth},
+	}
+	vertexBuffer := make([]float32, 0, 6*3*3)
+	for i := 0; i < 4; i++ {
+		side := geometry.NewDebugTriangle(origin, corners[i], corners[(i+1)%4])
+		vertexBuffer = append(vertexBuffer, side.VertexBuffer...)
+	}
+	vertexBuffer = append(vertexBuffer, geometry.NewQuad(corners).VertexBuffer...)
+	builder.addShapeMesh(cameraNode, name+"_frustum", vertexBuffer, materialIndex)
+}
+
+// Add an item model at the position set by the script
+func (builder *gltfBuilder) addItemModel(itemModel fileio.ScriptInstrObjModelSet, meshData *fileio.MD1Output, textureData *fileio.TIMOutput, materialIndex int) {
+	// Each vertex has a position, texture coordinates and a normal
+	vertexBuffer := geometry.NewMD1Geometry(meshData, textureData)
+	vertexCount := len(vertexBuffer) / md1VertexLength
+	if vertexCount == 0 {
+		return
+	}
+	positions := make([]float32, 0, vertexCount*3)
+	uvs := make([]float32, 0, vertexCount*2)
+	normals := make([]float32, 0, vertexCount*3)
+	for i := 0; i < vertexCount; i++ {
+		vertex := vertexBuffer[i*md1VertexLength : (i+1)*md1VertexLength]
+		positions = append(positions, vertex[0:3]...)
+		uvs = append(uvs, vertex[3:5]...)
+		normal := mgl32.Vec3{vertex[5], vertex[6], vertex[7]}
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		} else {
+			normal = mgl32.Vec3{0, 0, 1}
+		}
+		normals = append(normals, normal[:]...)
+	}
+
+	primitive := gltfPrimitive{
+		Attributes: map[string]int{
+			"POSITION":   builder.addFloatAccessor(positions, "VEC3", 3, gltfTargetArrayBuffer, true),
+			"NORMAL":     builder.addFloatAccessor(normals, "VEC3", 3, gltfTargetArrayBuffer, false),
+			"TEXCOORD_0": builder.addFloatAccessor(uvs, "VEC2", 2, gltfTargetArrayBuffer, false),
+		},
+		Material: &materialIndex,
+	}
+	name := fmt.Sprintf("item_model_%02d", itemModel.ObjectIndex)
+	meshIndex := len(builder.document.Meshes)
+	builder.document.Meshes = append(builder.document.Meshes, gltfMesh{Name: name, Primitives: []gltfPrimitive{primitive}})
+
+	// Same rotation as the item entities in the game
+	rotationAngle := (float32(itemModel.Direction[1]) / 4096.0) * 2 * math.Pi
+	rotation := mgl32.QuatRotate(rotationAngle, mgl32.Vec3{0, 1, 0})
+	builder.addChildNode(0, gltfNode{
+		Name:        name,
+		Translation: []float32{float32(itemModel.Position[0]), float32(itemModel.Position[1]), float32(itemModel.Position[2])},
+		Rotation:    []float32{rotation.V.X(), rotation.V.Y(), rotation.V.Z(), rotation.W},
+		Mesh:        &meshIndex,
+	})
+}
+
+func (builder *gltfBuilder) addColorMaterial(name string, color [4]float32) int {
+	material := gltfMaterial{
+		Name: name,
+		PBRMetallicRoughness: gltfPBRMetallicRough{
+			BaseColorFactor: color[:],
+			MetallicFactor:  0,
+			RoughnessFactor: 1,
+		},
+		DoubleSided: true,
+	}
+	if color[3] < 1 {
+		material.AlphaMode = "BLEND"
+	}
+	builder.document.Materials = append(builder.document.Materials, material)
+	return len(builder.document.Materials) - 1
+}
@@ -0,0 +1,105 @@
+package main
+
+// Finds where every flag and variable is read and written by scanning every room script
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
+)
+
+func main() {
+	playerNum := game.PLAYER_LEON
+	format := "csv"
+	namesFilename := ""
+	dataPath := ""
+	for _, arg := range os.Args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--player="):
+			switch strings.ToLower(strings.TrimPrefix(arg, "--player=")) {
+			case "leon":
+				playerNum = game.PLAYER_LEON
+			case "claire":
+				playerNum = game.PLAYER_CLAIRE
+			default:
+				fmt.Printf("Error: Unknown player '%s', expected leon or claire\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.ToLower(strings.TrimPrefix(arg, "--format="))
+			if format != "csv" && format != "json" {
+				fmt.Printf("Error: Unknown format '%s', expected csv or json\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--names="):
+			namesFilename = strings.TrimPrefix(arg, "--names=")
+		case strings.HasPrefix(arg, "--") || dataPath != "":
+			fmt.Printf("Error: Unknown argument '%s'\n", arg)
+			os.Exit(1)
+		default:
+			dataPath = arg
+		}
+	}
+
+	if dataPath == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	names := script.NewFlagNames()
+	if namesFilename != "" {
+		var err error
+		names, err = script.LoadFlagNamesFile(namesFilename)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	fsys, err := resource.OpenAssetFS(dataPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	xref, err := script.LoadFlagXref(fsys, playerNum)
+	if err != nil {
+		fmt.Printf("Error: Failed to scan rooms: %v\n", err)
+		os.Exit(1)
+	}
+	for _, failed := range xref.FailedRooms {
+		fmt.Fprintf(os.Stderr, "Warning: room %s: %s\n", failed.Room, failed.Error)
+	}
+	xref = xref.WithNames(names)
+
+	if format == "json" {
+		err = xref.WriteJSON(os.Stdout)
+	} else {
+		err = xref.WriteCSV(os.Stdout)
+	}
+	if err != nil {
+		fmt.Printf("Error: Failed to write flags: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: flagxref [flags] <dataPath>")
+	fmt.Println("")
+	fmt.Println("Prints every room script instruction that reads or writes a flag or variable")
+	fmt.Println("The data path is a folder or .zip archive containing the data folder")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  --player=NAME      - leon or claire (default leon)")
+	fmt.Println("  --format=FORMAT    - csv or json (default csv)")
+	fmt.Println("  --names=FILE       - Flag names file, with lines like 'bit 1 47 FLAG_NAME' or 'var 12 VAR_NAME'")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  flagxref . > flags.csv")
+	fmt.Println("  flagxref --names=flags.txt --format=json re2.zip")
+	fmt.Println("")
+}
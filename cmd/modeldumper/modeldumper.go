@@ -10,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
 )
 
 // JSON-compatible versions of the data structures for debugging
@@ -271,7 +272,7 @@ func main() {
 
 	switch format {
 	case "pld":
-		pldData, err := fileio.LoadPLDFile(inputFile)
+		pldData, err := resource.LoadLocalFile(inputFile, fileio.LoadPLDStream)
 		if err != nil {
 			log.Fatalf("Failed to load PLD file: %v", err)
 		}
@@ -289,9 +290,9 @@ func main() {
 		fmt.Printf("Successfully processed PLD file with %d components\n", len(pldData.MeshData.Components))
 
 	case "emd":
-		emdData := fileio.LoadEMDFile(inputFile)
-		if emdData == nil {
-			log.Fatalf("Failed to load EMD file")
+		emdData, err := resource.LoadLocalFile(inputFile, fileio.LoadEMDStream)
+		if err != nil {
+			log.Fatalf("Failed to load EMD file: %v", err)
 		}
 		
 		jsonOutput := convertEMDToJSON(emdData)
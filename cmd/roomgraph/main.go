@@ -0,0 +1,137 @@
+package main
+
+// Finds how the rooms are connected by scanning the door AOTs in every room script
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+)
+
+func main() {
+	playerNum := game.PLAYER_LEON
+	format := "dot"
+	pathQuery := ""
+	dataPath := ""
+	for _, arg := range os.Args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--player="):
+			switch strings.ToLower(strings.TrimPrefix(arg, "--player=")) {
+			case "leon":
+				playerNum = game.PLAYER_LEON
+			case "claire":
+				playerNum = game.PLAYER_CLAIRE
+			default:
+				fmt.Printf("Error: Unknown player '%s', expected leon or claire\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.ToLower(strings.TrimPrefix(arg, "--format="))
+			if format != "dot" && format != "json" {
+				fmt.Printf("Error: Unknown format '%s', expected dot or json\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--path="):
+			pathQuery = strings.TrimPrefix(arg, "--path=")
+		case strings.HasPrefix(arg, "--") || dataPath != "":
+			fmt.Printf("Error: Unknown argument '%s'\n", arg)
+			os.Exit(1)
+		default:
+			dataPath = arg
+		}
+	}
+
+	if dataPath == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	fsys, err := resource.OpenAssetFS(dataPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The AOT manager logs every door it finds
+	log.SetOutput(io.Discard)
+	graph, err := world.LoadRoomGraph(fsys, playerNum)
+	if err != nil {
+		fmt.Printf("Error: Failed to scan rooms: %v\n", err)
+		os.Exit(1)
+	}
+	for _, failed := range graph.FailedRooms {
+		fmt.Fprintf(os.Stderr, "Warning: room %s: %s\n", failed.Room, failed.Error)
+	}
+
+	if pathQuery != "" {
+		if err := printShortestPath(graph, pathQuery); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if format == "json" {
+		err = graph.WriteJSON(os.Stdout)
+	} else {
+		err = graph.WriteDOT(os.Stdout)
+	}
+	if err != nil {
+		fmt.Printf("Error: Failed to write graph: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: roomgraph [flags] <dataPath>")
+	fmt.Println("")
+	fmt.Println("Prints the doors between every room of the game, found by scanning the room scripts")
+	fmt.Println("The data path is a folder or .zip archive containing the data folder")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  --player=NAME      - leon or claire (default leon)")
+	fmt.Println("  --format=FORMAT    - dot or json (default dot)")
+	fmt.Println("  --path=FROM,TO     - Print the fewest doors from one room to another, e.g. --path=100,10A")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  roomgraph . | dot -Tsvg -o rooms.svg")
+	fmt.Println("  roomgraph --player=claire --format=json re2.zip")
+	fmt.Println("  roomgraph --path=100,20F .")
+	fmt.Println("")
+}
+
+func printShortestPath(graph *world.RoomGraph, pathQuery string) error {
+	rooms := strings.Split(pathQuery, ",")
+	if len(rooms) != 2 {
+		return fmt.Errorf("invalid path '%s', expected FROM,TO", pathQuery)
+	}
+	from, err := world.ParseRoomKey(strings.ToUpper(strings.TrimSpace(rooms[0])))
+	if err != nil {
+		return err
+	}
+	to, err := world.ParseRoomKey(strings.ToUpper(strings.TrimSpace(rooms[1])))
+	if err != nil {
+		return err
+	}
+
+	doors, found := graph.ShortestPath(from, to)
+	if !found {
+		return fmt.Errorf("no path from room %s to room %s", from, to)
+	}
+	fmt.Printf("%d doors from room %s to room %s\n", len(doors), from, to)
+	for _, door := range doors {
+		lock := ""
+		if door.KeyId != 0 {
+			lock = fmt.Sprintf(", needs key %d (type %d)", door.KeyId, door.KeyType)
+		}
+		fmt.Printf("  %s -> %s through AOT %d, arriving at (%d, %d, %d) camera %d%s\n",
+			door.From, door.To, door.Aot, door.NextX, door.NextY, door.NextZ, door.Camera, lock)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+// Drawing backends for the room map, in pixel coordinates
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	ellipseSegments = 48
+	fontScale       = 2 // each font pixel is drawn as a square of this size
+)
+
+type pixelPoint struct {
+	X, Y float64
+}
+
+type mapCanvas interface {
+	Polygon(points []pixelPoint, style mapStyle)
+	Ellipse(center pixelPoint, radiusX float64, radiusY float64, style mapStyle)
+	Line(from pixelPoint, to pixelPoint, lineColor color.RGBA)
+	Text(position pixelPoint, text string, textColor color.RGBA)
+	Write(w io.Writer) error
+}
+
+type svgCanvas struct {
+	width, height int
+	body          strings.Builder
+}
+
+func newSVGCanvas(width int, height int) *svgCanvas {
+	return &svgCanvas{width: width, height: height}
+}
+
+func (canvas *svgCanvas) Polygon(points []pixelPoint, style mapStyle) {
+	coordinates := make([]string, len(points))
+	for i, point := range points {
+		coordinates[i] = fmt.Sprintf("%.1f,%.1f", point.X, point.Y)
+	}
+	fmt.Fprintf(&canvas.body, "<polygon points=\"%s\" %s/>\n", strings.Join(coordinates, " "), svgStyle(style))
+}
+
+func (canvas *svgCanvas) Ellipse(center pixelPoint, radiusX float64, radiusY float64, style mapStyle) {
+	fmt.Fprintf(&canvas.body, "<ellipse cx=\"%.1f\" cy=\"%.1f\" rx=\"%.1f\" ry=\"%.1f\" %s/>\n",
+		center.X, center.Y, radiusX, radiusY, svgStyle(style))
+}
+
+func (canvas *svgCanvas) Line(from pixelPoint, to pixelPoint, lineColor color.RGBA) {
+	fmt.Fprintf(&canvas.body, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\" stroke-opacity=\"%.2f\"/>\n",
+		from.X, from.Y, to.X, to.Y, svgColor(lineColor), svgOpacity(lineColor))
+}
+
+func (canvas *svgCanvas) Text(position pixelPoint, text string, textColor color.RGBA) {
+	fmt.Fprintf(&canvas.body, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\">%s</text>\n",
+		position.X, position.Y, svgColor(textColor), html.EscapeString(text))
+}
+
+func (canvas *svgCanvas) Write(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\">\n"+
+		"<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n"+
+		"<g font-family=\"monospace\" font-size=\"10\" text-anchor=\"middle\" dominant-baseline=\"middle\">\n"+
+		"%s</g>\n</svg>\n", canvas.width, canvas.height, canvas.width, canvas.height, canvas.body.String())
+	return err
+}
+
+func svgStyle(style mapStyle) string {
+	return fmt.Sprintf("fill=\"%s\" fill-opacity=\"%.2f\" stroke=\"%s\" stroke-opacity=\"%.2f\"",
+		svgColor(style.Fill), svgOpacity(style.Fill), svgColor(style.Stroke), svgOpacity(style.Stroke))
+}
+
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+func svgOpacity(c color.RGBA) float64 {
+	return float64(c.A) / 255
+}
+
+// Software rasterizer with alpha blending
+type pngCanvas struct {
+	image *image.RGBA
+}
+
+func newPNGCanvas(width int, height int) *pngCanvas {
+	canvas := &pngCanvas{image: image.NewRGBA(image.Rect(0, 0, width, height))}
+	for i := range canvas.image.Pix {
+		canvas.image.Pix[i] = 255
+	}
+	return canvas
+}
+
+func (canvas *pngCanvas) Polygon(points []pixelPoint, style mapStyle) {
+	canvas.fillPolygon(points, style.Fill)
+	for i := range points {
+		canvas.Line(points[i], points[(i+1)%len(points)], style.Stroke)
+	}
+}
+
+func (canvas *pngCanvas) Ellipse(center pixelPoint, radiusX float64, radiusY float64, style mapStyle) {
+	points := make([]pixelPoint, ellipseSegments)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / ellipseSegments
+		points[i] = pixelPoint{center.X + radiusX*math.Cos(angle), center.Y + radiusY*math.Sin(angle)}
+	}
+	canvas.Polygon(points, style)
+}
+
+// Bresenham's line algorithm
+func (canvas *pngCanvas) Line(from pixelPoint, to pixelPoint, lineColor color.RGBA) {
+	x0, y0 := int(math.Round(from.X)), int(math.Round(from.Y))
+	x1, y1 := int(math.Round(to.X)), int(math.Round(to.Y))
+	dx, dy := absInt(x1-x0), -absInt(y1-y0)
+	stepX, stepY := 1, 1
+	if x0 > x1 {
+		stepX = -1
+	}
+	if y0 > y1 {
+		stepY = -1
+	}
+	err := dx + dy
+	for {
+		canvas.blend(x0, y0, lineColor)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		doubledErr := 2 * err
+		if doubledErr >= dy {
+			err += dy
+			x0 += stepX
+		}
+		if doubledErr <= dx {
+			err += dx
+			y0 += stepY
+		}
+	}
+}
+
+// Text is centered on the position, like the svg labels
+func (canvas *pngCanvas) Text(position pixelPoint, text string, textColor color.RGBA) {
+	text = strings.ToUpper(text)
+	advance := (fontGlyphWidth + 1) * fontScale
+	left := int(math.Round(position.X)) - (len(text)*advance-fontScale)/2
+	top := int(math.Round(position.Y)) - fontGlyphHeight*fontScale/2
+	for i, character := range text {
+		glyph, exists := fontGlyphs[character]
+		if !exists {
+			glyph = fontGlyphs['?']
+		}
+		for row, line := range glyph {
+			for column, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+				for offsetY := 0; offsetY < fontScale; offsetY++ {
+					for offsetX := 0; offsetX < fontScale; offsetX++ {
+						canvas.blend(left+i*advance+column*fontScale+offsetX, top+row*fontScale+offsetY, textColor)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (canvas *pngCanvas) Write(w io.Writer) error {
+	return png.Encode(w, canvas.image)
+}
+
+// Even-odd scanline fill, sampling at the center of each pixel
+func (canvas *pngCanvas) fillPolygon(points []pixelPoint, fillColor color.RGBA) {
+	if len(points) < 3 || fillColor.A == 0 {
+		return
+	}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, point := range points {
+		minY = math.Min(minY, point.Y)
+		maxY = math.Max(maxY, point.Y)
+	}
+
+	crossings := make([]float64, 0, len(points))
+	for y := int(math.Floor(minY)); y <= int(math.Ceil(maxY)); y++ {
+		sampleY := float64(y) + 0.5
+		crossings = crossings[:0]
+		for i := range points {
+			start, end := points[i], points[(i+1)%len(points)]
+			if (start.Y <= sampleY) == (end.Y <= sampleY) {
+				continue
+			}
+			crossings = append(crossings, start.X+(sampleY-start.Y)*(end.X-start.X)/(end.Y-start.Y))
+		}
+		sort.Float64s(crossings)
+		for i := 0; i+1 < len(crossings); i += 2 {
+			for x := int(math.Ceil(crossings[i] - 0.5)); x < int(math.Ceil(crossings[i+1]-0.5)); x++ {
+				canvas.blend(x, y, fillColor)
+			}
+		}
+	}
+}
+
+func (canvas *pngCanvas) blend(x int, y int, source color.RGBA) {
+	if !(image.Point{x, y}.In(canvas.image.Rect)) {
+		return
+	}
+	offset := canvas.image.PixOffset(x, y)
+	alpha := uint32(source.A)
+	sourceChannels := [3]uint8{source.R, source.G, source.B}
+	for i, channel := range sourceChannels {
+		destination := uint32(canvas.image.Pix[offset+i])
+		canvas.image.Pix[offset+i] = uint8((uint32(channel)*alpha + destination*(255-alpha)) / 255)
+	}
+	canvas.image.Pix[offset+3] = 255
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,148 @@
+package main
+
+// Draws a top-down plan of a room without a GPU
+// Shows collision shapes, camera switches, doors, items, events, cameras and the player start
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type roomMapOptions struct {
+	floor         int
+	size          int
+	startPosition *mgl32.Vec3
+}
+
+func main() {
+	options := roomMapOptions{floor: 0, size: 1024}
+	filenames := make([]string, 0, 2)
+	for _, arg := range os.Args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--floor="):
+			value, err := strconv.Atoi(strings.TrimPrefix(arg, "--floor="))
+			if err != nil || value < 0 || value >= 32 {
+				fmt.Printf("Error: Invalid floor '%s', expected 0 to 31\n", arg)
+				os.Exit(1)
+			}
+			options.floor = value
+		case strings.HasPrefix(arg, "--size="):
+			value, err := strconv.Atoi(strings.TrimPrefix(arg, "--size="))
+			if err != nil || value < 64 {
+				fmt.Printf("Error: Invalid size '%s', expected at least 64\n", arg)
+				os.Exit(1)
+			}
+			options.size = value
+		case strings.HasPrefix(arg, "--start="):
+			position, err := parseStartPosition(strings.TrimPrefix(arg, "--start="))
+			if err != nil {
+				fmt.Printf("Error: Invalid start position '%s': %v\n", arg, err)
+				os.Exit(1)
+			}
+			options.startPosition = position
+		case strings.HasPrefix(arg, "--"):
+			fmt.Printf("Error: Unknown argument '%s'\n", arg)
+			os.Exit(1)
+		default:
+			filenames = append(filenames, arg)
+		}
+	}
+
+	if len(filenames) != 2 {
+		printUsage()
+		os.Exit(1)
+	}
+	inputFilename, outputFilename := filenames[0], filenames[1]
+	outputExtension := strings.ToLower(filepath.Ext(outputFilename))
+	if outputExtension != ".svg" && outputExtension != ".png" {
+		fmt.Printf("Error: Output file '%s' must be .svg or .png\n", outputFilename)
+		os.Exit(1)
+	}
+
+	if !resource.LocalFileExists(inputFilename) {
+		fmt.Printf("Error: Input file '%s' does not exist\n", inputFilename)
+		os.Exit(1)
+	}
+	rdtOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadRDT)
+	if err != nil {
+		if rdtOutput == nil || !errors.Is(err, fileio.ErrUnknownOpcode) {
+			fmt.Printf("Error: Failed to load RDT file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if options.startPosition == nil {
+		if roomKey, _, ok := world.ParseRoomFilename(inputFilename); ok {
+			if position, exists := game.DebugLocations[game.RoomMapKey{StageId: roomKey.Stage, RoomId: roomKey.Room}]; exists {
+				options.startPosition = &position
+			}
+		}
+	}
+
+	scriptObjects := world.CollectScriptObjects(rdtOutput.InitScriptData)
+	roomMap := buildRoomMap(rdtOutput, scriptObjects, options)
+
+	var canvas mapCanvas
+	width, height := roomMap.imageSize(options.size)
+	if outputExtension == ".svg" {
+		canvas = newSVGCanvas(width, height)
+	} else {
+		canvas = newPNGCanvas(width, height)
+	}
+	roomMap.draw(canvas, options.size)
+
+	outputFile, err := os.Create(outputFilename)
+	if err != nil {
+		fmt.Printf("Error: Failed to create %s: %v\n", outputFilename, err)
+		os.Exit(1)
+	}
+	defer outputFile.Close()
+	if err := canvas.Write(outputFile); err != nil {
+		fmt.Printf("Error: Failed to write %s: %v\n", outputFilename, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote floor %d of %s to %s (%dx%d)\n", options.floor, inputFilename, outputFilename, width, height)
+}
+
+func printUsage() {
+	fmt.Println("Usage: roommap [flags] <inputFilename> <outputFilename>")
+	fmt.Println("")
+	fmt.Println("Draws a top-down plan of a room as SVG or PNG, depending on the output extension")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  --floor=N    - Floor to draw (default 0)")
+	fmt.Println("  --size=N     - Size of the longest side of the image in pixels (default 1024)")
+	fmt.Println("  --start=X,Z  - Player start position, instead of the debug start position of the room")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  roommap data/Pl0/Rdt/ROOM1000.RDT room1000.svg")
+	fmt.Println("  roommap --floor=1 --size=2048 data/Pl0/Rdt/ROOM1030.RDT room1030.png")
+	fmt.Println("")
+}
+
+func parseStartPosition(value string) (*mgl32.Vec3, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected X,Z")
+	}
+	x, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, err
+	}
+	z, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, err
+	}
+	return &mgl32.Vec3{float32(x), 0, float32(z)}, nil
+}
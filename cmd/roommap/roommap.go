@@ -0,0 +1,274 @@
+package main
+
+// Builds the plan of a room in game coordinates
+// X points right and Z points up on the map
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	mapMargin         = 24 // pixels around the room
+	markerLabelOffset = 14
+	cameraViewAspect  = 4.0 / 3.0
+)
+
+var (
+	collisionStyle    = mapStyle{Fill: color.RGBA{255, 0, 0, 64}, Stroke: color.RGBA{200, 0, 0, 255}}
+	slopeStyle        = mapStyle{Fill: color.RGBA{255, 0, 255, 64}, Stroke: color.RGBA{200, 0, 200, 255}}
+	cameraSwitchStyle = mapStyle{Fill: color.RGBA{0, 255, 0, 40}, Stroke: color.RGBA{0, 150, 0, 255}}
+	doorStyle         = mapStyle{Fill: color.RGBA{0, 0, 255, 64}, Stroke: color.RGBA{0, 0, 200, 255}}
+	itemStyle         = mapStyle{Fill: color.RGBA{0, 255, 255, 64}, Stroke: color.RGBA{0, 150, 150, 255}}
+	eventStyle        = mapStyle{Fill: color.RGBA{255, 160, 0, 64}, Stroke: color.RGBA{200, 110, 0, 255}}
+	cameraStyle       = mapStyle{Fill: color.RGBA{230, 180, 0, 255}, Stroke: color.RGBA{150, 110, 0, 255}}
+	startStyle        = mapStyle{Fill: color.RGBA{0, 0, 0, 255}, Stroke: color.RGBA{0, 0, 0, 255}}
+)
+
+type mapPoint struct {
+	X, Z float64
+}
+
+type mapStyle struct {
+	Fill   color.RGBA
+	Stroke color.RGBA
+}
+
+type mapElementKind int
+
+const (
+	mapPolygon mapElementKind = iota
+	mapEllipse
+	mapLine
+	mapLabel
+	mapDot   // fixed size in pixels
+	mapCross // fixed size in pixels
+)
+
+type mapElement struct {
+	kind             mapElementKind
+	points           []mapPoint // corners, line ends, or the position of a label or marker
+	radiusX, radiusZ float64
+	style            mapStyle
+	text             string
+	labelOffset      float64 // pixels below the position, to keep labels clear of markers
+}
+
+type roomMap struct {
+	elements []mapElement
+	minX     float64
+	minZ     float64
+	maxX     float64
+	maxZ     float64
+}
+
+func buildRoomMap(rdtOutput *fileio.RDTOutput, scriptObjects *world.ScriptObjects, options roomMapOptions) *roomMap {
+	roomMap := &roomMap{minX: math.Inf(1), minZ: math.Inf(1), maxX: math.Inf(-1), maxZ: math.Inf(-1)}
+	floor := options.floor
+
+	if rdtOutput.CollisionData != nil {
+		for _, entity := range rdtOutput.CollisionData.CollisionEntities {
+			if floor < len(entity.FloorCheck) && entity.FloorCheck[floor] {
+				roomMap.addCollisionEntity(entity)
+			}
+		}
+	}
+
+	if rdtOutput.CameraSwitchData != nil {
+		for _, cameraSwitch := range rdtOutput.CameraSwitchData.CameraSwitches {
+			if !world.IsOnFloor(cameraSwitch.Floor, floor) {
+				continue
+			}
+			corners := []mapPoint{
+				{float64(cameraSwitch.X1), float64(cameraSwitch.Z1)},
+				{float64(cameraSwitch.X2), float64(cameraSwitch.Z2)},
+				{float64(cameraSwitch.X3), float64(cameraSwitch.Z3)},
+				{float64(cameraSwitch.X4), float64(cameraSwitch.Z4)},
+			}
+			roomMap.addPolygon(corners, cameraSwitchStyle, fmt.Sprintf("%d>%d", cameraSwitch.Cam0, cameraSwitch.Cam1))
+		}
+	}
+
+	aotManager := scriptObjects.AotManager
+	for _, door := range aotManager.Doors {
+		if world.IsOnFloor(door.Header.Floor, floor) {
+			label := fmt.Sprintf("D%d>%d%02X", door.Header.Aot, int(door.Stage)+1, door.Room)
+			roomMap.addPolygon(quadCorners(door.Bounds.Vertices), doorStyle, label)
+		}
+	}
+	for _, item := range aotManager.Items {
+		if world.IsOnFloor(item.Header.Floor, floor) {
+			label := fmt.Sprintf("I%d:%d", item.Header.Aot, item.ItemId)
+			roomMap.addPolygon(quadCorners(item.Bounds.Vertices), itemStyle, label)
+		}
+	}
+	for _, aot := range aotManager.AotTriggers {
+		if world.IsOnFloor(aot.Header.Floor, floor) {
+			label := fmt.Sprintf("E%d", aot.Header.Aot)
+			roomMap.addPolygon(quadCorners(aot.Bounds.Vertices), eventStyle, label)
+		}
+	}
+
+	if rdtOutput.RIDOutput != nil {
+		for cameraId, cameraInfo := range rdtOutput.RIDOutput.CameraPositions {
+			roomMap.addCamera(cameraId, cameraInfo)
+		}
+	}
+
+	if options.startPosition != nil {
+		position := mapPoint{float64(options.startPosition.X()), float64(options.startPosition.Z())}
+		roomMap.add(mapElement{kind: mapCross, points: []mapPoint{position}, style: startStyle})
+		roomMap.add(mapElement{kind: mapLabel, points: []mapPoint{position}, style: startStyle, text: "START", labelOffset: markerLabelOffset})
+	}
+
+	if len(roomMap.elements) == 0 {
+		roomMap.minX, roomMap.minZ, roomMap.maxX, roomMap.maxZ = 0, 0, 1, 1
+	}
+	return roomMap
+}
+
+// Same shapes as world.CheckCollision
+func (roomMap *roomMap) addCollisionEntity(entity fileio.CollisionEntity) {
+	x, z := float64(entity.X), float64(entity.Z)
+	width, density := float64(entity.Width), float64(entity.Density)
+	label := fmt.Sprintf("#%d", entity.ScaIndex)
+	rectangle := []mapPoint{{x, z}, {x, z + density}, {x + width, z + density}, {x + width, z}}
+
+	switch entity.Shape {
+	case 0, 9, 10:
+		// Rectangle, climb up and jump down
+		roomMap.addPolygon(rectangle, collisionStyle, label)
+	case 1:
+		// Triangle \\|
+		roomMap.addPolygon([]mapPoint{{x, z + density}, {x + width, z + density}, {x + width, z}}, collisionStyle, label)
+	case 2:
+		// Triangle |/
+		roomMap.addPolygon([]mapPoint{{x, z}, {x, z + density}, {x + width, z + density}}, collisionStyle, label)
+	case 3:
+		// Triangle /|
+		roomMap.addPolygon([]mapPoint{{x, z}, {x + width, z + density}, {x + width, z}}, collisionStyle, label)
+	case 6:
+		// Circle
+		radius := width / 2
+		roomMap.addEllipse(mapPoint{x + radius, z + radius}, radius, radius, collisionStyle, label)
+	case 7, 8:
+		// Ellipses with the major axis on x or z
+		roomMap.addEllipse(mapPoint{x + width/2, z + density/2}, width/2, density/2, collisionStyle, label)
+	case fileio.SCA_TYPE_SLOPE, fileio.SCA_TYPE_STAIRS:
+		roomMap.addPolygon(rectangle, slopeStyle, label)
+	}
+}
+
+// The camera is a dot with lines to the edges of its view and to the point it looks at
+func (roomMap *roomMap) addCamera(cameraId int, cameraInfo fileio.CameraInfo) {
+	from := mapPoint{float64(cameraInfo.CameraFrom.X()), float64(cameraInfo.CameraFrom.Z())}
+	to := mapPoint{float64(cameraInfo.CameraTo.X()), float64(cameraInfo.CameraTo.Z())}
+	directionX, directionZ := to.X-from.X, to.Z-from.Z
+	distance := math.Hypot(directionX, directionZ)
+
+	if distance > 0 {
+		verticalFov := float64(cameraInfo.CameraFov) * math.Pi / 180
+		horizontalFov := 2 * math.Atan(math.Tan(verticalFov/2)*cameraViewAspect)
+		viewAngle := math.Atan2(directionZ, directionX)
+		for _, edgeAngle := range []float64{viewAngle - horizontalFov/2, viewAngle + horizontalFov/2} {
+			edge := mapPoint{from.X + distance*math.Cos(edgeAngle), from.Z + distance*math.Sin(edgeAngle)}
+			roomMap.add(mapElement{kind: mapLine, points: []mapPoint{from, edge}, style: cameraStyle})
+		}
+		roomMap.add(mapElement{kind: mapLine, points: []mapPoint{from, to}, style: cameraStyle})
+	}
+	roomMap.add(mapElement{kind: mapDot, points: []mapPoint{from}, style: cameraStyle})
+	roomMap.add(mapElement{kind: mapLabel, points: []mapPoint{from}, style: cameraStyle, text: fmt.Sprintf("C%d", cameraId), labelOffset: markerLabelOffset})
+}
+
+func (roomMap *roomMap) addPolygon(corners []mapPoint, style mapStyle, label string) {
+	roomMap.add(mapElement{kind: mapPolygon, points: corners, style: style})
+	center := mapPoint{}
+	for _, corner := range corners {
+		center.X += corner.X / float64(len(corners))
+		center.Z += corner.Z / float64(len(corners))
+	}
+	roomMap.add(mapElement{kind: mapLabel, points: []mapPoint{center}, style: style, text: label})
+}
+
+func (roomMap *roomMap) addEllipse(center mapPoint, radiusX float64, radiusZ float64, style mapStyle, label string) {
+	roomMap.add(mapElement{kind: mapEllipse, points: []mapPoint{center}, radiusX: radiusX, radiusZ: radiusZ, style: style})
+	roomMap.add(mapElement{kind: mapLabel, points: []mapPoint{center}, style: style, text: label})
+}
+
+func (roomMap *roomMap) add(element mapElement) {
+	roomMap.elements = append(roomMap.elements, element)
+	for _, point := range element.points {
+		roomMap.minX = math.Min(roomMap.minX, point.X-element.radiusX)
+		roomMap.maxX = math.Max(roomMap.maxX, point.X+element.radiusX)
+		roomMap.minZ = math.Min(roomMap.minZ, point.Z-element.radiusZ)
+		roomMap.maxZ = math.Max(roomMap.maxZ, point.Z+element.radiusZ)
+	}
+}
+
+func quadCorners(vertices [4]mgl32.Vec3) []mapPoint {
+	corners := make([]mapPoint, 4)
+	for i, vertex := range vertices {
+		corners[i] = mapPoint{float64(vertex[0]), float64(vertex[2])}
+	}
+	return corners
+}
+
+// scale converts game units to pixels so the longest side of the room fits the image
+func (roomMap *roomMap) scale(size int) float64 {
+	extent := math.Max(roomMap.maxX-roomMap.minX, roomMap.maxZ-roomMap.minZ)
+	if extent <= 0 {
+		extent = 1
+	}
+	return float64(size-2*mapMargin) / extent
+}
+
+func (roomMap *roomMap) imageSize(size int) (int, int) {
+	scale := roomMap.scale(size)
+	width := int(math.Ceil((roomMap.maxX-roomMap.minX)*scale)) + 2*mapMargin
+	height := int(math.Ceil((roomMap.maxZ-roomMap.minZ)*scale)) + 2*mapMargin
+	return width, height
+}
+
+func (roomMap *roomMap) draw(canvas mapCanvas, size int) {
+	scale := roomMap.scale(size)
+	toPixel := func(point mapPoint) pixelPoint {
+		return pixelPoint{
+			X: (point.X-roomMap.minX)*scale + mapMargin,
+			Y: (roomMap.maxZ-point.Z)*scale + mapMargin,
+		}
+	}
+
+	// Labels go on top of every shape
+	for _, element := range roomMap.elements {
+		switch element.kind {
+		case mapPolygon:
+			points := make([]pixelPoint, len(element.points))
+			for i, point := range element.points {
+				points[i] = toPixel(point)
+			}
+			canvas.Polygon(points, element.style)
+		case mapEllipse:
+			canvas.Ellipse(toPixel(element.points[0]), element.radiusX*scale, element.radiusZ*scale, element.style)
+		case mapLine:
+			canvas.Line(toPixel(element.points[0]), toPixel(element.points[1]), element.style.Stroke)
+		case mapDot:
+			canvas.Ellipse(toPixel(element.points[0]), 4, 4, element.style)
+		case mapCross:
+			center := toPixel(element.points[0])
+			canvas.Line(pixelPoint{center.X - 6, center.Y - 6}, pixelPoint{center.X + 6, center.Y + 6}, element.style.Stroke)
+			canvas.Line(pixelPoint{center.X - 6, center.Y + 6}, pixelPoint{center.X + 6, center.Y - 6}, element.style.Stroke)
+		}
+	}
+	for _, element := range roomMap.elements {
+		if element.kind == mapLabel {
+			position := toPixel(element.points[0])
+			position.Y += element.labelOffset
+			canvas.Text(position, element.text, element.style.Stroke)
+		}
+	}
+}
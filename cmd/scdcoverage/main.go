@@ -0,0 +1,88 @@
+package main
+
+// Counts the opcodes used by every room script and which of them the interpreter implements
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
+)
+
+func main() {
+	playerNum := game.PLAYER_LEON
+	format := "text"
+	dataPath := ""
+	for _, arg := range os.Args[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--player="):
+			switch strings.ToLower(strings.TrimPrefix(arg, "--player=")) {
+			case "leon":
+				playerNum = game.PLAYER_LEON
+			case "claire":
+				playerNum = game.PLAYER_CLAIRE
+			default:
+				fmt.Printf("Error: Unknown player '%s', expected leon or claire\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--format="):
+			format = strings.ToLower(strings.TrimPrefix(arg, "--format="))
+			if format != "text" && format != "json" {
+				fmt.Printf("Error: Unknown format '%s', expected text or json\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--") || dataPath != "":
+			fmt.Printf("Error: Unknown argument '%s'\n", arg)
+			os.Exit(1)
+		default:
+			dataPath = arg
+		}
+	}
+
+	if dataPath == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	fsys, err := resource.OpenAssetFS(dataPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	coverage, err := script.LoadOpcodeCoverage(fsys, playerNum)
+	if err != nil {
+		fmt.Printf("Error: Failed to scan rooms: %v\n", err)
+		os.Exit(1)
+	}
+
+	if format == "json" {
+		err = coverage.WriteJSON(os.Stdout)
+	} else {
+		err = coverage.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Printf("Error: Failed to write coverage: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printUsage() {
+	fmt.Println("Usage: scdcoverage [flags] <dataPath>")
+	fmt.Println("")
+	fmt.Println("Counts how often each opcode is used by the room scripts, and in which rooms")
+	fmt.Println("Opcodes the interpreter skips are listed first, so the ones blocking the most rooms stand out")
+	fmt.Println("The data path is a folder or .zip archive containing the data folder")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  --player=NAME      - leon or claire (default leon)")
+	fmt.Println("  --format=FORMAT    - text or json (default text)")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  scdcoverage .")
+	fmt.Println("  scdcoverage --player=claire --format=json re2.zip > coverage.json")
+	fmt.Println("")
+}
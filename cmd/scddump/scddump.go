@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
+)
+
+type scriptOutput struct {
+	Name      string                      `json:"name"`
+	Functions []script.DecompiledFunction `json:"functions"`
+}
+
+func main() {
+	jsonOutput := false
+	inputFilename := ""
+	var names *script.FlagNames
+	for _, arg := range os.Args[1:] {
+		if arg == "--json" {
+			jsonOutput = true
+		} else if strings.HasPrefix(arg, "--names=") {
+			var err error
+			names, err = script.LoadFlagNamesFile(strings.TrimPrefix(arg, "--names="))
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else if !strings.HasPrefix(arg, "--") && inputFilename == "" {
+			inputFilename = arg
+		} else {
+			fmt.Printf("Error: Unknown argument '%s'\n", arg)
+			os.Exit(1)
+		}
+	}
+
+	if inputFilename == "" {
+		fmt.Println("Usage: scddump [--json] [--names=FILE] <inputFilename>")
+		fmt.Println("")
+		fmt.Println("Prints the init and main scripts of a room as pseudocode")
+		fmt.Println("")
+		fmt.Println("Options:")
+		fmt.Println("  --json         - Print the decompiled scripts as JSON")
+		fmt.Println("  --names=FILE   - Show flags and variables by their names from a flag names file")
+		fmt.Println("")
+		fmt.Println("Examples:")
+		fmt.Println("  scddump data/Pl0/Rdt/ROOM1000.RDT")
+		fmt.Println("  scddump --json data/Pl0/Rdt/ROOM1000.RDT")
+		fmt.Println("  scddump --names=flags.txt data/Pl0/Rdt/ROOM1000.RDT")
+		fmt.Println("")
+		os.Exit(1)
+	}
+
+	if !resource.LocalFileExists(inputFilename) {
+		fmt.Printf("Error: Input file '%s' does not exist\n", inputFilename)
+		os.Exit(1)
+	}
+
+	rdtOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadRDT)
+	if err != nil {
+		if rdtOutput == nil || !errors.Is(err, fileio.ErrUnknownOpcode) {
+			fmt.Printf("Error: Failed to load RDT file: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	scripts := []scriptOutput{
+		{Name: "init", Functions: decompile(rdtOutput.InitScriptData, names)},
+		{Name: "main", Functions: decompile(rdtOutput.RoomScriptData, names)},
+	}
+
+	if jsonOutput {
+		output, err := json.MarshalIndent(scripts, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: Failed to write JSON: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(output))
+		return
+	}
+
+	for i, scriptData := range scripts {
+		if i > 0 {
+			fmt.Println("")
+		}
+		fmt.Printf("// %s script\n", scriptData.Name)
+		fmt.Print(script.FormatDecompiledScript(scriptData.Functions, names))
+	}
+}
+
+func decompile(scdOutput *fileio.SCDOutput, names *script.FlagNames) []script.DecompiledFunction {
+	if scdOutput == nil {
+		return []script.DecompiledFunction{}
+	}
+	return script.DecompileScript(scdOutput.ScriptData, names)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Buttons held down during a frame
+type frameInput struct {
+	Forward  bool
+	Backward bool
+	Left     bool
+	Right    bool
+	Action   bool
+}
+
+func (input frameInput) names() []string {
+	names := make([]string, 0)
+	for _, button := range []struct {
+		name string
+		held bool
+	}{
+		{"forward", input.Forward},
+		{"backward", input.Backward},
+		{"left", input.Left},
+		{"right", input.Right},
+		{"action", input.Action},
+	} {
+		if button.held {
+			names = append(names, button.name)
+		}
+	}
+	return names
+}
+
+// One line of the input sequence holds some buttons for a number of frames
+type inputStep struct {
+	frames int
+	input  frameInput
+}
+
+type inputSequence []inputStep
+
+// parseInputSequence reads lines of "<frames> <button>[+<button>...]", e.g. "30 forward+left".
+// Blank lines and lines starting with # are ignored.
+func parseInputSequence(r io.Reader) (inputSequence, error) {
+	sequence := make(inputSequence, 0)
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("line %d: expected <frames> <buttons>, got %q", lineNumber, line)
+		}
+		frames, err := strconv.Atoi(fields[0])
+		if err != nil || frames < 1 {
+			return nil, fmt.Errorf("line %d: invalid frame count %q", lineNumber, fields[0])
+		}
+		input, err := parseButtons(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		sequence = append(sequence, inputStep{frames: frames, input: input})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sequence, nil
+}
+
+func parseButtons(value string) (frameInput, error) {
+	input := frameInput{}
+	for _, button := range strings.Split(strings.ToLower(value), "+") {
+		switch button {
+		case "forward":
+			input.Forward = true
+		case "backward":
+			input.Backward = true
+		case "left":
+			input.Left = true
+		case "right":
+			input.Right = true
+		case "action":
+			input.Action = true
+		case "none":
+		default:
+			return input, fmt.Errorf("unknown button %q, expected forward, backward, left, right, action or none", button)
+		}
+	}
+	return input, nil
+}
+
+func (sequence inputSequence) totalFrames() int {
+	total := 0
+	for _, step := range sequence {
+		total += step.frames
+	}
+	return total
+}
+
+// inputAt returns the buttons held at a frame, with nothing held after the sequence ends
+func (sequence inputSequence) inputAt(frame int) frameInput {
+	for _, step := range sequence {
+		if frame < step.frames {
+			return step.input
+		}
+		frame -= step.frames
+	}
+	return frameInput{}
+}
@@ -0,0 +1,285 @@
+package main
+
+// Runs a room at 30 frames per second without a window or GPU
+// Applies a scripted input sequence and writes a JSON trace of every frame
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	simulationFrameRate = 30
+	defaultFrames       = 300
+)
+
+type simulateOptions struct {
+	dataPath      string
+	playerName    string
+	playerId      int
+	room          world.RoomKey
+	frames        int
+	inputFilename string
+	startPosition *mgl32.Vec3
+	startRotation float32
+}
+
+func main() {
+	options, outputFilename, err := parseArguments(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if outputFilename == "" {
+		printUsage()
+		os.Exit(1)
+	}
+
+	assetFS, err := resource.OpenAssetFS(options.dataPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	resource.SetAssetFS(assetFS)
+
+	inputs := inputSequence{}
+	if options.inputFilename != "" {
+		inputFile, err := os.Open(options.inputFilename)
+		if err != nil {
+			fmt.Printf("Error: Failed to open input sequence: %v\n", err)
+			os.Exit(1)
+		}
+		inputs, err = parseInputSequence(inputFile)
+		inputFile.Close()
+		if err != nil {
+			fmt.Printf("Error: Invalid input sequence %s: %v\n", options.inputFilename, err)
+			os.Exit(1)
+		}
+	}
+	if options.frames == 0 {
+		options.frames = inputs.totalFrames()
+		if options.frames == 0 {
+			options.frames = defaultFrames
+		}
+	}
+
+	if options.startPosition == nil {
+		options.startPosition = findStartPosition(options)
+	}
+
+	trace, err := simulate(options, inputs)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	output, err := json.MarshalIndent(trace, "", "  ")
+	if err != nil {
+		fmt.Printf("Error: Failed to write trace: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outputFilename, output, 0644); err != nil {
+		fmt.Printf("Error: Failed to write %s: %v\n", outputFilename, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Wrote %d frames to %s\n", len(trace.Frames), outputFilename)
+}
+
+func printUsage() {
+	fmt.Println("Usage: simulate [flags] <outputFilename>")
+	fmt.Println("")
+	fmt.Println("Runs a room at 30 frames per second without a window and writes a JSON trace of")
+	fmt.Println("the player position, camera, flag changes and script threads in every frame")
+	fmt.Println("")
+	fmt.Println("Flags:")
+	fmt.Println("  --data=PATH       - Folder or .zip archive containing the data folder (default .)")
+	fmt.Println("  --player=NAME     - leon or claire (default leon)")
+	fmt.Println("  --room=ROOM       - Stage and room to start in (default 100)")
+	fmt.Println("  --frames=N        - Number of frames to run (default is the length of the input, or 300)")
+	fmt.Println("  --input=FILE      - Input sequence, one \"<frames> <buttons>\" per line")
+	fmt.Println("                      Buttons are forward, backward, left, right, action or none, joined by +")
+	fmt.Println("  --start=X,Y,Z     - Player start position (default is the debug location or a door into the room)")
+	fmt.Println("  --rotation=DEG    - Player start rotation in degrees (default 180)")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  simulate --room=100 --frames=90 trace.json")
+	fmt.Println("  simulate --data=re2.zip --player=claire --input=walk.txt trace.json")
+	fmt.Println("")
+}
+
+func parseArguments(args []string) (simulateOptions, string, error) {
+	options := simulateOptions{
+		dataPath:      ".",
+		playerName:    "leon",
+		playerId:      game.PLAYER_LEON,
+		room:          world.RoomKey{Stage: 1, Room: 0},
+		startRotation: 180,
+	}
+	outputFilename := ""
+	for _, arg := range args {
+		name, value, _ := strings.Cut(arg, "=")
+		var err error
+		switch name {
+		case "--data":
+			options.dataPath = value
+		case "--player":
+			options.playerName = strings.ToLower(value)
+			switch options.playerName {
+			case "leon":
+				options.playerId = game.PLAYER_LEON
+			case "claire":
+				options.playerId = game.PLAYER_CLAIRE
+			default:
+				err = fmt.Errorf("unknown player %q, expected leon or claire", value)
+			}
+		case "--room":
+			options.room, err = world.ParseRoomKey(strings.ToUpper(value))
+		case "--frames":
+			options.frames, err = strconv.Atoi(value)
+			if err == nil && options.frames < 1 {
+				err = fmt.Errorf("invalid frame count %d", options.frames)
+			}
+		case "--input":
+			options.inputFilename = value
+		case "--start":
+			options.startPosition, err = parseVec3(value)
+		case "--rotation":
+			var rotation float64
+			rotation, err = strconv.ParseFloat(value, 32)
+			options.startRotation = float32(rotation)
+		default:
+			if strings.HasPrefix(arg, "--") || outputFilename != "" {
+				err = fmt.Errorf("unknown argument '%s'", arg)
+			}
+			outputFilename = arg
+		}
+		if err != nil {
+			return options, "", err
+		}
+	}
+	return options, outputFilename, nil
+}
+
+func parseVec3(value string) (*mgl32.Vec3, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid position %q, expected X,Y,Z", value)
+	}
+	position := mgl32.Vec3{}
+	for i, part := range parts {
+		coordinate, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid position %q, expected X,Y,Z", value)
+		}
+		position[i] = float32(coordinate)
+	}
+	return &position, nil
+}
+
+// The debug locations are checked first, then the doors leading into the room
+func findStartPosition(options simulateOptions) *mgl32.Vec3 {
+	roomMapKey := game.RoomMapKey{StageId: options.room.Stage, RoomId: options.room.Room}
+	if position, exists := game.DebugLocations[roomMapKey]; exists {
+		return &position
+	}
+	graph, err := world.LoadRoomGraph(resource.AssetFS(), options.playerId)
+	if err == nil {
+		if position, exists := graph.SpawnPosition(options.room); exists {
+			return &position
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Warning: no start position for room %s, starting at the origin\n", options.room)
+	return &mgl32.Vec3{}
+}
+
+// simulate follows the same steps as the main game state, one step per frame
+func simulate(options simulateOptions, inputs inputSequence) (*simulationTrace, error) {
+	timeElapsedSeconds := 1.0 / float64(simulationFrameRate)
+
+	gameDef := game.NewGame(options.playerId, options.room.Stage, options.room.Room, 0)
+	gameDef.Player = game.NewPlayer(*options.startPosition, options.startRotation)
+	scriptDef := script.NewScriptDef()
+	scriptDef.InitGameFlags(options.playerId)
+	scene := &traceScene{}
+	host := &simulateHost{GameDef: gameDef, traceScene: scene}
+
+	trace := &simulationTrace{
+		Player:    options.playerName,
+		FrameRate: simulationFrameRate,
+		Frames:    make([]frameTrace, 0, options.frames),
+	}
+	previousState := copyScriptState(scriptDef)
+
+	for frame := 0; frame < options.frames; frame++ {
+		input := inputs.inputAt(frame)
+		events := make([]string, 0)
+
+		switch gameDef.StateStatus {
+		case game.GAME_LOAD_ROOM:
+			if _, err := gameDef.LoadRoom(); err != nil {
+				return nil, fmt.Errorf("frame %d: failed to load room: %w", frame, err)
+			}
+			events = append(events, fmt.Sprintf("loaded %s", gameDef.GetRoomFilename(gameDef.PlayerId)))
+			scriptDef.InitRoomScripts(gameDef.RoomScript, host)
+			gameDef.StateStatus = game.GAME_LOAD_CAMERA
+		case game.GAME_LOAD_CAMERA:
+			events = append(events, fmt.Sprintf("camera %d", gameDef.CameraId))
+			gameDef.StateStatus = game.GAME_LOOP
+		case game.GAME_LOOP:
+			applyInput(gameDef, input, timeElapsedSeconds)
+			stageId, roomId := gameDef.StageId, gameDef.RoomId
+			gameDef.HandleCameraSwitch(gameDef.Player.Position)
+			gameDef.HandleRoomSwitch(gameDef.Player.Position)
+			if gameDef.StageId != stageId || gameDef.RoomId != roomId {
+				events = append(events, fmt.Sprintf("door to room %d%02X", gameDef.StageId, gameDef.RoomId))
+			}
+			scriptDef.RunEventTrigger(gameDef)
+			scriptDef.RunScript(gameDef.RoomScript.RoomScriptData, timeElapsedSeconds, host)
+		}
+
+		frameTrace := traceFrame(frame, input, gameDef, scriptDef)
+		currentState := copyScriptState(scriptDef)
+		frameTrace.FlagChanges, frameTrace.VariableChanges = previousState.changes(currentState)
+		previousState = currentState
+		frameTrace.Events = append(events, scene.takeEvents()...)
+		trace.Frames = append(trace.Frames, frameTrace)
+	}
+	trace.UnimplementedOpcodes = traceUnimplementedOpcodes(scriptDef)
+	return trace, nil
+}
+
+// Same tank controls as the game
+func applyInput(gameDef *game.GameDef, input frameInput, timeElapsedSeconds float64) {
+	player := gameDef.Player
+	collisionEntities := gameDef.GameWorld.GameRoom.CollisionEntities
+
+	if input.Forward {
+		player.HandlePlayerInputForward(collisionEntities, timeElapsedSeconds)
+	}
+	if input.Backward {
+		player.HandlePlayerInputBackward(collisionEntities, timeElapsedSeconds)
+	}
+	if !input.Forward && !input.Backward {
+		player.PoseNumber = game.PLAYER_IDLE_POSE
+	}
+
+	if input.Left {
+		player.RotatePlayerLeft(timeElapsedSeconds)
+	}
+	if input.Right {
+		player.RotatePlayerRight(timeElapsedSeconds)
+	}
+
+	if input.Action {
+		gameDef.HandlePlayerActionButton(collisionEntities)
+	}
+}
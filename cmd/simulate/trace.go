@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
+)
+
+type simulationTrace struct {
+	Player               string         `json:"player"`
+	FrameRate            int            `json:"frameRate"`
+	Frames               []frameTrace   `json:"frames"`
+	UnimplementedOpcodes map[string]int `json:"unimplementedOpcodes,omitempty"` // times each skipped opcode was hit
+}
+
+type frameTrace struct {
+	Frame           int              `json:"frame"`
+	Input           []string         `json:"input,omitempty"`
+	Stage           int              `json:"stage"`
+	Room            int              `json:"room"`
+	Camera          int              `json:"camera"`
+	Position        [3]float32       `json:"position"`
+	Rotation        float32          `json:"rotation"`
+	Pose            int              `json:"pose"`
+	FlagChanges     []flagChange     `json:"flagChanges,omitempty"`
+	VariableChanges []variableChange `json:"variableChanges,omitempty"`
+	Threads         []threadTrace    `json:"threads"`
+	Events          []string         `json:"events,omitempty"`
+}
+
+type flagChange struct {
+	BitArray int `json:"bitArray"`
+	Bit      int `json:"bit"`
+	Old      int `json:"old"`
+	New      int `json:"new"`
+}
+
+type variableChange struct {
+	Id  int `json:"id"`
+	Old int `json:"old"`
+	New int `json:"new"`
+}
+
+type threadTrace struct {
+	Thread         int   `json:"thread"`
+	ProgramCounter int   `json:"programCounter"`
+	SubLevel       int   `json:"subLevel"`
+	FunctionIds    []int `json:"functionIds"`
+}
+
+// scriptState is a copy of the flags and variables, to find what changed in a frame
+type scriptState struct {
+	bitArrays map[int]map[int]int
+	variables map[int]int
+}
+
+func copyScriptState(scriptDef *script.ScriptDef) scriptState {
+	state := scriptState{bitArrays: make(map[int]map[int]int), variables: make(map[int]int)}
+	for bitArrayIndex, bitArray := range scriptDef.ScriptBitArray {
+		state.bitArrays[bitArrayIndex] = make(map[int]int)
+		for bit, value := range bitArray {
+			state.bitArrays[bitArrayIndex][bit] = value
+		}
+	}
+	for id, value := range scriptDef.ScriptVariable {
+		state.variables[id] = value
+	}
+	return state
+}
+
+// Missing flags and variables are 0, so reading one for the first time is not a change
+func (previous scriptState) changes(current scriptState) ([]flagChange, []variableChange) {
+	flagChanges := make([]flagChange, 0)
+	for bitArrayIndex, bitArray := range current.bitArrays {
+		for bit, value := range bitArray {
+			if oldValue := previous.bitArrays[bitArrayIndex][bit]; oldValue != value {
+				flagChanges = append(flagChanges, flagChange{BitArray: bitArrayIndex, Bit: bit, Old: oldValue, New: value})
+			}
+		}
+	}
+	sort.Slice(flagChanges, func(i, j int) bool {
+		if flagChanges[i].BitArray != flagChanges[j].BitArray {
+			return flagChanges[i].BitArray < flagChanges[j].BitArray
+		}
+		return flagChanges[i].Bit < flagChanges[j].Bit
+	})
+
+	variableChanges := make([]variableChange, 0)
+	for id, value := range current.variables {
+		if oldValue := previous.variables[id]; oldValue != value {
+			variableChanges = append(variableChanges, variableChange{Id: id, Old: oldValue, New: value})
+		}
+	}
+	sort.Slice(variableChanges, func(i, j int) bool { return variableChanges[i].Id < variableChanges[j].Id })
+	return flagChanges, variableChanges
+}
+
+// Keyed by name and opcode so the trace is readable
+func traceUnimplementedOpcodes(scriptDef *script.ScriptDef) map[string]int {
+	opcodes := make(map[string]int)
+	for opcode, count := range scriptDef.UnimplementedOpcodes {
+		opcodes[fmt.Sprintf("%s (0x%02x)", script.FunctionName[opcode], opcode)] = count
+	}
+	return opcodes
+}
+
+func traceThreads(scriptDef *script.ScriptDef) []threadTrace {
+	threads := make([]threadTrace, 0)
+	for threadNum, thread := range scriptDef.ScriptThreads {
+		if !thread.RunStatus {
+			continue
+		}
+		threads = append(threads, threadTrace{
+			Thread:         threadNum,
+			ProgramCounter: thread.ProgramCounter,
+			SubLevel:       thread.SubLevel,
+			FunctionIds:    append([]int(nil), thread.FunctionIds...),
+		})
+	}
+	return threads
+}
+
+func traceFrame(frame int, input frameInput, gameDef *game.GameDef, scriptDef *script.ScriptDef) frameTrace {
+	player := gameDef.Player
+	return frameTrace{
+		Frame:    frame,
+		Input:    input.names(),
+		Stage:    gameDef.StageId,
+		Room:     gameDef.RoomId,
+		Camera:   gameDef.CameraId,
+		Position: [3]float32{player.Position.X(), player.Position.Y(), player.Position.Z()},
+		Rotation: player.RotationAngle,
+		Pose:     player.PoseNumber,
+		Threads:  traceThreads(scriptDef),
+	}
+}
+
+// traceScene records what the scripts add to the scene instead of drawing it
+type traceScene struct {
+	events []string
+}
+
+func (scene *traceScene) SetObjectModel(instruction fileio.ScriptInstrObjModelSet) {
+	scene.addEvent("object %d model set at (%d, %d, %d)", instruction.ObjectIndex,
+		instruction.Position[0], instruction.Position[1], instruction.Position[2])
+}
+
+func (scene *traceScene) SetObjectRotation(objectIndex int, rotationAngle float32) {
+	scene.addEvent("object %d rotated to %.1f", objectIndex, rotationAngle)
+}
+
+func (scene *traceScene) AddEnemy(instruction fileio.ScriptInstrSceEmSet) {
+	scene.addEvent("enemy type 0x%03X added at (%d, %d, %d)", instruction.Type, instruction.X, instruction.Y, instruction.Z)
+}
+
+func (scene *traceScene) AddSprite(sprite fileio.ScriptInstrSceEsprOn) {
+	scene.addEvent("sprite %d added at (%d, %d, %d)", sprite.Id, sprite.X, sprite.Y, sprite.Z)
+}
+
+func (scene *traceScene) RemoveSprite(instruction fileio.ScriptInstrSceEsprKill) {
+	scene.addEvent("sprite %d removed", instruction.Id)
+}
+
+func (scene *traceScene) addEvent(format string, args ...any) {
+	scene.events = append(scene.events, fmt.Sprintf(format, args...))
+}
+
+// takeEvents returns the events since the last call
+func (scene *traceScene) takeEvents() []string {
+	events := scene.events
+	scene.events = nil
+	return events
+}
+
+// simulateHost runs the scripts against the game and records the scene, sounds and messages in the trace
+type simulateHost struct {
+	*game.GameDef
+	*traceScene
+}
+
+func (host *simulateHost) AddSprite(sprite fileio.ScriptInstrSceEsprOn) {
+	host.GameWorld.AotManager.AddScriptSprite(sprite)
+	host.traceScene.AddSprite(sprite)
+}
+
+func (host *simulateHost) ControlBgm(instruction fileio.ScriptInstrSceBgmControl) {
+	host.addEvent("bgm %d operation %d", instruction.Id, instruction.Operation)
+}
+
+func (host *simulateHost) PlaySoundEffect(instruction fileio.ScriptInstrSeOn) {
+	host.addEvent("sound effect %d:%d at (%d, %d, %d)", instruction.VabId, instruction.EdtId,
+		instruction.X, instruction.Y, instruction.Z)
+}
+
+func (host *simulateHost) PlayVoice(instruction fileio.ScriptInstrXaOn) {
+	host.addEvent("voice %d on channel %d", instruction.Id, instruction.Channel)
+}
+
+func (host *simulateHost) ShowMessage(instruction fileio.ScriptInstrMessageOn) {
+	host.addEvent("message %d", instruction.MessageId)
+}
@@ -0,0 +1,244 @@
+package main
+
+// Extracts every background image and mask from roomcut.bin
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+)
+
+var maskOverlayColor = color.RGBA{255, 0, 255, 255}
+
+type roomcutOptions struct {
+	rdtFolder string // rooms with the camera masks, only needed for overlays
+	playerNum int    // whose room files are in rdtFolder
+	workers   int
+}
+
+// roomMaskCache loads the camera masks of each room once, even when several workers need them
+type roomMaskCache struct {
+	mutex     sync.Mutex
+	rdtFolder string
+	playerNum int
+	rooms     map[game.RoomMapKey]*roomMasks
+}
+
+type roomMasks struct {
+	once        sync.Once
+	cameraMasks [][]fileio.MaskRectangle
+	err         error
+}
+
+type roomcutResult struct {
+	imageNumber int
+	filenames   []string
+	err         error
+}
+
+func unpackRoomcut(inputFilename string, outputFolder string, options roomcutOptions) {
+	binFile, err := resource.OpenLocalFile(inputFilename)
+	if err != nil {
+		fmt.Printf("Error: Failed to open BIN file: %v\n", err)
+		os.Exit(1)
+	}
+	defer binFile.Close()
+
+	binOutput, err := fileio.LoadBINStream(binFile, binFile.Size)
+	if err != nil {
+		fmt.Printf("Error: Failed to load BIN file: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Found %d images, decoding with %d workers\n", len(binOutput.ImagesIndex), options.workers)
+
+	var maskCache *roomMaskCache
+	if options.rdtFolder != "" {
+		maskCache = &roomMaskCache{rdtFolder: options.rdtFolder, playerNum: options.playerNum, rooms: make(map[game.RoomMapKey]*roomMasks)}
+	}
+
+	jobs := make(chan int)
+	results := make(chan roomcutResult)
+	var workers sync.WaitGroup
+	for i := 0; i < options.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for imageNumber := range jobs {
+				filenames, err := extractRoomcutImage(binFile, binOutput, imageNumber, outputFolder, maskCache)
+				results <- roomcutResult{imageNumber: imageNumber, filenames: filenames, err: err}
+			}
+		}()
+	}
+	go func() {
+		for imageNumber := range binOutput.ImagesIndex {
+			jobs <- imageNumber
+		}
+		close(jobs)
+		workers.Wait()
+		close(results)
+	}()
+
+	allResults := make([]roomcutResult, 0, len(binOutput.ImagesIndex))
+	for result := range results {
+		allResults = append(allResults, result)
+	}
+	sort.Slice(allResults, func(i, j int) bool { return allResults[i].imageNumber < allResults[j].imageNumber })
+
+	fileCount := 0
+	errorCount := 0
+	for _, result := range allResults {
+		if result.err != nil {
+			fmt.Printf("  ✗ Image %d: %v\n", result.imageNumber, result.err)
+			errorCount++
+			continue
+		}
+		for _, filename := range result.filenames {
+			fmt.Printf("  ✓ Image %d -> %s\n", result.imageNumber, filename)
+		}
+		fileCount += len(result.filenames)
+	}
+	fmt.Printf("\nSuccessfully wrote %d files to %s (%d images failed)\n", fileCount, outputFolder, errorCount)
+}
+
+// extractRoomcutImage writes the background, the mask and optionally the mask overlay of one camera
+func extractRoomcutImage(binFile *resource.Asset, binOutput *fileio.BinOutput, imageNumber int, outputFolder string, maskCache *roomMaskCache) ([]string, error) {
+	if binOutput.ImagesIndex[imageNumber].Length == 0 {
+		return nil, nil
+	}
+	roomOutput, err := fileio.ExtractRoomBackgroundStream(binFile, binOutput, imageNumber)
+	if err != nil {
+		return nil, err
+	}
+	if roomOutput == nil || roomOutput.BackgroundImage == nil || roomOutput.BackgroundImage.PixelData == nil {
+		return nil, fmt.Errorf("no background image")
+	}
+
+	stageId, roomId, cameraId := game.GetBackgroundImageLocation(imageNumber)
+	baseFilename := fmt.Sprintf("room%01d%02x_cam%02d", stageId, roomId, cameraId)
+	filenames := make([]string, 0, 3)
+
+	backgroundImage := roomOutput.BackgroundImage.Image()
+	if err := writePNG(filepath.Join(outputFolder, baseFilename+".png"), backgroundImage); err != nil {
+		return nil, err
+	}
+	filenames = append(filenames, baseFilename+".png")
+
+	if roomOutput.ImageMask == nil {
+		return filenames, nil
+	}
+	maskImage, err := roomOutput.ImageMask.ImageWithPalette(fileio.TIM_PALETTE_AUTO)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mask: %w", err)
+	}
+	if err := writePNG(filepath.Join(outputFolder, baseFilename+"_mask.png"), maskImage); err != nil {
+		return nil, err
+	}
+	filenames = append(filenames, baseFilename+"_mask.png")
+
+	if maskCache == nil {
+		return filenames, nil
+	}
+	cameraMasks, err := maskCache.cameraMasks(stageId, roomId)
+	if err != nil {
+		return nil, err
+	}
+	if cameraId >= len(cameraMasks) {
+		return nil, fmt.Errorf("room %d%02x has no camera %d", stageId, roomId, cameraId)
+	}
+	maskPixels, err := roomOutput.ImageMask.PixelDataWithPalette(fileio.TIM_PALETTE_AUTO)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode mask: %w", err)
+	}
+	drawMaskOverlay(backgroundImage, maskPixels, cameraMasks[cameraId])
+	if err := writePNG(filepath.Join(outputFolder, baseFilename+"_overlay.png"), backgroundImage); err != nil {
+		return nil, err
+	}
+	filenames = append(filenames, baseFilename+"_overlay.png")
+	return filenames, nil
+}
+
+func (cache *roomMaskCache) cameraMasks(stageId int, roomId int) ([][]fileio.MaskRectangle, error) {
+	key := game.RoomMapKey{StageId: stageId, RoomId: roomId}
+	cache.mutex.Lock()
+	room, exists := cache.rooms[key]
+	if !exists {
+		room = &roomMasks{}
+		cache.rooms[key] = room
+	}
+	cache.mutex.Unlock()
+
+	room.once.Do(func() {
+		rdtFilename := filepath.Join(cache.rdtFolder, path.Base(resource.PlayerRDTFile(cache.playerNum, stageId, roomId)))
+		rdtOutput, err := resource.LoadLocalFile(rdtFilename, fileio.LoadRDT)
+		if err != nil {
+			if rdtOutput == nil || !errors.Is(err, fileio.ErrUnknownOpcode) {
+				room.err = err
+				return
+			}
+			fmt.Printf("Warning: %v\n", err)
+		}
+		room.cameraMasks = rdtOutput.RIDOutput.CameraMasks
+	})
+	return room.cameraMasks, room.err
+}
+
+// drawMaskOverlay tints the pixels covered by each mask rectangle and outlines the rectangle
+func drawMaskOverlay(backgroundImage *image.RGBA, maskPixels [][]uint16, cameraMasks []fileio.MaskRectangle) {
+	for _, cameraMask := range cameraMasks {
+		for y := 0; y < cameraMask.Height; y++ {
+			for x := 0; x < cameraMask.Width; x++ {
+				srcX, srcY := cameraMask.SrcX+x, cameraMask.SrcY+y
+				if srcY >= len(maskPixels) || srcX >= len(maskPixels[srcY]) || maskPixels[srcY][srcX] == 0 {
+					continue
+				}
+				destX, destY := cameraMask.DestX+x, cameraMask.DestY+y
+				original := backgroundImage.RGBAAt(destX, destY)
+				backgroundImage.SetRGBA(destX, destY, color.RGBA{
+					uint8((int(original.R) + int(maskOverlayColor.R)) / 2),
+					uint8((int(original.G) + int(maskOverlayColor.G)) / 2),
+					uint8((int(original.B) + int(maskOverlayColor.B)) / 2),
+					255,
+				})
+			}
+		}
+
+		left, top := cameraMask.DestX, cameraMask.DestY
+		right, bottom := left+cameraMask.Width-1, top+cameraMask.Height-1
+		for x := left; x <= right; x++ {
+			backgroundImage.SetRGBA(x, top, maskOverlayColor)
+			backgroundImage.SetRGBA(x, bottom, maskOverlayColor)
+		}
+		for y := top; y <= bottom; y++ {
+			backgroundImage.SetRGBA(left, y, maskOverlayColor)
+			backgroundImage.SetRGBA(right, y, maskOverlayColor)
+		}
+	}
+}
+
+func writePNG(filename string, imageData image.Image) error {
+	imageFile, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create PNG file %s: %w", filename, err)
+	}
+	defer imageFile.Close()
+	if err := png.Encode(imageFile, imageData); err != nil {
+		return fmt.Errorf("failed to write PNG file %s: %w", filename, err)
+	}
+	return nil
+}
+
+func defaultWorkerCount() int {
+	return runtime.NumCPU()
+}
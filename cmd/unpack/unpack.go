@@ -1,29 +1,43 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
 )
 
 func main() {
 	if len(os.Args) < 3 {
-		fmt.Println("Usage: unpack <fileFormat> <inputFilename>")
+		fmt.Println("Usage: unpack <fileFormat> <inputFilename> [options]")
 		fmt.Println("")
 		fmt.Println("Supported file formats:")
+		fmt.Println("  bin    - roomcut.bin backgrounds and masks, written as PNG")
 		fmt.Println("  do2    - Door files")
 		fmt.Println("  pld    - Player model files")
+		fmt.Println("  rdt    - Room files, with a manifest for packing them again")
 		fmt.Println("")
 		fmt.Println("Examples:")
 		fmt.Println("  unpack do2 door00.do2")
 		fmt.Println("  unpack pld leon.pld")
 		fmt.Println("  unpack do2 data/Pl0/Door/door01.do2")
 		fmt.Println("  unpack pld data/Pl0/Pld/leon.pld")
+		fmt.Println("  unpack rdt data/Pl0/Rdt/ROOM1000.RDT")
+		fmt.Println("  unpack bin data/Common/bin/roomcut.bin --rdt=data/Pl0/Rdt --workers=8")
+		fmt.Println("  unpack bin data/Common/bin/roomcut.bin --rdt=data/Pl1/Rdt --player=claire")
+		fmt.Println("")
+		fmt.Println("Options for bin:")
+		fmt.Println("  --rdt=<folder>  Draw the camera mask rectangles from the rooms in this folder over each background")
+		fmt.Println("  --player=<name> Whose rooms are in the --rdt folder, leon or claire (default leon)")
+		fmt.Println("  --workers=<n>   Number of images decoded at the same time (default is the number of CPUs)")
 		fmt.Println("")
 		os.Exit(1)
 	}
@@ -31,8 +45,36 @@ func main() {
 	fileFormat := os.Args[1]
 	inputFilename := os.Args[2]
 
+	roomcutOptions := roomcutOptions{playerNum: game.PLAYER_LEON, workers: defaultWorkerCount()}
+	for _, arg := range os.Args[3:] {
+		switch {
+		case strings.HasPrefix(arg, "--rdt="):
+			roomcutOptions.rdtFolder = strings.TrimPrefix(arg, "--rdt=")
+		case strings.HasPrefix(arg, "--player="):
+			switch strings.ToLower(strings.TrimPrefix(arg, "--player=")) {
+			case "leon":
+				roomcutOptions.playerNum = game.PLAYER_LEON
+			case "claire":
+				roomcutOptions.playerNum = game.PLAYER_CLAIRE
+			default:
+				fmt.Printf("Error: Unknown player '%s', expected leon or claire\n", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--workers="):
+			workers, err := strconv.Atoi(strings.TrimPrefix(arg, "--workers="))
+			if err != nil || workers < 1 {
+				fmt.Printf("Error: Invalid worker count '%s'\n", arg)
+				os.Exit(1)
+			}
+			roomcutOptions.workers = workers
+		default:
+			fmt.Printf("Error: Unknown option '%s'\n", arg)
+			os.Exit(1)
+		}
+	}
+
 	// Validate input file exists
-	if _, err := os.Stat(inputFilename); os.IsNotExist(err) {
+	if !resource.LocalFileExists(inputFilename) {
 		fmt.Printf("Error: Input file '%s' does not exist\n", inputFilename)
 		os.Exit(1)
 	}
@@ -55,18 +97,15 @@ func main() {
 	case "do2":
 		fmt.Println("Processing DO2 file...")
 		fmt.Println("Loading DO2 file structure...")
-		do2Output := fileio.LoadDO2File(inputFilename)
-
-		file, err := os.Open(inputFilename)
+		do2Output, err := resource.LoadLocalFile(inputFilename, fileio.LoadDO2Stream)
 		if err != nil {
-			fmt.Printf("Error: Failed to read file '%s': %v\n", inputFilename, err)
+			fmt.Printf("Error: Failed to load DO2 file: %v\n", err)
 			os.Exit(1)
 		}
-		defer file.Close()
 
-		data, err := io.ReadAll(file)
+		data, err := resource.LoadLocalFile(inputFilename, readFileData)
 		if err != nil {
-			fmt.Printf("Error: Failed to read file data: %v\n", err)
+			fmt.Printf("Error: Failed to read file '%s': %v\n", inputFilename, err)
 			os.Exit(1)
 		}
 
@@ -90,18 +129,11 @@ func main() {
 	case "pld":
 		fmt.Println("Processing PLD file...")
 		
-		file, err := os.Open(inputFilename)
+		data, err := resource.LoadLocalFile(inputFilename, readFileData)
 		if err != nil {
 			fmt.Printf("Error: Failed to read file '%s': %v\n", inputFilename, err)
 			os.Exit(1)
 		}
-		defer file.Close()
-
-		data, err := io.ReadAll(file)
-		if err != nil {
-			fmt.Printf("Error: Failed to read file data: %v\n", err)
-			os.Exit(1)
-		}
 
 		// Parse PLD header to get offsets
 		fmt.Println("Parsing PLD header...")
@@ -166,13 +198,80 @@ func main() {
 		
 		fmt.Printf("\nSuccessfully unpacked %s to %s\n", inputFilename, outputFolder)
 		
+	case "bin":
+		fmt.Println("Processing roomcut BIN file...")
+		unpackRoomcut(inputFilename, outputFolder, roomcutOptions)
+
+	case "rdt":
+		fmt.Println("Processing RDT file...")
+		rdtOutput, err := resource.LoadLocalFile(inputFilename, fileio.LoadRDTRaw)
+		if err != nil {
+			fmt.Printf("Error: Failed to load RDT file: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Each section ends where the next section or referenced block starts
+		manifest, err := rdtOutput.Manifest()
+		if err != nil {
+			fmt.Printf("Error: Failed to find RDT sections: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Found %d sections in %d chunks\n", countRDTSections(manifest), len(manifest.Chunks))
+
+		fmt.Println("Extracting components:")
+		for i, chunk := range rdtOutput.Chunks {
+			manifestChunk := &manifest.Chunks[i]
+			manifestChunk.Filename = rdtChunkFilename(inputBase, manifestChunk)
+			writeFile(filepath.Join(outputFolder, manifestChunk.Filename), chunk.Data, manifestChunk.Name)
+		}
+
+		// The manifest has the header and original offsets needed to put the room back together
+		manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: Failed to encode manifest: %v\n", err)
+			os.Exit(1)
+		}
+		writeFile(filepath.Join(outputFolder, inputBase+"_manifest.json"), manifestJSON, "Manifest")
+
+		fmt.Printf("\nSuccessfully unpacked %s to %s\n", inputFilename, outputFolder)
+
 	default:
 		fmt.Printf("Error: Unsupported file format '%s'\n", fileFormat)
-		fmt.Println("Supported formats: do2, pld")
+		fmt.Println("Supported formats: bin, do2, pld, rdt")
 		os.Exit(1)
 	}
 }
 
+func readFileData(r io.ReaderAt, fileLength int64) ([]byte, error) {
+	data := make([]byte, fileLength)
+	if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return data, nil
+}
+
+func countRDTSections(manifest *fileio.RDTManifest) int {
+	count := 0
+	for _, section := range manifest.Sections {
+		if section.Offset != 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// rdtChunkFilename names a chunk after the room, such as ROOM1000.sca or ROOM1000_init.scd
+func rdtChunkFilename(inputBase string, chunk *fileio.RDTManifestChunk) string {
+	extensionName := strings.TrimPrefix(chunk.Extension, ".")
+	name := chunk.Name
+	if name == extensionName {
+		return inputBase + chunk.Extension
+	}
+	name = strings.TrimSuffix(name, "_"+extensionName)
+	name = strings.TrimPrefix(name, extensionName+"_")
+	return inputBase + "_" + name + chunk.Extension
+}
+
 func getBufferSubset(data []byte, offset int64, length int64) []byte {
 	return data[offset : offset+length]
 }
@@ -0,0 +1,108 @@
+package fileio
+
+// Playstation 1 SPU ADPCM audio (.vag waveforms stored in .vb files)
+
+const (
+	ADPCM_BLOCK_SIZE        = 16
+	ADPCM_SAMPLES_PER_BLOCK = 28
+	SPU_SAMPLE_RATE         = 44100 // playback rate of a waveform at its center note
+
+	ADPCM_FLAG_LOOP_END    = 0x01
+	ADPCM_FLAG_LOOP_REPEAT = 0x02
+	ADPCM_FLAG_LOOP_START  = 0x04
+)
+
+var (
+	// Prediction filter coefficients, scaled by 64
+	spuADPCMFilters = [5][2]int32{
+		{0, 0},
+		{60, 0},
+		{115, -52},
+		{98, -55},
+		{122, -60},
+	}
+)
+
+type ADPCMSample struct {
+	PCMData   []int16
+	Looping   bool // sound repeats from LoopStart after reaching LoopEnd
+	LoopStart int  // first sample of the loop
+	LoopEnd   int  // sample after the end of the loop
+}
+
+// DecodeSPUADPCM converts raw SPU ADPCM blocks into 16-bit PCM.
+// Each 16 byte block has a shift/filter byte, a flags byte and 28 4-bit samples.
+// Decoding stops at the first block with the loop end flag set.
+func DecodeSPUADPCM(adpcmData []uint8) *ADPCMSample {
+	output := &ADPCMSample{
+		PCMData: make([]int16, 0, (len(adpcmData)/ADPCM_BLOCK_SIZE)*ADPCM_SAMPLES_PER_BLOCK),
+	}
+
+	history1 := int32(0)
+	history2 := int32(0)
+	for offset := 0; offset+ADPCM_BLOCK_SIZE <= len(adpcmData); offset += ADPCM_BLOCK_SIZE {
+		block := adpcmData[offset : offset+ADPCM_BLOCK_SIZE]
+		shift := int32(block[0] & 0x0F)
+		filter := int(block[0]>>4) & 0x07
+		flags := block[1]
+
+		// All flags set marks the end of the waveform and the block has no audio
+		if flags == ADPCM_FLAG_LOOP_END|ADPCM_FLAG_LOOP_REPEAT|ADPCM_FLAG_LOOP_START {
+			break
+		}
+
+		// The hardware treats invalid values like these
+		if shift > 12 {
+			shift = 9
+		}
+		if filter >= len(spuADPCMFilters) {
+			filter = 0
+		}
+
+		if flags&ADPCM_FLAG_LOOP_START != 0 {
+			output.LoopStart = len(output.PCMData)
+		}
+
+		coefficient1 := spuADPCMFilters[filter][0]
+		coefficient2 := spuADPCMFilters[filter][1]
+		for i := 0; i < ADPCM_SAMPLES_PER_BLOCK; i++ {
+			nibble := block[2+i/2]
+			if i%2 == 0 {
+				nibble &= 0x0F
+			} else {
+				nibble >>= 4
+			}
+
+			// Sign extend the 4-bit value into the top of a 16-bit sample
+			sample := int32(int16(uint16(nibble)<<12)) >> shift
+			sample += (history1*coefficient1 + history2*coefficient2 + 32) >> 6
+			sample = clampInt16(sample)
+
+			output.PCMData = append(output.PCMData, int16(sample))
+			history2 = history1
+			history1 = sample
+		}
+
+		if flags&ADPCM_FLAG_LOOP_END != 0 {
+			output.LoopEnd = len(output.PCMData)
+			output.Looping = flags&ADPCM_FLAG_LOOP_REPEAT != 0
+			break
+		}
+	}
+
+	if !output.Looping {
+		output.LoopStart = 0
+		output.LoopEnd = len(output.PCMData)
+	}
+	return output
+}
+
+func clampInt16(value int32) int32 {
+	if value > 32767 {
+		return 32767
+	}
+	if value < -32768 {
+		return -32768
+	}
+	return value
+}
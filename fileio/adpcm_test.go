@@ -0,0 +1,219 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func buildADPCMBlock(shiftFilter uint8, flags uint8, nibble uint8) []byte {
+	block := make([]byte, ADPCM_BLOCK_SIZE)
+	block[0] = shiftFilter
+	block[1] = flags
+	for i := 2; i < ADPCM_BLOCK_SIZE; i++ {
+		block[i] = nibble | nibble<<4
+	}
+	return block
+}
+
+func TestDecodeSPUADPCM_NoFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		shift    uint8
+		nibble   uint8
+		expected int16
+	}{
+		{"positive no shift", 0, 0x1, 4096},
+		{"negative no shift", 0, 0xF, -4096},
+		{"most negative", 0, 0x8, -32768},
+		{"positive shift 4", 4, 0x7, 1792},
+		{"negative shift 12", 12, 0x8, -8},
+		{"invalid shift is 9", 13, 0x1, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := buildADPCMBlock(tt.shift, ADPCM_FLAG_LOOP_END, tt.nibble)
+			output := DecodeSPUADPCM(data)
+
+			if len(output.PCMData) != ADPCM_SAMPLES_PER_BLOCK {
+				t.Fatalf("Expected %d samples, got %d", ADPCM_SAMPLES_PER_BLOCK, len(output.PCMData))
+			}
+			for i, sample := range output.PCMData {
+				if sample != tt.expected {
+					t.Errorf("Sample %d: expected %d, got %d", i, tt.expected, sample)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeSPUADPCM_Filter(t *testing.T) {
+	// Filter 1 adds 60/64 of the previous sample
+	data := buildADPCMBlock(0x10|8, ADPCM_FLAG_LOOP_END, 0x1)
+	output := DecodeSPUADPCM(data)
+
+	expected := []int16{16}
+	previous := int32(16)
+	for i := 1; i < 4; i++ {
+		next := 16 + (previous*60+32)>>6
+		expected = append(expected, int16(next))
+		previous = next
+	}
+
+	for i, value := range expected {
+		if output.PCMData[i] != value {
+			t.Errorf("Sample %d: expected %d, got %d", i, value, output.PCMData[i])
+		}
+	}
+}
+
+func TestDecodeSPUADPCM_Clamp(t *testing.T) {
+	// Filter 4 with a large positive input overflows without clamping
+	data := buildADPCMBlock(0x40, ADPCM_FLAG_LOOP_END, 0x7)
+	output := DecodeSPUADPCM(data)
+
+	for i, sample := range output.PCMData {
+		if sample < 0 {
+			t.Errorf("Sample %d wrapped around to %d", i, sample)
+		}
+	}
+	if output.PCMData[len(output.PCMData)-1] != 32767 {
+		t.Errorf("Expected last sample to be clamped to 32767, got %d", output.PCMData[len(output.PCMData)-1])
+	}
+}
+
+func TestDecodeSPUADPCM_LoopFlags(t *testing.T) {
+	tests := []struct {
+		name            string
+		flags           []uint8
+		expectedSamples int
+		expectedLooping bool
+		expectedStart   int
+		expectedEnd     int
+	}{
+		{
+			name:            "one shot stops at loop end",
+			flags:           []uint8{0, ADPCM_FLAG_LOOP_END, 0},
+			expectedSamples: 56,
+			expectedLooping: false,
+			expectedStart:   0,
+			expectedEnd:     56,
+		},
+		{
+			name:            "looping from second block",
+			flags:           []uint8{0, ADPCM_FLAG_LOOP_START, 0, ADPCM_FLAG_LOOP_END | ADPCM_FLAG_LOOP_REPEAT},
+			expectedSamples: 112,
+			expectedLooping: true,
+			expectedStart:   28,
+			expectedEnd:     112,
+		},
+		{
+			name:            "end marker block is not decoded",
+			flags:           []uint8{0, 0, 0x07, 0},
+			expectedSamples: 56,
+			expectedLooping: false,
+			expectedStart:   0,
+			expectedEnd:     56,
+		},
+		{
+			name:            "no end flag decodes everything",
+			flags:           []uint8{0, 0},
+			expectedSamples: 56,
+			expectedLooping: false,
+			expectedStart:   0,
+			expectedEnd:     56,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make([]byte, 0)
+			for _, flags := range tt.flags {
+				data = append(data, buildADPCMBlock(0, flags, 0)...)
+			}
+			// Partial blocks at the end are ignored
+			data = append(data, 0, 0, 0)
+
+			output := DecodeSPUADPCM(data)
+			if len(output.PCMData) != tt.expectedSamples {
+				t.Errorf("Expected %d samples, got %d", tt.expectedSamples, len(output.PCMData))
+			}
+			if output.Looping != tt.expectedLooping {
+				t.Errorf("Expected looping %v, got %v", tt.expectedLooping, output.Looping)
+			}
+			if output.LoopStart != tt.expectedStart {
+				t.Errorf("Expected loop start %d, got %d", tt.expectedStart, output.LoopStart)
+			}
+			if output.LoopEnd != tt.expectedEnd {
+				t.Errorf("Expected loop end %d, got %d", tt.expectedEnd, output.LoopEnd)
+			}
+		})
+	}
+}
+
+func TestADPCMSampleWriteWAV(t *testing.T) {
+	sample := &ADPCMSample{
+		PCMData:   []int16{1, -2, 3, -4},
+		Looping:   true,
+		LoopStart: 1,
+		LoopEnd:   4,
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := sample.WriteWAV(buffer, 22050); err != nil {
+		t.Fatalf("WriteWAV() error: %v", err)
+	}
+	data := buffer.Bytes()
+
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		t.Fatalf("Invalid RIFF header: %q", data[0:12])
+	}
+	if riffSize := binary.LittleEndian.Uint32(data[4:8]); int(riffSize) != len(data)-8 {
+		t.Errorf("Expected RIFF size %d, got %d", len(data)-8, riffSize)
+	}
+	if string(data[12:16]) != "fmt " {
+		t.Fatalf("Expected fmt chunk, got %q", data[12:16])
+	}
+	if sampleRate := binary.LittleEndian.Uint32(data[24:28]); sampleRate != 22050 {
+		t.Errorf("Expected sample rate 22050, got %d", sampleRate)
+	}
+	if string(data[36:40]) != "data" {
+		t.Fatalf("Expected data chunk, got %q", data[36:40])
+	}
+	if dataSize := binary.LittleEndian.Uint32(data[40:44]); dataSize != 8 {
+		t.Errorf("Expected data size 8, got %d", dataSize)
+	}
+	if value := int16(binary.LittleEndian.Uint16(data[46:48])); value != -2 {
+		t.Errorf("Expected second sample -2, got %d", value)
+	}
+	if string(data[52:56]) != "smpl" {
+		t.Errorf("Expected smpl chunk for looping sample, got %q", data[52:56])
+	}
+}
+
+func TestConvertVABADSR(t *testing.T) {
+	// Fast linear attack, no decay, full sustain, slow release
+	adsr := ConvertVABADSR(0x000F, 0x001F)
+	if adsr.AttackTime > 0.01 {
+		t.Errorf("Expected fast attack, got %f seconds", adsr.AttackTime)
+	}
+	if adsr.SustainLevel != 0 {
+		t.Errorf("Expected no sustain attenuation, got %f", adsr.SustainLevel)
+	}
+	if adsr.ReleaseTime <= adsr.AttackTime {
+		t.Errorf("Expected release %f to be slower than attack %f", adsr.ReleaseTime, adsr.AttackTime)
+	}
+
+	// Slower attack shift takes longer
+	slowAttack := ConvertVABADSR(0x500F, 0x001F)
+	if slowAttack.AttackTime <= adsr.AttackTime {
+		t.Errorf("Expected attack shift 20 to be slower than shift 0, got %f and %f", slowAttack.AttackTime, adsr.AttackTime)
+	}
+
+	// Half sustain level is about 6 dB of attenuation
+	halfSustain := ConvertVABADSR(0x0007, 0x0000)
+	if halfSustain.SustainLevel < 55 || halfSustain.SustainLevel > 65 {
+		t.Errorf("Expected sustain attenuation near 60 cB, got %f", halfSustain.SustainLevel)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"image/png"
 	"io"
 	"os"
-	"strconv"
 	"unsafe"
 )
 
@@ -393,27 +392,26 @@ func (adtOutput *ADTOutput) ConvertToRenderData() []uint16 {
 	return pixelData1D
 }
 
-func (adtOutput *ADTOutput) ConvertToPNG(outputFilename string) {
+// Image converts the background to RGBA
+func (adtOutput *ADTOutput) Image() *image.RGBA {
 	pixelData := adtOutput.PixelData
 
 	imageOutputData := image.NewRGBA(image.Rect(0, 0, TOTAL_IMAGE_WIDTH, TOTAL_IMAGE_HEIGHT))
 	for y := 0; y < len(pixelData); y++ {
 		for x := 0; x < len(pixelData[y]); x++ {
-			colorBits := fmt.Sprintf("%016b", pixelData[y][x])
 			// color is in A1B5G5R5 format
-			a, _ := strconv.ParseInt(string(colorBits[0]), 2, 1)
-			a = 255
-			b, _ := strconv.ParseInt(string(colorBits[1:6]), 2, 5)
-			g, _ := strconv.ParseInt(string(colorBits[6:11]), 2, 5)
-			r, _ := strconv.ParseInt(string(colorBits[11:16]), 2, 5)
-
-			b *= 8
-			g *= 8
-			r *= 8
-
-			imageOutputData.Set(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), uint8(a)})
+			pixel := pixelData[y][x]
+			r := (pixel & 0x1F) * 8
+			g := ((pixel >> 5) & 0x1F) * 8
+			b := ((pixel >> 10) & 0x1F) * 8
+			imageOutputData.SetRGBA(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
 		}
 	}
+	return imageOutputData
+}
+
+func (adtOutput *ADTOutput) ConvertToPNG(outputFilename string) {
+	imageOutputData := adtOutput.Image()
 
 	imageOutputFile, err := os.Create(outputFilename)
 	if err != nil {
@@ -0,0 +1,380 @@
+package fileio
+
+// Encoder for .adt images, the inverse of unpackADT and restoreImage
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math/bits"
+	"os"
+)
+
+const (
+	adtWindowSize      = 16384
+	adtMinMatch        = 3
+	adtMaxMatch        = 511 - 0xfd
+	adtMaxCodeLength   = 15
+	adtMaxBlockSymbols = 0xFFFF
+	adtHashBits        = 15
+	adtMaxChainLength  = 128
+	adtSymbolCount     = 512
+	adtDistanceCount   = 16
+	adtCodeLengthCount = 16
+)
+
+// A literal byte or a copy from earlier data
+type adtSymbol struct {
+	value    int // byte value, or 0xfd + number of bytes to copy
+	distance int // distance back to the copy start minus one
+}
+
+// EncodeADT compresses a 320x240 image in A1B5G5R5 colors
+func EncodeADT(pixelData [][]uint16) ([]byte, error) {
+	if len(pixelData) != TOTAL_IMAGE_HEIGHT {
+		return nil, fmt.Errorf("ADT image must be %dx%d, got height %d", TOTAL_IMAGE_WIDTH, TOTAL_IMAGE_HEIGHT, len(pixelData))
+	}
+	for y, row := range pixelData {
+		if len(row) != TOTAL_IMAGE_WIDTH {
+			return nil, fmt.Errorf("ADT image must be %dx%d, row %d has width %d", TOTAL_IMAGE_WIDTH, TOTAL_IMAGE_HEIGHT, y, len(row))
+		}
+	}
+
+	colorArr := splitImage(pixelData)
+	imageByteData := make([]byte, len(colorArr)*2)
+	for i, pixel := range colorArr {
+		binary.LittleEndian.PutUint16(imageByteData[i*2:], pixel)
+	}
+	return packADT(imageByteData), nil
+}
+
+func EncodeADTImage(img image.Image) ([]byte, error) {
+	return EncodeADT(ConvertImageToTIMPixels(img, false))
+}
+
+func ConvertImageToADTFile(img image.Image, outputFilename string) error {
+	adtData, err := EncodeADTImage(img)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFilename, adtData, 0644); err != nil {
+		return fmt.Errorf("failed to write ADT file %s: %w", outputFilename, err)
+	}
+	return nil
+}
+
+// splitImage stores the image in the 256 pixel wide layout read by restoreImage
+func splitImage(pixelData [][]uint16) []uint16 {
+	colorArr := make([]uint16, 256*320)
+
+	// The first part is a 256x240 image on the left side
+	for y := 0; y < TOTAL_IMAGE_HEIGHT; y++ {
+		for x := 0; x < 256; x++ {
+			colorArr[(256*y)+x] = pixelData[y][x]
+		}
+	}
+
+	// The second part is a 64x128 image on the top right
+	offsetY := 256
+	for y := 0; y < 128; y += 2 {
+		for offsetX := 0; offsetX < 64; offsetX++ {
+			colorArr[offsetX+(256*offsetY)] = pixelData[y][256+offsetX]
+			colorArr[(128+offsetX)+(256*offsetY)] = pixelData[y+1][256+offsetX]
+		}
+		offsetY++
+	}
+
+	// The third part is a 64x112 image on the bottom right
+	offsetY = 256
+	for y := 128; y < TOTAL_IMAGE_HEIGHT; y += 2 {
+		for offsetX := 0; offsetX < 64; offsetX++ {
+			colorArr[(64+offsetX)+(256*offsetY)] = pixelData[y][256+offsetX]
+			colorArr[(192+offsetX)+(256*offsetY)] = pixelData[y+1][256+offsetX]
+		}
+		offsetY++
+	}
+
+	return colorArr
+}
+
+// packADT compresses data into blocks of huffman coded literals and copies
+func packADT(data []byte) []byte {
+	bitWriter := NewBitWriter()
+
+	// The first uint32 is skipped by the decoder
+	for _, value := range binary.LittleEndian.AppendUint32(nil, uint32(len(data))) {
+		bitWriter.WriteNumBits(uint64(value), 8)
+	}
+
+	symbols := findADTSymbols(data)
+	for start := 0; start < len(symbols); start += adtMaxBlockSymbols {
+		end := min(start+adtMaxBlockSymbols, len(symbols))
+		writeADTBlock(bitWriter, symbols[start:end])
+	}
+
+	// Empty block marks the end
+	bitWriter.WriteNumBits(0, 16)
+	return bitWriter.Bytes()
+}
+
+// findADTSymbols finds repeated data with hash chains
+func findADTSymbols(data []byte) []adtSymbol {
+	symbols := make([]adtSymbol, 0, len(data)/2)
+	head := make([]int, 1<<adtHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	previous := make([]int, len(data))
+
+	hash := func(position int) int {
+		value := uint32(data[position])<<16 | uint32(data[position+1])<<8 | uint32(data[position+2])
+		return int((value * 2654435761) >> (32 - adtHashBits))
+	}
+	insert := func(position int) {
+		if position+adtMinMatch > len(data) {
+			return
+		}
+		hashValue := hash(position)
+		previous[position] = head[hashValue]
+		head[hashValue] = position
+	}
+
+	position := 0
+	for position < len(data) {
+		bestLength := 0
+		bestDistance := 0
+		if position+adtMinMatch <= len(data) {
+			maxLength := min(adtMaxMatch, len(data)-position)
+			candidate := head[hash(position)]
+			for chain := 0; candidate >= 0 && chain < adtMaxChainLength; chain++ {
+				distance := position - candidate
+				if distance >= adtWindowSize {
+					break
+				}
+				length := 0
+				for length < maxLength && data[candidate+length] == data[position+length] {
+					length++
+				}
+				if length > bestLength {
+					bestLength = length
+					bestDistance = distance
+					if length == maxLength {
+						break
+					}
+				}
+				candidate = previous[candidate]
+			}
+		}
+
+		if bestLength >= adtMinMatch {
+			symbols = append(symbols, adtSymbol{value: 0xfd + bestLength, distance: bestDistance - 1})
+			for i := 0; i < bestLength; i++ {
+				insert(position + i)
+			}
+			position += bestLength
+		} else {
+			symbols = append(symbols, adtSymbol{value: int(data[position])})
+			insert(position)
+			position++
+		}
+	}
+	return symbols
+}
+
+func writeADTBlock(bitWriter *BitWriter, symbols []adtSymbol) {
+	bitWriter.WriteNumBits(uint64(len(symbols)&0xFF), 8)
+	bitWriter.WriteNumBits(uint64(len(symbols)>>8), 8)
+
+	symbolFrequencies := make([]int, adtSymbolCount)
+	distanceFrequencies := make([]int, adtDistanceCount)
+	for _, symbol := range symbols {
+		symbolFrequencies[symbol.value]++
+		if symbol.value >= 256 {
+			distanceFrequencies[adtDistanceCode(symbol.distance)]++
+		}
+	}
+	symbolLengths := buildHuffmanLengths(symbolFrequencies, adtMaxCodeLength)
+	distanceLengths := buildHuffmanLengths(distanceFrequencies, adtMaxCodeLength)
+
+	// Symbol code lengths are stored as the xor with the previous length,
+	// in alternating runs of zeros and values coded with the code length table
+	lengthDeltas := make([]int, adtSymbolCount)
+	previousLength := 0
+	for i, length := range symbolLengths {
+		lengthDeltas[i] = length ^ previousLength
+		previousLength = length
+	}
+	deltaFrequencies := make([]int, adtCodeLengthCount)
+	for _, delta := range lengthDeltas {
+		if delta != 0 {
+			deltaFrequencies[delta]++
+		}
+	}
+	deltaLengths := buildHuffmanLengths(deltaFrequencies, adtMaxCodeLength)
+	deltaCodes := buildCanonicalCodes(deltaLengths)
+
+	writeADTLengthTable(bitWriter, deltaLengths)
+	isValueRun := lengthDeltas[0] != 0
+	if isValueRun {
+		bitWriter.WriteBit(1)
+	} else {
+		bitWriter.WriteBit(0)
+	}
+	for i := 0; i < len(lengthDeltas); {
+		runLength := 0
+		for i+runLength < len(lengthDeltas) && (lengthDeltas[i+runLength] != 0) == isValueRun {
+			runLength++
+		}
+		writeBinaryNumber(bitWriter, runLength)
+		if isValueRun {
+			for _, delta := range lengthDeltas[i : i+runLength] {
+				bitWriter.WriteNumBits(uint64(deltaCodes[delta]), deltaLengths[delta])
+			}
+		}
+		i += runLength
+		isValueRun = !isValueRun
+	}
+
+	writeADTLengthTable(bitWriter, distanceLengths)
+
+	symbolCodes := buildCanonicalCodes(symbolLengths)
+	distanceCodes := buildCanonicalCodes(distanceLengths)
+	for _, symbol := range symbols {
+		bitWriter.WriteNumBits(uint64(symbolCodes[symbol.value]), symbolLengths[symbol.value])
+		if symbol.value < 256 {
+			continue
+		}
+
+		distanceCode := adtDistanceCode(symbol.distance)
+		bitWriter.WriteNumBits(uint64(distanceCodes[distanceCode]), distanceLengths[distanceCode])
+		if distanceCode != 0 {
+			numBits := distanceCode - 1
+			bitWriter.WriteNumBits(uint64(symbol.distance-(1<<uint(numBits))), numBits)
+		}
+	}
+}
+
+// writeADTLengthTable writes 16 code lengths, each as a flag and the xor with the previous length
+func writeADTLengthTable(bitWriter *BitWriter, lengths []int) {
+	previousLength := 0
+	for _, length := range lengths {
+		if length == previousLength {
+			bitWriter.WriteBit(0)
+			continue
+		}
+		bitWriter.WriteBit(1)
+		writeBinaryNumber(bitWriter, length^previousLength)
+		previousLength = length
+	}
+}
+
+// writeBinaryNumber is the inverse of readBinaryNumber
+func writeBinaryNumber(bitWriter *BitWriter, value int) {
+	numZeroBits := bits.Len(uint(value)) - 1
+	bitWriter.WriteNumBits(0, numZeroBits)
+	bitWriter.WriteNumBits(uint64(value), numZeroBits+1)
+}
+
+// adtDistanceCode is the number of bits in the distance
+func adtDistanceCode(distance int) int {
+	return bits.Len(uint(distance))
+}
+
+// buildCanonicalCodes assigns codes in the same order as initArrayStart
+func buildCanonicalCodes(lengths []int) []uint32 {
+	var lengthCounts [17]uint32
+	for _, length := range lengths {
+		if length <= 16 {
+			lengthCounts[length]++
+		}
+	}
+
+	var nextCode [18]uint32
+	for i := 0; i < 16; i++ {
+		nextCode[i+2] = (nextCode[i+1] + lengthCounts[i+1]) << 1
+	}
+
+	codes := make([]uint32, len(lengths))
+	for length := 1; length < len(nextCode); length++ {
+		for symbol, symbolLength := range lengths {
+			if symbolLength == length {
+				codes[symbol] = nextCode[length]
+				nextCode[length]++
+			}
+		}
+	}
+	return codes
+}
+
+type huffmanNode struct {
+	frequency int
+	symbol    int // -1 for internal nodes
+	left      *huffmanNode
+	right     *huffmanNode
+}
+
+type huffmanHeap []*huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].frequency == h[j].frequency {
+		return h[i].symbol > h[j].symbol
+	}
+	return h[i].frequency < h[j].frequency
+}
+func (h huffmanHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x interface{}) { *h = append(*h, x.(*huffmanNode)) }
+func (h *huffmanHeap) Pop() interface{} {
+	old := *h
+	node := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return node
+}
+
+// buildHuffmanLengths returns the code length of each symbol, or 0 for unused symbols.
+// Frequencies are flattened until no code is longer than maxLength.
+func buildHuffmanLengths(frequencies []int, maxLength int) []int {
+	lengths := make([]int, len(frequencies))
+	scaledFrequencies := append([]int{}, frequencies...)
+	for {
+		nodes := &huffmanHeap{}
+		for symbol, frequency := range scaledFrequencies {
+			if frequency > 0 {
+				heap.Push(nodes, &huffmanNode{frequency: frequency, symbol: symbol})
+			}
+		}
+		if nodes.Len() == 0 {
+			return lengths
+		}
+		if nodes.Len() == 1 {
+			lengths[(*nodes)[0].symbol] = 1
+			return lengths
+		}
+
+		for nodes.Len() > 1 {
+			left := heap.Pop(nodes).(*huffmanNode)
+			right := heap.Pop(nodes).(*huffmanNode)
+			heap.Push(nodes, &huffmanNode{frequency: left.frequency + right.frequency, symbol: -1, left: left, right: right})
+		}
+
+		longestCode := assignHuffmanLengths(heap.Pop(nodes).(*huffmanNode), 0, lengths)
+		if longestCode <= maxLength {
+			return lengths
+		}
+
+		for symbol, frequency := range scaledFrequencies {
+			if frequency > 0 {
+				scaledFrequencies[symbol] = (frequency + 1) / 2
+			}
+		}
+	}
+}
+
+func assignHuffmanLengths(node *huffmanNode, depth int, lengths []int) int {
+	if node.symbol >= 0 {
+		lengths[node.symbol] = depth
+		return depth
+	}
+	return max(assignHuffmanLengths(node.left, depth+1, lengths), assignHuffmanLengths(node.right, depth+1, lengths))
+}
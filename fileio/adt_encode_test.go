@@ -0,0 +1,112 @@
+package fileio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBitWriter_ReadBack(t *testing.T) {
+	bitWriter := NewBitWriter()
+	bitWriter.WriteBit(1)
+	bitWriter.WriteNumBits(0x5, 3)
+	bitWriter.WriteNumBits(0x1234, 16)
+	bitWriter.WriteBit(1)
+
+	data := bitWriter.Bytes()
+	if len(data) != 3 {
+		t.Fatalf("Expected 3 bytes, got %d", len(data))
+	}
+
+	bitReader := NewBitReader(io.NewSectionReader(bytes.NewReader(data), 0, int64(len(data))))
+	if bit := bitReader.UnsafeReadBit(); bit != 1 {
+		t.Errorf("Expected bit 1, got %d", bit)
+	}
+	if value := bitReader.UnsafeReadNumBits(3); value != 0x5 {
+		t.Errorf("Expected 0x5, got 0x%X", value)
+	}
+	if value := bitReader.UnsafeReadNumBits(16); value != 0x1234 {
+		t.Errorf("Expected 0x1234, got 0x%X", value)
+	}
+	if bit := bitReader.UnsafeReadBit(); bit != 1 {
+		t.Errorf("Expected bit 1, got %d", bit)
+	}
+}
+
+func buildTestADTImage(pattern func(x int, y int) uint16) [][]uint16 {
+	pixelData := make([][]uint16, TOTAL_IMAGE_HEIGHT)
+	for y := range pixelData {
+		pixelData[y] = make([]uint16, TOTAL_IMAGE_WIDTH)
+		for x := range pixelData[y] {
+			pixelData[y][x] = pattern(x, y)
+		}
+	}
+	return pixelData
+}
+
+func TestEncodeADT_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern func(x int, y int) uint16
+	}{
+		{"solid", func(x int, y int) uint16 { return 0x7FFF }},
+		{"gradient", func(x int, y int) uint16 { return uint16(x/10) | uint16(y/8)<<5 | uint16((x+y)%32)<<10 }},
+		{"noise", func(x int, y int) uint16 {
+			value := uint32(x*7919+y*104729) * 2654435761
+			return uint16(value >> 16)
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pixelData := buildTestADTImage(test.pattern)
+			data, err := EncodeADT(pixelData)
+			if err != nil {
+				t.Fatalf("EncodeADT() error: %v", err)
+			}
+
+			adtOutput, err := LoadADTStream(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("LoadADTStream() error: %v", err)
+			}
+			if len(adtOutput.PixelData) != TOTAL_IMAGE_HEIGHT {
+				t.Fatalf("Expected %d rows, got %d", TOTAL_IMAGE_HEIGHT, len(adtOutput.PixelData))
+			}
+			for y := 0; y < TOTAL_IMAGE_HEIGHT; y++ {
+				for x := 0; x < TOTAL_IMAGE_WIDTH; x++ {
+					if adtOutput.PixelData[y][x] != pixelData[y][x] {
+						t.Fatalf("Pixel (%d, %d): expected 0x%04X, got 0x%04X", x, y, pixelData[y][x], adtOutput.PixelData[y][x])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeADT_InvalidSize(t *testing.T) {
+	if _, err := EncodeADT(make([][]uint16, 10)); err == nil {
+		t.Error("Expected error for image with the wrong size")
+	}
+}
+
+func TestBuildHuffmanLengths_LimitsLength(t *testing.T) {
+	// Fibonacci frequencies produce a very unbalanced tree
+	frequencies := make([]int, 30)
+	a, b := 1, 1
+	for i := range frequencies {
+		frequencies[i] = a
+		a, b = b, a+b
+	}
+
+	lengths := buildHuffmanLengths(frequencies, adtMaxCodeLength)
+	kraftSum := 0.0
+	for symbol, length := range lengths {
+		if length == 0 || length > adtMaxCodeLength {
+			t.Fatalf("Symbol %d has invalid length %d", symbol, length)
+		}
+		kraftSum += 1.0 / float64(uint(1)<<uint(length))
+	}
+	if kraftSum > 1.0 {
+		t.Errorf("Code lengths are not a prefix code, kraft sum %f", kraftSum)
+	}
+}
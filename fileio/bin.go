@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
@@ -34,12 +33,18 @@ func LoadBINFile(inputFilename string) (*BinOutput, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to stat BIN file %s: %w", inputFilename, err)
 	}
-	archiveLength := fi.Size()
-
-	imagesIndex, err := LoadBIN(binFile, archiveLength)
+	binOutput, err := LoadBINStream(binFile, fi.Size())
 	if err != nil {
 		return nil, fmt.Errorf("failed to load BIN data from %s: %w", inputFilename, err)
 	}
+	return binOutput, nil
+}
+
+func LoadBINStream(r io.ReaderAt, archiveLength int64) (*BinOutput, error) {
+	imagesIndex, err := LoadBIN(r, archiveLength)
+	if err != nil {
+		return nil, err
+	}
 
 	return &BinOutput{
 		ImagesIndex: imagesIndex,
@@ -90,28 +95,34 @@ func LoadBIN(r io.ReaderAt, archiveLength int64) ([]ImageFile, error) {
 }
 
 func LoadTIMImages(inputFilename string) ([]*TIMOutput, error) {
-	binFile, _ := os.Open(inputFilename)
-	defer binFile.Close()
-
-	if binFile == nil {
-		log.Fatal("Failed to load TIM images. BIN file doesn't exist", inputFilename)
-		return nil, nil
+	binFile, err := os.Open(inputFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open BIN file %s: %w", inputFilename, err)
 	}
+	defer binFile.Close()
 
 	fi, err := binFile.Stat()
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("failed to stat BIN file %s: %w", inputFilename, err)
 	}
-	archiveLength := fi.Size()
+	images, err := LoadTIMImagesStream(binFile, fi.Size())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TIM images from %s: %w", inputFilename, err)
+	}
+	return images, nil
+}
 
+// LoadTIMImagesStream reads TIM images stored back to back
+func LoadTIMImagesStream(r io.ReaderAt, archiveLength int64) ([]*TIMOutput, error) {
 	images := make([]*TIMOutput, 0)
 	totalBytesRead := 0
 
 	for totalBytesRead < int(archiveLength) {
-		timReader := io.NewSectionReader(binFile, int64(totalBytesRead), archiveLength)
-		timOutput, err := LoadTIMStream(timReader, archiveLength)
+		timLength := archiveLength - int64(totalBytesRead)
+		timReader := io.NewSectionReader(r, int64(totalBytesRead), timLength)
+		timOutput, err := LoadTIMStream(timReader, timLength)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("failed to load TIM image %d at offset 0x%x: %w", len(images), totalBytesRead, err)
 		}
 		images = append(images, timOutput)
 		totalBytesRead += timOutput.NumBytes
@@ -127,7 +138,11 @@ func ExtractItemImage(inputFilename string, binOutput *BinOutput, imageId int) (
 	}
 	defer binFile.Close()
 
-	binReader := io.NewSectionReader(binFile, int64(0), binOutput.FileLength)
+	return ExtractItemImageStream(binFile, binOutput, imageId)
+}
+
+func ExtractItemImageStream(r io.ReaderAt, binOutput *BinOutput, imageId int) (*RoomImageOutput, error) {
+	binReader := io.NewSectionReader(r, int64(0), binOutput.FileLength)
 
 	imageBlock := binOutput.ImagesIndex[imageId]
 	if imageBlock.Length == 0 {
@@ -165,7 +180,11 @@ func ExtractRoomBackground(inputFilename string, binOutput *BinOutput, roomId in
 	}
 	defer binFile.Close()
 
-	binReader := io.NewSectionReader(binFile, int64(0), binOutput.FileLength)
+	return ExtractRoomBackgroundStream(binFile, binOutput, roomId)
+}
+
+func ExtractRoomBackgroundStream(r io.ReaderAt, binOutput *BinOutput, roomId int) (*RoomImageOutput, error) {
+	binReader := io.NewSectionReader(r, int64(0), binOutput.FileLength)
 
 	imageBlock := binOutput.ImagesIndex[roomId]
 	if imageBlock.Length == 0 {
@@ -0,0 +1,46 @@
+package fileio
+
+import (
+	"bytes"
+)
+
+// BitWriter writes bits in the same order BitReader reads them,
+// starting from the most significant bit of each byte
+type BitWriter struct {
+	buffer *bytes.Buffer
+	byte   byte
+	offset byte
+}
+
+func NewBitWriter() *BitWriter {
+	return &BitWriter{new(bytes.Buffer), 0, 0}
+}
+
+// Writes the next bit
+func (w *BitWriter) WriteBit(bit int) {
+	if bit != 0 {
+		w.byte |= 0x80 >> w.offset
+	}
+	w.offset++
+	if w.offset == 8 {
+		w.buffer.WriteByte(w.byte)
+		w.byte = 0
+		w.offset = 0
+	}
+}
+
+// Writes a number with the most significant bit first
+func (w *BitWriter) WriteNumBits(value uint64, numBits int) {
+	for i := numBits - 1; i >= 0; i-- {
+		w.WriteBit(int((value >> uint(i)) & 1))
+	}
+}
+
+// Bytes returns the data written so far, padding the last byte with zeros
+func (w *BitWriter) Bytes() []byte {
+	output := append([]byte{}, w.buffer.Bytes()...)
+	if w.offset > 0 {
+		output = append(output, w.byte)
+	}
+	return output
+}
@@ -4,8 +4,8 @@ package fileio
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
@@ -27,47 +27,44 @@ type DO2Output struct {
 	DO2FileFormat   *DO2FileFormat
 }
 
-func LoadDO2File(filename string) *DO2Output {
-	file, _ := os.Open(filename)
-	defer file.Close()
-	if file == nil {
-		log.Fatal("DO2 file doesn't exist: ", filename)
-		return nil
+func LoadDO2File(filename string) (*DO2Output, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DO2 file %s: %w", filename, err)
 	}
+	defer file.Close()
+
 	fi, err := file.Stat()
 	if err != nil {
-		log.Fatal(err)
-		return nil
+		return nil, fmt.Errorf("failed to stat DO2 file %s: %w", filename, err)
 	}
 	fileLength := fi.Size()
 	fileOutput, err := LoadDO2Stream(file, fileLength)
 	if err != nil {
-		log.Fatal("Failed to load DO2 file: ", err)
-		return nil
+		return nil, fmt.Errorf("failed to load DO2 file %s: %w", filename, err)
 	}
-
-	return fileOutput
+	return fileOutput, nil
 }
 
 func LoadDO2Stream(r io.ReaderAt, fileLength int64) (*DO2Output, error) {
 	streamReader := io.NewSectionReader(r, int64(0), fileLength)
 	do2Header := make([]uint16, 8)
 	if err := binary.Read(streamReader, binary.LittleEndian, &do2Header); err != nil {
-		return nil, err
+		return nil, wrapReadError("DO2 header", 0, err)
 	}
 
 	vabHeaderOffset := int64(16)
 	vabHeaderReader := io.NewSectionReader(r, vabHeaderOffset, fileLength)
 	vabHeaderOutput, err := LoadVABHeaderStream(vabHeaderReader, fileLength)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("DO2 sound header at offset 0x%x: %w", vabHeaderOffset, err)
 	}
 
 	vabDataOffset := vabHeaderOffset + int64(vabHeaderOutput.NumBytes) + int64(8)
 	vabDataReader := io.NewSectionReader(r, vabDataOffset, fileLength)
 	vabDataOutput, err := LoadVABDataStream(vabDataReader, fileLength, vabHeaderOutput)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("DO2 sound data at offset 0x%x: %w", vabDataOffset, err)
 	}
 
 	offsetAfterVab := vabDataOffset + int64(vabDataOutput.NumBytes)
@@ -78,7 +75,7 @@ func LoadDO2Stream(r io.ReaderAt, fileLength int64) (*DO2Output, error) {
 	emptySectionBytes := 0
 	for {
 		if err := binary.Read(emptyReader, binary.LittleEndian, &intBlock); err != nil {
-			return nil, err
+			return nil, wrapReadError("DO2 padding", offsetAfterVab, err)
 		}
 
 		if intBlock != 0 {
@@ -92,23 +89,24 @@ func LoadDO2Stream(r io.ReaderAt, fileLength int64) (*DO2Output, error) {
 	unknownSectionReader := io.NewSectionReader(r, offsetAfterVab+int64(emptySectionBytes), fileLength)
 	var unknownSectionSize uint32
 	if err := binary.Read(unknownSectionReader, binary.LittleEndian, &unknownSectionSize); err != nil {
-		return nil, err
+		return nil, wrapReadError("DO2 unknown section", offsetAfterVab+int64(emptySectionBytes), err)
 	}
 
 	md1Offset := offsetAfterVab + int64(emptySectionBytes) + int64(unknownSectionSize)
+	if err := checkSectionSize("DO2 model", md1Offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	md1Reader := io.NewSectionReader(r, md1Offset, fileLength-md1Offset)
 	md1Output, err := LoadMD1Stream(md1Reader, fileLength-md1Offset)
 	if err != nil {
-		log.Fatal("Error loading md1 file in do2 file")
-		return nil, err
+		return nil, fmt.Errorf("DO2 model at offset 0x%x: %w", md1Offset, err)
 	}
 
 	timOffset := md1Offset + int64(md1Output.NumBytes)
 	timReader := io.NewSectionReader(r, timOffset, fileLength-timOffset)
 	timOutput, err := LoadTIMStream(timReader, fileLength-timOffset)
 	if err != nil {
-		log.Fatal("Error loading tim file in do2 file")
-		return nil, err
+		return nil, fmt.Errorf("DO2 texture at offset 0x%x: %w", timOffset, err)
 	}
 
 	do2FileFormat := &DO2FileFormat{
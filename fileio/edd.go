@@ -28,7 +28,7 @@ func LoadEDDStream(r io.ReaderAt, fileLength int64) (*EDDOutput, error) {
 	// Everything before the first header offset is header data
 	firstHeader := EDDHeaderObject{}
 	if err := binary.Read(streamReader, binary.LittleEndian, &firstHeader); err != nil {
-		return nil, err
+		return nil, wrapReadError("EDD header", 0, err)
 	}
 	headerCount := int(firstHeader.Offset) / 4
 
@@ -36,13 +36,19 @@ func LoadEDDStream(r io.ReaderAt, fileLength int64) (*EDDOutput, error) {
 	streamReader = io.NewSectionReader(r, int64(0), fileLength)
 	eddHeaders := make([]EDDHeaderObject, int(headerCount))
 	if err := binary.Read(streamReader, binary.LittleEndian, &eddHeaders); err != nil {
-		return nil, err
+		return nil, wrapReadError("EDD header", 0, err)
 	}
 
 	animationIndexFrames := make([][]EDDTableElement, len(eddHeaders))
 	bitReader := NewBitReader(streamReader)
 	maxFrameNumber := 0
+	tableOffset := int64(headerCount) * 4
 	for i := 0; i < len(eddHeaders); i++ {
+		// The tables are stored one after another
+		if err := checkSectionSize("EDD animation", tableOffset, int64(eddHeaders[i].Count)*4, fileLength); err != nil {
+			return nil, err
+		}
+		tableOffset += int64(eddHeaders[i].Count) * 4
 		eddTable := make([]EDDTableElement, int(eddHeaders[i].Count))
 		// Each element is 4 bytes (32 bits) in little endian
 		for j := 0; j < int(eddHeaders[i].Count); j++ {
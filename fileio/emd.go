@@ -4,8 +4,8 @@ package fileio
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
@@ -35,26 +35,23 @@ type EMDOutput struct {
 	MeshData       *MD1Output
 }
 
-func LoadEMDFile(filename string) *EMDOutput {
-	file, _ := os.Open(filename)
-	defer file.Close()
-	if file == nil {
-		log.Fatal("EMD file doesn't exist: ", filename)
-		return nil
+func LoadEMDFile(filename string) (*EMDOutput, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open EMD file %s: %w", filename, err)
 	}
+	defer file.Close()
+
 	fi, err := file.Stat()
 	if err != nil {
-		log.Fatal(err)
-		return nil
+		return nil, fmt.Errorf("failed to stat EMD file %s: %w", filename, err)
 	}
 	fileLength := fi.Size()
 	fileOutput, err := LoadEMDStream(file, fileLength)
 	if err != nil {
-		log.Fatal("Failed to load EMD file: ", err)
-		return nil
+		return nil, fmt.Errorf("failed to load EMD file %s: %w", filename, err)
 	}
-
-	return fileOutput
+	return fileOutput, nil
 }
 
 func LoadEMDStream(r io.ReaderAt, fileLength int64) (*EMDOutput, error) {
@@ -62,15 +59,18 @@ func LoadEMDStream(r io.ReaderAt, fileLength int64) (*EMDOutput, error) {
 
 	emdHeader := EMDHeader{}
 	if err := binary.Read(streamReader, binary.LittleEndian, &emdHeader); err != nil {
-		return nil, err
+		return nil, wrapReadError("EMD header", 0, err)
 	}
 
 	// Read the offset for each section
 	offset := int64(emdHeader.DirOffset)
+	if err := checkSectionSize("EMD directory", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	offsetReader := io.NewSectionReader(r, offset, fileLength-offset)
 	emdOffsets := EMDOffsets{}
 	if err := binary.Read(offsetReader, binary.LittleEndian, &emdOffsets); err != nil {
-		return nil, err
+		return nil, wrapReadError("EMD directory", offset, err)
 	}
 
 	animationData1, err := loadAnimationData(r, fileLength, int64(emdOffsets.OffsetAnimation1))
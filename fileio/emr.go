@@ -56,20 +56,23 @@ func LoadEMRStream(r io.ReaderAt, fileLength int64, animationData *EDDOutput) (*
 	// Read header
 	emrHeader := EMRHeader{}
 	if err := binary.Read(streamReader, binary.LittleEndian, &emrHeader); err != nil {
-		return nil, err
+		return nil, wrapReadError("EMR header", 0, err)
+	}
+	if emrHeader.Count == 0 {
+		return nil, newFormatError("EMR header", 4, ErrInvalidValue, "skeleton has no bones")
 	}
 
 	// Read relative positions
 	relativePositions := make([]EMRRelativePosition, int(emrHeader.Count))
 	if err := binary.Read(streamReader, binary.LittleEndian, &relativePositions); err != nil {
-		return nil, err
+		return nil, wrapReadError("EMR positions", 8, err)
 	}
 
 	// Read armature offsets
 	armatureReader := io.NewSectionReader(r, int64(emrHeader.OffsetArmatures), fileLength)
 	armatures := make([]EMRArmature, int(emrHeader.Count))
 	if err := binary.Read(armatureReader, binary.LittleEndian, &armatures); err != nil {
-		return nil, err
+		return nil, wrapReadError("EMR armatures", int64(emrHeader.OffsetArmatures), err)
 	}
 
 	// Stores a hierarchy of the components
@@ -86,7 +89,7 @@ func LoadEMRStream(r io.ReaderAt, fileLength int64, animationData *EDDOutput) (*
 
 		armatureChildren[i] = make([]uint8, int(armatures[i].Count))
 		if err := binary.Read(streamReader, binary.LittleEndian, &armatureChildren[i]); err != nil {
-			return nil, err
+			return nil, wrapReadError("EMR armatures", int64(emrHeader.OffsetArmatures)+int64(armatures[i].Offset), err)
 		}
 	}
 
@@ -94,7 +97,7 @@ func LoadEMRStream(r io.ReaderAt, fileLength int64, animationData *EDDOutput) (*
 	streamReader = io.NewSectionReader(r, int64(emrHeader.OffsetArmatures)+int64(armatures[0].Offset), fileLength)
 	meshList := make([]uint8, int(emrHeader.Count))
 	if err := binary.Read(streamReader, binary.LittleEndian, &meshList); err != nil {
-		return nil, err
+		return nil, wrapReadError("EMR mesh list", int64(emrHeader.OffsetArmatures)+int64(armatures[0].Offset), err)
 	}
 
 	// Read animation frames
@@ -102,13 +105,19 @@ func LoadEMRStream(r io.ReaderAt, fileLength int64, animationData *EDDOutput) (*
 	// EMR frame header is 12 bytes (6 uint16 values)
 	// Remaining data contains an array of rotation angles
 	remainSize := int(emrHeader.ElementSize) - 2*6
+	if remainSize < 0 {
+		return nil, newFormatError("EMR header", 6, ErrInvalidValue, "frame size %d is smaller than the frame header", emrHeader.ElementSize)
+	}
+	if err := checkSectionSize("EMR frames", int64(emrHeader.OffsetFrames), int64(numFrames)*int64(emrHeader.ElementSize), fileLength); err != nil {
+		return nil, err
+	}
 
 	frameData := make([]AnimationFrame, numFrames)
 	for i := 0; i < numFrames; i++ {
 		streamReader = io.NewSectionReader(r, int64(emrHeader.OffsetFrames)+int64(i*int(emrHeader.ElementSize)), fileLength)
 		frameHeader := EMRFrame{}
 		if err := binary.Read(streamReader, binary.LittleEndian, &frameHeader); err != nil {
-			return nil, err
+			return nil, wrapReadError("EMR frames", int64(emrHeader.OffsetFrames)+int64(i*int(emrHeader.ElementSize)), err)
 		}
 		maxAngleCount := int(math.Floor(float64(remainSize) * 8.0 / 12.0))
 
@@ -0,0 +1,81 @@
+package fileio
+
+// Errors returned by the file loaders when the data is invalid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrBadMagic         = errors.New("bad magic")
+	ErrTruncated        = errors.New("truncated section")
+	ErrOffsetOutOfRange = errors.New("offset out of range")
+	ErrUnknownOpcode    = errors.New("unknown opcode")
+	ErrInvalidValue     = errors.New("invalid value")
+)
+
+// FormatError describes where a file stopped making sense.
+// Use errors.Is with the sentinel errors to check what went wrong.
+type FormatError struct {
+	Section string // part of the file being read, such as "TIM clut"
+	Offset  int64  // position of the bad data, relative to the start of the stream
+	Err     error  // one of the sentinel errors
+	Detail  string
+}
+
+func (e *FormatError) Error() string {
+	message := fmt.Sprintf("%s at offset 0x%x: %v", e.Section, e.Offset, e.Err)
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+func (e *FormatError) Unwrap() error {
+	return e.Err
+}
+
+func newFormatError(section string, offset int64, err error, format string, args ...interface{}) *FormatError {
+	return &FormatError{
+		Section: section,
+		Offset:  offset,
+		Err:     err,
+		Detail:  fmt.Sprintf(format, args...),
+	}
+}
+
+// wrapReadError reports a failed read as a truncated section
+func wrapReadError(section string, offset int64, err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &FormatError{Section: section, Offset: offset, Err: ErrTruncated, Detail: err.Error()}
+	}
+	return fmt.Errorf("%s at offset 0x%x: %w", section, offset, err)
+}
+
+// checkSectionSize makes sure a section fits in the stream before it is allocated
+func checkSectionSize(section string, offset int64, size int64, fileLength int64) error {
+	if offset < 0 || offset > fileLength {
+		return newFormatError(section, offset, ErrOffsetOutOfRange, "file length is 0x%x", fileLength)
+	}
+	if size < 0 || size > fileLength-offset {
+		return newFormatError(section, offset, ErrTruncated, "needs 0x%x bytes, 0x%x left", size, fileLength-offset)
+	}
+	return nil
+}
+
+// readArrayAt reads count values from offset after checking that they fit in the stream
+func readArrayAt[T any](streamReader *StreamReader, section string, offset int64, count int) ([]T, error) {
+	var value T
+	if err := checkSectionSize(section, offset, int64(count)*int64(binary.Size(value)), streamReader.reader.Size()); err != nil {
+		return nil, err
+	}
+	streamReader.SetPosition(offset)
+	values := make([]T, count)
+	if err := streamReader.ReadData(&values); err != nil {
+		return nil, wrapReadError(section, offset, err)
+	}
+	return values, nil
+}
@@ -0,0 +1,94 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestLoaderErrors(t *testing.T) {
+	validTIM := buildTestTIMBytes(t, TIM_BPP_16, nil, 2, 2, make([]byte, 8))
+	badMagicTIM := append([]byte{}, validTIM...)
+	badMagicTIM[0] = 17
+	hugeClutTIM := buildTestTIMBytes(t, TIM_BPP_8, [][]uint16{make([]uint16, 256)}, 2, 1, make([]byte, 4))
+	hugeClutTIM[16], hugeClutTIM[17], hugeClutTIM[18], hugeClutTIM[19] = 0xFF, 0xFF, 0xFF, 0xFF
+
+	vabHeader, _ := buildTestVABBytes(t)
+	badMagicVAB := append([]byte{}, vabHeader...)
+	badMagicVAB[0] = 'x'
+	tooManyPrograms := append([]byte{}, vabHeader...)
+	tooManyPrograms[18] = 200
+
+	tests := []struct {
+		name          string
+		load          func(data []byte) error
+		data          []byte
+		expected      error
+		expectedStart int64
+	}{
+		{"TIM bad magic", loadTIM, badMagicTIM, ErrBadMagic, 0},
+		{"TIM truncated header", loadTIM, validTIM[:6], ErrTruncated, 4},
+		{"TIM truncated image", loadTIM, validTIM[:len(validTIM)-1], ErrTruncated, 20},
+		{"TIM clut larger than file", loadTIM, hugeClutTIM, ErrTruncated, 20},
+		{"VAB bad magic", loadVABHeader, badMagicVAB, ErrBadMagic, 0},
+		{"VAB too many programs", loadVABHeader, tooManyPrograms, ErrInvalidValue, 18},
+		{"VAB truncated tones", loadVABHeader, vabHeader[:32+128*16+100], ErrTruncated, 32 + 128*16},
+		{"SCD unknown opcode", loadSCD, []byte{2, 0, 0xFF}, ErrUnknownOpcode, 2},
+		{"SCD truncated instruction", loadSCD, []byte{2, 0, OP_SET_BIT, 1}, ErrTruncated, 2},
+		{"MSG unsorted offsets", loadMSG, []byte{4, 0, 2, 0, MSG_CODE_END, 0}, ErrOffsetOutOfRange, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.load(test.data)
+			if !errors.Is(err, test.expected) {
+				t.Fatalf("Expected %v, got %v", test.expected, err)
+			}
+			var formatError *FormatError
+			if !errors.As(err, &formatError) {
+				t.Fatalf("Expected a FormatError, got %T", err)
+			}
+			if formatError.Offset != test.expectedStart {
+				t.Errorf("Expected offset 0x%x, got 0x%x (%v)", test.expectedStart, formatError.Offset, err)
+			}
+		})
+	}
+}
+
+func TestLoadRDT_ItemOffsetOutOfRange(t *testing.T) {
+	// Room with no cameras and one item texture past the end of the file
+	fileData := make([]byte, 0x1000)
+	fileData[2] = 1 // NumModels
+	binary.LittleEndian.PutUint32(fileData[8+4*RDT_SECTION_ITEMS:], RDT_HEADER_SIZE)
+	binary.LittleEndian.PutUint32(fileData[RDT_HEADER_SIZE:], 0xFFFFFF)
+
+	_, err := LoadRDT(bytes.NewReader(fileData), int64(len(fileData)))
+	if !errors.Is(err, ErrOffsetOutOfRange) {
+		t.Fatalf("Expected offset out of range, got %v", err)
+	}
+	var formatError *FormatError
+	if !errors.As(err, &formatError) || formatError.Section != "RDT item texture" || formatError.Offset != 0xFFFFFF {
+		t.Errorf("Expected item texture error at 0xffffff, got %v", err)
+	}
+}
+
+func loadTIM(data []byte) error {
+	_, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	return err
+}
+
+func loadVABHeader(data []byte) error {
+	_, err := LoadVABHeaderStream(bytes.NewReader(data), int64(len(data)))
+	return err
+}
+
+func loadSCD(data []byte) error {
+	_, err := LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	return err
+}
+
+func loadMSG(data []byte) error {
+	_, err := LoadRDT_MSGStream(bytes.NewReader(data), int64(len(data)))
+	return err
+}
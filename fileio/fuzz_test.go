@@ -0,0 +1,67 @@
+package fileio
+
+import (
+	"bytes"
+	"testing"
+)
+
+// The loaders must return an error for bad data instead of panicking or exiting
+
+func FuzzLoadTIMStream(f *testing.F) {
+	f.Add(buildTestTIMBytes(f, TIM_BPP_16, nil, 2, 2, make([]byte, 8)))
+	f.Add(buildTestTIMBytes(f, TIM_BPP_4, [][]uint16{make([]uint16, 16)}, 1, 1, make([]byte, 2)))
+	f.Add(buildTestTIMBytes(f, TIM_BPP_8, [][]uint16{make([]uint16, 256)}, 1, 1, make([]byte, 2)))
+	f.Add(buildTestTIMBytes(f, TIM_BPP_24, nil, 3, 1, make([]byte, 6)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func FuzzLoadVABHeaderStream(f *testing.F) {
+	headerBytes, _ := buildTestVABBytes(f)
+	f.Add(headerBytes)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadVABHeaderStream(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func FuzzLoadRDT(f *testing.F) {
+	f.Add(buildTestRDTBytes(f))
+	f.Add(make([]byte, 0x1000))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadRDT(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func FuzzLoadRDT_MSGStream(f *testing.F) {
+	f.Add([]byte{0x04, 0x00, 0x08, 0x00, MSG_CODE_START, 0x00, MSG_CODE_END, 0x00, 0x27, MSG_CODE_END, 0x01})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadRDT_MSGStream(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func FuzzLoadRDT_SCDStream(f *testing.F) {
+	f.Add([]byte{4, 0, 6, 0, OP_SET_BIT, 1, 2, 1, OP_EVT_END, OP_SLEEP, 0, 30, 0, OP_EVT_END})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func FuzzLoadEMDStream(f *testing.F) {
+	f.Add(make([]byte, 0x100))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadEMDStream(bytes.NewReader(data), int64(len(data)))
+	})
+}
+
+func FuzzLoadDO2Stream(f *testing.F) {
+	headerBytes, dataBytes := buildTestVABBytes(f)
+	do2Data := append(make([]byte, 16), headerBytes...)
+	do2Data = append(do2Data, make([]byte, 8)...)
+	do2Data = append(do2Data, dataBytes...)
+	do2Data = append(do2Data, 4, 0, 0, 0)
+	f.Add(do2Data)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		LoadDO2Stream(bytes.NewReader(data), int64(len(data)))
+	})
+}
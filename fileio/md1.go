@@ -114,12 +114,12 @@ func LoadMD1Stream(r io.ReaderAt, fileLength int64) (*MD1Output, error) {
 	// Read header
 	md1Header := MD1Header{}
 	if err := fileStreamReader.ReadData(&md1Header); err != nil {
-		return nil, err
+		return nil, wrapReadError("MD1 header", 0, err)
 	}
 
 	// Read header offsets
-	modelObjectHeaders := make([]MD1ObjectHeader, int(md1Header.NumObj)/2)
-	if err := fileStreamReader.ReadData(&modelObjectHeaders); err != nil {
+	modelObjectHeaders, err := readArrayAt[MD1ObjectHeader](fileStreamReader, "MD1 objects", 12, int(md1Header.NumObj)/2)
+	if err != nil {
 		return nil, err
 	}
 
@@ -132,60 +132,52 @@ func LoadMD1Stream(r io.ReaderAt, fileLength int64) (*MD1Output, error) {
 		modelObjectHeader := modelObjectHeaders[i]
 		// Triangle data
 		offset := beginOffset + int64(modelObjectHeader.TrianglesHeader.VertexOffset)
-		fileStreamReader.SetPosition(offset)
-		triangleVertices := make([]MD1Vertex, modelObjectHeader.TrianglesHeader.VertexCount)
-		if err := fileStreamReader.ReadData(&triangleVertices); err != nil {
+		triangleVertices, err := readArrayAt[MD1Vertex](fileStreamReader, "MD1 triangle vertices", offset, int(modelObjectHeader.TrianglesHeader.VertexCount))
+		if err != nil {
 			return nil, err
 		}
 
 		offset = beginOffset + int64(modelObjectHeader.TrianglesHeader.NormalOffset)
-		fileStreamReader.SetPosition(offset)
-		triangleNormals := make([]MD1Vertex, modelObjectHeader.TrianglesHeader.NormalCount)
-		if err := fileStreamReader.ReadData(&triangleNormals); err != nil {
+		triangleNormals, err := readArrayAt[MD1Vertex](fileStreamReader, "MD1 triangle normals", offset, int(modelObjectHeader.TrianglesHeader.NormalCount))
+		if err != nil {
 			return nil, err
 		}
 
 		offset = beginOffset + int64(modelObjectHeader.TrianglesHeader.TriangleIndexOffset)
-		fileStreamReader.SetPosition(offset)
-		triangleIndices := make([]MD1TriangleIndex, modelObjectHeader.TrianglesHeader.TriangleIndexCount)
-		if err := fileStreamReader.ReadData(&triangleIndices); err != nil {
+		triangleIndices, err := readArrayAt[MD1TriangleIndex](fileStreamReader, "MD1 triangle indices", offset, int(modelObjectHeader.TrianglesHeader.TriangleIndexCount))
+		if err != nil {
 			return nil, err
 		}
 
 		offset = beginOffset + int64(modelObjectHeader.TrianglesHeader.TextureOffset)
-		fileStreamReader.SetPosition(offset)
-		triangleTextures := make([]MD1TriangleTexture, modelObjectHeader.TrianglesHeader.TriangleIndexCount)
-		if err := fileStreamReader.ReadData(&triangleTextures); err != nil {
+		triangleTextures, err := readArrayAt[MD1TriangleTexture](fileStreamReader, "MD1 triangle textures", offset, int(modelObjectHeader.TrianglesHeader.TriangleIndexCount))
+		if err != nil {
 			return nil, err
 		}
 
 		// Quad data
 		offset = beginOffset + int64(modelObjectHeader.QuadsHeader.VertexOffset)
-		fileStreamReader.SetPosition(offset)
-		quadVertices := make([]MD1Vertex, modelObjectHeader.QuadsHeader.VertexCount)
-		if err := fileStreamReader.ReadData(&quadVertices); err != nil {
+		quadVertices, err := readArrayAt[MD1Vertex](fileStreamReader, "MD1 quad vertices", offset, int(modelObjectHeader.QuadsHeader.VertexCount))
+		if err != nil {
 			return nil, err
 		}
 
 		offset = beginOffset + int64(modelObjectHeader.QuadsHeader.NormalOffset)
-		fileStreamReader.SetPosition(offset)
-		quadNormals := make([]MD1Vertex, modelObjectHeader.QuadsHeader.NormalCount)
-		if err := fileStreamReader.ReadData(&quadNormals); err != nil {
+		quadNormals, err := readArrayAt[MD1Vertex](fileStreamReader, "MD1 quad normals", offset, int(modelObjectHeader.QuadsHeader.NormalCount))
+		if err != nil {
 			return nil, err
 		}
 
 		// A quad has 2 triangles
 		offset = beginOffset + int64(modelObjectHeader.QuadsHeader.QuadIndexOffset)
-		fileStreamReader.SetPosition(offset)
-		quadIndices := make([]MD1QuadIndex, modelObjectHeader.QuadsHeader.QuadIndexCount)
-		if err := fileStreamReader.ReadData(&quadIndices); err != nil {
+		quadIndices, err := readArrayAt[MD1QuadIndex](fileStreamReader, "MD1 quad indices", offset, int(modelObjectHeader.QuadsHeader.QuadIndexCount))
+		if err != nil {
 			return nil, err
 		}
 
 		offset = beginOffset + int64(modelObjectHeader.QuadsHeader.TextureOffset)
-		fileStreamReader.SetPosition(offset)
-		quadTextures := make([]MD1QuadTexture, modelObjectHeader.QuadsHeader.QuadIndexCount)
-		if err := fileStreamReader.ReadData(&quadTextures); err != nil {
+		quadTextures, err := readArrayAt[MD1QuadTexture](fileStreamReader, "MD1 quad textures", offset, int(modelObjectHeader.QuadsHeader.QuadIndexCount))
+		if err != nil {
 			return nil, err
 		}
 
@@ -88,21 +88,33 @@ func LoadPLDStream(r io.ReaderAt, fileLength int64) (*PLDOutput, error) {
 }
 
 func loadAnimationData(fileReader io.ReaderAt, fileLength int64, offset int64) (*EDDOutput, error) {
+	if err := checkSectionSize("EDD animation", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	eddReader := io.NewSectionReader(fileReader, offset, fileLength-offset)
 	return LoadEDDStream(eddReader, fileLength-offset)
 }
 
 func loadSkeletonData(fileReader io.ReaderAt, fileLength int64, offset int64, animationData *EDDOutput) (*EMROutput, error) {
+	if err := checkSectionSize("EMR skeleton", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	emrReader := io.NewSectionReader(fileReader, offset, fileLength-offset)
 	return LoadEMRStream(emrReader, fileLength-offset, animationData)
 }
 
 func loadMeshData(fileReader io.ReaderAt, fileLength int64, offset int64) (*MD1Output, error) {
+	if err := checkSectionSize("MD1 mesh", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	md1Reader := io.NewSectionReader(fileReader, offset, fileLength-offset)
 	return LoadMD1Stream(md1Reader, fileLength-offset)
 }
 
 func loadTexture(fileReader io.ReaderAt, fileLength int64, offset int64) (*TIMOutput, error) {
+	if err := checkSectionSize("TIM texture", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	TIMReader := io.NewSectionReader(fileReader, offset, fileLength-int64(offset))
 	return LoadTIMStream(TIMReader, fileLength-int64(offset))
 }
@@ -6,7 +6,6 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"log"
 	"os"
 )
 
@@ -63,6 +62,12 @@ type RDTOutput struct {
 	SpriteOutput     *ESPOutput
 	ItemTextureData  []*TIMOutput
 	ItemModelData    []*MD1Output
+	Lang1MessageData *MSGOutput // main language messages
+	Lang2MessageData *MSGOutput // second language messages
+	RoomSoundData    *SNDOutput // sound table
+	RoomVABData      *VABOutput // room sound effects
+	EnemyVABData     *VABOutput // enemy sound effects
+	FloorSoundData   *FLROutput // footstep sounds
 }
 
 func LoadRDTFile(filename string) (*RDTOutput, error) {
@@ -81,17 +86,19 @@ func LoadRDTFile(filename string) (*RDTOutput, error) {
 	return LoadRDT(rdtFile, fileLength)
 }
 
+// LoadRDT reads every section of a room.
+// If a script has an opcode with an unknown size, the room is still returned along with an ErrUnknownOpcode error.
 func LoadRDT(r io.ReaderAt, fileLength int64) (*RDTOutput, error) {
 	reader := io.NewSectionReader(r, int64(0), fileLength)
 
 	rdtHeader := RDTHeader{}
 	if err := binary.Read(reader, binary.LittleEndian, &rdtHeader); err != nil {
-		return nil, err
+		return nil, wrapReadError("RDT header", 0, err)
 	}
 
 	offsets := RDTOffsets{}
 	if err := binary.Read(reader, binary.LittleEndian, &offsets); err != nil {
-		return nil, err
+		return nil, wrapReadError("RDT offsets", 8, err)
 	}
 
 	// Camera position data
@@ -124,19 +131,26 @@ func LoadRDT(r io.ReaderAt, fileLength int64) (*RDTOutput, error) {
 	if rdtHeader.NumModels > 0 {
 		// Get the offsets
 		offset := int64(offsets.OffsetItems)
+		if err := checkSectionSize("RDT items", offset, int64(rdtHeader.NumModels)*RDT_ITEM_OFFSETS_SIZE, fileLength); err != nil {
+			return nil, err
+		}
 		tempReader := io.NewSectionReader(r, offset, fileLength-offset)
 		modelItemData := make([]RDTItemOffsets, rdtHeader.NumModels)
 		if err := binary.Read(tempReader, binary.LittleEndian, &modelItemData); err != nil {
-			log.Fatal("Error reading item model data ", err)
+			return nil, wrapReadError("RDT items", offset, err)
 		}
 
 		// Read item texture
-		modelTextureLength := fileLength - int64(offsets.OffsetModelImage)
 		for i := 0; i < int(rdtHeader.NumModels); i++ {
-			timReader := io.NewSectionReader(r, int64(modelItemData[i].OffsetTexture), modelTextureLength)
-			timOutput, err := LoadTIMStream(timReader, modelTextureLength)
+			offset = int64(modelItemData[i].OffsetTexture)
+			if err := checkSectionSize("RDT item texture", offset, 0, fileLength); err != nil {
+				return nil, err
+			}
+			textureLength := fileLength - offset
+			timReader := io.NewSectionReader(r, offset, textureLength)
+			timOutput, err := LoadTIMStream(timReader, textureLength)
 			if err != nil {
-				log.Fatal("Error reading item texture: ", err)
+				return nil, fmt.Errorf("RDT item %d texture at offset 0x%x: %w", i, offset, err)
 			}
 			itemTextureData[i] = timOutput
 		}
@@ -148,38 +162,62 @@ func LoadRDT(r io.ReaderAt, fileLength int64) (*RDTOutput, error) {
 			if offset == 0 {
 				continue
 			}
+			if err := checkSectionSize("RDT item model", offset, 0, fileLength); err != nil {
+				return nil, err
+			}
 			modelLength := fileLength - offset
-			timReader := io.NewSectionReader(r, offset, modelLength)
-			md1Output, err := LoadMD1Stream(timReader, modelLength)
+			md1Reader := io.NewSectionReader(r, offset, modelLength)
+			md1Output, err := LoadMD1Stream(md1Reader, modelLength)
 			if err != nil {
-				log.Fatal("Error reading item model: ", err)
+				return nil, fmt.Errorf("RDT item %d model at offset 0x%x: %w", i, offset, err)
 			}
 			itemModelData[i] = md1Output
 		}
 	}
 
-	offset := int64(offsets.OffsetLang1)
-	if offset > 0 {
-		lang1MsgReader := io.NewSectionReader(r, offset, fileLength-offset)
-		LoadRDT_MSGStream(lang1MsgReader, fileLength)
+	// Messages
+	var messageData [2]*MSGOutput
+	for i, langOffset := range []uint32{offsets.OffsetLang1, offsets.OffsetLang2} {
+		offset := int64(langOffset)
+		if offset == 0 {
+			continue
+		}
+		if err := checkSectionSize("RDT messages", offset, 0, fileLength); err != nil {
+			return nil, err
+		}
+		msgReader := io.NewSectionReader(r, offset, fileLength-offset)
+		msgOutput, err := LoadRDT_MSGStream(msgReader, fileLength-offset)
+		if err != nil {
+			return nil, err
+		}
+		messageData[i] = msgOutput
 	}
 
 	// Script data
 	// Run once when the level loads
-	offset = int64(offsets.OffsetInitScript)
-	initSCDReader := io.NewSectionReader(r, offset, fileLength-offset)
-	initSCDOutput, err := LoadRDT_SCDStream(initSCDReader, fileLength)
-	if err != nil {
+	offset := int64(offsets.OffsetInitScript)
+	if err := checkSectionSize("RDT init script", offset, 0, fileLength); err != nil {
 		return nil, err
 	}
+	initSCDReader := io.NewSectionReader(r, offset, fileLength-offset)
+	initSCDOutput, scriptErr := LoadRDT_SCDStream(initSCDReader, fileLength)
+	if initSCDOutput == nil {
+		return nil, scriptErr
+	}
 
 	// Run during the game
 	offset = int64(offsets.OffsetExecuteScript)
+	if err := checkSectionSize("RDT room script", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	roomSCDReader := io.NewSectionReader(r, offset, fileLength-offset)
 	roomSCDOutput, err := LoadRDT_SCDStream(roomSCDReader, fileLength)
-	if err != nil {
+	if roomSCDOutput == nil {
 		return nil, err
 	}
+	if scriptErr == nil {
+		scriptErr = err
+	}
 
 	// Sprite animations
 	espOutput, err := LoadRDT_ESP(r, fileLength, rdtHeader, offsets)
@@ -188,12 +226,22 @@ func LoadRDT(r io.ReaderAt, fileLength int64) (*RDTOutput, error) {
 	}
 
 	// Audio
-	_, err = LoadRDT_VABStream(r, fileLength, offsets)
+	sndOutput, err := LoadRDT_SNDStream(r, fileLength, offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	roomVABOutput, err := LoadRDT_VABStream(r, fileLength, offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	enemyVABOutput, err := LoadRDT_EnemyVABStream(r, fileLength, offsets)
 	if err != nil {
 		return nil, err
 	}
 
-	_, err = LoadRDT_FLRStream(r, fileLength, offsets)
+	flrOutput, err := LoadRDT_FLRStream(r, fileLength, offsets)
 	if err != nil {
 		return nil, err
 	}
@@ -209,6 +257,12 @@ func LoadRDT(r io.ReaderAt, fileLength int64) (*RDTOutput, error) {
 		SpriteOutput:     espOutput,
 		ItemTextureData:  itemTextureData,
 		ItemModelData:    itemModelData,
+		Lang1MessageData: messageData[0],
+		Lang2MessageData: messageData[1],
+		RoomSoundData:    sndOutput,
+		RoomVABData:      roomVABOutput,
+		EnemyVABData:     enemyVABOutput,
+		FloorSoundData:   flrOutput,
 	}
-	return output, nil
+	return output, scriptErr
 }
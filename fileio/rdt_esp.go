@@ -1,27 +1,34 @@
 package fileio
 
 import (
+	"fmt"
 	"io"
 )
 
 func LoadRDT_ESP(r io.ReaderAt, fileLength int64, rdtHeader RDTHeader, offsets RDTOffsets) (*ESPOutput, error) {
 	sectionBeginOffset := int64(offsets.OffsetSpriteAnimations)
+	if err := checkSectionSize("RDT sprite animations", sectionBeginOffset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	reader := io.NewSectionReader(r, sectionBeginOffset, fileLength-sectionBeginOffset)
 
 	eofOffset := int64(offsets.OffsetSpriteAnimationsOffset)
 
 	espOutput, err := LoadESPStream(reader, (eofOffset+4)-sectionBeginOffset, eofOffset-sectionBeginOffset)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("RDT sprite animations at offset 0x%x: %w", sectionBeginOffset, err)
 	}
 
 	// Read Sprite TIM image
 	timOffset := offsets.OffsetSpriteImage
 	for i := 0; i < espOutput.ValidSpriteCount; i++ {
+		if err := checkSectionSize("RDT sprite image", int64(timOffset), 0, fileLength); err != nil {
+			return nil, err
+		}
 		timReader := io.NewSectionReader(r, int64(timOffset), fileLength-int64(timOffset))
 		timOutput, err := LoadTIMStream(timReader, fileLength-int64(timOffset))
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("RDT sprite image %d at offset 0x%x: %w", i, timOffset, err)
 		}
 		timOffset += uint32(timOutput.NumBytes)
 
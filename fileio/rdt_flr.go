@@ -22,15 +22,21 @@ type FLROutput struct {
 
 func LoadRDT_FLRStream(r io.ReaderAt, fileLength int64, offsets RDTOffsets) (*FLROutput, error) {
 	offset := int64(offsets.OffsetFloorSound)
+	if offset == 0 {
+		return nil, nil
+	}
+	if err := checkSectionSize("RDT floor sounds", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	flrHeaderReader := io.NewSectionReader(r, offset, fileLength-offset)
 	floorSoundCount := uint16(0)
 	if err := binary.Read(flrHeaderReader, binary.LittleEndian, &floorSoundCount); err != nil {
-		return nil, err
+		return nil, wrapReadError("RDT floor sounds", offset, err)
 	}
 
 	floorSounds := make([]FLRSound, floorSoundCount)
 	if err := binary.Read(flrHeaderReader, binary.LittleEndian, &floorSounds); err != nil {
-		return nil, err
+		return nil, wrapReadError("RDT floor sounds", offset, err)
 	}
 
 	output := &FLROutput{
@@ -33,11 +33,14 @@ type LITOutput struct {
 
 func LoadRDT_LIT(r io.ReaderAt, fileLength int64, rdtHeader RDTHeader, offsets RDTOffsets) (*LITOutput, error) {
 	offset := int64(offsets.OffsetLights)
+	if err := checkSectionSize("RDT lights", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	reader := io.NewSectionReader(r, offset, fileLength-offset)
 
 	lights := make([]LITCameraLight, int(rdtHeader.NumCameras))
 	if err := binary.Read(reader, binary.LittleEndian, &lights); err != nil {
-		return nil, err
+		return nil, wrapReadError("RDT lights", offset, err)
 	}
 
 	output := &LITOutput{
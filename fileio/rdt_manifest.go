@@ -0,0 +1,141 @@
+package fileio
+
+// Manifest for an RDT file that was split into one file per chunk
+// It keeps the header and original offsets so the chunks can be packed back into a room file
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+type RDTManifest struct {
+	Header   RDTHeader            `json:"header"`
+	Sections []RDTManifestSection `json:"sections"` // in the order of the offset table
+	Chunks   []RDTManifestChunk   `json:"chunks"`   // sorted by offset
+}
+
+type RDTManifestSection struct {
+	Name   string `json:"name"`
+	Offset uint32 `json:"offset"` // 0 if the room doesn't have this section
+}
+
+type RDTManifestChunk struct {
+	Name      string `json:"name"`
+	Extension string `json:"extension"`
+	Offset    uint32 `json:"offset"` // offset in the original file
+	Length    int    `json:"length"`
+	Filename  string `json:"filename,omitempty"` // file the chunk was extracted to
+}
+
+// Manifest describes every chunk, named after the section or pointer that starts it.
+// Chunks that nothing points to directly are named after their offset.
+func (rdt *RDTRawOutput) Manifest() (*RDTManifest, error) {
+	offsetValues := rdt.Offsets.Values()
+	sections := make([]RDTManifestSection, RDT_SECTION_COUNT)
+	for i, offset := range offsetValues {
+		sections[i] = RDTManifestSection{Name: RDTSectionNames[i], Offset: offset}
+	}
+
+	originalData := rdt.originalBytes()
+	pointers, err := findRDTPointers(originalData, rdt.Header, rdt.Offsets)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make([]RDTManifestChunk, len(rdt.Chunks))
+	for i, chunk := range rdt.Chunks {
+		chunks[i] = RDTManifestChunk{
+			Name:      fmt.Sprintf("data_%06x", chunk.Offset),
+			Extension: ".bin",
+			Offset:    chunk.Offset,
+			Length:    len(chunk.Data),
+		}
+	}
+	// The first name found for a chunk is kept
+	named := make(map[uint32]bool)
+	setName := func(offset uint32, name string, extension string) {
+		if named[offset] {
+			return
+		}
+		for i := range chunks {
+			if chunks[i].Offset == offset {
+				chunks[i].Name = name
+				chunks[i].Extension = extension
+				named[offset] = true
+			}
+		}
+	}
+	for i, offset := range offsetValues {
+		if offset != 0 {
+			setName(offset, RDTSectionNames[i], RDTSectionExtensions[i])
+		}
+	}
+	for _, pointer := range pointers {
+		setName(binary.LittleEndian.Uint32(originalData[pointer.position:]), pointer.chunkName, pointer.extension)
+	}
+
+	return &RDTManifest{
+		Header:   rdt.Header,
+		Sections: sections,
+		Chunks:   chunks,
+	}, nil
+}
+
+// originalBytes puts the chunks back at their original offsets
+func (rdt *RDTRawOutput) originalBytes() []byte {
+	fileLength := uint32(RDT_HEADER_SIZE)
+	for _, chunk := range rdt.Chunks {
+		if end := chunk.Offset + uint32(len(chunk.Data)); end > fileLength {
+			fileLength = end
+		}
+	}
+	fileData := make([]byte, fileLength)
+	for _, chunk := range rdt.Chunks {
+		copy(fileData[chunk.Offset:], chunk.Data)
+	}
+	return fileData
+}
+
+// NewRDTRawFromManifest rebuilds a room from a manifest and the data of each chunk, in manifest order.
+// Chunks may have changed size since they were extracted.
+func NewRDTRawFromManifest(manifest *RDTManifest, chunkData [][]byte) (*RDTRawOutput, error) {
+	if len(chunkData) != len(manifest.Chunks) {
+		return nil, fmt.Errorf("manifest has %d chunks, but %d were given", len(manifest.Chunks), len(chunkData))
+	}
+
+	var offsetValues [RDT_SECTION_COUNT]uint32
+	for _, section := range manifest.Sections {
+		sectionIndex := -1
+		for i, name := range RDTSectionNames {
+			if name == section.Name {
+				sectionIndex = i
+				break
+			}
+		}
+		if sectionIndex == -1 {
+			return nil, fmt.Errorf("unknown RDT section %q in manifest", section.Name)
+		}
+		offsetValues[sectionIndex] = section.Offset
+	}
+
+	chunks := make([]*RDTChunk, len(manifest.Chunks))
+	chunkStarts := make(map[uint32]bool)
+	for i, manifestChunk := range manifest.Chunks {
+		if i > 0 && manifestChunk.Offset <= manifest.Chunks[i-1].Offset {
+			return nil, fmt.Errorf("chunk %s at 0x%x is out of order", manifestChunk.Name, manifestChunk.Offset)
+		}
+		chunks[i] = &RDTChunk{Offset: manifestChunk.Offset, Data: chunkData[i]}
+		chunkStarts[manifestChunk.Offset] = true
+	}
+	for i, offset := range offsetValues {
+		if offset != 0 && !chunkStarts[offset] {
+			return nil, fmt.Errorf("section %s offset 0x%x doesn't match any chunk", RDTSectionNames[i], offset)
+		}
+	}
+
+	return &RDTRawOutput{
+		Header:  manifest.Header,
+		Offsets: NewRDTOffsets(offsetValues),
+		Chunks:  chunks,
+	}, nil
+}
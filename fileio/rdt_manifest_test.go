@@ -0,0 +1,78 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestRDTManifest_ChunkNames(t *testing.T) {
+	fileData := buildTestRDTBytes(t)
+	rdtOutput, err := LoadRDTRaw(bytes.NewReader(fileData), int64(len(fileData)))
+	if err != nil {
+		t.Fatalf("LoadRDTRaw() error: %v", err)
+	}
+	manifest, err := rdtOutput.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest() error: %v", err)
+	}
+
+	// The item texture is also the start of the model image section, so the section name is used
+	expectedNames := []string{"rid", "camera00_mask", "sca", "items", "model_tim", "item00_model", "init_scd", "main_scd"}
+	if len(manifest.Chunks) != len(expectedNames) {
+		t.Fatalf("Expected %d chunks, got %d", len(expectedNames), len(manifest.Chunks))
+	}
+	for i, name := range expectedNames {
+		if manifest.Chunks[i].Name != name {
+			t.Errorf("Chunk %d: expected name %s, got %s", i, name, manifest.Chunks[i].Name)
+		}
+		if manifest.Chunks[i].Length != len(rdtOutput.Chunks[i].Data) {
+			t.Errorf("Chunk %d: expected length %d, got %d", i, len(rdtOutput.Chunks[i].Data), manifest.Chunks[i].Length)
+		}
+	}
+	if manifest.Chunks[1].Extension != ".pri" || manifest.Chunks[5].Extension != ".md1" {
+		t.Errorf("Unexpected extensions %s and %s", manifest.Chunks[1].Extension, manifest.Chunks[5].Extension)
+	}
+}
+
+func TestRDTManifest_RoundTrip(t *testing.T) {
+	fileData := buildTestRDTBytes(t)
+	rdtOutput, err := LoadRDTRaw(bytes.NewReader(fileData), int64(len(fileData)))
+	if err != nil {
+		t.Fatalf("LoadRDTRaw() error: %v", err)
+	}
+	manifest, err := rdtOutput.Manifest()
+	if err != nil {
+		t.Fatalf("Manifest() error: %v", err)
+	}
+
+	// The manifest is stored as JSON next to the extracted chunks
+	manifestJSON, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("json.Marshal() error: %v", err)
+	}
+	loadedManifest := &RDTManifest{}
+	if err := json.Unmarshal(manifestJSON, loadedManifest); err != nil {
+		t.Fatalf("json.Unmarshal() error: %v", err)
+	}
+
+	chunkData := make([][]byte, len(rdtOutput.Chunks))
+	for i, chunk := range rdtOutput.Chunks {
+		chunkData[i] = chunk.Data
+	}
+	packed, err := NewRDTRawFromManifest(loadedManifest, chunkData)
+	if err != nil {
+		t.Fatalf("NewRDTRawFromManifest() error: %v", err)
+	}
+	outputData, err := packed.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	if !bytes.Equal(outputData, fileData) {
+		t.Errorf("Packing the chunks changed the file:\nexpected %v\ngot      %v", fileData, outputData)
+	}
+
+	if _, err := NewRDTRawFromManifest(loadedManifest, chunkData[1:]); err == nil {
+		t.Error("Expected error when a chunk is missing")
+	}
+}
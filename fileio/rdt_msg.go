@@ -3,12 +3,56 @@ package fileio
 // .msg - Message data
 
 import (
+	"bytes"
 	"encoding/binary"
+	"fmt"
 	"io"
-	"log"
+	"os"
+	"strconv"
+	"strings"
 )
 
+const (
+	MSG_CODE_EXTENDED_CHAR = 0xEA // next byte is a character from the extended font
+	MSG_CODE_ITEM_NAME     = 0xF8 // insert the name of an item
+	MSG_CODE_COLOR         = 0xF9 // change the text color
+	MSG_CODE_START         = 0xFA // start of message
+	MSG_CODE_YES_NO        = 0xFB // show a yes/no choice
+	MSG_CODE_LINE_BREAK    = 0xFC
+	MSG_CODE_PAUSE         = 0xFD // wait for input before continuing
+	MSG_CODE_END           = 0xFE // end of message
+
+	// Only the last message doesn't have its length in the offset table
+	msgMaxLastMessageLength = 0x1000
+)
+
+type MSGTokenType int
+
+const (
+	MSG_TOKEN_TEXT MSGTokenType = iota
+	MSG_TOKEN_CHAR              // character with no known text
+	MSG_TOKEN_EXTENDED_CHAR
+	MSG_TOKEN_ITEM_NAME
+	MSG_TOKEN_COLOR
+	MSG_TOKEN_START
+	MSG_TOKEN_YES_NO
+	MSG_TOKEN_LINE_BREAK
+	MSG_TOKEN_PAUSE
+	MSG_TOKEN_END
+)
+
+type MSGToken struct {
+	Type  MSGTokenType
+	Text  string // only for text tokens
+	Value uint8  // parameter of a control code, or the raw character
+}
+
+type MSGMessage struct {
+	Tokens []MSGToken
+}
+
 type MSGOutput struct {
+	Messages []MSGMessage // indexed by message id
 }
 
 var (
@@ -20,75 +64,348 @@ var (
 		"defghijklmnopqrs",
 		"tuvwxyz_________",
 	}
+
+	// Control codes followed by a parameter byte
+	msgCodeTokenTypes = map[uint8]MSGTokenType{
+		MSG_CODE_EXTENDED_CHAR: MSG_TOKEN_EXTENDED_CHAR,
+		MSG_CODE_ITEM_NAME:     MSG_TOKEN_ITEM_NAME,
+		MSG_CODE_COLOR:         MSG_TOKEN_COLOR,
+		MSG_CODE_START:         MSG_TOKEN_START,
+		MSG_CODE_YES_NO:        MSG_TOKEN_YES_NO,
+		MSG_CODE_PAUSE:         MSG_TOKEN_PAUSE,
+		MSG_CODE_END:           MSG_TOKEN_END,
+	}
+
+	// Tag names used in the text format
+	msgTokenTags = map[MSGTokenType]string{
+		MSG_TOKEN_CHAR:          "char",
+		MSG_TOKEN_EXTENDED_CHAR: "ext",
+		MSG_TOKEN_ITEM_NAME:     "item",
+		MSG_TOKEN_COLOR:         "color",
+		MSG_TOKEN_START:         "start",
+		MSG_TOKEN_YES_NO:        "yesno",
+		MSG_TOKEN_LINE_BREAK:    "br",
+		MSG_TOKEN_PAUSE:         "pause",
+		MSG_TOKEN_END:           "end",
+	}
 )
 
+func LoadMSGFile(filename string) (*MSGOutput, error) {
+	msgFile, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MSG file %s: %w", filename, err)
+	}
+	defer msgFile.Close()
+
+	fi, err := msgFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat MSG file %s: %w", filename, err)
+	}
+	return LoadRDT_MSGStream(msgFile, fi.Size())
+}
+
+// LoadRDT_MSGStream reads the offset table and decodes every message.
+// The first offset is also the size of the offset table.
 func LoadRDT_MSGStream(fileReader io.ReaderAt, fileLength int64) (*MSGOutput, error) {
 	streamReader := io.NewSectionReader(fileReader, int64(0), fileLength)
 
 	offsets := make([]uint16, 0)
 	firstOffset := uint16(0)
 	if err := binary.Read(streamReader, binary.LittleEndian, &firstOffset); err != nil {
-		return nil, err
+		return nil, wrapReadError("MSG offsets", 0, err)
 	}
 
 	offsets = append(offsets, firstOffset)
 	for i := 2; i < int(firstOffset); i += 2 {
 		nextOffset := uint16(0)
 		if err := binary.Read(streamReader, binary.LittleEndian, &nextOffset); err != nil {
-			return nil, err
+			return nil, wrapReadError("MSG offsets", int64(i), err)
 		}
 		offsets = append(offsets, nextOffset)
 	}
 
+	messages := make([]MSGMessage, 0, len(offsets))
 	for i := 0; i < len(offsets)-1; i++ {
 		if offsets[i] >= offsets[i+1] {
-			log.Fatal("MSG offsets are not sorted")
+			return nil, newFormatError("MSG offsets", int64(i*2), ErrOffsetOutOfRange,
+				"offsets are not sorted: message %d at %d, message %d at %d", i, offsets[i], i+1, offsets[i+1])
 		}
 
 		textData := make([]uint8, offsets[i+1]-offsets[i])
-		if err := binary.Read(streamReader, binary.LittleEndian, &textData); err != nil {
-			return nil, err
+		if _, err := streamReader.ReadAt(textData, int64(offsets[i])); err != nil {
+			return nil, wrapReadError("MSG message", int64(offsets[i]), err)
 		}
-		convertBytesToText(textData)
+		messages = append(messages, DecodeMSGMessage(textData))
 	}
 
-	// Read last message
+	// Read last message up to the end code and its parameter
+	lastOffset := int64(offsets[len(offsets)-1])
+	streamReader.Seek(lastOffset, io.SeekStart)
 	textData := make([]uint8, 0)
-	for i := 0; i < 200; i++ {
+	for i := 0; i < msgMaxLastMessageLength; i++ {
 		nextChar := uint8(0)
 		if err := binary.Read(streamReader, binary.LittleEndian, &nextChar); err != nil {
-			return nil, err
+			return nil, wrapReadError("MSG message", lastOffset, err)
 		}
 
 		textData = append(textData, nextChar)
-		if nextChar == 0xFE {
+		if nextChar == MSG_CODE_END {
+			endParameter := uint8(0)
+			if err := binary.Read(streamReader, binary.LittleEndian, &endParameter); err != nil {
+				return nil, wrapReadError("MSG message", lastOffset, err)
+			}
+			textData = append(textData, endParameter)
 			break
 		}
 	}
-	convertBytesToText(textData)
+	messages = append(messages, DecodeMSGMessage(textData))
 
-	return nil, nil
+	return &MSGOutput{Messages: messages}, nil
 }
 
-func convertBytesToText(byteData []uint8) []string {
-	textData := make([]string, len(byteData))
+// DecodeMSGMessage converts message bytes into text and control code tokens
+func DecodeMSGMessage(byteData []uint8) MSGMessage {
+	tokens := make([]MSGToken, 0)
+	text := strings.Builder{}
+	flushText := func() {
+		if text.Len() > 0 {
+			tokens = append(tokens, MSGToken{Type: MSG_TOKEN_TEXT, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(byteData); i++ {
+		number := byteData[i]
 
-	for i, number := range byteData {
-		if number >= 96 {
-			if number == 0xF3 {
-				textData[i] = string("?")
+		if tokenType, ok := msgCodeTokenTypes[number]; ok {
+			flushText()
+			token := MSGToken{Type: tokenType}
+			if i+1 < len(byteData) {
+				i++
+				token.Value = byteData[i]
 			}
-			if number == 0xFC {
-				textData[i] = string("\n")
+			tokens = append(tokens, token)
+			continue
+		}
+
+		if number == MSG_CODE_LINE_BREAK {
+			flushText()
+			tokens = append(tokens, MSGToken{Type: MSG_TOKEN_LINE_BREAK})
+			continue
+		}
+
+		if character, ok := msgCharacterText(number); ok {
+			text.WriteString(character)
+			continue
+		}
+
+		flushText()
+		tokens = append(tokens, MSGToken{Type: MSG_TOKEN_CHAR, Value: number})
+	}
+	flushText()
+
+	return MSGMessage{Tokens: tokens}
+}
+
+// EncodeMSGMessage converts tokens back into message bytes
+func EncodeMSGMessage(message MSGMessage) ([]uint8, error) {
+	byteData := make([]uint8, 0)
+	for _, token := range message.Tokens {
+		switch token.Type {
+		case MSG_TOKEN_TEXT:
+			for _, character := range token.Text {
+				number, ok := msgCharacterCode(character)
+				if !ok {
+					return nil, fmt.Errorf("character %q can't be stored in a message", character)
+				}
+				byteData = append(byteData, number)
+			}
+		case MSG_TOKEN_CHAR:
+			byteData = append(byteData, token.Value)
+		case MSG_TOKEN_LINE_BREAK:
+			byteData = append(byteData, MSG_CODE_LINE_BREAK)
+		default:
+			code, ok := msgTokenCode(token.Type)
+			if !ok {
+				return nil, fmt.Errorf("unknown message token type %d", token.Type)
+			}
+			byteData = append(byteData, code, token.Value)
+		}
+	}
+	return byteData, nil
+}
+
+// Encode builds the offset table and message data
+func (msgOutput *MSGOutput) Encode() ([]uint8, error) {
+	tableSize := len(msgOutput.Messages) * 2
+	offsets := make([]uint16, len(msgOutput.Messages))
+	messageData := new(bytes.Buffer)
+	for i, message := range msgOutput.Messages {
+		byteData, err := EncodeMSGMessage(message)
+		if err != nil {
+			return nil, fmt.Errorf("message %d: %w", i, err)
+		}
+
+		offset := tableSize + messageData.Len()
+		if offset > 0xFFFF {
+			return nil, fmt.Errorf("message %d starts at offset %d, which doesn't fit in the offset table", i, offset)
+		}
+		offsets[i] = uint16(offset)
+		messageData.Write(byteData)
+	}
+
+	output := new(bytes.Buffer)
+	if err := binary.Write(output, binary.LittleEndian, offsets); err != nil {
+		return nil, err
+	}
+	output.Write(messageData.Bytes())
+	return output.Bytes(), nil
+}
+
+// String returns the message with control codes written as tags, such as {br} and {end:00}
+func (message MSGMessage) String() string {
+	output := strings.Builder{}
+	for _, token := range message.Tokens {
+		switch token.Type {
+		case MSG_TOKEN_TEXT:
+			output.WriteString(token.Text)
+		case MSG_TOKEN_LINE_BREAK:
+			output.WriteString("{br}")
+		default:
+			output.WriteString(fmt.Sprintf("{%s:%02X}", msgTokenTags[token.Type], token.Value))
+		}
+	}
+	return output.String()
+}
+
+// PlainText returns only the text and line breaks, such as for a text box
+func (message MSGMessage) PlainText() string {
+	output := strings.Builder{}
+	for _, token := range message.Tokens {
+		switch token.Type {
+		case MSG_TOKEN_TEXT:
+			output.WriteString(token.Text)
+		case MSG_TOKEN_LINE_BREAK:
+			output.WriteString("\n")
+		}
+	}
+	return output.String()
+}
+
+// ParseMSGMessage reads a message in the format written by String
+func ParseMSGMessage(text string) (MSGMessage, error) {
+	tokens := make([]MSGToken, 0)
+	for len(text) > 0 {
+		tagStart := strings.IndexByte(text, '{')
+		if tagStart != 0 {
+			if tagStart < 0 {
+				tagStart = len(text)
 			}
+			tokens = append(tokens, MSGToken{Type: MSG_TOKEN_TEXT, Text: text[:tagStart]})
+			text = text[tagStart:]
+			continue
+		}
+
+		tagEnd := strings.IndexByte(text, '}')
+		if tagEnd < 0 {
+			return MSGMessage{}, fmt.Errorf("unterminated tag in %q", text)
+		}
+		token, err := parseMSGTag(text[1:tagEnd])
+		if err != nil {
+			return MSGMessage{}, err
+		}
+		tokens = append(tokens, token)
+		text = text[tagEnd+1:]
+	}
+	return MSGMessage{Tokens: tokens}, nil
+}
+
+// FormatText writes one message per line, prefixed by the message id
+func (msgOutput *MSGOutput) FormatText() string {
+	output := strings.Builder{}
+	for i, message := range msgOutput.Messages {
+		output.WriteString(fmt.Sprintf("%d: %s\n", i, message.String()))
+	}
+	return output.String()
+}
 
+// ParseMSGText reads messages in the format written by FormatText
+func ParseMSGText(text string) (*MSGOutput, error) {
+	messages := make([]MSGMessage, 0)
+	for lineNumber, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
 			continue
 		}
 
-		row := number / 16
-		column := number % 16
-		textData[i] = string(convertText[row][column])
+		separator := strings.Index(line, ": ")
+		if separator < 0 {
+			return nil, fmt.Errorf("line %d: expected \"<id>: <message>\"", lineNumber+1)
+		}
+		messageId, err := strconv.Atoi(line[:separator])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid message id: %w", lineNumber+1, err)
+		}
+		if messageId != len(messages) {
+			return nil, fmt.Errorf("line %d: expected message id %d, got %d", lineNumber+1, len(messages), messageId)
+		}
+
+		message, err := ParseMSGMessage(line[separator+2:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber+1, err)
+		}
+		messages = append(messages, message)
 	}
+	return &MSGOutput{Messages: messages}, nil
+}
 
-	return textData
+func parseMSGTag(tag string) (MSGToken, error) {
+	name, valueText, hasValue := strings.Cut(tag, ":")
+	for tokenType, tagName := range msgTokenTags {
+		if tagName != name {
+			continue
+		}
+		if tokenType == MSG_TOKEN_LINE_BREAK {
+			return MSGToken{Type: tokenType}, nil
+		}
+		if !hasValue {
+			return MSGToken{}, fmt.Errorf("tag {%s} is missing a value", tag)
+		}
+		value, err := strconv.ParseUint(valueText, 16, 8)
+		if err != nil {
+			return MSGToken{}, fmt.Errorf("tag {%s} has an invalid value: %w", tag, err)
+		}
+		return MSGToken{Type: tokenType, Value: uint8(value)}, nil
+	}
+	return MSGToken{}, fmt.Errorf("unknown tag {%s}", tag)
+}
+
+func msgCharacterText(number uint8) (string, bool) {
+	row := int(number / 16)
+	column := int(number % 16)
+	if row >= len(convertText) || convertText[row][column] == '_' {
+		return "", false
+	}
+	return string(convertText[row][column]), true
+}
+
+func msgCharacterCode(character rune) (uint8, bool) {
+	if character == '_' {
+		return 0, false
+	}
+	for row, rowText := range convertText {
+		if column := strings.IndexRune(rowText, character); column >= 0 {
+			return uint8(row*16 + column), true
+		}
+	}
+	return 0, false
+}
+
+func msgTokenCode(tokenType MSGTokenType) (uint8, bool) {
+	for code, codeTokenType := range msgCodeTokenTypes {
+		if codeTokenType == tokenType {
+			return code, true
+		}
+	}
+	return 0, false
 }
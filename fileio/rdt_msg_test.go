@@ -0,0 +1,152 @@
+package fileio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeMSGMessage(t *testing.T) {
+	// {start:00}Hi{br}{color:01}Key{pause:00}{yesno:00}{item:2A}{char:70}{end:00}
+	data := []uint8{
+		MSG_CODE_START, 0x00,
+		0x24, 0x45,
+		MSG_CODE_LINE_BREAK,
+		MSG_CODE_COLOR, 0x01,
+		0x27, 0x41, 0x55,
+		MSG_CODE_PAUSE, 0x00,
+		MSG_CODE_YES_NO, 0x00,
+		MSG_CODE_ITEM_NAME, 0x2A,
+		0x70,
+		MSG_CODE_END, 0x00,
+	}
+
+	message := DecodeMSGMessage(data)
+	expected := []MSGToken{
+		{Type: MSG_TOKEN_START},
+		{Type: MSG_TOKEN_TEXT, Text: "Hi"},
+		{Type: MSG_TOKEN_LINE_BREAK},
+		{Type: MSG_TOKEN_COLOR, Value: 0x01},
+		{Type: MSG_TOKEN_TEXT, Text: "Key"},
+		{Type: MSG_TOKEN_PAUSE},
+		{Type: MSG_TOKEN_YES_NO},
+		{Type: MSG_TOKEN_ITEM_NAME, Value: 0x2A},
+		{Type: MSG_TOKEN_CHAR, Value: 0x70},
+		{Type: MSG_TOKEN_END},
+	}
+	if len(message.Tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens, got %d: %+v", len(expected), len(message.Tokens), message.Tokens)
+	}
+	for i, token := range expected {
+		if message.Tokens[i] != token {
+			t.Errorf("Token %d: expected %+v, got %+v", i, token, message.Tokens[i])
+		}
+	}
+
+	if text := message.PlainText(); text != "Hi\nKey" {
+		t.Errorf("Expected plain text %q, got %q", "Hi\nKey", text)
+	}
+	expectedString := "{start:00}Hi{br}{color:01}Key{pause:00}{yesno:00}{item:2A}{char:70}{end:00}"
+	if text := message.String(); text != expectedString {
+		t.Errorf("Expected %q, got %q", expectedString, text)
+	}
+
+	encoded, err := EncodeMSGMessage(message)
+	if err != nil {
+		t.Fatalf("EncodeMSGMessage() error: %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("Expected round trip %v, got %v", data, encoded)
+	}
+}
+
+func TestLoadRDT_MSGStream(t *testing.T) {
+	// Offset table with 2 messages, followed by padding after the last message
+	data := []uint8{
+		0x04, 0x00, 0x08, 0x00,
+		MSG_CODE_START, 0x00, MSG_CODE_END, 0x00,
+		0x27, MSG_CODE_END, 0x01,
+		0x00, 0x00,
+	}
+
+	msgOutput, err := LoadRDT_MSGStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadRDT_MSGStream() error: %v", err)
+	}
+	if len(msgOutput.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(msgOutput.Messages))
+	}
+	if text := msgOutput.Messages[1].String(); text != "K{end:01}" {
+		t.Errorf("Expected last message %q, got %q", "K{end:01}", text)
+	}
+
+	encoded, err := msgOutput.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+	if !bytes.Equal(encoded, data[:11]) {
+		t.Errorf("Expected round trip %v, got %v", data[:11], encoded)
+	}
+}
+
+func TestLoadRDT_MSGStream_UnsortedOffsets(t *testing.T) {
+	data := []uint8{0x04, 0x00, 0x02, 0x00, MSG_CODE_END, 0x00}
+	if _, err := LoadRDT_MSGStream(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Expected error for unsorted offsets")
+	}
+}
+
+func TestMSGTextRoundTrip(t *testing.T) {
+	text := "0: {start:00}It's locked.{end:00}\n1: {start:00}Use the {item:05}?{br}{yesno:00}{end:00}\n"
+
+	msgOutput, err := ParseMSGText(text)
+	if err != nil {
+		t.Fatalf("ParseMSGText() error: %v", err)
+	}
+	if len(msgOutput.Messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(msgOutput.Messages))
+	}
+	if formatted := msgOutput.FormatText(); formatted != text {
+		t.Errorf("Expected %q, got %q", text, formatted)
+	}
+
+	encoded, err := msgOutput.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error: %v", err)
+	}
+	decoded, err := LoadRDT_MSGStream(bytes.NewReader(encoded), int64(len(encoded)))
+	if err != nil {
+		t.Fatalf("LoadRDT_MSGStream() error: %v", err)
+	}
+	if formatted := decoded.FormatText(); formatted != text {
+		t.Errorf("Expected binary round trip %q, got %q", text, formatted)
+	}
+}
+
+func TestParseMSGText_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"missing id", "{start:00}"},
+		{"wrong id", "1: {end:00}"},
+		{"unknown tag", "0: {wait:00}"},
+		{"missing value", "0: {end}"},
+		{"unterminated tag", "0: {end:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseMSGText(tt.text); err == nil {
+				t.Errorf("Expected error for %q", tt.text)
+			}
+		})
+	}
+
+	msgOutput, err := ParseMSGText("0: é{end:00}")
+	if err != nil {
+		t.Fatalf("ParseMSGText() error: %v", err)
+	}
+	if _, err := msgOutput.Encode(); err == nil {
+		t.Error("Expected error for a character that isn't in the font")
+	}
+}
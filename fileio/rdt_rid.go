@@ -4,6 +4,7 @@ package fileio
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"math"
 
@@ -35,12 +36,15 @@ type RIDOutput struct {
 
 func LoadRDT_RID(r io.ReaderAt, fileLength int64, rdtHeader RDTHeader, offsets RDTOffsets) (*RIDOutput, error) {
 	offset := int64(offsets.OffsetCameraPosition)
+	if err := checkSectionSize("RDT cameras", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	reader := io.NewSectionReader(r, offset, fileLength-offset)
 
 	// Read from file
 	cameraPositions := make([]RIDHeader, int(rdtHeader.NumCameras))
 	if err := binary.Read(reader, binary.LittleEndian, &cameraPositions); err != nil {
-		return nil, err
+		return nil, wrapReadError("RDT cameras", offset, err)
 	}
 
 	// Convert camera positions to use floating point
@@ -64,10 +68,13 @@ func LoadRDT_RID(r io.ReaderAt, fileLength int64, rdtHeader RDTHeader, offsets R
 		}
 
 		offset := int64(cameraPositions[i].MaskOffset)
+		if err := checkSectionSize("RDT camera mask", offset, 0, fileLength); err != nil {
+			return nil, err
+		}
 		reader := io.NewSectionReader(r, offset, fileLength-offset)
-		priOutput, err := LoadRDT_PRI(reader, fileLength)
+		priOutput, err := LoadRDT_PRI(reader, fileLength-offset)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("RDT camera %d mask at offset 0x%x: %w", i, offset, err)
 		}
 		// Some cameras don't have image masks
 		if priOutput != nil {
@@ -28,15 +28,19 @@ type RVDOutput struct {
 // A camera switch is a flat zone in 3D space, where you switch from one camera to
 // another when the player crosses it
 func LoadRDT_RVD(r io.ReaderAt, fileLength int64, rdtHeader RDTHeader, offsets RDTOffsets) (*RVDOutput, error) {
+	offset := int64(offsets.OffsetCameraSwitches)
+	if err := checkSectionSize("RDT camera switches", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	reader := io.NewSectionReader(r, int64(0), fileLength)
 	fileStreamReader := NewStreamReader(reader)
-	fileStreamReader.SetPosition(int64(offsets.OffsetCameraSwitches))
+	fileStreamReader.SetPosition(offset)
 
 	cameraSwitches := make([]RVDHeader, 0)
 	for i := 0; i < 100; i++ {
 		rvdHeader := RVDHeader{}
 		if err := fileStreamReader.ReadData(&rvdHeader); err != nil {
-			return nil, err
+			return nil, wrapReadError("RDT camera switches", offset, err)
 		}
 
 		// End of block
@@ -3,6 +3,7 @@ package fileio
 // .sca - Collision data
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
 )
@@ -51,20 +52,33 @@ type SCAOutput struct {
 }
 
 func LoadRDT_SCA(r io.ReaderAt, fileLength int64, rdtHeader RDTHeader, offsets RDTOffsets) (*SCAOutput, error) {
+	offset := int64(offsets.OffsetCollisionData)
+	if err := checkSectionSize("RDT collision", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
 	reader := io.NewSectionReader(r, int64(0), fileLength)
 	fileStreamReader := NewStreamReader(reader)
-	fileStreamReader.SetPosition(int64(offsets.OffsetCollisionData))
+	fileStreamReader.SetPosition(offset)
 
 	scaHeader := SCAHeader{}
 	if err := fileStreamReader.ReadData(&scaHeader); err != nil {
+		return nil, wrapReadError("RDT collision", offset, err)
+	}
+
+	// The count includes the header
+	entityCount := int64(0)
+	if scaHeader.Count > 0 {
+		entityCount = int64(scaHeader.Count) - 1
+	}
+	if err := checkSectionSize("RDT collision", fileStreamReader.Position(), entityCount*int64(binary.Size(SCAElement{})), fileLength); err != nil {
 		return nil, err
 	}
 
-	collisionEntities := make([]CollisionEntity, int(scaHeader.Count)-1)
-	for i := 0; i < int(scaHeader.Count)-1; i++ {
+	collisionEntities := make([]CollisionEntity, entityCount)
+	for i := 0; i < int(entityCount); i++ {
 		scaElement := SCAElement{}
 		if err := fileStreamReader.ReadData(&scaElement); err != nil {
-			return nil, err
+			return nil, wrapReadError("RDT collision", offset, err)
 		}
 
 		shape := scaElement.Flag & 0x000F
@@ -5,7 +5,6 @@ package fileio
 import (
 	"fmt"
 	"io"
-	"log"
 )
 
 const (
@@ -36,7 +35,11 @@ const (
 	OP_GOSUB            = 24
 	OP_GOSUB_RETURN     = 25
 	OP_BREAK            = 26
+	OP_FOR2             = 27
+	OP_BREAK_POINT      = 28
 	OP_WORK_COPY        = 29
+	OP_NO_OP_1E         = 30
+	OP_NO_OP_1F         = 31
 	OP_NO_OP2           = 32
 	OP_CHECK            = 33
 	OP_SET_BIT          = 34
@@ -61,6 +64,7 @@ const (
 	OP_MEMBER_SET2      = 53
 	OP_SE_ON            = 54
 	OP_SCA_ID_SET       = 55
+	OP_FLR_SET          = 56
 	OP_DIR_CK           = 57
 	OP_SCE_ESPR_ON      = 58
 	OP_DOOR_AOT_SET     = 59
@@ -73,18 +77,24 @@ const (
 	OP_PLC_RET          = 66
 	OP_PLC_FLAG         = 67
 	OP_SCE_EM_SET       = 68
+	OP_COL_CHG_SET      = 69
 	OP_AOT_RESET        = 70
 	OP_AOT_ON           = 71
 	OP_SUPER_SET        = 72
+	OP_SUPER_RESET      = 73
+	OP_PLC_GUN          = 74
 	OP_CUT_REPLACE      = 75
 	OP_SCE_ESPR_KILL    = 76
 	OP_DOOR_MODEL_SET   = 77
 	OP_ITEM_AOT_SET     = 78
+	OP_SCE_KEY_CK       = 79
 	OP_SCE_TRG_CK       = 80
 	OP_SCE_BGM_CONTROL  = 81
 	OP_SCE_ESPR_CONTROL = 82
 	OP_SCE_FADE_SET     = 83
 	OP_SCE_ESPR3D_ON    = 84
+	OP_MEMBER_CALC      = 85
+	OP_MEMBER_CALC2     = 86
 	OP_SCE_BGMTBL_SET   = 87
 	OP_PLC_ROT          = 88
 	OP_XA_ON            = 89
@@ -110,6 +120,16 @@ const (
 	OP_SCE_SCR_MOVE     = 109
 	OP_PARTS_SET        = 110
 	OP_MOVIE_ON         = 111
+	OP_SPLC_RET         = 112
+	OP_SPLC_SCE         = 113
+	OP_SUPER_ON         = 114
+	OP_MIRROR_SET       = 115
+	OP_SCE_FADE_ADJUST  = 116
+	OP_SCE_ESPR3D_ON2   = 117
+	OP_SCE_ITEM_GET     = 118
+	OP_SCE_LINE_START   = 119
+	OP_SCE_LINE_MAIN    = 120
+	OP_SCE_LINE_END     = 121
 	OP_SCE_PARTS_BOMB   = 122
 	OP_SCE_PARTS_DOWN   = 123
 )
@@ -143,7 +163,11 @@ var (
 		OP_GOSUB:            2,
 		OP_GOSUB_RETURN:     2,
 		OP_BREAK:            2,
+		OP_FOR2:             6,
+		OP_BREAK_POINT:      1,
 		OP_WORK_COPY:        4,
+		OP_NO_OP_1E:         1,
+		OP_NO_OP_1F:         1,
 		OP_NO_OP2:           1,
 		OP_CHECK:            4,
 		OP_SET_BIT:          4,
@@ -168,6 +192,7 @@ var (
 		OP_MEMBER_SET2:      3,
 		OP_SE_ON:            12,
 		OP_SCA_ID_SET:       4,
+		OP_FLR_SET:          3,
 		OP_DIR_CK:           8,
 		OP_SCE_ESPR_ON:      16,
 		OP_DOOR_AOT_SET:     32,
@@ -180,18 +205,24 @@ var (
 		OP_PLC_RET:          1,
 		OP_PLC_FLAG:         4,
 		OP_SCE_EM_SET:       22,
+		OP_COL_CHG_SET:      5,
 		OP_AOT_RESET:        10,
 		OP_AOT_ON:           2,
 		OP_SUPER_SET:        16,
+		OP_SUPER_RESET:      8,
+		OP_PLC_GUN:          2,
 		OP_CUT_REPLACE:      3,
 		OP_SCE_ESPR_KILL:    5,
 		OP_DOOR_MODEL_SET:   22,
 		OP_ITEM_AOT_SET:     22,
+		OP_SCE_KEY_CK:       4,
 		OP_SCE_TRG_CK:       4,
 		OP_SCE_BGM_CONTROL:  6,
 		OP_SCE_ESPR_CONTROL: 6,
 		OP_SCE_FADE_SET:     6,
 		OP_SCE_ESPR3D_ON:    22,
+		OP_MEMBER_CALC:      6,
+		OP_MEMBER_CALC2:     4,
 		OP_SCE_BGMTBL_SET:   8,
 		OP_PLC_ROT:          4,
 		OP_XA_ON:            4,
@@ -217,11 +248,28 @@ var (
 		OP_SCE_SCR_MOVE:     4,
 		OP_PARTS_SET:        6,
 		OP_MOVIE_ON:         2,
+		OP_SPLC_RET:         1,
+		OP_SPLC_SCE:         1,
+		OP_SUPER_ON:         16,
+		OP_MIRROR_SET:       8,
+		OP_SCE_FADE_ADJUST:  4,
+		OP_SCE_ESPR3D_ON2:   22,
+		OP_SCE_ITEM_GET:     3,
+		OP_SCE_LINE_START:   4,
+		OP_SCE_LINE_MAIN:    6,
+		OP_SCE_LINE_END:     1,
 		OP_SCE_PARTS_BOMB:   16,
 		OP_SCE_PARTS_DOWN:   16,
 	}
 )
 
+type ScriptInstrEvtChain struct {
+	Opcode   uint8 // 0x03
+	Dummy    uint8
+	ExOpcode uint8
+	Event    uint8 // function that replaces the current one
+}
+
 type ScriptInstrEventExec struct {
 	Opcode    uint8 // 0x04
 	ThreadNum uint8
@@ -229,6 +277,11 @@ type ScriptInstrEventExec struct {
 	Event     uint8
 }
 
+type ScriptInstrEvtKill struct {
+	Opcode    uint8 // 0x05
+	ThreadNum uint8
+}
+
 type ScriptInstrIfElseStart struct {
 	Opcode      uint8 // 0x06
 	Dummy       uint8
@@ -254,6 +307,18 @@ type ScriptInstrForStart struct {
 	Count       uint16
 }
 
+type ScriptInstrWhileStart struct {
+	Opcode      uint8 // 0x0f
+	Dummy       uint8
+	BlockLength uint16 // up to and including the while end
+}
+
+type ScriptInstrDoStart struct {
+	Opcode      uint8 // 0x11
+	Dummy       uint8
+	BlockLength uint16 // up to and including the conditions after the do end
+}
+
 type ScriptInstrSwitch struct {
 	Opcode      uint8 // 0x13
 	VarId       uint8
@@ -334,6 +399,14 @@ type ScriptInstrCutChg struct {
 	CameraId uint8
 }
 
+type ScriptInstrMessageOn struct {
+	Opcode    uint8 // 0x2b
+	Dummy     uint8
+	Data0     uint8
+	MessageId uint8 // index in the room messages
+	Data1     uint16
+}
+
 type ScriptInstrAotSet struct {
 	Opcode       uint8 // 0x2c
 	Aot          uint8
@@ -384,6 +457,14 @@ type ScriptInstrMemberSet struct {
 	Value       uint16
 }
 
+type ScriptInstrSeOn struct {
+	Opcode  uint8 // 0x36
+	VabId   uint8 // sound bank
+	EdtId   int16 // sound effect in the bank
+	Data0   int16
+	X, Y, Z int16 // where the sound comes from
+}
+
 type ScriptInstrScaIdSet struct {
 	Opcode uint8 // 0x37
 	Id     uint8
@@ -645,11 +726,14 @@ type ScriptFunction struct {
 	StartProgramCounter []int          // set per function
 }
 
+// LoadRDT_SCDStream reads every function of a script.
+// An opcode with an unknown size ends the function it is in, since the rest can't be read.
+// The other functions are still returned, along with an ErrUnknownOpcode error.
 func LoadRDT_SCDStream(fileReader io.ReaderAt, fileLength int64) (*SCDOutput, error) {
 	streamReader := NewStreamReader(io.NewSectionReader(fileReader, int64(0), fileLength))
 	firstOffset, err := streamReader.ReadUint16()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read first offset: %w", err)
+		return nil, wrapReadError("SCD offsets", 0, err)
 	}
 
 	functionOffsets := make([]uint16, 0)
@@ -657,11 +741,12 @@ func LoadRDT_SCDStream(fileReader io.ReaderAt, fileLength int64) (*SCDOutput, er
 	for i := 2; i < int(firstOffset); i += 2 {
 		nextOffset, err := streamReader.ReadUint16()
 		if err != nil {
-			return nil, fmt.Errorf("failed to read offset at position %d: %w", i, err)
+			return nil, wrapReadError("SCD offsets", int64(i), err)
 		}
 		functionOffsets = append(functionOffsets, nextOffset)
 	}
 
+	var unknownOpcodeErr error
 	programCounter := 0
 	scriptData := ScriptFunction{}
 	scriptData.Instructions = make(map[int][]byte)
@@ -678,17 +763,25 @@ func LoadRDT_SCDStream(fileReader io.ReaderAt, fileLength int64) (*SCDOutput, er
 
 		streamReader = NewStreamReader(io.NewSectionReader(fileReader, int64(functionOffsets[functionNum]), functionLength))
 		for lineNum := 0; lineNum < int(functionLength); lineNum++ {
+			lineOffset := int64(functionOffsets[functionNum]) + streamReader.Position()
 			opcode, err := streamReader.ReadUint8()
 			if err != nil {
-				return nil, fmt.Errorf("failed to read opcode at line %d: %w", lineNum, err)
+				return nil, wrapReadError("SCD function", lineOffset, err)
 			}
 
 			byteSize, exists := InstructionSize[opcode]
 			if !exists {
-				fmt.Println("Unknown opcode:", opcode)
+				if unknownOpcodeErr == nil {
+					unknownOpcodeErr = newFormatError("SCD function", lineOffset, ErrUnknownOpcode, "opcode 0x%02x in function %d", opcode, functionNum)
+				}
+				break
 			}
 
-			scriptData.Instructions[programCounter] = generateScriptLine(streamReader, byteSize, opcode)
+			scriptLine, err := generateScriptLine(streamReader, byteSize, opcode)
+			if err != nil {
+				return nil, wrapReadError("SCD function", lineOffset, err)
+			}
+			scriptData.Instructions[programCounter] = scriptLine
 			// Sleep contains sleep and sleeping commands
 			if opcode == OP_SLEEP {
 				scriptData.Instructions[programCounter+1] = scriptData.Instructions[programCounter][1:]
@@ -706,23 +799,23 @@ func LoadRDT_SCDStream(fileReader io.ReaderAt, fileLength int64) (*SCDOutput, er
 	output := &SCDOutput{
 		ScriptData: scriptData,
 	}
-	return output, nil
+	return output, unknownOpcodeErr
 }
 
-func generateScriptLine(streamReader *StreamReader, totalByteSize int, opcode byte) []byte {
+func generateScriptLine(streamReader *StreamReader, totalByteSize int, opcode byte) ([]byte, error) {
 	scriptLine := make([]byte, 0)
 	scriptLine = append(scriptLine, opcode)
 
 	if totalByteSize == 1 {
-		return scriptLine
+		return scriptLine, nil
 	}
 
 	parameters, err := readRemainingBytes(streamReader, totalByteSize-1)
 	if err != nil {
-		log.Fatalf("Error reading script for opcode %v\n", opcode)
+		return nil, fmt.Errorf("failed to read parameters of opcode 0x%02x: %w", opcode, err)
 	}
 	scriptLine = append(scriptLine, parameters...)
-	return scriptLine
+	return scriptLine, nil
 }
 
 func readRemainingBytes(streamReader *StreamReader, byteSize int) ([]byte, error) {
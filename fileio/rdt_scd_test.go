@@ -0,0 +1,65 @@
+package fileio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestLoadRDT_SCDStream_InstructionSizes(t *testing.T) {
+	opcodes := []byte{
+		OP_FOR2, OP_BREAK_POINT, OP_NO_OP_1E, OP_NO_OP_1F, OP_FLR_SET, OP_COL_CHG_SET, OP_SUPER_RESET,
+		OP_PLC_GUN, OP_SCE_KEY_CK, OP_MEMBER_CALC, OP_MEMBER_CALC2, OP_SPLC_RET, OP_SPLC_SCE,
+		OP_SUPER_ON, OP_MIRROR_SET, OP_SCE_FADE_ADJUST, OP_SCE_ESPR3D_ON2, OP_SCE_ITEM_GET,
+		OP_SCE_LINE_START, OP_SCE_LINE_MAIN, OP_SCE_LINE_END,
+	}
+	data := []byte{2, 0}
+	for _, opcode := range opcodes {
+		instruction := make([]byte, InstructionSize[opcode])
+		instruction[0] = opcode
+		data = append(data, instruction...)
+	}
+	data = append(data, OP_EVT_END)
+
+	output, err := LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadRDT_SCDStream() error: %v", err)
+	}
+	programCounter := 0
+	for _, opcode := range opcodes {
+		lineData, exists := output.ScriptData.Instructions[programCounter]
+		if !exists || lineData[0] != opcode || len(lineData) != InstructionSize[opcode] {
+			t.Fatalf("Expected opcode 0x%02x at %d, got %v", opcode, programCounter, lineData)
+		}
+		programCounter += InstructionSize[opcode]
+	}
+	if lineData := output.ScriptData.Instructions[programCounter]; len(lineData) != 1 || lineData[0] != OP_EVT_END {
+		t.Errorf("Expected EvtEnd at %d, got %v", programCounter, lineData)
+	}
+}
+
+func TestLoadRDT_SCDStream_UnknownOpcode(t *testing.T) {
+	data := []byte{
+		4, 0, 11, 0, // function offsets
+		OP_SAVE, 1, 5, 0, 0xFF, 0xAB, 0xCD, // the rest of the first function can't be read
+		OP_EVT_END,
+	}
+
+	output, err := LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	if !errors.Is(err, ErrUnknownOpcode) {
+		t.Fatalf("Expected unknown opcode, got %v", err)
+	}
+	if output == nil {
+		t.Fatal("Expected the functions that could be read")
+	}
+	if lineData := output.ScriptData.Instructions[0]; len(lineData) != 4 || lineData[0] != OP_SAVE {
+		t.Errorf("Expected Save at 0, got %v", lineData)
+	}
+	if len(output.ScriptData.StartProgramCounter) != 2 {
+		t.Fatalf("Expected 2 functions, got %v", output.ScriptData.StartProgramCounter)
+	}
+	start := output.ScriptData.StartProgramCounter[1]
+	if lineData := output.ScriptData.Instructions[start]; len(lineData) != 1 || lineData[0] != OP_EVT_END {
+		t.Errorf("Expected EvtEnd at the start of the second function, got %v", lineData)
+	}
+}
@@ -0,0 +1,39 @@
+package fileio
+
+// .snd - Room sound table
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+type SNDOutput struct {
+	SoundTable []uint16 // maps the room's sound effect ids to the room VAB
+}
+
+// LoadRDT_SNDStream reads the sound table, which fills the space up to the room VAB header
+func LoadRDT_SNDStream(r io.ReaderAt, fileLength int64, offsets RDTOffsets) (*SNDOutput, error) {
+	offset := int64(offsets.OffsetRoomSound)
+	if offset == 0 {
+		return nil, nil
+	}
+	if err := checkSectionSize("RDT sound table", offset, 0, fileLength); err != nil {
+		return nil, err
+	}
+
+	endOffset := fileLength
+	if int64(offsets.OffsetRoomVABHeader) > offset {
+		endOffset = int64(offsets.OffsetRoomVABHeader)
+	}
+
+	reader := io.NewSectionReader(r, offset, endOffset-offset)
+	soundTable := make([]uint16, (endOffset-offset)/2)
+	if err := binary.Read(reader, binary.LittleEndian, &soundTable); err != nil {
+		return nil, wrapReadError("RDT sound table", offset, err)
+	}
+
+	output := &SNDOutput{
+		SoundTable: soundTable,
+	}
+	return output, nil
+}
@@ -1,26 +1,64 @@
 package fileio
 
 import (
+	"fmt"
 	"io"
 )
 
 type VABOutput struct {
+	HeaderOutput *VABHeaderOutput // programs, tones and waveform sizes
+	DataOutput   *VABDataOutput   // raw ADPCM waveforms
 }
 
+// LoadRDT_VABStream loads the room sound effects
 func LoadRDT_VABStream(r io.ReaderAt, fileLength int64, offsets RDTOffsets) (*VABOutput, error) {
-	offset := int64(offsets.OffsetRoomVABHeader)
-	vabHeaderReader := io.NewSectionReader(r, offset, fileLength-offset)
-	vabHeaderOutput, err := LoadVABHeaderStream(vabHeaderReader, fileLength)
-	if err != nil {
+	return loadRDT_VABAt(r, fileLength, int64(offsets.OffsetRoomVABHeader), int64(offsets.OffsetRoomVABData))
+}
+
+// LoadRDT_EnemyVABStream loads the sound effects of the enemies in the room.
+// Rooms without enemies may not point to a valid bank, so it is skipped if the magic doesn't match.
+func LoadRDT_EnemyVABStream(r io.ReaderAt, fileLength int64, offsets RDTOffsets) (*VABOutput, error) {
+	headerOffset := int64(offsets.OffsetEnemyVABHeader)
+	if headerOffset == 0 || headerOffset+4 > fileLength {
+		return nil, nil
+	}
+	magic := make([]byte, 4)
+	if _, err := r.ReadAt(magic, headerOffset); err != nil {
 		return nil, err
 	}
+	if string(magic) != "pBAV" {
+		return nil, nil
+	}
+
+	return loadRDT_VABAt(r, fileLength, int64(offsets.OffsetEnemyVABHeader), int64(offsets.OffsetEnemyVABData))
+}
 
-	offset = int64(offsets.OffsetRoomVABData)
-	vabDataReader := io.NewSectionReader(r, int64(offset), fileLength-int64(offset))
-	_, err = LoadVABDataStream(vabDataReader, fileLength, vabHeaderOutput)
+func loadRDT_VABAt(r io.ReaderAt, fileLength int64, headerOffset int64, dataOffset int64) (*VABOutput, error) {
+	// The room doesn't have this sound bank
+	if headerOffset == 0 || dataOffset == 0 {
+		return nil, nil
+	}
+
+	if err := checkSectionSize("RDT sound header", headerOffset, 0, fileLength); err != nil {
+		return nil, err
+	}
+	vabHeaderReader := io.NewSectionReader(r, headerOffset, fileLength-headerOffset)
+	vabHeaderOutput, err := LoadVABHeaderStream(vabHeaderReader, fileLength-headerOffset)
 	if err != nil {
+		return nil, fmt.Errorf("RDT sound header at offset 0x%x: %w", headerOffset, err)
+	}
+
+	if err := checkSectionSize("RDT sound data", dataOffset, 0, fileLength); err != nil {
 		return nil, err
 	}
+	vabDataReader := io.NewSectionReader(r, dataOffset, fileLength-dataOffset)
+	vabDataOutput, err := LoadVABDataStream(vabDataReader, fileLength-dataOffset, vabHeaderOutput)
+	if err != nil {
+		return nil, fmt.Errorf("RDT sound data at offset 0x%x: %w", dataOffset, err)
+	}
 
-	return &VABOutput{}, nil
+	return &VABOutput{
+		HeaderOutput: vabHeaderOutput,
+		DataOutput:   vabDataOutput,
+	}, nil
 }
@@ -0,0 +1,92 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildTestVABBytes serializes a .vh header followed by the .vb data
+func buildTestVABBytes(t testing.TB) (headerBytes []byte, dataBytes []byte) {
+	header, data := buildTestVAB()
+	header.VABHeader.Magic = [4]byte{'p', 'B', 'A', 'V'}
+
+	buffer := new(bytes.Buffer)
+	audioSizes := make([]uint16, 256)
+	copy(audioSizes, header.AudioSizes)
+	for _, value := range []interface{}{header.VABHeader, header.Programs, header.Tones[0], header.Tones[1], audioSizes} {
+		if err := binary.Write(buffer, binary.LittleEndian, value); err != nil {
+			t.Fatalf("Failed to build VAB header: %v", err)
+		}
+	}
+	return buffer.Bytes(), bytes.Join(data.RawADPCMData, nil)
+}
+
+func TestLoadRDT_VABStream(t *testing.T) {
+	headerBytes, dataBytes := buildTestVABBytes(t)
+
+	// Room VAB at 0x10, enemy VAB offset points at something else
+	fileData := make([]byte, 0x10)
+	fileData = append(fileData, headerBytes...)
+	dataOffset := len(fileData)
+	fileData = append(fileData, dataBytes...)
+	offsets := RDTOffsets{
+		OffsetRoomVABHeader:  0x10,
+		OffsetRoomVABData:    uint32(dataOffset),
+		OffsetEnemyVABHeader: uint32(dataOffset),
+		OffsetEnemyVABData:   uint32(dataOffset),
+	}
+	reader := bytes.NewReader(fileData)
+
+	roomVAB, err := LoadRDT_VABStream(reader, int64(len(fileData)), offsets)
+	if err != nil {
+		t.Fatalf("LoadRDT_VABStream() error: %v", err)
+	}
+	if roomVAB == nil {
+		t.Fatal("Expected room VAB to be loaded")
+	}
+	if roomVAB.HeaderOutput.NumBytes != len(headerBytes) {
+		t.Errorf("Expected header size %d, got %d", len(headerBytes), roomVAB.HeaderOutput.NumBytes)
+	}
+	if tones := roomVAB.HeaderOutput.ProgramTones(2); len(tones) != 2 || tones[1].Center != 72 {
+		t.Errorf("Expected program 2 tones to be retained, got %+v", tones)
+	}
+	if len(roomVAB.DataOutput.RawADPCMData) != 2 {
+		t.Errorf("Expected 2 waveforms, got %d", len(roomVAB.DataOutput.RawADPCMData))
+	}
+
+	enemyVAB, err := LoadRDT_EnemyVABStream(reader, int64(len(fileData)), offsets)
+	if err != nil {
+		t.Fatalf("LoadRDT_EnemyVABStream() error: %v", err)
+	}
+	if enemyVAB != nil {
+		t.Errorf("Expected enemy VAB without magic to be skipped")
+	}
+}
+
+func TestLoadRDT_SNDStream(t *testing.T) {
+	fileData := []byte{0, 0, 0, 0, 0x01, 0x02, 0x03, 0x04, 0xFF, 0xFF}
+	offsets := RDTOffsets{
+		OffsetRoomSound:     4,
+		OffsetRoomVABHeader: 8,
+	}
+
+	output, err := LoadRDT_SNDStream(bytes.NewReader(fileData), int64(len(fileData)), offsets)
+	if err != nil {
+		t.Fatalf("LoadRDT_SNDStream() error: %v", err)
+	}
+	expected := []uint16{0x0201, 0x0403}
+	if len(output.SoundTable) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(output.SoundTable))
+	}
+	for i, value := range expected {
+		if output.SoundTable[i] != value {
+			t.Errorf("Entry %d: expected 0x%04X, got 0x%04X", i, value, output.SoundTable[i])
+		}
+	}
+
+	output, err = LoadRDT_SNDStream(bytes.NewReader(fileData), int64(len(fileData)), RDTOffsets{})
+	if err != nil || output != nil {
+		t.Errorf("Expected no sound table without an offset, got %v, %v", output, err)
+	}
+}
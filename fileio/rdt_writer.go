@@ -0,0 +1,434 @@
+package fileio
+
+// Writer for .rdt room files
+// The file is split into chunks at every offset the file refers to, so sections can be
+// replaced with data of a different size and the offsets are recomputed when it is written.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Index of each section in RDTOffsets
+const (
+	RDT_SECTION_ROOM_SOUND = iota
+	RDT_SECTION_ROOM_VAB_HEADER
+	RDT_SECTION_ROOM_VAB_DATA
+	RDT_SECTION_ENEMY_VAB_HEADER
+	RDT_SECTION_ENEMY_VAB_DATA
+	RDT_SECTION_OTA
+	RDT_SECTION_COLLISION
+	RDT_SECTION_CAMERA_POSITION
+	RDT_SECTION_CAMERA_SWITCHES
+	RDT_SECTION_LIGHTS
+	RDT_SECTION_ITEMS
+	RDT_SECTION_FLOOR_SOUND
+	RDT_SECTION_BLOCKS
+	RDT_SECTION_LANG1
+	RDT_SECTION_LANG2
+	RDT_SECTION_SCROLL_TEXTURE
+	RDT_SECTION_INIT_SCRIPT
+	RDT_SECTION_EXECUTE_SCRIPT
+	RDT_SECTION_SPRITE_ANIMATIONS
+	RDT_SECTION_SPRITE_ANIMATIONS_OFFSET
+	RDT_SECTION_SPRITE_IMAGE
+	RDT_SECTION_MODEL_IMAGE
+	RDT_SECTION_RBJ
+	RDT_SECTION_COUNT
+)
+
+const (
+	RDT_HEADER_SIZE = 8 + RDT_SECTION_COUNT*4 // header and offset table
+
+	RID_HEADER_SIZE       = 32
+	RID_MASK_OFFSET_START = 28 // position of MaskOffset in RIDHeader
+	RID_NO_MASK           = 0xffffffff
+
+	RDT_ITEM_OFFSETS_SIZE = 8
+)
+
+// Short name of each section, matching the extension of the file it is usually extracted to
+var RDTSectionNames = [RDT_SECTION_COUNT]string{
+	"snd",
+	"vh",
+	"vb",
+	"enemy_vh",
+	"enemy_vb",
+	"ota",
+	"sca",
+	"rid",
+	"rvd",
+	"lit",
+	"items",
+	"flr",
+	"blk",
+	"msg_lang1",
+	"msg_lang2",
+	"scroll_tim",
+	"init_scd",
+	"main_scd",
+	"esp",
+	"esp_offsets",
+	"esp_tim",
+	"model_tim",
+	"rbj",
+}
+
+// File extension used when a section is extracted
+var RDTSectionExtensions = [RDT_SECTION_COUNT]string{
+	".snd",
+	".vh",
+	".vb",
+	".vh",
+	".vb",
+	".ota",
+	".sca",
+	".rid",
+	".rvd",
+	".lit",
+	".bin",
+	".flr",
+	".blk",
+	".msg",
+	".msg",
+	".tim",
+	".scd",
+	".scd",
+	".esp",
+	".bin",
+	".tim",
+	".tim",
+	".rbj",
+}
+
+// RDTChunk is a block of data that starts at an offset referenced by the file.
+// It ends where the next chunk starts.
+type RDTChunk struct {
+	Offset uint32 // offset in the original file
+	Data   []byte
+}
+
+// RDTRawOutput keeps the sections of an RDT file as raw bytes.
+// Offsets stored inside chunks (camera masks and item models) use offsets from the original file.
+type RDTRawOutput struct {
+	Header  RDTHeader
+	Offsets RDTOffsets  // offsets in the original file
+	Chunks  []*RDTChunk // sorted by offset
+}
+
+func LoadRDTRawFile(filename string) (*RDTRawOutput, error) {
+	rdtFile, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open RDT file %s: %w", filename, err)
+	}
+	defer rdtFile.Close()
+
+	fi, err := rdtFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat RDT file %s: %w", filename, err)
+	}
+
+	return LoadRDTRaw(rdtFile, fi.Size())
+}
+
+func LoadRDTRaw(r io.ReaderAt, fileLength int64) (*RDTRawOutput, error) {
+	if fileLength < RDT_HEADER_SIZE {
+		return nil, fmt.Errorf("RDT file is %d bytes, smaller than the %d byte header", fileLength, RDT_HEADER_SIZE)
+	}
+	fileData := make([]byte, fileLength)
+	if _, err := r.ReadAt(fileData, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	reader := bytes.NewReader(fileData)
+	rdtHeader := RDTHeader{}
+	if err := binary.Read(reader, binary.LittleEndian, &rdtHeader); err != nil {
+		return nil, err
+	}
+	offsets := RDTOffsets{}
+	if err := binary.Read(reader, binary.LittleEndian, &offsets); err != nil {
+		return nil, err
+	}
+
+	// Every referenced offset starts a chunk
+	chunkStarts := map[uint32]bool{RDT_HEADER_SIZE: true}
+	addChunkStart := func(offset uint32, name string) error {
+		if offset < RDT_HEADER_SIZE || int64(offset) > fileLength {
+			return fmt.Errorf("%s offset 0x%x is outside the file", name, offset)
+		}
+		chunkStarts[offset] = true
+		return nil
+	}
+
+	for i, offset := range offsets.Values() {
+		if offset == 0 {
+			continue
+		}
+		if err := addChunkStart(offset, RDTSectionNames[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	pointers, err := findRDTPointers(fileData, rdtHeader, offsets)
+	if err != nil {
+		return nil, err
+	}
+	for _, pointer := range pointers {
+		offset := binary.LittleEndian.Uint32(fileData[pointer.position:])
+		if err := addChunkStart(offset, pointer.name); err != nil {
+			return nil, err
+		}
+	}
+
+	sortedStarts := make([]uint32, 0, len(chunkStarts))
+	for offset := range chunkStarts {
+		sortedStarts = append(sortedStarts, offset)
+	}
+	sort.Slice(sortedStarts, func(i, j int) bool { return sortedStarts[i] < sortedStarts[j] })
+
+	chunks := make([]*RDTChunk, len(sortedStarts))
+	for i, start := range sortedStarts {
+		end := uint32(fileLength)
+		if i+1 < len(sortedStarts) {
+			end = sortedStarts[i+1]
+		}
+		chunks[i] = &RDTChunk{
+			Offset: start,
+			Data:   append([]byte{}, fileData[start:end]...),
+		}
+	}
+
+	output := &RDTRawOutput{
+		Header:  rdtHeader,
+		Offsets: offsets,
+		Chunks:  chunks,
+	}
+	return output, nil
+}
+
+// Section returns the data from the start of the section to the next chunk, or nil if the section is missing
+func (rdt *RDTRawOutput) Section(sectionIndex int) []byte {
+	chunk := rdt.sectionChunk(sectionIndex)
+	if chunk == nil {
+		return nil
+	}
+	return chunk.Data
+}
+
+// SetSection replaces the data of a section that exists in the file
+func (rdt *RDTRawOutput) SetSection(sectionIndex int, data []byte) error {
+	if sectionIndex < 0 || sectionIndex >= RDT_SECTION_COUNT {
+		return fmt.Errorf("invalid RDT section %d", sectionIndex)
+	}
+	chunk := rdt.sectionChunk(sectionIndex)
+	if chunk == nil {
+		return fmt.Errorf("RDT section %s is not in the file", RDTSectionNames[sectionIndex])
+	}
+	chunk.Data = data
+	return nil
+}
+
+func (rdt *RDTRawOutput) sectionChunk(sectionIndex int) *RDTChunk {
+	if sectionIndex < 0 || sectionIndex >= RDT_SECTION_COUNT {
+		return nil
+	}
+	offset := rdt.Offsets.Values()[sectionIndex]
+	if offset == 0 {
+		return nil
+	}
+	for _, chunk := range rdt.Chunks {
+		if chunk.Offset == offset {
+			return chunk
+		}
+	}
+	return nil
+}
+
+// Bytes lays out the chunks in order and updates every offset to the new positions.
+// Chunks that started on a 4 byte boundary are kept aligned.
+func (rdt *RDTRawOutput) Bytes() ([]byte, error) {
+	newOffsets := make(map[uint32]uint32, len(rdt.Chunks))
+	position := uint32(RDT_HEADER_SIZE)
+	for _, chunk := range rdt.Chunks {
+		if chunk.Offset%4 == 0 {
+			position = (position + 3) &^ 3
+		}
+		newOffsets[chunk.Offset] = position
+		position += uint32(len(chunk.Data))
+	}
+	relocate := func(offset uint32, name string) (uint32, error) {
+		newOffset, exists := newOffsets[offset]
+		if !exists {
+			return 0, fmt.Errorf("%s offset 0x%x doesn't point to the start of a chunk", name, offset)
+		}
+		return newOffset, nil
+	}
+
+	var offsetValues [RDT_SECTION_COUNT]uint32
+	for i, offset := range rdt.Offsets.Values() {
+		if offset == 0 {
+			continue
+		}
+		newOffset, err := relocate(offset, RDTSectionNames[i])
+		if err != nil {
+			return nil, err
+		}
+		offsetValues[i] = newOffset
+	}
+	offsets := NewRDTOffsets(offsetValues)
+
+	headerBuffer := new(bytes.Buffer)
+	if err := binary.Write(headerBuffer, binary.LittleEndian, rdt.Header); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(headerBuffer, binary.LittleEndian, offsets); err != nil {
+		return nil, err
+	}
+
+	fileData := make([]byte, position)
+	copy(fileData, headerBuffer.Bytes())
+	for _, chunk := range rdt.Chunks {
+		copy(fileData[newOffsets[chunk.Offset]:], chunk.Data)
+	}
+
+	// Offsets inside chunks still point to the original file
+	pointers, err := findRDTPointers(fileData, rdt.Header, offsets)
+	if err != nil {
+		return nil, err
+	}
+	for _, pointer := range pointers {
+		oldOffset := binary.LittleEndian.Uint32(fileData[pointer.position:])
+		newOffset, err := relocate(oldOffset, pointer.name)
+		if err != nil {
+			return nil, err
+		}
+		binary.LittleEndian.PutUint32(fileData[pointer.position:], newOffset)
+	}
+	return fileData, nil
+}
+
+func (rdt *RDTRawOutput) Write(w io.Writer) error {
+	fileData, err := rdt.Bytes()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(fileData)
+	return err
+}
+
+func (rdt *RDTRawOutput) WriteFile(filename string) error {
+	fileData, err := rdt.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filename, fileData, 0644); err != nil {
+		return fmt.Errorf("failed to write RDT file %s: %w", filename, err)
+	}
+	return nil
+}
+
+// A file offset stored inside a section
+type rdtPointer struct {
+	position  int // position of the uint32 in the file
+	name      string
+	chunkName string // name of the chunk it points to
+	extension string
+}
+
+// findRDTPointers finds the camera mask offsets and the item texture and model offsets
+func findRDTPointers(fileData []byte, rdtHeader RDTHeader, offsets RDTOffsets) ([]rdtPointer, error) {
+	pointers := make([]rdtPointer, 0)
+	addPointer := func(position int, name string, chunkName string, extension string) error {
+		if position+4 > len(fileData) {
+			return fmt.Errorf("%s at 0x%x is outside the file", name, position)
+		}
+		offset := binary.LittleEndian.Uint32(fileData[position:])
+		if offset != 0 && offset != RID_NO_MASK {
+			pointers = append(pointers, rdtPointer{position: position, name: name, chunkName: chunkName, extension: extension})
+		}
+		return nil
+	}
+
+	if offsets.OffsetCameraPosition != 0 {
+		for i := 0; i < int(rdtHeader.NumCameras); i++ {
+			position := int(offsets.OffsetCameraPosition) + i*RID_HEADER_SIZE + RID_MASK_OFFSET_START
+			if err := addPointer(position, fmt.Sprintf("camera %d mask", i), fmt.Sprintf("camera%02d_mask", i), ".pri"); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if offsets.OffsetItems != 0 {
+		for i := 0; i < int(rdtHeader.NumModels); i++ {
+			position := int(offsets.OffsetItems) + i*RDT_ITEM_OFFSETS_SIZE
+			if err := addPointer(position, fmt.Sprintf("item %d texture", i), fmt.Sprintf("item%02d_texture", i), ".tim"); err != nil {
+				return nil, err
+			}
+			if err := addPointer(position+4, fmt.Sprintf("item %d model", i), fmt.Sprintf("item%02d_model", i), ".md1"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return pointers, nil
+}
+
+// Values returns the offsets in file order
+func (offsets RDTOffsets) Values() [RDT_SECTION_COUNT]uint32 {
+	return [RDT_SECTION_COUNT]uint32{
+		offsets.OffsetRoomSound,
+		offsets.OffsetRoomVABHeader,
+		offsets.OffsetRoomVABData,
+		offsets.OffsetEnemyVABHeader,
+		offsets.OffsetEnemyVABData,
+		offsets.OffsetOTA,
+		offsets.OffsetCollisionData,
+		offsets.OffsetCameraPosition,
+		offsets.OffsetCameraSwitches,
+		offsets.OffsetLights,
+		offsets.OffsetItems,
+		offsets.OffsetFloorSound,
+		offsets.OffsetBlocks,
+		offsets.OffsetLang1,
+		offsets.OffsetLang2,
+		offsets.OffsetScrollTexture,
+		offsets.OffsetInitScript,
+		offsets.OffsetExecuteScript,
+		offsets.OffsetSpriteAnimations,
+		offsets.OffsetSpriteAnimationsOffset,
+		offsets.OffsetSpriteImage,
+		offsets.OffsetModelImage,
+		offsets.OffsetRBJ,
+	}
+}
+
+func NewRDTOffsets(values [RDT_SECTION_COUNT]uint32) RDTOffsets {
+	return RDTOffsets{
+		OffsetRoomSound:              values[RDT_SECTION_ROOM_SOUND],
+		OffsetRoomVABHeader:          values[RDT_SECTION_ROOM_VAB_HEADER],
+		OffsetRoomVABData:            values[RDT_SECTION_ROOM_VAB_DATA],
+		OffsetEnemyVABHeader:         values[RDT_SECTION_ENEMY_VAB_HEADER],
+		OffsetEnemyVABData:           values[RDT_SECTION_ENEMY_VAB_DATA],
+		OffsetOTA:                    values[RDT_SECTION_OTA],
+		OffsetCollisionData:          values[RDT_SECTION_COLLISION],
+		OffsetCameraPosition:         values[RDT_SECTION_CAMERA_POSITION],
+		OffsetCameraSwitches:         values[RDT_SECTION_CAMERA_SWITCHES],
+		OffsetLights:                 values[RDT_SECTION_LIGHTS],
+		OffsetItems:                  values[RDT_SECTION_ITEMS],
+		OffsetFloorSound:             values[RDT_SECTION_FLOOR_SOUND],
+		OffsetBlocks:                 values[RDT_SECTION_BLOCKS],
+		OffsetLang1:                  values[RDT_SECTION_LANG1],
+		OffsetLang2:                  values[RDT_SECTION_LANG2],
+		OffsetScrollTexture:          values[RDT_SECTION_SCROLL_TEXTURE],
+		OffsetInitScript:             values[RDT_SECTION_INIT_SCRIPT],
+		OffsetExecuteScript:          values[RDT_SECTION_EXECUTE_SCRIPT],
+		OffsetSpriteAnimations:       values[RDT_SECTION_SPRITE_ANIMATIONS],
+		OffsetSpriteAnimationsOffset: values[RDT_SECTION_SPRITE_ANIMATIONS_OFFSET],
+		OffsetSpriteImage:            values[RDT_SECTION_SPRITE_IMAGE],
+		OffsetModelImage:             values[RDT_SECTION_MODEL_IMAGE],
+		OffsetRBJ:                    values[RDT_SECTION_RBJ],
+	}
+}
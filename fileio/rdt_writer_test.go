@@ -0,0 +1,126 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// buildTestRDTBytes builds a room with one camera, one camera mask and one item model
+func buildTestRDTBytes(t testing.TB) []byte {
+	fileData := make([]byte, RDT_HEADER_SIZE)
+	appendSection := func(data []byte) uint32 {
+		offset := uint32(len(fileData))
+		fileData = append(fileData, data...)
+		return offset
+	}
+
+	var offsetValues [RDT_SECTION_COUNT]uint32
+	ridOffset := appendSection(make([]byte, RID_HEADER_SIZE))
+	offsetValues[RDT_SECTION_CAMERA_POSITION] = ridOffset
+	maskOffset := appendSection([]byte{1, 0, 1, 0, 0xAA, 0xBB, 0xCC, 0xDD})
+	offsetValues[RDT_SECTION_COLLISION] = appendSection([]byte{1, 2, 3, 4, 5, 6})
+	// Unaligned chunk end, the next chunk is aligned
+	fileData = append(fileData, 0, 0)
+	offsetValues[RDT_SECTION_ITEMS] = appendSection(make([]byte, RDT_ITEM_OFFSETS_SIZE))
+	textureOffset := appendSection([]byte{0x10, 0, 0, 0, 0x11, 0x12})
+	offsetValues[RDT_SECTION_MODEL_IMAGE] = textureOffset
+	modelOffset := appendSection([]byte{0x20, 0x21, 0x22})
+	offsetValues[RDT_SECTION_INIT_SCRIPT] = appendSection([]byte{2, 0, 1, 0})
+	offsetValues[RDT_SECTION_EXECUTE_SCRIPT] = appendSection([]byte{2, 0, 1, 0})
+
+	binary.LittleEndian.PutUint32(fileData[ridOffset+RID_MASK_OFFSET_START:], maskOffset)
+	binary.LittleEndian.PutUint32(fileData[offsetValues[RDT_SECTION_ITEMS]:], textureOffset)
+	binary.LittleEndian.PutUint32(fileData[offsetValues[RDT_SECTION_ITEMS]+4:], modelOffset)
+
+	headerBuffer := new(bytes.Buffer)
+	for _, value := range []interface{}{RDTHeader{NumCameras: 1, NumModels: 1}, NewRDTOffsets(offsetValues)} {
+		if err := binary.Write(headerBuffer, binary.LittleEndian, value); err != nil {
+			t.Fatalf("Failed to build RDT: %v", err)
+		}
+	}
+	copy(fileData, headerBuffer.Bytes())
+	return fileData
+}
+
+func TestRDTRawOutput_RoundTrip(t *testing.T) {
+	fileData := buildTestRDTBytes(t)
+	rdtOutput, err := LoadRDTRaw(bytes.NewReader(fileData), int64(len(fileData)))
+	if err != nil {
+		t.Fatalf("LoadRDTRaw() error: %v", err)
+	}
+
+	// rid, mask, sca, items, texture, model, init script, execute script
+	if len(rdtOutput.Chunks) != 8 {
+		t.Errorf("Expected 8 chunks, got %d", len(rdtOutput.Chunks))
+	}
+
+	outputData, err := rdtOutput.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	if !bytes.Equal(outputData, fileData) {
+		t.Errorf("Round trip changed the file:\nexpected %v\ngot      %v", fileData, outputData)
+	}
+}
+
+func TestRDTRawOutput_SetSectionRelocates(t *testing.T) {
+	fileData := buildTestRDTBytes(t)
+	rdtOutput, err := LoadRDTRaw(bytes.NewReader(fileData), int64(len(fileData)))
+	if err != nil {
+		t.Fatalf("LoadRDTRaw() error: %v", err)
+	}
+
+	newCollision := []byte{9, 9, 9, 9, 9, 9, 9, 9, 9, 9, 9}
+	if err := rdtOutput.SetSection(RDT_SECTION_COLLISION, newCollision); err != nil {
+		t.Fatalf("SetSection() error: %v", err)
+	}
+	if err := rdtOutput.SetSection(RDT_SECTION_LIGHTS, []byte{1}); err == nil {
+		t.Error("Expected error when setting a section that isn't in the file")
+	}
+
+	outputData, err := rdtOutput.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	modified, err := LoadRDTRaw(bytes.NewReader(outputData), int64(len(outputData)))
+	if err != nil {
+		t.Fatalf("LoadRDTRaw() of modified file error: %v", err)
+	}
+
+	if !bytes.Equal(modified.Section(RDT_SECTION_COLLISION)[:len(newCollision)], newCollision) {
+		t.Errorf("Expected new collision data, got %v", modified.Section(RDT_SECTION_COLLISION))
+	}
+	if modified.Offsets.OffsetItems%4 != 0 {
+		t.Errorf("Expected items to stay aligned, got offset 0x%x", modified.Offsets.OffsetItems)
+	}
+	if modified.Offsets.OffsetItems <= rdtOutput.Offsets.OffsetItems {
+		t.Errorf("Expected items to move after the larger collision data")
+	}
+	if !bytes.Equal(modified.Section(RDT_SECTION_INIT_SCRIPT), rdtOutput.Section(RDT_SECTION_INIT_SCRIPT)) {
+		t.Errorf("Expected init script to be unchanged")
+	}
+
+	// Offsets inside the sections point to the moved data
+	ridOffset := modified.Offsets.OffsetCameraPosition
+	maskOffset := binary.LittleEndian.Uint32(outputData[ridOffset+RID_MASK_OFFSET_START:])
+	if !bytes.Equal(outputData[maskOffset:maskOffset+4], []byte{1, 0, 1, 0}) {
+		t.Errorf("Camera mask offset 0x%x doesn't point to the mask", maskOffset)
+	}
+	textureOffset := binary.LittleEndian.Uint32(outputData[modified.Offsets.OffsetItems:])
+	if textureOffset != modified.Offsets.OffsetModelImage || outputData[textureOffset] != 0x10 {
+		t.Errorf("Item texture offset 0x%x doesn't point to the texture", textureOffset)
+	}
+	modelOffset := binary.LittleEndian.Uint32(outputData[modified.Offsets.OffsetItems+4:])
+	if outputData[modelOffset] != 0x20 {
+		t.Errorf("Item model offset 0x%x doesn't point to the model", modelOffset)
+	}
+}
+
+func TestLoadRDTRaw_InvalidOffset(t *testing.T) {
+	fileData := buildTestRDTBytes(t)
+	binary.LittleEndian.PutUint32(fileData[8+4*RDT_SECTION_LIGHTS:], uint32(len(fileData)+1))
+	if _, err := LoadRDTRaw(bytes.NewReader(fileData), int64(len(fileData))); err == nil {
+		t.Error("Expected error for offset outside the file")
+	}
+}
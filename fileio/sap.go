@@ -4,6 +4,7 @@ package fileio
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 )
@@ -13,15 +14,32 @@ type SAPOutput struct {
 }
 
 func LoadSAPFile(filename string) (*SAPOutput, error) {
+	sapFile, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SAP file %s: %w", filename, err)
+	}
+	defer sapFile.Close()
+
+	fi, err := sapFile.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat SAP file %s: %w", filename, err)
+	}
+	return LoadSAPStream(sapFile, fi.Size())
+}
+
+func LoadSAPStream(r io.ReaderAt, fileLength int64) (*SAPOutput, error) {
 	// Skip first 8 bytes
 	// The rest is a .wav file
-	buffer, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read SAP file %s: %w", filename, err)
+	if err := checkSectionSize("SAP header", 0, 8, fileLength); err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, fileLength-8)
+	if _, err := r.ReadAt(buffer, 8); err != nil && err != io.EOF {
+		return nil, wrapReadError("SAP audio", 8, err)
 	}
 
 	return &SAPOutput{
-		AudioData: buffer[8:],
+		AudioData: buffer,
 	}, nil
 }
 
@@ -0,0 +1,357 @@
+package fileio
+
+// .sf2 - SoundFont 2 instrument bank built from a VAB
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// SoundFont generator operators
+const (
+	SF2_GEN_PAN                 = 17
+	SF2_GEN_ATTACK_VOL_ENV      = 34
+	SF2_GEN_DECAY_VOL_ENV       = 36
+	SF2_GEN_SUSTAIN_VOL_ENV     = 37
+	SF2_GEN_RELEASE_VOL_ENV     = 38
+	SF2_GEN_INSTRUMENT          = 41
+	SF2_GEN_KEY_RANGE           = 43
+	SF2_GEN_INITIAL_ATTENUATION = 48
+	SF2_GEN_FINE_TUNE           = 52
+	SF2_GEN_SAMPLE_ID           = 53
+	SF2_GEN_SAMPLE_MODES        = 54
+	SF2_GEN_OVERRIDING_ROOT_KEY = 58
+
+	SF2_SAMPLE_MONO = 1
+
+	// Every sample in the smpl chunk is followed by this many zero samples
+	sf2SamplePadding = 46
+)
+
+type SF2PresetHeader struct {
+	Name         [20]byte
+	Preset       uint16
+	Bank         uint16
+	PresetBagNdx uint16
+	Library      uint32
+	Genre        uint32
+	Morphology   uint32
+}
+
+type SF2Bag struct {
+	GenNdx uint16
+	ModNdx uint16
+}
+
+type SF2Modulator struct {
+	SrcOper    uint16
+	DestOper   uint16
+	Amount     int16
+	AmtSrcOper uint16
+	TransOper  uint16
+}
+
+type SF2Generator struct {
+	Oper   uint16
+	Amount uint16 // signed value, or low/high byte range
+}
+
+type SF2Instrument struct {
+	Name       [20]byte
+	InstBagNdx uint16
+}
+
+type SF2SampleHeader struct {
+	Name            [20]byte
+	Start           uint32
+	End             uint32
+	StartLoop       uint32
+	EndLoop         uint32
+	SampleRate      uint32
+	OriginalPitch   uint8
+	PitchCorrection int8
+	SampleLink      uint16
+	SampleType      uint16
+}
+
+// VABADSR is the volume envelope of a tone in seconds and centibels of attenuation
+type VABADSR struct {
+	AttackTime   float64
+	DecayTime    float64
+	SustainLevel float64 // attenuation in centibels
+	ReleaseTime  float64
+}
+
+// WriteSF2 builds a SoundFont with one preset per program.
+// Each tone becomes an instrument zone with its key range, center note, pan and envelope.
+func WriteSF2(w io.Writer, bankName string, vabHeaderOutput *VABHeaderOutput, vabDataOutput *VABDataOutput) error {
+	samples := vabDataOutput.DecodeWaveforms()
+
+	// Sample data
+	sampleData := new(bytes.Buffer)
+	sampleHeaders := make([]SF2SampleHeader, 0)
+	for i, sample := range samples {
+		start := uint32(sampleData.Len() / 2)
+		if err := binary.Write(sampleData, binary.LittleEndian, sample.PCMData); err != nil {
+			return err
+		}
+		sampleData.Write(make([]byte, sf2SamplePadding*2))
+
+		sampleHeaders = append(sampleHeaders, SF2SampleHeader{
+			Name:          sf2Name(fmt.Sprintf("vag%03d", i+1)),
+			Start:         start,
+			End:           start + uint32(len(sample.PCMData)),
+			StartLoop:     start + uint32(sample.LoopStart),
+			EndLoop:       start + uint32(sample.LoopEnd),
+			SampleRate:    SPU_SAMPLE_RATE,
+			OriginalPitch: 60,
+			SampleType:    SF2_SAMPLE_MONO,
+		})
+	}
+	sampleHeaders = append(sampleHeaders, SF2SampleHeader{Name: sf2Name("EOS")})
+
+	// Instruments and presets
+	presetHeaders := make([]SF2PresetHeader, 0)
+	presetBags := make([]SF2Bag, 0)
+	presetGenerators := make([]SF2Generator, 0)
+	instruments := make([]SF2Instrument, 0)
+	instrumentBags := make([]SF2Bag, 0)
+	instrumentGenerators := make([]SF2Generator, 0)
+
+	header := vabHeaderOutput.VABHeader
+	for programIndex, program := range vabHeaderOutput.Programs {
+		tones := vabHeaderOutput.ProgramTones(programIndex)
+		if len(tones) == 0 {
+			continue
+		}
+
+		instrumentIndex := len(instruments)
+		instruments = append(instruments, SF2Instrument{
+			Name:       sf2Name(fmt.Sprintf("program%03d", programIndex)),
+			InstBagNdx: uint16(len(instrumentBags)),
+		})
+		for _, tone := range tones {
+			sampleIndex := int(tone.Vag) - 1
+			if sampleIndex < 0 || sampleIndex >= len(samples) {
+				continue
+			}
+
+			instrumentBags = append(instrumentBags, SF2Bag{GenNdx: uint16(len(instrumentGenerators))})
+			instrumentGenerators = append(instrumentGenerators,
+				buildToneGenerators(header, program, tone, sampleIndex, samples[sampleIndex].Looping)...)
+		}
+
+		presetHeaders = append(presetHeaders, SF2PresetHeader{
+			Name:         sf2Name(fmt.Sprintf("program%03d", programIndex)),
+			Preset:       uint16(programIndex),
+			PresetBagNdx: uint16(len(presetBags)),
+		})
+		presetBags = append(presetBags, SF2Bag{GenNdx: uint16(len(presetGenerators))})
+		presetGenerators = append(presetGenerators, SF2Generator{Oper: SF2_GEN_INSTRUMENT, Amount: uint16(instrumentIndex)})
+	}
+
+	// Each list ends with a terminal record
+	presetHeaders = append(presetHeaders, SF2PresetHeader{Name: sf2Name("EOP"), PresetBagNdx: uint16(len(presetBags))})
+	presetBags = append(presetBags, SF2Bag{GenNdx: uint16(len(presetGenerators))})
+	presetGenerators = append(presetGenerators, SF2Generator{})
+	instruments = append(instruments, SF2Instrument{Name: sf2Name("EOI"), InstBagNdx: uint16(len(instrumentBags))})
+	instrumentBags = append(instrumentBags, SF2Bag{GenNdx: uint16(len(instrumentGenerators))})
+	instrumentGenerators = append(instrumentGenerators, SF2Generator{})
+
+	infoList := new(bytes.Buffer)
+	infoList.WriteString("INFO")
+	if err := writeRIFFChunk(infoList, "ifil", [2]uint16{2, 1}); err != nil {
+		return err
+	}
+	if err := writeRIFFChunk(infoList, "isng", sf2String("EMU8000")); err != nil {
+		return err
+	}
+	if err := writeRIFFChunk(infoList, "INAM", sf2String(bankName)); err != nil {
+		return err
+	}
+
+	sampleList := new(bytes.Buffer)
+	sampleList.WriteString("sdta")
+	if err := writeRIFFChunk(sampleList, "smpl", sampleData.Bytes()); err != nil {
+		return err
+	}
+
+	presetList := new(bytes.Buffer)
+	presetList.WriteString("pdta")
+	presetChunks := []struct {
+		id   string
+		data interface{}
+	}{
+		{"phdr", presetHeaders},
+		{"pbag", presetBags},
+		{"pmod", []SF2Modulator{{}}},
+		{"pgen", presetGenerators},
+		{"inst", instruments},
+		{"ibag", instrumentBags},
+		{"imod", []SF2Modulator{{}}},
+		{"igen", instrumentGenerators},
+		{"shdr", sampleHeaders},
+	}
+	for _, chunk := range presetChunks {
+		if err := writeRIFFChunk(presetList, chunk.id, chunk.data); err != nil {
+			return err
+		}
+	}
+
+	body := new(bytes.Buffer)
+	body.WriteString("sfbk")
+	for _, list := range []*bytes.Buffer{infoList, sampleList, presetList} {
+		if err := writeRIFFChunk(body, "LIST", list.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.Write([]byte("RIFF")); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(body.Len())); err != nil {
+		return err
+	}
+	_, err := w.Write(body.Bytes())
+	return err
+}
+
+func ConvertVABToSF2(outputFilename string, bankName string, vabHeaderOutput *VABHeaderOutput, vabDataOutput *VABDataOutput) error {
+	outputFile, err := os.Create(outputFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create SF2 file %s: %w", outputFilename, err)
+	}
+	defer outputFile.Close()
+
+	if err := WriteSF2(outputFile, bankName, vabHeaderOutput, vabDataOutput); err != nil {
+		return fmt.Errorf("failed to write SF2 file %s: %w", outputFilename, err)
+	}
+	return nil
+}
+
+func buildToneGenerators(header VABHeader, program VABProgram, tone VABTone, sampleIndex int, looping bool) []SF2Generator {
+	// Key range must be the first generator and sample id the last
+	generators := []SF2Generator{
+		{Oper: SF2_GEN_KEY_RANGE, Amount: uint16(tone.NoteMin) | uint16(tone.NoteMax)<<8},
+	}
+
+	// Pan is 0-127 with 64 at the center, SoundFont pan is -500 to 500
+	pan := (int(tone.Pan) - 64) + (int(program.Pan) - 64)
+	pan = pan * 500 / 64
+	pan = max(-500, min(500, pan))
+	generators = append(generators, SF2Generator{Oper: SF2_GEN_PAN, Amount: uint16(int16(pan))})
+
+	volume := (float64(tone.Volume) / 127.0) * (float64(program.Volume) / 127.0) * (float64(header.MasterVolume) / 127.0)
+	generators = append(generators, SF2Generator{Oper: SF2_GEN_INITIAL_ATTENUATION, Amount: uint16(amplitudeToCentibels(volume))})
+
+	adsr := ConvertVABADSR(tone.Adsr1, tone.Adsr2)
+	generators = append(generators,
+		SF2Generator{Oper: SF2_GEN_ATTACK_VOL_ENV, Amount: uint16(secondsToTimecents(adsr.AttackTime))},
+		SF2Generator{Oper: SF2_GEN_DECAY_VOL_ENV, Amount: uint16(secondsToTimecents(adsr.DecayTime))},
+		SF2Generator{Oper: SF2_GEN_SUSTAIN_VOL_ENV, Amount: uint16(int16(adsr.SustainLevel))},
+		SF2Generator{Oper: SF2_GEN_RELEASE_VOL_ENV, Amount: uint16(secondsToTimecents(adsr.ReleaseTime))},
+	)
+
+	generators = append(generators,
+		SF2Generator{Oper: SF2_GEN_FINE_TUNE, Amount: uint16(shiftToCents(tone.Shift))},
+		SF2Generator{Oper: SF2_GEN_OVERRIDING_ROOT_KEY, Amount: uint16(tone.Center)},
+	)
+	if looping {
+		generators = append(generators, SF2Generator{Oper: SF2_GEN_SAMPLE_MODES, Amount: 1})
+	}
+	generators = append(generators, SF2Generator{Oper: SF2_GEN_SAMPLE_ID, Amount: uint16(sampleIndex)})
+	return generators
+}
+
+// ConvertVABADSR converts the SPU ADSR register values into envelope times.
+// The times are found by stepping the SPU envelope at 44100 Hz.
+func ConvertVABADSR(adsr1 uint16, adsr2 uint16) VABADSR {
+	attackExponential := adsr1&0x8000 != 0
+	attackShift := int((adsr1 >> 10) & 0x1F)
+	attackStep := int((adsr1 >> 8) & 0x03)
+	decayShift := int((adsr1 >> 4) & 0x0F)
+	sustainLevel := (int(adsr1&0x0F) + 1) * 0x800
+	releaseExponential := adsr2&0x0020 != 0
+	releaseShift := int(adsr2 & 0x1F)
+
+	attackSamples := spuEnvelopeSamples(0, 0x7FFF, attackShift, 7-attackStep, attackExponential)
+	decaySamples := spuEnvelopeSamples(0x7FFF, sustainLevel, decayShift, -8, true)
+	releaseSamples := spuEnvelopeSamples(sustainLevel, 0, releaseShift, -8, releaseExponential)
+
+	return VABADSR{
+		AttackTime:   float64(attackSamples) / SPU_SAMPLE_RATE,
+		DecayTime:    float64(decaySamples) / SPU_SAMPLE_RATE,
+		SustainLevel: float64(amplitudeToCentibels(float64(sustainLevel) / 0x7FFF)),
+		ReleaseTime:  float64(releaseSamples) / SPU_SAMPLE_RATE,
+	}
+}
+
+// spuEnvelopeSamples counts the samples it takes the envelope to move from start to target
+func spuEnvelopeSamples(start int, target int, shift int, step int, exponential bool) int {
+	const maxSamples = SPU_SAMPLE_RATE * 100
+
+	level := start
+	samples := 0
+	for samples < maxSamples {
+		if (step > 0 && level >= target) || (step < 0 && level <= target) {
+			break
+		}
+
+		cycles := 1 << max(0, shift-11)
+		levelStep := step << max(0, 11-shift)
+		if exponential && step > 0 && level > 0x6000 {
+			cycles *= 4
+		}
+		if exponential && step < 0 {
+			levelStep = levelStep * level / 0x8000
+		}
+
+		// Exponential decrease stalls near zero
+		if levelStep == 0 {
+			break
+		}
+
+		samples += cycles
+		level += levelStep
+	}
+	return samples
+}
+
+func secondsToTimecents(seconds float64) int16 {
+	if seconds <= 0.001 {
+		return -12000
+	}
+	return int16(math.Round(1200 * math.Log2(seconds)))
+}
+
+// The pitch correction is in 1/128 semitone steps, SoundFont fine tune is in cents
+func shiftToCents(shift uint8) int16 {
+	return int16(math.Round(float64(shift) * 100 / 128))
+}
+
+func amplitudeToCentibels(amplitude float64) int16 {
+	if amplitude <= 0 {
+		return 1440
+	}
+	centibels := -200 * math.Log10(amplitude)
+	return int16(math.Round(max(0, min(1440, centibels))))
+}
+
+func sf2Name(name string) [20]byte {
+	var output [20]byte
+	copy(output[:19], name)
+	return output
+}
+
+// sf2String returns a zero terminated string padded to an even length
+func sf2String(text string) []byte {
+	data := append([]byte(text), 0)
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	return data
+}
@@ -0,0 +1,141 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func buildTestVAB() (*VABHeaderOutput, *VABDataOutput) {
+	programs := make([]VABProgram, 128)
+	programs[0] = VABProgram{Tones: 1, Volume: 127, Pan: 64}
+	programs[2] = VABProgram{Tones: 2, Volume: 127, Pan: 64}
+
+	tones := [][]VABTone{make([]VABTone, 16), make([]VABTone, 16)}
+	tones[0][0] = VABTone{Volume: 127, Pan: 64, Center: 60, NoteMin: 0, NoteMax: 127, Program: 0, Vag: 1}
+	tones[1][0] = VABTone{Volume: 127, Pan: 64, Center: 48, NoteMin: 0, NoteMax: 59, Program: 2, Vag: 1}
+	tones[1][1] = VABTone{Volume: 127, Pan: 64, Center: 72, NoteMin: 60, NoteMax: 127, Program: 2, Vag: 2}
+
+	header := &VABHeaderOutput{
+		VABHeader:  VABHeader{ProgramCount: 2, ToneCount: 3, WaveformCount: 2, MasterVolume: 127},
+		Programs:   programs,
+		Tones:      tones,
+		AudioSizes: []uint16{0, 2, 2},
+	}
+	data := &VABDataOutput{
+		RawADPCMData: [][]uint8{
+			buildADPCMBlock(0, ADPCM_FLAG_LOOP_END, 1),
+			append(buildADPCMBlock(0, ADPCM_FLAG_LOOP_START, 1), buildADPCMBlock(0, ADPCM_FLAG_LOOP_END|ADPCM_FLAG_LOOP_REPEAT, 1)...),
+		},
+	}
+	return header, data
+}
+
+func TestVABProgramTones(t *testing.T) {
+	header, _ := buildTestVAB()
+
+	tests := []struct {
+		program       int
+		expectedTones int
+		expectedVag   int16
+	}{
+		{0, 1, 1},
+		{1, 0, 0},
+		{2, 2, 1},
+		{200, 0, 0},
+	}
+
+	for _, tt := range tests {
+		tones := header.ProgramTones(tt.program)
+		if len(tones) != tt.expectedTones {
+			t.Errorf("Program %d: expected %d tones, got %d", tt.program, tt.expectedTones, len(tones))
+			continue
+		}
+		if len(tones) > 0 && tones[0].Vag != tt.expectedVag {
+			t.Errorf("Program %d: expected first tone to use vag %d, got %d", tt.program, tt.expectedVag, tones[0].Vag)
+		}
+	}
+}
+
+func TestBuildToneGenerators_FineTune(t *testing.T) {
+	tests := []struct {
+		shift         uint8
+		expectedCents int16
+	}{
+		{0, 0},
+		{64, 50},
+		{127, 99},
+	}
+
+	for _, tt := range tests {
+		tone := VABTone{Volume: 127, Pan: 64, Center: 60, Shift: tt.shift, NoteMax: 127}
+		generators := buildToneGenerators(VABHeader{MasterVolume: 127}, VABProgram{Volume: 127, Pan: 64}, tone, 0, false)
+		found := false
+		for _, generator := range generators {
+			if generator.Oper == SF2_GEN_FINE_TUNE {
+				found = true
+				if int16(generator.Amount) != tt.expectedCents {
+					t.Errorf("Shift %d: expected fine tune %d cents, got %d", tt.shift, tt.expectedCents, int16(generator.Amount))
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Shift %d: missing fine tune generator", tt.shift)
+		}
+	}
+}
+
+func TestWriteSF2(t *testing.T) {
+	header, data := buildTestVAB()
+
+	buffer := new(bytes.Buffer)
+	if err := WriteSF2(buffer, "test", header, data); err != nil {
+		t.Fatalf("WriteSF2() error: %v", err)
+	}
+	output := buffer.Bytes()
+
+	if string(output[0:4]) != "RIFF" || string(output[8:12]) != "sfbk" {
+		t.Fatalf("Invalid SoundFont header: %q", output[0:12])
+	}
+	if riffSize := binary.LittleEndian.Uint32(output[4:8]); int(riffSize) != len(output)-8 {
+		t.Errorf("Expected RIFF size %d, got %d", len(output)-8, riffSize)
+	}
+
+	// Each chunk has a fixed record size, plus one terminal record
+	expectedChunks := []struct {
+		id         string
+		recordSize int
+		records    int
+	}{
+		{"phdr", 38, 3},
+		{"pbag", 4, 3},
+		{"inst", 22, 3},
+		{"ibag", 4, 4},
+		{"shdr", 46, 3},
+	}
+	for _, chunk := range expectedChunks {
+		index := bytes.Index(output, []byte(chunk.id))
+		if index < 0 {
+			t.Errorf("Missing chunk %s", chunk.id)
+			continue
+		}
+		size := int(binary.LittleEndian.Uint32(output[index+4 : index+8]))
+		if size != chunk.recordSize*chunk.records {
+			t.Errorf("Chunk %s: expected %d records, got size %d", chunk.id, chunk.records, size)
+		}
+	}
+
+	// The second sample loops over both blocks
+	shdrIndex := bytes.Index(output, []byte("shdr")) + 8
+	secondSample := SF2SampleHeader{}
+	if err := binary.Read(bytes.NewReader(output[shdrIndex+46:]), binary.LittleEndian, &secondSample); err != nil {
+		t.Fatalf("Failed to read sample header: %v", err)
+	}
+	expectedStart := uint32(ADPCM_SAMPLES_PER_BLOCK + sf2SamplePadding)
+	if secondSample.Start != expectedStart || secondSample.StartLoop != expectedStart {
+		t.Errorf("Expected sample and loop to start at %d, got %d and %d", expectedStart, secondSample.Start, secondSample.StartLoop)
+	}
+	if secondSample.EndLoop != expectedStart+2*ADPCM_SAMPLES_PER_BLOCK {
+		t.Errorf("Expected loop to end at %d, got %d", expectedStart+2*ADPCM_SAMPLES_PER_BLOCK, secondSample.EndLoop)
+	}
+}
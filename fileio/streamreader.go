@@ -18,6 +18,17 @@ func (streamReader *StreamReader) SetPosition(newPosition int64) {
 	streamReader.reader.Seek(newPosition, io.SeekStart)
 }
 
+// Position returns the offset of the next read
+func (streamReader *StreamReader) Position() int64 {
+	position, _ := streamReader.reader.Seek(0, io.SeekCurrent)
+	return position
+}
+
+// Remaining returns the number of bytes left in the stream
+func (streamReader *StreamReader) Remaining() int64 {
+	return streamReader.reader.Size() - streamReader.Position()
+}
+
 func (streamReader *StreamReader) ReadData(data interface{}) error {
 	// The data is little endian by default
 	return binary.Read(streamReader.reader, binary.LittleEndian, data)
@@ -109,7 +120,7 @@ func (streamReader *StreamReader) ReadBytes(count int) ([]byte, error) {
 		return nil, fmt.Errorf("failed to read %d bytes: %w", count, err)
 	}
 	if n != count {
-		return nil, fmt.Errorf("expected to read %d bytes, got %d", count, n)
+		return nil, fmt.Errorf("expected to read %d bytes, got %d: %w", count, n, io.ErrUnexpectedEOF)
 	}
 	return data, nil
 }
@@ -8,10 +8,8 @@ import (
 	"image/color"
 	"image/png"
 	"io"
-	"log"
 	"math"
 	"os"
-	"strconv"
 )
 
 const (
@@ -19,12 +17,18 @@ const (
 	TIM_BPP_8  = 9
 	TIM_BPP_16 = 2
 	TIM_BPP_24 = 3
+
+	TIM_FLAG_PIXEL_MODE = 0x03 // 0 = 4 bit, 1 = 8 bit, 2 = 16 bit, 3 = 24 bit
+	TIM_FLAG_CLUT       = 0x08 // the file has a color lookup table
+
+	// Each section of the image uses the palette for its column
+	TIM_PALETTE_AUTO = -1
 )
 
 type TIMHeader struct {
 	Magic     uint32 // always 16
 	BPP       uint32 // (8 = 4 bit), (9 = 8 bit), (2 = 16 bit), (3 = 24 bit)
-	Offset    uint32
+	Offset    uint32 // total clut data size in bytes (clut header + palettes)
 	OriginX   uint16
 	OriginY   uint16
 	NumColors uint16
@@ -35,16 +39,20 @@ type TIMImageHeader struct {
 	Size    uint32 // total image data size in bytes (image header + image data)
 	OriginX uint16 // image x origin
 	OriginY uint16 // image y origin
-	Width   uint16 // *4 for 4 bit, *2 for 8 bit
+	Width   uint16 // *4 for 4 bit, *2 for 8 bit, *2/3 for 24 bit
 	Height  uint16 // image height
 }
 
 type TIMOutput struct {
-	PixelData   [][]uint16
-	ImageWidth  int
-	ImageHeight int
-	NumPalettes int
-	NumBytes    int
+	PixelData     [][]uint16 // A1B5G5R5 colors
+	IndexData     [][]uint8  // palette index of each pixel for 4 bit and 8 bit images
+	Palettes      [][]uint16 // every palette in the clut
+	TrueColorData [][]color.RGBA
+	BPP           uint32
+	ImageWidth    int
+	ImageHeight   int
+	NumPalettes   int
+	NumBytes      int
 }
 
 func LoadTIMFile(filename string) (*TIMOutput, error) {
@@ -71,45 +79,105 @@ func LoadTIMStream(r io.ReaderAt, fileLength int64) (*TIMOutput, error) {
 	fileStreamReader := NewStreamReader(fileReader)
 
 	timHeader := TIMHeader{}
-	if err := fileStreamReader.ReadData(&timHeader); err != nil {
-		return nil, err
+	if err := fileStreamReader.ReadData(&timHeader.Magic); err != nil {
+		return nil, wrapReadError("TIM header", 0, err)
+	}
+	if err := fileStreamReader.ReadData(&timHeader.BPP); err != nil {
+		return nil, wrapReadError("TIM header", 4, err)
 	}
 
 	if timHeader.Magic != 16 {
-		log.Fatal("TIM header is invalid: ", timHeader)
+		return nil, newFormatError("TIM header", 0, ErrBadMagic, "expected 16, got %d", timHeader.Magic)
 	}
 
-	// Read TIM cluts
-	if timHeader.BPP == TIM_BPP_4 {
-		return read4BPP(fileStreamReader, timHeader)
-	} else if timHeader.BPP == TIM_BPP_8 {
-		return read8BPP(fileStreamReader, timHeader)
-	} else {
-		log.Fatal(fmt.Errorf("BPP %v is not supported.\n", timHeader.BPP))
+	// Direct color images usually don't have a clut
+	numBytes := 8
+	palettes := make([][]uint16, 0)
+	if timHeader.BPP&TIM_FLAG_CLUT != 0 {
+		var err error
+		palettes, err = readTIMPalettes(fileStreamReader, &timHeader)
+		if err != nil {
+			return nil, err
+		}
+		numBytes += 12 + int(timHeader.NumColors)*int(timHeader.NumCluts)*2
 	}
 
-	return nil, nil
-}
+	timImageHeader := TIMImageHeader{}
+	imageHeaderOffset := fileStreamReader.Position()
+	if err := fileStreamReader.ReadData(&timImageHeader); err != nil {
+		return nil, wrapReadError("TIM image header", imageHeaderOffset, err)
+	}
+	numBytes += 12
 
-func read4BPP(streamReader *StreamReader, timHeader TIMHeader) (*TIMOutput, error) {
-	numColors := timHeader.NumColors
-	palettes := make([][]uint16, int(timHeader.NumCluts))
+	imageOffset := fileStreamReader.Position()
+	imageSize := int64(timImageHeader.Width) * 2 * int64(timImageHeader.Height)
+	if err := checkSectionSize("TIM image", imageOffset, imageSize, fileLength); err != nil {
+		return nil, err
+	}
 
-	if numColors != 16 {
-		log.Fatal("4BPP TIM image does not have 16 colors in palette. It has ", numColors, "colors.")
+	var timOutput *TIMOutput
+	var err error
+	switch timHeader.BPP & TIM_FLAG_PIXEL_MODE {
+	case TIM_BPP_4 & TIM_FLAG_PIXEL_MODE:
+		timOutput, err = read4BPP(fileStreamReader, timImageHeader, palettes)
+	case TIM_BPP_8 & TIM_FLAG_PIXEL_MODE:
+		timOutput, err = read8BPP(fileStreamReader, timImageHeader, palettes)
+	case TIM_BPP_16:
+		timOutput, err = read16BPP(fileStreamReader, timImageHeader)
+	case TIM_BPP_24:
+		timOutput, err = read24BPP(fileStreamReader, timImageHeader)
+	}
+	if err != nil {
+		return nil, wrapReadError("TIM image", imageOffset, err)
 	}
 
-	for i := 0; i < int(timHeader.NumCluts); i++ {
-		palettes[i] = make([]uint16, numColors)
-		if err := streamReader.ReadData(&palettes[i]); err != nil {
-			return nil, err
+	timOutput.BPP = timHeader.BPP
+	timOutput.Palettes = palettes
+	timOutput.NumPalettes = len(palettes)
+	timOutput.NumBytes = numBytes + int(timImageHeader.Width)*2*int(timImageHeader.Height)
+	return timOutput, nil
+}
+
+// readTIMPalettes reads the clut header and splits the clut into palettes.
+// A clut row can hold several 16 color palettes.
+func readTIMPalettes(streamReader *StreamReader, timHeader *TIMHeader) ([][]uint16, error) {
+	clutHeaderOffset := streamReader.Position()
+	for _, field := range []interface{}{&timHeader.Offset, &timHeader.OriginX, &timHeader.OriginY, &timHeader.NumColors, &timHeader.NumCluts} {
+		if err := streamReader.ReadData(field); err != nil {
+			return nil, wrapReadError("TIM clut header", clutHeaderOffset, err)
 		}
 	}
-	timImageHeader := TIMImageHeader{}
-	if err := streamReader.ReadData(&timImageHeader); err != nil {
-		return nil, err
+
+	clutOffset := streamReader.Position()
+	clutSize := int64(timHeader.NumColors) * int64(timHeader.NumCluts) * 2
+	if clutSize > streamReader.Remaining() {
+		return nil, newFormatError("TIM clut", clutOffset, ErrTruncated, "needs 0x%x bytes, 0x%x left", clutSize, streamReader.Remaining())
+	}
+	clutData := make([]uint16, int(timHeader.NumColors)*int(timHeader.NumCluts))
+	if err := streamReader.ReadData(&clutData); err != nil {
+		return nil, wrapReadError("TIM clut", clutOffset, err)
 	}
 
+	paletteSize := int(timHeader.NumColors)
+	switch timHeader.BPP {
+	case TIM_BPP_4:
+		paletteSize = 16
+	case TIM_BPP_8:
+		paletteSize = 256
+	}
+	if paletteSize == 0 || len(clutData)%paletteSize != 0 {
+		return nil, newFormatError("TIM clut", clutOffset, ErrInvalidValue,
+			"%dx%d colors can't be split into palettes of %d colors", timHeader.NumColors, timHeader.NumCluts, paletteSize)
+	}
+
+	palettes := make([][]uint16, len(clutData)/paletteSize)
+	for i := range palettes {
+		palettes[i] = clutData[i*paletteSize : (i+1)*paletteSize]
+	}
+	return palettes, nil
+}
+
+func read4BPP(streamReader *StreamReader, timImageHeader TIMImageHeader, palettes [][]uint16) (*TIMOutput, error) {
 	totalImageWidth := int(timImageHeader.Width) * 4
 	totalImageHeight := int(timImageHeader.Height)
 
@@ -119,103 +187,174 @@ func read4BPP(streamReader *StreamReader, timHeader TIMHeader) (*TIMOutput, erro
 		return nil, err
 	}
 
-	pixelData2D := make([][]uint16, totalImageHeight)
+	indexData2D := make([][]uint8, totalImageHeight)
 	for i := 0; i < totalImageHeight; i++ {
-		pixelData2D[i] = make([]uint16, totalImageWidth)
+		indexData2D[i] = make([]uint8, totalImageWidth)
 	}
 
 	for i := 0; i < imageDataLength; i += 2 {
 		index := imageData[i/2]
-		colorPalette := palettes[(i%totalImageWidth)/(totalImageWidth/len(palettes))]
-
-		color1Index := index & 0x0F
-		color2Index := (index & 0xF0) >> 4
 
 		// color 1
 		x := i % totalImageWidth
 		y := i / totalImageWidth
-		pixelData2D[y][x] = colorPalette[color1Index]
+		indexData2D[y][x] = index & 0x0F
 
 		// color 2
 		x = (i + 1) % totalImageWidth
 		y = (i + 1) / totalImageWidth
-		pixelData2D[y][x] = colorPalette[color2Index]
+		indexData2D[y][x] = (index & 0xF0) >> 4
+	}
+
+	return buildPalettedTIMOutput(indexData2D, palettes, totalImageWidth, totalImageHeight)
+}
+
+func read8BPP(streamReader *StreamReader, timImageHeader TIMImageHeader, palettes [][]uint16) (*TIMOutput, error) {
+	totalImageWidth := int(timImageHeader.Width) * 2
+	totalImageHeight := int(timImageHeader.Height)
+
+	imageDataLength := totalImageWidth * totalImageHeight
+	imageData := make([]uint8, imageDataLength)
+	if err := streamReader.ReadData(&imageData); err != nil {
+		return nil, err
+	}
+
+	indexData2D := make([][]uint8, totalImageHeight)
+	for y := 0; y < totalImageHeight; y++ {
+		indexData2D[y] = imageData[y*totalImageWidth : (y+1)*totalImageWidth]
 	}
 
-	headerBytes := 32
-	paletteBytes := int(timHeader.NumColors) * int(timHeader.NumCluts) * 2
-	imageBytes := (totalImageWidth / 2) * totalImageHeight
-	numBytes := imageBytes + paletteBytes + headerBytes
+	return buildPalettedTIMOutput(indexData2D, palettes, totalImageWidth, totalImageHeight)
+}
+
+func read16BPP(streamReader *StreamReader, timImageHeader TIMImageHeader) (*TIMOutput, error) {
+	totalImageWidth := int(timImageHeader.Width)
+	totalImageHeight := int(timImageHeader.Height)
+
+	pixelData2D := make([][]uint16, totalImageHeight)
+	for y := 0; y < totalImageHeight; y++ {
+		pixelData2D[y] = make([]uint16, totalImageWidth)
+		if err := streamReader.ReadData(&pixelData2D[y]); err != nil {
+			return nil, err
+		}
+	}
 
 	timOutput := &TIMOutput{
 		PixelData:   pixelData2D,
 		ImageWidth:  totalImageWidth,
 		ImageHeight: totalImageHeight,
-		NumPalettes: int(timHeader.NumCluts),
-		NumBytes:    numBytes,
 	}
 	return timOutput, nil
 }
 
-func read8BPP(streamReader *StreamReader, timHeader TIMHeader) (*TIMOutput, error) {
-	numColors := timHeader.NumColors
-	palettes := make([][]uint16, int(timHeader.NumCluts))
-
-	if numColors != 256 {
-		log.Fatal("8BPP TIM image does not have 256 colors in palette. It has ", numColors, "colors.")
-	}
+func read24BPP(streamReader *StreamReader, timImageHeader TIMImageHeader) (*TIMOutput, error) {
+	// Each row is stored in 16 bit units, so it is padded when the width is odd
+	rowBytes := int(timImageHeader.Width) * 2
+	totalImageWidth := rowBytes / 3
+	totalImageHeight := int(timImageHeader.Height)
 
-	for i := 0; i < int(timHeader.NumCluts); i++ {
-		palettes[i] = make([]uint16, numColors)
-		if err := streamReader.ReadData(&palettes[i]); err != nil {
+	pixelData2D := make([][]uint16, totalImageHeight)
+	trueColorData2D := make([][]color.RGBA, totalImageHeight)
+	rowData := make([]uint8, rowBytes)
+	for y := 0; y < totalImageHeight; y++ {
+		if err := streamReader.ReadData(&rowData); err != nil {
 			return nil, err
 		}
+
+		pixelData2D[y] = make([]uint16, totalImageWidth)
+		trueColorData2D[y] = make([]color.RGBA, totalImageWidth)
+		for x := 0; x < totalImageWidth; x++ {
+			r := rowData[x*3]
+			g := rowData[x*3+1]
+			b := rowData[x*3+2]
+			trueColorData2D[y][x] = color.RGBA{r, g, b, 255}
+
+			pixel := uint16(r>>3) | uint16(g>>3)<<5 | uint16(b>>3)<<10
+			// Black is transparent unless the semi-transparency bit is set
+			if pixel == 0 {
+				pixel = 0x8000
+			}
+			pixelData2D[y][x] = pixel
+		}
 	}
-	timImageHeader := TIMImageHeader{}
-	if err := streamReader.ReadData(&timImageHeader); err != nil {
-		return nil, err
+
+	timOutput := &TIMOutput{
+		PixelData:     pixelData2D,
+		TrueColorData: trueColorData2D,
+		ImageWidth:    totalImageWidth,
+		ImageHeight:   totalImageHeight,
 	}
+	return timOutput, nil
+}
 
-	totalImageWidth := int(timImageHeader.Width) * 2
-	totalImageHeight := int(timImageHeader.Height)
+func buildPalettedTIMOutput(indexData2D [][]uint8, palettes [][]uint16, imageWidth int, imageHeight int) (*TIMOutput, error) {
+	timOutput := &TIMOutput{
+		IndexData:   indexData2D,
+		Palettes:    palettes,
+		NumPalettes: len(palettes),
+		ImageWidth:  imageWidth,
+		ImageHeight: imageHeight,
+	}
 
-	imageDataLength := totalImageWidth * totalImageHeight
-	imageData := make([]uint8, imageDataLength)
-	if err := streamReader.ReadData(&imageData); err != nil {
+	pixelData2D, err := timOutput.PixelDataWithPalette(TIM_PALETTE_AUTO)
+	if err != nil {
 		return nil, err
 	}
+	timOutput.PixelData = pixelData2D
+	return timOutput, nil
+}
 
-	pixelData2D := make([][]uint16, totalImageHeight)
-	for i := 0; i < totalImageHeight; i++ {
-		pixelData2D[i] = make([]uint16, totalImageWidth)
+// PixelDataWithPalette returns the colors of a 4 bit or 8 bit image using one palette for every pixel.
+// TIM_PALETTE_AUTO splits the image into columns and uses a different palette for each column.
+func (timOutput *TIMOutput) PixelDataWithPalette(paletteIndex int) ([][]uint16, error) {
+	if timOutput.IndexData == nil {
+		if paletteIndex != TIM_PALETTE_AUTO {
+			return nil, fmt.Errorf("TIM image with BPP %v doesn't use a palette", timOutput.BPP)
+		}
+		return timOutput.PixelData, nil
 	}
 
-	for i := 0; i < imageDataLength; i++ {
-		index := imageData[i]
-		paletteIndex := int(math.Floor(float64(i%totalImageWidth) * float64(len(palettes)) / float64(totalImageWidth)))
-		colorPalette := palettes[paletteIndex]
-		x := i % totalImageWidth
-		y := i / totalImageWidth
-		pixelData2D[y][x] = colorPalette[index]
+	numPalettes := len(timOutput.Palettes)
+	if numPalettes == 0 {
+		return nil, fmt.Errorf("TIM image doesn't have a palette")
+	}
+	if paletteIndex < TIM_PALETTE_AUTO || paletteIndex >= numPalettes {
+		return nil, fmt.Errorf("palette %d is out of range, the image has %d palettes", paletteIndex, numPalettes)
 	}
 
-	headerBytes := 32
-	paletteBytes := int(timHeader.NumColors) * int(timHeader.NumCluts) * 2
-	imageBytes := totalImageWidth * totalImageHeight
-	numBytes := imageBytes + paletteBytes + headerBytes
-
-	timOutput := &TIMOutput{
-		PixelData:   pixelData2D,
-		ImageWidth:  totalImageWidth,
-		ImageHeight: totalImageHeight,
-		NumPalettes: int(timHeader.NumCluts),
-		NumBytes:    numBytes,
+	pixelData2D := make([][]uint16, timOutput.ImageHeight)
+	for y := 0; y < timOutput.ImageHeight; y++ {
+		pixelData2D[y] = make([]uint16, timOutput.ImageWidth)
+		for x := 0; x < timOutput.ImageWidth; x++ {
+			columnPaletteIndex := paletteIndex
+			if paletteIndex == TIM_PALETTE_AUTO {
+				columnPaletteIndex = int(math.Floor(float64(x) * float64(numPalettes) / float64(timOutput.ImageWidth)))
+			}
+			colorPalette := timOutput.Palettes[columnPaletteIndex]
+
+			index := int(timOutput.IndexData[y][x])
+			if index < len(colorPalette) {
+				pixelData2D[y][x] = colorPalette[index]
+			}
+		}
 	}
-	return timOutput, nil
+	return pixelData2D, nil
 }
 
 func (timOutput *TIMOutput) ConvertToRenderData() []uint16 {
-	pixelData2D := timOutput.PixelData
+	return convertPixelsToRenderData(timOutput.PixelData)
+}
+
+// ConvertToRenderDataWithPalette is the same as ConvertToRenderData with a chosen palette
+func (timOutput *TIMOutput) ConvertToRenderDataWithPalette(paletteIndex int) ([]uint16, error) {
+	pixelData2D, err := timOutput.PixelDataWithPalette(paletteIndex)
+	if err != nil {
+		return nil, err
+	}
+	return convertPixelsToRenderData(pixelData2D), nil
+}
+
+func convertPixelsToRenderData(pixelData2D [][]uint16) []uint16 {
 	pixelData1D := make([]uint16, len(pixelData2D)*len(pixelData2D[0]))
 
 	for y := 0; y < len(pixelData2D); y++ {
@@ -227,33 +366,57 @@ func (timOutput *TIMOutput) ConvertToRenderData() []uint16 {
 	return pixelData1D
 }
 
-func (timOutput *TIMOutput) ConvertToPNG(outputFilename string) error {
-	pixelData2D := timOutput.PixelData
+// ImageWithPalette converts the image to RGBA.
+// 24 bit images keep their full colors and ignore the palette.
+func (timOutput *TIMOutput) ImageWithPalette(paletteIndex int) (*image.RGBA, error) {
 	totalImageWidth := timOutput.ImageWidth
 	totalImageHeight := timOutput.ImageHeight
 	imageOutputData := image.NewRGBA(image.Rect(0, 0, totalImageWidth, totalImageHeight))
+
+	if timOutput.TrueColorData != nil {
+		for y := 0; y < totalImageHeight; y++ {
+			for x := 0; x < totalImageWidth; x++ {
+				imageOutputData.SetRGBA(x, y, timOutput.TrueColorData[y][x])
+			}
+		}
+		return imageOutputData, nil
+	}
+
+	pixelData2D, err := timOutput.PixelDataWithPalette(paletteIndex)
+	if err != nil {
+		return nil, err
+	}
 	for y := 0; y < totalImageHeight; y++ {
 		for x := 0; x < totalImageWidth; x++ {
-			colorBits := fmt.Sprintf("%016b", pixelData2D[y][x])
 			// color is in A1B5G5R5 format
-			a, _ := strconv.ParseInt(string(colorBits[0]), 2, 1)
-			a = 255
-			b, _ := strconv.ParseInt(string(colorBits[1:6]), 2, 5)
-			g, _ := strconv.ParseInt(string(colorBits[6:11]), 2, 5)
-			r, _ := strconv.ParseInt(string(colorBits[11:16]), 2, 5)
-			b *= 8
-			g *= 8
-			r *= 8
-			imageOutputData.Set(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), uint8(a)})
+			pixel := pixelData2D[y][x]
+			r := (pixel & 0x1F) * 8
+			g := ((pixel >> 5) & 0x1F) * 8
+			b := ((pixel >> 10) & 0x1F) * 8
+			imageOutputData.SetRGBA(x, y, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
 		}
 	}
+	return imageOutputData, nil
+}
+
+func (timOutput *TIMOutput) ConvertToPNG(outputFilename string) error {
+	return timOutput.ConvertToPNGWithPalette(outputFilename, TIM_PALETTE_AUTO)
+}
+
+func (timOutput *TIMOutput) ConvertToPNGWithPalette(outputFilename string, paletteIndex int) error {
+	imageOutputData, err := timOutput.ImageWithPalette(paletteIndex)
+	if err != nil {
+		return err
+	}
 
 	imageOutputFile, err := os.Create(outputFilename)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create PNG file %s: %w", outputFilename, err)
 	}
 	defer imageOutputFile.Close()
-	png.Encode(imageOutputFile, imageOutputData)
+	if err := png.Encode(imageOutputFile, imageOutputData); err != nil {
+		return fmt.Errorf("failed to write PNG file %s: %w", outputFilename, err)
+	}
 
 	fmt.Println("Written image data to " + outputFilename)
 	return nil
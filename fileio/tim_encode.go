@@ -0,0 +1,305 @@
+package fileio
+
+// Encoder for .tim images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"sort"
+)
+
+type TIMEncodeOptions struct {
+	BPP             uint32 // TIM_BPP_4, TIM_BPP_8 or TIM_BPP_16
+	SemiTransparent bool   // set the semi-transparency bit on every opaque color
+}
+
+type timColorCount struct {
+	color uint16
+	count int
+}
+
+// EncodeTIM converts an image into a TIM file.
+// Pixels with alpha below 128 become transparent (color 0) and opaque black keeps the semi-transparency bit so it stays visible.
+// Paletted images that fit in the palette keep their palette order, other images are quantized.
+func EncodeTIM(img image.Image, options TIMEncodeOptions) ([]byte, error) {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	buffer := new(bytes.Buffer)
+	write := func(value interface{}) {
+		binary.Write(buffer, binary.LittleEndian, value)
+	}
+
+	write(uint32(16))
+	write(options.BPP)
+
+	var imageData []byte
+	var widthUnits int
+	switch options.BPP {
+	case TIM_BPP_4, TIM_BPP_8:
+		pixelsPerUnit := 4
+		paletteSize := 16
+		if options.BPP == TIM_BPP_8 {
+			pixelsPerUnit = 2
+			paletteSize = 256
+		}
+		if width%pixelsPerUnit != 0 {
+			return nil, fmt.Errorf("image width %d must be a multiple of %d for BPP %v", width, pixelsPerUnit, options.BPP)
+		}
+		widthUnits = width / pixelsPerUnit
+
+		palette, indexData := buildTIMPalette(img, paletteSize, options.SemiTransparent)
+		write(uint32(12 + paletteSize*2))
+		write([2]uint16{0, 0})
+		write(uint16(paletteSize))
+		write(uint16(1))
+		write(palette)
+
+		if options.BPP == TIM_BPP_4 {
+			imageData = make([]byte, 0, width*height/2)
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x += 2 {
+					imageData = append(imageData, indexData[y][x]&0x0F|indexData[y][x+1]<<4)
+				}
+			}
+		} else {
+			imageData = make([]byte, 0, width*height)
+			for y := 0; y < height; y++ {
+				imageData = append(imageData, indexData[y]...)
+			}
+		}
+	case TIM_BPP_16:
+		widthUnits = width
+		pixelData := ConvertImageToTIMPixels(img, options.SemiTransparent)
+		imageDataBuffer := new(bytes.Buffer)
+		for y := 0; y < height; y++ {
+			binary.Write(imageDataBuffer, binary.LittleEndian, pixelData[y])
+		}
+		imageData = imageDataBuffer.Bytes()
+	default:
+		return nil, fmt.Errorf("BPP %v can't be encoded", options.BPP)
+	}
+
+	if widthUnits > 0xFFFF || height > 0xFFFF {
+		return nil, fmt.Errorf("image size %dx%d is too large", width, height)
+	}
+	write(uint32(12 + len(imageData)))
+	write([2]uint16{0, 0})
+	write(uint16(widthUnits))
+	write(uint16(height))
+	buffer.Write(imageData)
+	return buffer.Bytes(), nil
+}
+
+func ConvertImageToTIMFile(img image.Image, options TIMEncodeOptions, outputFilename string) error {
+	timData, err := EncodeTIM(img, options)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outputFilename, timData, 0644); err != nil {
+		return fmt.Errorf("failed to write TIM file %s: %w", outputFilename, err)
+	}
+	return nil
+}
+
+// ConvertColorToTIM converts a color to A1B5G5R5
+func ConvertColorToTIM(c color.Color, semiTransparent bool) uint16 {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A < 128 {
+		return 0
+	}
+
+	r := min((uint16(nrgba.R)+4)>>3, 31)
+	g := min((uint16(nrgba.G)+4)>>3, 31)
+	b := min((uint16(nrgba.B)+4)>>3, 31)
+	pixel := r | g<<5 | b<<10
+	if semiTransparent || pixel == 0 {
+		pixel |= 0x8000
+	}
+	return pixel
+}
+
+func ConvertImageToTIMPixels(img image.Image, semiTransparent bool) [][]uint16 {
+	bounds := img.Bounds()
+	pixelData2D := make([][]uint16, bounds.Dy())
+	for y := 0; y < bounds.Dy(); y++ {
+		pixelData2D[y] = make([]uint16, bounds.Dx())
+		for x := 0; x < bounds.Dx(); x++ {
+			pixelData2D[y][x] = ConvertColorToTIM(img.At(bounds.Min.X+x, bounds.Min.Y+y), semiTransparent)
+		}
+	}
+	return pixelData2D
+}
+
+// buildTIMPalette returns a palette of paletteSize colors and the palette index of each pixel
+func buildTIMPalette(img image.Image, paletteSize int, semiTransparent bool) ([]uint16, [][]uint8) {
+	bounds := img.Bounds()
+	palette := make([]uint16, paletteSize)
+	indexData := make([][]uint8, bounds.Dy())
+	for y := range indexData {
+		indexData[y] = make([]uint8, bounds.Dx())
+	}
+
+	// Keep the original palette order if possible
+	if palettedImage, ok := img.(*image.Paletted); ok && len(palettedImage.Palette) <= paletteSize {
+		for i, paletteColor := range palettedImage.Palette {
+			palette[i] = ConvertColorToTIM(paletteColor, semiTransparent)
+		}
+		for y := 0; y < bounds.Dy(); y++ {
+			for x := 0; x < bounds.Dx(); x++ {
+				indexData[y][x] = palettedImage.ColorIndexAt(bounds.Min.X+x, bounds.Min.Y+y)
+			}
+		}
+		return palette, indexData
+	}
+
+	pixelData := ConvertImageToTIMPixels(img, semiTransparent)
+	colorCounts := make(map[uint16]int)
+	for _, row := range pixelData {
+		for _, pixel := range row {
+			colorCounts[pixel]++
+		}
+	}
+
+	// Transparency always uses the first palette entry
+	_, hasTransparency := colorCounts[0]
+	opaqueColors := make([]timColorCount, 0, len(colorCounts))
+	for pixel, count := range colorCounts {
+		if pixel != 0 {
+			opaqueColors = append(opaqueColors, timColorCount{pixel, count})
+		}
+	}
+	sort.Slice(opaqueColors, func(i, j int) bool {
+		return opaqueColors[i].color < opaqueColors[j].color
+	})
+
+	paletteStart := 0
+	if hasTransparency {
+		paletteStart = 1
+	}
+	paletteColors := quantizeTIMColors(opaqueColors, paletteSize-paletteStart)
+	copy(palette[paletteStart:], paletteColors)
+
+	colorIndices := make(map[uint16]uint8)
+	for pixel := range colorCounts {
+		if pixel == 0 {
+			colorIndices[pixel] = 0
+			continue
+		}
+		colorIndices[pixel] = uint8(paletteStart + nearestTIMColor(pixel, paletteColors))
+	}
+	for y, row := range pixelData {
+		for x, pixel := range row {
+			indexData[y][x] = colorIndices[pixel]
+		}
+	}
+	return palette, indexData
+}
+
+// quantizeTIMColors reduces the colors to maxColors using median cut
+func quantizeTIMColors(colors []timColorCount, maxColors int) []uint16 {
+	if len(colors) <= maxColors {
+		palette := make([]uint16, len(colors))
+		for i, colorCount := range colors {
+			palette[i] = colorCount.color
+		}
+		return palette
+	}
+
+	boxes := [][]timColorCount{colors}
+	for len(boxes) < maxColors {
+		// Split the box with the widest range of a color channel
+		splitIndex := -1
+		splitChannel := 0
+		widestRange := 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			for channel := 0; channel < 3; channel++ {
+				minValue, maxValue := 31, 0
+				for _, colorCount := range box {
+					value := timColorChannel(colorCount.color, channel)
+					minValue = min(minValue, value)
+					maxValue = max(maxValue, value)
+				}
+				if maxValue-minValue >= widestRange {
+					widestRange = maxValue - minValue
+					splitIndex = i
+					splitChannel = channel
+				}
+			}
+		}
+		if splitIndex < 0 {
+			break
+		}
+
+		box := boxes[splitIndex]
+		sort.SliceStable(box, func(i, j int) bool {
+			return timColorChannel(box[i].color, splitChannel) < timColorChannel(box[j].color, splitChannel)
+		})
+		totalCount := 0
+		for _, colorCount := range box {
+			totalCount += colorCount.count
+		}
+		medianIndex := 1
+		runningCount := box[0].count
+		for medianIndex < len(box)-1 && runningCount*2 < totalCount {
+			runningCount += box[medianIndex].count
+			medianIndex++
+		}
+		boxes[splitIndex] = box[:medianIndex]
+		boxes = append(boxes, box[medianIndex:])
+	}
+
+	palette := make([]uint16, len(boxes))
+	for i, box := range boxes {
+		var sums [3]int
+		totalCount := 0
+		for _, colorCount := range box {
+			for channel := 0; channel < 3; channel++ {
+				sums[channel] += timColorChannel(colorCount.color, channel) * colorCount.count
+			}
+			totalCount += colorCount.count
+		}
+		pixel := box[0].color & 0x8000
+		for channel := 0; channel < 3; channel++ {
+			pixel |= uint16((sums[channel]+totalCount/2)/totalCount) << (5 * channel)
+		}
+		if pixel == 0 {
+			pixel = 0x8000
+		}
+		palette[i] = pixel
+	}
+	return palette
+}
+
+func nearestTIMColor(pixel uint16, palette []uint16) int {
+	bestIndex := 0
+	bestDistance := -1
+	for i, paletteColor := range palette {
+		distance := 0
+		for channel := 0; channel < 3; channel++ {
+			difference := timColorChannel(pixel, channel) - timColorChannel(paletteColor, channel)
+			distance += difference * difference
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			bestIndex = i
+			bestDistance = distance
+		}
+	}
+	return bestIndex
+}
+
+// timColorChannel returns red (0), green (1) or blue (2)
+func timColorChannel(pixel uint16, channel int) int {
+	return int(pixel>>(5*channel)) & 0x1F
+}
@@ -0,0 +1,153 @@
+package fileio
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func buildTestPalettedImage(width int, height int, numColors int) *image.Paletted {
+	palette := make(color.Palette, numColors)
+	palette[0] = color.NRGBA{0, 0, 0, 0}
+	for i := 1; i < numColors; i++ {
+		palette[i] = color.NRGBA{uint8(i * 8), uint8(255 - i*8), uint8(i * 16), 255}
+	}
+	img := image.NewPaletted(image.Rect(0, 0, width, height), palette)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetColorIndex(x, y, uint8((x+y*3)%numColors))
+		}
+	}
+	return img
+}
+
+func TestEncodeTIM_PalettedRoundTrip(t *testing.T) {
+	tests := []struct {
+		name      string
+		bpp       uint32
+		numColors int
+	}{
+		{"4bpp", TIM_BPP_4, 16},
+		{"8bpp", TIM_BPP_8, 32},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img := buildTestPalettedImage(8, 4, test.numColors)
+			data, err := EncodeTIM(img, TIMEncodeOptions{BPP: test.bpp})
+			if err != nil {
+				t.Fatalf("EncodeTIM() error: %v", err)
+			}
+
+			timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("LoadTIMStream() error: %v", err)
+			}
+			if timOutput.NumBytes != len(data) {
+				t.Errorf("Expected %d bytes, got %d", len(data), timOutput.NumBytes)
+			}
+			if timOutput.ImageWidth != 8 || timOutput.ImageHeight != 4 {
+				t.Fatalf("Expected 8x4 image, got %dx%d", timOutput.ImageWidth, timOutput.ImageHeight)
+			}
+
+			for y := 0; y < 4; y++ {
+				for x := 0; x < 8; x++ {
+					expectedIndex := img.ColorIndexAt(x, y)
+					if timOutput.IndexData[y][x] != expectedIndex {
+						t.Fatalf("Pixel (%d, %d): expected index %d, got %d", x, y, expectedIndex, timOutput.IndexData[y][x])
+					}
+					expectedColor := ConvertColorToTIM(img.Palette[expectedIndex], false)
+					if timOutput.PixelData[y][x] != expectedColor {
+						t.Fatalf("Pixel (%d, %d): expected color 0x%04X, got 0x%04X", x, y, expectedColor, timOutput.PixelData[y][x])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeTIM_16BPPRoundTrip(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.NRGBA{0, 0, 0, 255})
+	img.Set(2, 0, color.NRGBA{0, 0, 0, 0})
+	img.Set(0, 1, color.NRGBA{8, 16, 24, 255})
+
+	data, err := EncodeTIM(img, TIMEncodeOptions{BPP: TIM_BPP_16})
+	if err != nil {
+		t.Fatalf("EncodeTIM() error: %v", err)
+	}
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+
+	expectedPixels := [][]uint16{
+		{0x001F, 0x8000, 0x0000},
+		{0x0C41, 0x0000, 0x0000},
+	}
+	for y, row := range expectedPixels {
+		for x, expected := range row {
+			if timOutput.PixelData[y][x] != expected {
+				t.Errorf("Pixel (%d, %d): expected 0x%04X, got 0x%04X", x, y, expected, timOutput.PixelData[y][x])
+			}
+		}
+	}
+}
+
+func TestEncodeTIM_SemiTransparent(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.NRGBA{255, 255, 255, 255})
+
+	data, err := EncodeTIM(img, TIMEncodeOptions{BPP: TIM_BPP_16, SemiTransparent: true})
+	if err != nil {
+		t.Fatalf("EncodeTIM() error: %v", err)
+	}
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+	if timOutput.PixelData[0][0] != 0xFFFF {
+		t.Errorf("Expected semi-transparent white, got 0x%04X", timOutput.PixelData[0][0])
+	}
+	// Transparent pixels stay transparent
+	if timOutput.PixelData[0][1] != 0 {
+		t.Errorf("Expected transparent pixel, got 0x%04X", timOutput.PixelData[0][1])
+	}
+}
+
+func TestEncodeTIM_Quantize(t *testing.T) {
+	// 64 shades of gray have to fit in 16 colors
+	img := image.NewGray(image.Rect(0, 0, 64, 1))
+	for x := 0; x < 64; x++ {
+		img.SetGray(x, 0, color.Gray{uint8(x * 4)})
+	}
+
+	data, err := EncodeTIM(img, TIMEncodeOptions{BPP: TIM_BPP_4})
+	if err != nil {
+		t.Fatalf("EncodeTIM() error: %v", err)
+	}
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+
+	for x := 0; x < 64; x++ {
+		expected := timColorChannel(ConvertColorToTIM(img.At(x, 0), false), 0)
+		actual := timColorChannel(timOutput.PixelData[0][x], 0)
+		if actual-expected > 2 || expected-actual > 2 {
+			t.Errorf("Pixel %d: expected gray level near %d, got %d", x, expected, actual)
+		}
+	}
+}
+
+func TestEncodeTIM_InvalidWidth(t *testing.T) {
+	img := buildTestPalettedImage(6, 1, 16)
+	if _, err := EncodeTIM(img, TIMEncodeOptions{BPP: TIM_BPP_4}); err == nil {
+		t.Error("Expected error for 4 bit image with a width that isn't a multiple of 4")
+	}
+	if _, err := EncodeTIM(img, TIMEncodeOptions{BPP: TIM_BPP_24}); err == nil {
+		t.Error("Expected error for unsupported BPP")
+	}
+}
@@ -0,0 +1,177 @@
+package fileio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/color"
+	"testing"
+)
+
+func buildTestTIMBytes(t testing.TB, flags uint32, clut [][]uint16, width uint16, height uint16, imageData []byte) []byte {
+	buffer := new(bytes.Buffer)
+	write := func(value interface{}) {
+		if err := binary.Write(buffer, binary.LittleEndian, value); err != nil {
+			t.Fatalf("Failed to build TIM: %v", err)
+		}
+	}
+
+	write(uint32(16))
+	write(flags)
+	if clut != nil {
+		numColors := len(clut[0])
+		write(uint32(12 + numColors*len(clut)*2))
+		write([2]uint16{0, 0})
+		write(uint16(numColors))
+		write(uint16(len(clut)))
+		for _, row := range clut {
+			write(row)
+		}
+	}
+	write(uint32(12 + len(imageData)))
+	write([2]uint16{0, 0})
+	write(width)
+	write(height)
+	buffer.Write(imageData)
+	return buffer.Bytes()
+}
+
+func TestLoadTIMStream_4BPPMultiplePalettes(t *testing.T) {
+	// One clut row of 32 colors holds two 16 color palettes
+	clutRow := make([]uint16, 32)
+	for i := range clutRow {
+		clutRow[i] = uint16(i + 1)
+	}
+	// 8x1 image, stored as 2 units of 16 bits
+	imageData := []byte{0x10, 0x32, 0x10, 0x32}
+	data := buildTestTIMBytes(t, TIM_BPP_4, [][]uint16{clutRow}, 2, 1, imageData)
+
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+	if timOutput.ImageWidth != 8 || timOutput.ImageHeight != 1 {
+		t.Fatalf("Expected 8x1 image, got %dx%d", timOutput.ImageWidth, timOutput.ImageHeight)
+	}
+	if timOutput.NumPalettes != 2 {
+		t.Fatalf("Expected 2 palettes, got %d", timOutput.NumPalettes)
+	}
+	if timOutput.NumBytes != len(data) {
+		t.Errorf("Expected %d bytes, got %d", len(data), timOutput.NumBytes)
+	}
+
+	// The left half uses the first palette and the right half uses the second
+	expectedAuto := []uint16{1, 2, 3, 4, 17, 18, 19, 20}
+	for x, expected := range expectedAuto {
+		if timOutput.PixelData[0][x] != expected {
+			t.Errorf("Auto palette pixel %d: expected %d, got %d", x, expected, timOutput.PixelData[0][x])
+		}
+	}
+
+	pixelData, err := timOutput.PixelDataWithPalette(1)
+	if err != nil {
+		t.Fatalf("PixelDataWithPalette() error: %v", err)
+	}
+	expectedSecond := []uint16{17, 18, 19, 20, 17, 18, 19, 20}
+	for x, expected := range expectedSecond {
+		if pixelData[0][x] != expected {
+			t.Errorf("Palette 1 pixel %d: expected %d, got %d", x, expected, pixelData[0][x])
+		}
+	}
+
+	if _, err := timOutput.PixelDataWithPalette(2); err == nil {
+		t.Error("Expected error for palette out of range")
+	}
+}
+
+func TestLoadTIMStream_8BPP(t *testing.T) {
+	palette := make([]uint16, 256)
+	palette[0] = 0x7FFF
+	palette[255] = 0x001F
+	data := buildTestTIMBytes(t, TIM_BPP_8, [][]uint16{palette}, 1, 1, []byte{255, 0})
+
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+	if timOutput.PixelData[0][0] != 0x001F || timOutput.PixelData[0][1] != 0x7FFF {
+		t.Errorf("Unexpected pixels %v", timOutput.PixelData[0])
+	}
+	if timOutput.IndexData[0][0] != 255 {
+		t.Errorf("Expected index 255, got %d", timOutput.IndexData[0][0])
+	}
+}
+
+func TestLoadTIMStream_16BPP(t *testing.T) {
+	imageData := []byte{0x1F, 0x00, 0xE0, 0x83}
+	data := buildTestTIMBytes(t, TIM_BPP_16, nil, 2, 1, imageData)
+
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+	if timOutput.ImageWidth != 2 || timOutput.NumPalettes != 0 {
+		t.Fatalf("Expected 2 pixels without palettes, got %d pixels and %d palettes", timOutput.ImageWidth, timOutput.NumPalettes)
+	}
+	if timOutput.PixelData[0][0] != 0x001F || timOutput.PixelData[0][1] != 0x83E0 {
+		t.Errorf("Unexpected pixels %v", timOutput.PixelData[0])
+	}
+	if timOutput.NumBytes != len(data) {
+		t.Errorf("Expected %d bytes, got %d", len(data), timOutput.NumBytes)
+	}
+
+	if _, err := timOutput.PixelDataWithPalette(0); err == nil {
+		t.Error("Expected error when choosing a palette for a direct color image")
+	}
+
+	imageOutput, err := timOutput.ImageWithPalette(TIM_PALETTE_AUTO)
+	if err != nil {
+		t.Fatalf("ImageWithPalette() error: %v", err)
+	}
+	if c := imageOutput.RGBAAt(0, 0); c != (color.RGBA{248, 0, 0, 255}) {
+		t.Errorf("Expected red pixel, got %v", c)
+	}
+}
+
+func TestLoadTIMStream_24BPP(t *testing.T) {
+	// 3 pixels fit in 9 bytes, the row is padded to 10 bytes
+	imageData := []byte{
+		255, 0, 0,
+		0, 128, 255,
+		0, 0, 0,
+		0,
+	}
+	data := buildTestTIMBytes(t, TIM_BPP_24, nil, 5, 1, imageData)
+
+	timOutput, err := LoadTIMStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadTIMStream() error: %v", err)
+	}
+	if timOutput.ImageWidth != 3 {
+		t.Fatalf("Expected 3 pixels, got %d", timOutput.ImageWidth)
+	}
+	if timOutput.NumBytes != len(data) {
+		t.Errorf("Expected %d bytes, got %d", len(data), timOutput.NumBytes)
+	}
+
+	expectedPixels := []uint16{0x001F, 0x7E00, 0x8000}
+	for x, expected := range expectedPixels {
+		if timOutput.PixelData[0][x] != expected {
+			t.Errorf("Pixel %d: expected 0x%04X, got 0x%04X", x, expected, timOutput.PixelData[0][x])
+		}
+	}
+
+	imageOutput, err := timOutput.ImageWithPalette(TIM_PALETTE_AUTO)
+	if err != nil {
+		t.Fatalf("ImageWithPalette() error: %v", err)
+	}
+	if c := imageOutput.RGBAAt(1, 0); c != (color.RGBA{0, 128, 255, 255}) {
+		t.Errorf("Expected full color pixel, got %v", c)
+	}
+}
+
+func TestLoadTIMStream_MissingPalette(t *testing.T) {
+	data := buildTestTIMBytes(t, TIM_BPP_4&TIM_FLAG_PIXEL_MODE, nil, 1, 1, []byte{0, 0})
+	if _, err := LoadTIMStream(bytes.NewReader(data), int64(len(data))); err == nil {
+		t.Error("Expected error for 4 bit image without a clut")
+	}
+}
@@ -4,8 +4,9 @@ package fileio
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
-	"log"
+	"os"
 )
 
 type VABHeader struct {
@@ -61,7 +62,9 @@ type VABTone struct {
 
 type VABHeaderOutput struct {
 	VABHeader  VABHeader
-	AudioSizes []uint16
+	Programs   []VABProgram // all 128 program slots
+	Tones      [][]VABTone  // one block of 16 tones for each program in use
+	AudioSizes []uint16     // waveform sizes in units of 8 bytes, the first entry is always 0
 	NumBytes   int
 }
 
@@ -70,37 +73,80 @@ type VABDataOutput struct {
 	NumBytes     int
 }
 
+// LoadVABFiles loads a .vh header and its matching .vb waveform data
+func LoadVABFiles(headerFilename string, dataFilename string) (*VABHeaderOutput, *VABDataOutput, error) {
+	headerFile, err := os.Open(headerFilename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open VAB header file %s: %w", headerFilename, err)
+	}
+	defer headerFile.Close()
+
+	headerInfo, err := headerFile.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat VAB header file %s: %w", headerFilename, err)
+	}
+	vabHeaderOutput, err := LoadVABHeaderStream(headerFile, headerInfo.Size())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load VAB header file %s: %w", headerFilename, err)
+	}
+
+	dataFile, err := os.Open(dataFilename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open VAB data file %s: %w", dataFilename, err)
+	}
+	defer dataFile.Close()
+
+	dataInfo, err := dataFile.Stat()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to stat VAB data file %s: %w", dataFilename, err)
+	}
+	vabDataOutput, err := LoadVABDataStream(dataFile, dataInfo.Size(), vabHeaderOutput)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load VAB data file %s: %w", dataFilename, err)
+	}
+
+	return vabHeaderOutput, vabDataOutput, nil
+}
+
 func LoadVABHeaderStream(r io.ReaderAt, fileLength int64) (*VABHeaderOutput, error) {
 	vabHeaderReader := io.NewSectionReader(r, int64(0), fileLength)
 
 	vabHeader := VABHeader{}
 	if err := binary.Read(vabHeaderReader, binary.LittleEndian, &vabHeader); err != nil {
-		return nil, err
+		return nil, wrapReadError("VAB header", 0, err)
 	}
 
 	if string(vabHeader.Magic[:]) != "pBAV" {
-		log.Fatal("VAB header is invalid: ", vabHeader.Magic)
+		return nil, newFormatError("VAB header", 0, ErrBadMagic, "expected pBAV, got %q", vabHeader.Magic[:])
 	}
 	if vabHeader.ProgramCount > 128 {
-		log.Fatal("Too many programs: ", vabHeader.ProgramCount)
+		return nil, newFormatError("VAB header", 18, ErrInvalidValue, "too many programs: %d", vabHeader.ProgramCount)
 	}
 
+	programOffset := int64(32)
 	programData := make([]VABProgram, 128)
 	if err := binary.Read(vabHeaderReader, binary.LittleEndian, &programData); err != nil {
-		return nil, err
+		return nil, wrapReadError("VAB programs", programOffset, err)
 	}
 
+	toneOffset := programOffset + 128*16
+	toneData := make([][]VABTone, vabHeader.ProgramCount)
 	for i := 0; i < int(vabHeader.ProgramCount); i++ {
 		tones := make([]VABTone, 16)
 		if err := binary.Read(vabHeaderReader, binary.LittleEndian, &tones); err != nil {
-			return nil, err
+			return nil, wrapReadError("VAB tones", toneOffset+int64(i)*16*32, err)
 		}
+		toneData[i] = tones
 	}
 
-	audioSizes := make([]uint16, vabHeader.WaveformCount+1)
-	if err := binary.Read(vabHeaderReader, binary.LittleEndian, &audioSizes); err != nil {
+	audioSizeOffset := toneOffset + int64(vabHeader.ProgramCount)*16*32
+	if err := checkSectionSize("VAB waveform sizes", audioSizeOffset, (int64(vabHeader.WaveformCount)+1)*2, fileLength); err != nil {
 		return nil, err
 	}
+	audioSizes := make([]uint16, int(vabHeader.WaveformCount)+1)
+	if err := binary.Read(vabHeaderReader, binary.LittleEndian, &audioSizes); err != nil {
+		return nil, wrapReadError("VAB waveform sizes", audioSizeOffset, err)
+	}
 
 	headerSize := 32                                       // sizeof(VABHeader)
 	totalProgramSize := 128 * 16                           // 128 * sizeof(VABProgram)
@@ -109,6 +155,8 @@ func LoadVABHeaderStream(r io.ReaderAt, fileLength int64) (*VABHeaderOutput, err
 	totalVabHeaderSize := headerSize + totalProgramSize + totalToneSize + totalWaveformSize
 	vabHeaderOutput := &VABHeaderOutput{
 		VABHeader:  vabHeader,
+		Programs:   programData,
+		Tones:      toneData,
 		AudioSizes: audioSizes,
 		NumBytes:   totalVabHeaderSize,
 	}
@@ -126,9 +174,12 @@ func LoadVABDataStream(r io.ReaderAt, fileLength int64, vabHeaderOutput *VABHead
 			continue
 		}
 
+		if err := checkSectionSize("VAB waveform", int64(totalBytes), int64(rawAudioSize)*8, fileLength); err != nil {
+			return nil, err
+		}
 		adpcmData := make([]byte, rawAudioSize*8)
 		if err := binary.Read(vabDataReader, binary.LittleEndian, &adpcmData); err != nil {
-			return nil, err
+			return nil, wrapReadError("VAB waveform", int64(totalBytes), err)
 		}
 		rawADPCMData = append(rawADPCMData, adpcmData)
 		totalBytes += len(adpcmData)
@@ -141,3 +192,37 @@ func LoadVABDataStream(r io.ReaderAt, fileLength int64, vabHeaderOutput *VABHead
 
 	return vabDataOutput, nil
 }
+
+// ProgramTones returns the tones in use by a program.
+// Tone blocks are stored in order for each program that has at least one tone.
+func (vabHeaderOutput *VABHeaderOutput) ProgramTones(programIndex int) []VABTone {
+	if programIndex < 0 || programIndex >= len(vabHeaderOutput.Programs) {
+		return nil
+	}
+
+	blockIndex := 0
+	for i := 0; i < programIndex; i++ {
+		if vabHeaderOutput.Programs[i].Tones > 0 {
+			blockIndex++
+		}
+	}
+
+	numTones := int(vabHeaderOutput.Programs[programIndex].Tones)
+	if numTones == 0 || blockIndex >= len(vabHeaderOutput.Tones) {
+		return nil
+	}
+	if numTones > len(vabHeaderOutput.Tones[blockIndex]) {
+		numTones = len(vabHeaderOutput.Tones[blockIndex])
+	}
+	return vabHeaderOutput.Tones[blockIndex][:numTones]
+}
+
+// DecodeWaveforms converts every waveform into 16-bit PCM.
+// Waveform i corresponds to VAG number i+1 in VABTone.Vag.
+func (vabDataOutput *VABDataOutput) DecodeWaveforms() []*ADPCMSample {
+	samples := make([]*ADPCMSample, len(vabDataOutput.RawADPCMData))
+	for i, adpcmData := range vabDataOutput.RawADPCMData {
+		samples[i] = DecodeSPUADPCM(adpcmData)
+	}
+	return samples
+}
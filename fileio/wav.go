@@ -0,0 +1,124 @@
+package fileio
+
+// .wav - RIFF audio file
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+type WAVFormatChunk struct {
+	AudioFormat   uint16 // 1 = PCM
+	NumChannels   uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+type WAVSampleLoop struct {
+	CuePointId uint32
+	Type       uint32 // 0 = loop forward
+	Start      uint32 // first sample of the loop
+	End        uint32 // last sample of the loop
+	Fraction   uint32
+	PlayCount  uint32 // 0 = infinite
+}
+
+type WAVSamplerChunk struct {
+	Manufacturer      uint32
+	Product           uint32
+	SamplePeriod      uint32 // nanoseconds per sample
+	MIDIUnityNote     uint32
+	MIDIPitchFraction uint32
+	SMPTEFormat       uint32
+	SMPTEOffset       uint32
+	NumSampleLoops    uint32
+	SamplerData       uint32
+}
+
+// WriteWAV writes mono 16-bit PCM audio.
+// Looping samples also get a sampler chunk so audio tools can see the loop points.
+func (sample *ADPCMSample) WriteWAV(w io.Writer, sampleRate int) error {
+	body := new(bytes.Buffer)
+	body.WriteString("WAVE")
+
+	format := WAVFormatChunk{
+		AudioFormat:   1,
+		NumChannels:   1,
+		SampleRate:    uint32(sampleRate),
+		ByteRate:      uint32(sampleRate) * 2,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+	}
+	if err := writeRIFFChunk(body, "fmt ", format); err != nil {
+		return err
+	}
+
+	if err := writeRIFFChunk(body, "data", sample.PCMData); err != nil {
+		return err
+	}
+
+	if sample.Looping && sample.LoopEnd > sample.LoopStart {
+		samplerChunk := struct {
+			Sampler WAVSamplerChunk
+			Loop    WAVSampleLoop
+		}{
+			Sampler: WAVSamplerChunk{
+				SamplePeriod:   uint32(1000000000 / sampleRate),
+				MIDIUnityNote:  60,
+				NumSampleLoops: 1,
+			},
+			Loop: WAVSampleLoop{
+				Start: uint32(sample.LoopStart),
+				End:   uint32(sample.LoopEnd - 1),
+			},
+		}
+		if err := writeRIFFChunk(body, "smpl", samplerChunk); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.Write([]byte("RIFF")); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint32(body.Len())); err != nil {
+		return err
+	}
+	_, err := w.Write(body.Bytes())
+	return err
+}
+
+func (sample *ADPCMSample) ConvertToWAV(outputFilename string, sampleRate int) error {
+	outputFile, err := os.Create(outputFilename)
+	if err != nil {
+		return fmt.Errorf("failed to create WAV file %s: %w", outputFilename, err)
+	}
+	defer outputFile.Close()
+
+	if err := sample.WriteWAV(outputFile, sampleRate); err != nil {
+		return fmt.Errorf("failed to write WAV file %s: %w", outputFilename, err)
+	}
+	return nil
+}
+
+// writeRIFFChunk writes a chunk id, its size and the data padded to an even length
+func writeRIFFChunk(w *bytes.Buffer, chunkId string, data interface{}) error {
+	chunkData := new(bytes.Buffer)
+	if err := binary.Write(chunkData, binary.LittleEndian, data); err != nil {
+		return err
+	}
+
+	w.WriteString(chunkId)
+	if err := binary.Write(w, binary.LittleEndian, uint32(chunkData.Len())); err != nil {
+		return err
+	}
+	w.Write(chunkData.Bytes())
+	if chunkData.Len()%2 == 1 {
+		w.WriteByte(0)
+	}
+	return nil
+}
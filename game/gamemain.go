@@ -14,12 +14,17 @@ const (
 	DIFFICULTY_EASY   = 0
 	DIFFICULTY_NORMAL = 1
 
+	// System flags (bit array 0) checked by the room scripts
+	SYSTEM_FLAG_DIFFICULTY = 25
+	SYSTEM_FLAG_PLAYER     = 31 // 0 is Leon, 1 is Claire
+
 	GAME_LOAD_ROOM   = 0
 	GAME_LOAD_CAMERA = 1
 	GAME_LOOP        = 2
 )
 
 type GameDef struct {
+	PlayerId    int // PLAYER_LEON or PLAYER_CLAIRE
 	StageId     int
 	RoomId      int
 	CameraId    int
@@ -29,8 +34,9 @@ type GameDef struct {
 	Player      *Player
 }
 
-func NewGame(stageId int, roomId int, cameraId int) *GameDef {
+func NewGame(playerId int, stageId int, roomId int, cameraId int) *GameDef {
 	return &GameDef{
+		PlayerId:    playerId,
 		StageId:     stageId,
 		RoomId:      roomId,
 		CameraId:    cameraId,
@@ -1,7 +1,8 @@
 package game
 
 import (
-	"fmt"
+	"errors"
+	"log"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
 	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
@@ -25,7 +26,22 @@ func (gameDef *GameDef) NewRoomScript(rdtOutput *fileio.RDTOutput) RoomScript {
 func (g *GameDef) GetRoomFilename(playerNum int) string {
 	stage := g.StageId
 	roomNumber := g.RoomId
-	return fmt.Sprintf(resource.RDT_FILE, stage, roomNumber, playerNum)
+	return resource.PlayerRDTFile(playerNum, stage, roomNumber)
+}
+
+// LoadRoom reads the file of the current room and sets up its collision, cameras and scripts.
+// A script function with an unknown opcode is cut short instead of stopping the room from loading.
+func (gameDef *GameDef) LoadRoom() (*fileio.RDTOutput, error) {
+	rdtOutput, err := resource.LoadAsset(gameDef.GetRoomFilename(gameDef.PlayerId), fileio.LoadRDT)
+	if err != nil {
+		if rdtOutput == nil || !errors.Is(err, fileio.ErrUnknownOpcode) {
+			return nil, err
+		}
+		log.Printf("Warning: %v", err)
+	}
+	gameDef.RoomScript = gameDef.NewRoomScript(rdtOutput)
+	gameDef.GameWorld.LoadNewRoom(rdtOutput)
+	return rdtOutput, nil
 }
 
 func (g *GameDef) GetBackgroundImageNumber() int {
@@ -35,6 +51,15 @@ func (g *GameDef) GetBackgroundImageNumber() int {
 	return ((stage - 1) * 512) + (roomNumber * 16) + cameraNum
 }
 
+// GetBackgroundImageLocation finds the stage, room and camera of an image in roomcut.bin
+// It is the inverse of GetBackgroundImageNumber
+func GetBackgroundImageLocation(imageNumber int) (stageId int, roomId int, cameraId int) {
+	stageId = (imageNumber / 512) + 1
+	roomId = (imageNumber % 512) / 16
+	cameraId = imageNumber % 16
+	return stageId, roomId, cameraId
+}
+
 func (gameDef *GameDef) NextRoom() {
 	gameDef.CameraId = 0
 	gameDef.RoomId++
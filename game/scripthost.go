@@ -0,0 +1,60 @@
+package game
+
+import (
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The game state the room scripts change, such as the player, the AOTs and the camera
+
+func (gameDef *GameDef) SetPlayerPosition(position mgl32.Vec3) {
+	gameDef.Player.Position = position
+}
+
+func (gameDef *GameDef) SetPlayerRotation(rotationAngle float32) {
+	gameDef.Player.RotationAngle = rotationAngle
+}
+
+func (gameDef *GameDef) AddAotTrigger(instruction fileio.ScriptInstrAotSet) {
+	gameDef.GameWorld.AotManager.AddAotTrigger(instruction)
+}
+
+func (gameDef *GameDef) AddAotTrigger4p(instruction fileio.ScriptInstrAotSet4p) {
+	gameDef.GameWorld.AotManager.AddAotTrigger4p(instruction)
+}
+
+func (gameDef *GameDef) AddDoorAot(instruction fileio.ScriptInstrDoorAotSet) {
+	gameDef.GameWorld.AotManager.AddDoorAot(instruction)
+}
+
+func (gameDef *GameDef) AddDoorAot4p(instruction fileio.ScriptInstrDoorAotSet4p) {
+	gameDef.GameWorld.AotManager.AddDoorAot4p(instruction)
+}
+
+func (gameDef *GameDef) AddItemAot(instruction fileio.ScriptInstrItemAotSet) {
+	gameDef.GameWorld.AotManager.AddItemAot(instruction)
+}
+
+func (gameDef *GameDef) AddItemAot4p(instruction fileio.ScriptInstrItemAotSet4p) {
+	gameDef.GameWorld.AotManager.AddItemAot4p(instruction)
+}
+
+func (gameDef *GameDef) ResetAotTrigger(instruction fileio.ScriptInstrAotReset) {
+	gameDef.GameWorld.AotManager.ResetAotTrigger(instruction)
+}
+
+func (gameDef *GameDef) RemoveCollisionEntity(entityId int) {
+	world.RemoveCollisionEntity(gameDef.GameWorld.GameRoom.CollisionEntities, entityId)
+}
+
+// There is no audio or message window yet, so these do nothing.
+// The script debugger shows the instructions if they are needed.
+
+func (gameDef *GameDef) ControlBgm(instruction fileio.ScriptInstrSceBgmControl) {}
+
+func (gameDef *GameDef) PlaySoundEffect(instruction fileio.ScriptInstrSeOn) {}
+
+func (gameDef *GameDef) PlayVoice(instruction fileio.ScriptInstrXaOn) {}
+
+func (gameDef *GameDef) ShowMessage(instruction fileio.ScriptInstrMessageOn) {}
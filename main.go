@@ -1,14 +1,18 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
 	"runtime"
+	"strings"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/client"
 	"github.com/OpenBiohazard2/OpenBiohazard2/game"
 	"github.com/OpenBiohazard2/OpenBiohazard2/render"
 	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+	"github.com/OpenBiohazard2/OpenBiohazard2/script"
 	"github.com/OpenBiohazard2/OpenBiohazard2/state"
 	"github.com/OpenBiohazard2/OpenBiohazard2/ui"
 	"github.com/OpenBiohazard2/OpenBiohazard2/ui_render"
@@ -20,9 +24,40 @@ const (
 	WINDOW_HEIGHT = 768
 )
 
+// modFlags collects every --mod folder in the order given
+type modFlags []string
+
+func (m *modFlags) String() string {
+	return strings.Join(*m, ",")
+}
+
+func (m *modFlags) Set(value string) error {
+	*m = append(*m, value)
+	return nil
+}
+
 func main() {
+	var mods modFlags
+	dataPath := flag.String("data", ".", "folder or .zip archive containing the game data folder")
+	flag.Var(&mods, "mod", "folder with replacement game files, can be repeated (later mods take priority)")
+	playerName := flag.String("player", "leon", "character to play as (leon or claire)")
+	flagNamesFile := flag.String("flag-names", "", "file naming script flags and variables, shown by the script debugger")
+	scriptDebug := flag.String("script-debug", "", "attach the script debugger on a TCP address such as localhost:4000, or stdin")
+	flag.Parse()
+
+	playerId, err := parsePlayer(*playerName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assetFS, err := resource.OpenAssetFS(*dataPath, mods...)
+	if err != nil {
+		log.Fatal("Failed to open game data: ", err)
+	}
+	resource.SetAssetFS(assetFS)
+
 	fmt.Println("Validating game folders exist...")
-	if err := resource.ValidateFilesExist(); err != nil {
+	if err := resource.ValidateFilesExist(playerId); err != nil {
 		log.Fatal("File validation failed: ", err)
 	}
 	fmt.Println("Validated game folders exist")
@@ -36,20 +71,59 @@ func main() {
 	windowHandler := client.NewWindowHandler(WINDOW_WIDTH, WINDOW_HEIGHT, "OpenBiohazard2")
 
 	// Initialize game components
-	renderDef, gameDef, gameStateManager := initializeGame()
+	renderDef, gameDef, gameStateManager := initializeGame(playerId)
 
 	// Create all state inputs
 	stateInputs := createStateInputs(renderDef, gameDef)
+	debugOptions := script.ScriptDebugOptions{}
+	if *flagNamesFile != "" {
+		names, err := script.LoadFlagNamesFile(*flagNamesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		debugOptions.FlagNames = names
+	}
+	if *scriptDebug != "" {
+		if err := startScriptDebugServer(stateInputs["mainGame"].(*state.MainGameStateInput), *scriptDebug, debugOptions); err != nil {
+			log.Fatal("Failed to start script debugger: ", err)
+		}
+	}
 
 	// Run the main game loop
 	runMainGameLoop(windowHandler, gameStateManager, stateInputs, renderDef)
 }
 
+// parsePlayer converts the --player flag to a player id
+func parsePlayer(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "leon":
+		return game.PLAYER_LEON, nil
+	case "claire":
+		return game.PLAYER_CLAIRE, nil
+	}
+	return 0, fmt.Errorf("unknown player %q, expected leon or claire", name)
+}
+
+// startScriptDebugServer lets a debugger client stop and step the room scripts while the game runs
+func startScriptDebugServer(mainGameStateInput *state.MainGameStateInput, address string, options script.ScriptDebugOptions) error {
+	server := script.NewDebugServer(mainGameStateInput.ScriptDef, options)
+	if address == "stdin" {
+		go server.Serve(os.Stdin, os.Stdout)
+	} else {
+		if err := server.Listen(address); err != nil {
+			return err
+		}
+		fmt.Println("Script debugger listening on", address)
+	}
+	mainGameStateInput.ScriptDebugServer = server
+	return nil
+}
+
 // initializeGame sets up the core game components
-func initializeGame() (*render.RenderDef, *game.GameDef, *state.GameStateManager) {
+func initializeGame(playerId int) (*render.RenderDef, *game.GameDef, *state.GameStateManager) {
 	renderDef := render.InitRenderer(WINDOW_WIDTH, WINDOW_HEIGHT)
 
-	gameDef := game.NewGame(1, 0, 0)
+	gameDef := game.NewGame(playerId, 1, 0, 0)
 	gameDef.Player = game.NewPlayer(game.DebugLocations[game.RoomMapKey{StageId: gameDef.StageId, RoomId: gameDef.RoomId}], 180)
 
 	gameStateManager := state.NewGameStateManager()
@@ -71,7 +145,7 @@ func createStateInputs(renderDef *render.RenderDef, gameDef *game.GameDef) map[s
 			UIRenderer: ui_render.NewUIRenderer(renderDef),
 			Menu:       ui.NewMenu(2),
 		},
-		"inventory": state.NewInventoryStateInput(renderDef),
+		"inventory": state.NewInventoryStateInput(renderDef, gameDef.PlayerId),
 	}
 }
 
@@ -1,5 +1,9 @@
 package render
 
+import (
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
 type EnemyGroupEntity struct {
 	EnemyEntities []*EnemyEntity
 }
@@ -27,3 +31,9 @@ func (ege *EnemyGroupEntity) ClearEnemies() {
 func (ege *EnemyGroupEntity) GetEnemyCount() int {
 	return len(ege.EnemyEntities)
 }
+
+func (renderDef *RenderDef) AddEnemy(instruction fileio.ScriptInstrSceEmSet, emdOutput *fileio.EMDOutput) {
+	enemyEntity := NewEnemyEntity(emdOutput)
+	enemyEntity.SetEnemyData(instruction)
+	renderDef.SceneSystem.EnemyGroupEntity.AddEnemy(enemyEntity)
+}
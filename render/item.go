@@ -63,3 +63,7 @@ func (renderDef *RenderDef) SetItemEntity(instruction fileio.ScriptInstrObjModel
 	itemEntity.RotationAngle = rotationAngle
 	renderDef.SceneSystem.ItemGroupEntity.ModelObjectData[modelIndex] = itemEntity
 }
+
+func (renderDef *RenderDef) SetItemRotation(modelIndex int, rotationAngle float32) {
+	renderDef.SceneSystem.ItemGroupEntity.ModelObjectData[modelIndex].RotationAngle = rotationAngle
+}
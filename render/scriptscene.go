@@ -0,0 +1,55 @@
+package render
+
+import (
+	"fmt"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+)
+
+// ScriptScene draws what the room scripts add to the scene, such as objects, enemies and sprites
+type ScriptScene struct {
+	renderDef *RenderDef
+	playerId  int
+}
+
+func NewScriptScene(renderDef *RenderDef, playerId int) *ScriptScene {
+	return &ScriptScene{
+		renderDef: renderDef,
+		playerId:  playerId,
+	}
+}
+
+func (scene *ScriptScene) SetObjectModel(instruction fileio.ScriptInstrObjModelSet) {
+	scene.renderDef.SetItemEntity(instruction)
+}
+
+func (scene *ScriptScene) SetObjectRotation(objectIndex int, rotationAngle float32) {
+	scene.renderDef.SetItemRotation(objectIndex, rotationAngle)
+}
+
+func (scene *ScriptScene) AddEnemy(instruction fileio.ScriptInstrSceEmSet) {
+	// Load the EMD file based on the enemy type from the current player's folder
+	enemyEMDPath := resource.PlayerEnemyFile(scene.playerId, int(instruction.Type))
+
+	// Load the enemy model data
+	emdOutput, err := resource.LoadAsset(enemyEMDPath, fileio.LoadEMDStream)
+	if err != nil {
+		// Only log failures for debugging purposes
+		fmt.Printf("Failed to load enemy model for type 0x%03X: %v\n", instruction.Type, err)
+		return
+	}
+	scene.renderDef.AddEnemy(instruction, emdOutput)
+
+	// Log enemy creation since there won't be too many enemies
+	fmt.Printf("Created enemy type 0x%03X at position (%d, %d, %d)\n",
+		instruction.Type, instruction.X, instruction.Y, instruction.Z)
+}
+
+func (scene *ScriptScene) AddSprite(sprite fileio.ScriptInstrSceEsprOn) {
+	scene.renderDef.AddSprite(sprite)
+}
+
+func (scene *ScriptScene) RemoveSprite(instruction fileio.ScriptInstrSceEsprKill) {
+	scene.renderDef.RemoveSprite(int(instruction.Id))
+}
@@ -21,10 +21,17 @@ type SpriteGroupEntity struct {
 	SpriteTextureIndexMap map[int]int
 	TextureIdPool         [][]uint32
 	VertexBuffer          []float32
+	Sprites               []SpriteInstance // In the same order as their vertices in VertexBuffer
 	VertexArrayObject     uint32
 	VertexBufferObject    uint32
 }
 
+// A sprite added by a room script
+type SpriteInstance struct {
+	Id          int
+	VertexCount int // Number of floats in the vertex buffer
+}
+
 func NewSpriteGroupEntity(spriteData []fileio.SpriteData) *SpriteGroupEntity {
 	spriteTextureIds := make([][]uint32, 0)
 	for i := 0; i < len(spriteData); i++ {
@@ -47,6 +54,7 @@ func NewSpriteGroupEntity(spriteData []fileio.SpriteData) *SpriteGroupEntity {
 		SpriteTextureIndexMap: spriteTextureIndexMap,
 		TextureIdPool:         spriteTextureIds,
 		VertexBuffer:          make([]float32, 0),
+		Sprites:               make([]SpriteInstance, 0),
 		VertexArrayObject:     vao,
 		VertexBufferObject:    vbo,
 	}
@@ -108,7 +116,32 @@ func (renderDef *RenderDef) AddSprite(sprite fileio.ScriptInstrSceEsprOn) {
 
 	// Generate billboard sprite using geometry package
 	rect := geometry.NewBillboardSprite(spriteCenter, spriteWidth, viewMatrix)
-	renderDef.SceneSystem.SpriteGroupEntity.VertexBuffer = append(renderDef.SceneSystem.SpriteGroupEntity.VertexBuffer, rect.VertexBuffer...)
+	renderDef.SceneSystem.SpriteGroupEntity.AddSpriteVertices(int(sprite.Id), rect.VertexBuffer)
+}
+
+func (renderDef *RenderDef) RemoveSprite(spriteId int) {
+	renderDef.SceneSystem.SpriteGroupEntity.RemoveSprite(spriteId)
+}
+
+func (spriteGroupEntity *SpriteGroupEntity) AddSpriteVertices(spriteId int, vertexBuffer []float32) {
+	spriteGroupEntity.VertexBuffer = append(spriteGroupEntity.VertexBuffer, vertexBuffer...)
+	spriteGroupEntity.Sprites = append(spriteGroupEntity.Sprites, SpriteInstance{Id: spriteId, VertexCount: len(vertexBuffer)})
+}
+
+// Removes every sprite with the id and its vertices
+func (spriteGroupEntity *SpriteGroupEntity) RemoveSprite(spriteId int) {
+	vertexBuffer := make([]float32, 0, len(spriteGroupEntity.VertexBuffer))
+	sprites := make([]SpriteInstance, 0, len(spriteGroupEntity.Sprites))
+	offset := 0
+	for _, sprite := range spriteGroupEntity.Sprites {
+		if sprite.Id != spriteId {
+			vertexBuffer = append(vertexBuffer, spriteGroupEntity.VertexBuffer[offset:offset+sprite.VertexCount]...)
+			sprites = append(sprites, sprite)
+		}
+		offset += sprite.VertexCount
+	}
+	spriteGroupEntity.VertexBuffer = vertexBuffer
+	spriteGroupEntity.Sprites = sprites
 }
 
 func RenderSprites(r *RenderDef, spriteGroupEntity *SpriteGroupEntity, timeElapsedSeconds float64) {
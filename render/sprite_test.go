@@ -0,0 +1,26 @@
+package render
+
+import "testing"
+
+func TestSpriteGroupEntity_RemoveSprite(t *testing.T) {
+	spriteGroupEntity := &SpriteGroupEntity{}
+	spriteGroupEntity.AddSpriteVertices(1, []float32{1, 1})
+	spriteGroupEntity.AddSpriteVertices(2, []float32{2, 2, 2})
+	spriteGroupEntity.AddSpriteVertices(1, []float32{1})
+	spriteGroupEntity.AddSpriteVertices(3, []float32{3, 3})
+
+	spriteGroupEntity.RemoveSprite(1)
+
+	expected := []float32{2, 2, 2, 3, 3}
+	if len(spriteGroupEntity.VertexBuffer) != len(expected) {
+		t.Fatalf("Expected vertices %v, got %v", expected, spriteGroupEntity.VertexBuffer)
+	}
+	for i := range expected {
+		if spriteGroupEntity.VertexBuffer[i] != expected[i] {
+			t.Fatalf("Expected vertices %v, got %v", expected, spriteGroupEntity.VertexBuffer)
+		}
+	}
+	if len(spriteGroupEntity.Sprites) != 2 || spriteGroupEntity.Sprites[0].Id != 2 || spriteGroupEntity.Sprites[1].Id != 3 {
+		t.Errorf("Expected sprites 2 and 3 to remain, got %v", spriteGroupEntity.Sprites)
+	}
+}
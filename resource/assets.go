@@ -0,0 +1,303 @@
+package resource
+
+// Asset sources for the game data
+// The game files are looked up case-insensitively, because the data folder
+// names differ between releases (data/Pl0/Rdt vs DATA/PL0/RDT)
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// All game assets are loaded from here
+// Defaults to the current directory, which is expected to contain the data folder
+var assetFS fs.FS = NewCaseInsensitiveFS(os.DirFS("."))
+
+// SetAssetFS replaces the source used by LoadAsset and OpenAsset
+func SetAssetFS(fsys fs.FS) {
+	assetFS = fsys
+}
+
+// AssetFS returns the source used by LoadAsset and OpenAsset
+func AssetFS() fs.FS {
+	return assetFS
+}
+
+// OpenAssetFS builds the asset source from the game data and a list of mod folders.
+// The game data is either a directory or a .zip archive containing the data folder.
+// Mods are directories with the same layout as the game data. A file in a mod replaces
+// the file with the same name in the game data, and later mods take priority over earlier ones.
+func OpenAssetFS(dataPath string, modPaths ...string) (fs.FS, error) {
+	baseFS, err := openDataFS(dataPath)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := make([]fs.FS, 0, len(modPaths)+1)
+	for i := len(modPaths) - 1; i >= 0; i-- {
+		info, err := os.Stat(modPaths[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to open mod folder %s: %w", modPaths[i], err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("mod %s is not a folder", modPaths[i])
+		}
+		layers = append(layers, NewCaseInsensitiveFS(os.DirFS(modPaths[i])))
+	}
+	layers = append(layers, baseFS)
+
+	if len(layers) == 1 {
+		return baseFS, nil
+	}
+	return NewOverlayFS(layers...), nil
+}
+
+func openDataFS(dataPath string) (fs.FS, error) {
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open game data %s: %w", dataPath, err)
+	}
+	if info.IsDir() {
+		return NewCaseInsensitiveFS(os.DirFS(dataPath)), nil
+	}
+
+	if !strings.EqualFold(filepath.Ext(dataPath), ".zip") {
+		return nil, fmt.Errorf("game data %s must be a folder or a .zip archive", dataPath)
+	}
+	// The archive stays open while the game is running
+	zipReader, err := zip.OpenReader(dataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open game data archive %s: %w", dataPath, err)
+	}
+	return NewCaseInsensitiveFS(zipReader), nil
+}
+
+// Asset is an open file from an asset source
+type Asset struct {
+	io.ReaderAt
+	Size   int64
+	closer io.Closer
+}
+
+func (asset *Asset) Close() error {
+	if asset.closer == nil {
+		return nil
+	}
+	return asset.closer.Close()
+}
+
+// OpenAsset opens a file from the asset source for random access
+func OpenAsset(name string) (*Asset, error) {
+	return OpenAssetFrom(assetFS, name)
+}
+
+// OpenAssetFrom opens a file from fsys for random access.
+// Files that can't be read at an offset, such as compressed zip entries, are read into memory.
+func OpenAssetFrom(fsys fs.FS, name string) (*Asset, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		file.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		return &Asset{ReaderAt: readerAt, Size: info.Size(), closer: file}, nil
+	}
+
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return &Asset{ReaderAt: bytes.NewReader(data), Size: int64(len(data))}, nil
+}
+
+// LoadAsset opens a file from the asset source and decodes it with one of the fileio stream loaders
+func LoadAsset[T any](name string, load func(r io.ReaderAt, fileLength int64) (T, error)) (T, error) {
+	return LoadAssetFrom(assetFS, name, load)
+}
+
+// LoadAssetFrom opens a file from fsys and decodes it with one of the fileio stream loaders
+func LoadAssetFrom[T any](fsys fs.FS, name string, load func(r io.ReaderAt, fileLength int64) (T, error)) (T, error) {
+	var empty T
+	asset, err := OpenAssetFrom(fsys, name)
+	if err != nil {
+		return empty, fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer asset.Close()
+
+	// Some loaders return what they could read along with the error
+	output, err := load(asset, asset.Size)
+	if err != nil {
+		return output, fmt.Errorf("failed to load %s: %w", name, err)
+	}
+	return output, nil
+}
+
+// LoadLocalFile decodes a file given on the command line.
+// Only the file name is matched case-insensitively, the folder has to exist as written.
+func LoadLocalFile[T any](filename string, load func(r io.ReaderAt, fileLength int64) (T, error)) (T, error) {
+	fsys := NewCaseInsensitiveFS(os.DirFS(filepath.Dir(filename)))
+	return LoadAssetFrom(fsys, filepath.Base(filename), load)
+}
+
+// OpenLocalFile opens a file given on the command line for random access
+func OpenLocalFile(filename string) (*Asset, error) {
+	fsys := NewCaseInsensitiveFS(os.DirFS(filepath.Dir(filename)))
+	return OpenAssetFrom(fsys, filepath.Base(filename))
+}
+
+// LocalFileExists checks if a file given on the command line exists, ignoring the case of the file name
+func LocalFileExists(filename string) bool {
+	fsys := NewCaseInsensitiveFS(os.DirFS(filepath.Dir(filename)))
+	info, err := fs.Stat(fsys, filepath.Base(filename))
+	return err == nil && !info.IsDir()
+}
+
+type caseInsensitiveFS struct {
+	fsys fs.FS
+}
+
+// NewCaseInsensitiveFS wraps fsys so each part of a path matches
+// a directory entry regardless of case. An exact match is always tried first.
+func NewCaseInsensitiveFS(fsys fs.FS) fs.FS {
+	return &caseInsensitiveFS{fsys: fsys}
+}
+
+func (c *caseInsensitiveFS) Open(name string) (fs.File, error) {
+	realName, err := c.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return c.fsys.Open(realName)
+}
+
+func (c *caseInsensitiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	realName, err := c.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(c.fsys, realName)
+}
+
+func (c *caseInsensitiveFS) Stat(name string) (fs.FileInfo, error) {
+	realName, err := c.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(c.fsys, realName)
+}
+
+// resolve converts name to the path with the case used by the underlying file system
+func (c *caseInsensitiveFS) resolve(op string, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := fs.Stat(c.fsys, name); err == nil {
+		return name, nil
+	}
+
+	realName := "."
+	for _, part := range strings.Split(name, "/") {
+		entries, err := fs.ReadDir(c.fsys, realName)
+		if err != nil {
+			return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		found := ""
+		for _, entry := range entries {
+			if entry.Name() == part {
+				found = part
+				break
+			}
+			if found == "" && strings.EqualFold(entry.Name(), part) {
+				found = entry.Name()
+			}
+		}
+		if found == "" {
+			return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+		}
+		realName = path.Join(realName, found)
+	}
+	return realName, nil
+}
+
+type overlayFS struct {
+	layers []fs.FS
+}
+
+// NewOverlayFS stacks file systems on top of each other.
+// A file is read from the first layer that has it, and directory listings are merged.
+func NewOverlayFS(layers ...fs.FS) fs.FS {
+	return &overlayFS{layers: layers}
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, layer := range o.layers {
+		file, err := layer.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	found := false
+	entriesByName := make(map[string]fs.DirEntry)
+	for _, layer := range o.layers {
+		entries, err := fs.ReadDir(layer, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, entry := range entries {
+			key := strings.ToLower(entry.Name())
+			if _, exists := entriesByName[key]; !exists {
+				entriesByName[key] = entry
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(entriesByName))
+	for _, entry := range entriesByName {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
@@ -0,0 +1,130 @@
+package resource
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func readAssetString(t *testing.T, fsys fs.FS, name string) string {
+	t.Helper()
+	text, err := LoadAssetFrom(fsys, name, func(r io.ReaderAt, fileLength int64) (string, error) {
+		data := make([]byte, fileLength)
+		if _, err := r.ReadAt(data, 0); err != nil && err != io.EOF {
+			return "", err
+		}
+		return string(data), nil
+	})
+	if err != nil {
+		t.Fatalf("LoadAssetFrom(%q) failed: %v", name, err)
+	}
+	return text
+}
+
+func TestCaseInsensitiveFS(t *testing.T) {
+	fsys := NewCaseInsensitiveFS(fstest.MapFS{
+		"DATA/PL0/RDT/ROOM1000.RDT":   {Data: []byte("room")},
+		"DATA/Common/bin/roomcut.bin": {Data: []byte("roomcut")},
+	})
+
+	tests := []struct {
+		name     string
+		filename string
+		expected string
+	}{
+		{name: "Exact_case", filename: "DATA/PL0/RDT/ROOM1000.RDT", expected: "room"},
+		{name: "Mixed_case", filename: "data/Pl0/Rdt/room1000.rdt", expected: "room"},
+		{name: "Upper_case", filename: "DATA/COMMON/BIN/ROOMCUT.BIN", expected: "roomcut"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if text := readAssetString(t, fsys, test.filename); text != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, text)
+			}
+		})
+	}
+
+	if _, err := fs.Stat(fsys, "data/Pl0/Rdt/ROOM1010.RDT"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected ErrNotExist for a missing file, got %v", err)
+	}
+	if _, err := fsys.Open("../data"); !errors.Is(err, fs.ErrInvalid) {
+		t.Errorf("expected ErrInvalid for a path outside the root, got %v", err)
+	}
+}
+
+func TestCaseInsensitiveFS_PrefersExactMatch(t *testing.T) {
+	fsys := NewCaseInsensitiveFS(fstest.MapFS{
+		"data/EM010.TIM": {Data: []byte("upper")},
+		"data/em010.tim": {Data: []byte("lower")},
+	})
+	if text := readAssetString(t, fsys, "data/em010.tim"); text != "lower" {
+		t.Errorf("expected the exact match, got %q", text)
+	}
+}
+
+func TestCaseInsensitiveFS_Zip(t *testing.T) {
+	buffer := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(buffer)
+	fileWriter, err := zipWriter.Create("DATA/PL0/EMD0/EM010.EMD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileWriter.Write([]byte("enemy"))
+	if err := zipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := NewCaseInsensitiveFS(zipReader)
+	if text := readAssetString(t, fsys, "data/Pl0/Emd0/em010.emd"); text != "enemy" {
+		t.Errorf("expected %q, got %q", "enemy", text)
+	}
+}
+
+func TestOverlayFS(t *testing.T) {
+	base := NewCaseInsensitiveFS(fstest.MapFS{
+		"data/Pl0/Rdt/ROOM1000.RDT": {Data: []byte("original 1000")},
+		"data/Pl0/Rdt/ROOM1010.RDT": {Data: []byte("original 1010")},
+	})
+	mod := NewCaseInsensitiveFS(fstest.MapFS{
+		"DATA/PL0/RDT/room1000.rdt": {Data: []byte("modded 1000")},
+		"DATA/PL0/RDT/ROOM1020.RDT": {Data: []byte("new 1020")},
+	})
+	fsys := NewOverlayFS(mod, base)
+
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{filename: "data/Pl0/Rdt/ROOM1000.RDT", expected: "modded 1000"},
+		{filename: "data/Pl0/Rdt/ROOM1010.RDT", expected: "original 1010"},
+		{filename: "data/Pl0/Rdt/ROOM1020.RDT", expected: "new 1020"},
+	}
+	for _, test := range tests {
+		if text := readAssetString(t, fsys, test.filename); text != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.filename, test.expected, text)
+		}
+	}
+
+	entries, err := fs.ReadDir(fsys, "data/Pl0/Rdt")
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 merged entries, got %d", len(entries))
+	}
+}
+
+func TestOpenAssetFrom_Directory(t *testing.T) {
+	fsys := fstest.MapFS{"data/Pl0/Rdt": {Mode: fs.ModeDir}}
+	if _, err := OpenAssetFrom(fsys, "data/Pl0/Rdt"); err == nil {
+		t.Error("expected an error when opening a folder")
+	}
+}
@@ -1,6 +1,7 @@
 package resource
 
 import (
+	"io"
 	"log"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
@@ -8,7 +9,7 @@ import (
 
 // LoadTIMImages loads multiple TIM images and converts them to Image16Bit
 func LoadTIMImages(filename string) []*Image16Bit {
-	timOutputs, err := fileio.LoadTIMImages(filename)
+	timOutputs, err := LoadAsset(filename, fileio.LoadTIMImagesStream)
 	if err != nil {
 		log.Fatal("Error loading TIM images: ", err)
 	}
@@ -23,7 +24,9 @@ func LoadTIMImages(filename string) []*Image16Bit {
 
 // LoadADTImage loads a single ADT image and converts it to Image16Bit
 func LoadADTImage(filename string) *Image16Bit {
-	adtOutput, err := fileio.LoadADTFile(filename)
+	adtOutput, err := LoadAsset(filename, func(r io.ReaderAt, fileLength int64) (*fileio.ADTOutput, error) {
+		return fileio.LoadADTStream(io.NewSectionReader(r, 0, fileLength))
+	})
 	if err != nil {
 		log.Fatal("Error loading ADT image: ", err)
 	}
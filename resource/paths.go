@@ -1,5 +1,7 @@
 package resource
 
+import "fmt"
+
 const (
 	BASE_FOLDER         = "data/"
 	COMMON_FOLDER       = BASE_FOLDER + "Common/"
@@ -10,9 +12,9 @@ const (
 	ESPDATA2_FILE       = COMMON_BIN_FOLDER + "espdat2.bin"
 	COMMON_DOOR_FOLDER  = COMMON_FOLDER + "Door/"
 	DOOR_FILE           = COMMON_DOOR_FOLDER + "Door%02x.DO2"
-	PL_FOLDER           = BASE_FOLDER + "Pl0/"
-	LEON_MODEL_FILE     = PL_FOLDER + "PLD/PL00.PLD"
-	ENEMY_FILE          = PL_FOLDER + "Emd0/EM%03x.EMD"
+	PL_FOLDER           = BASE_FOLDER + "Pl%d/"
+	PLAYER_MODEL_FILE   = PL_FOLDER + "PLD/PL%d0.PLD"
+	ENEMY_FILE          = PL_FOLDER + "Emd%d/EM%d%02x.EMD"
 	RDT_FOLDER          = PL_FOLDER + "RDP/"
 	RDT_FILE            = RDT_FOLDER + "ROOM%01d%02x%01d.RDT"
 	COMMON_DATA_FOLDER  = COMMON_FOLDER + "DATP/"
@@ -24,3 +26,28 @@ const (
 	SAVE_SCREEN_FILE    = COMMON_DATA_FOLDER + "type00.adt"
 	COMMON_SOUND_FOLDER = BASE_FOLDER + "Common/Sound/"
 )
+
+// Each player has their own copy of the rooms, models and enemies
+// Leon's files are in Pl0 and Claire's files are in Pl1
+
+func PlayerFolder(playerNum int) string {
+	return fmt.Sprintf(PL_FOLDER, playerNum)
+}
+
+func PlayerRDTFolder(playerNum int) string {
+	return fmt.Sprintf(RDT_FOLDER, playerNum)
+}
+
+// stage starts from 1
+// room number is a hex from 0
+func PlayerRDTFile(playerNum int, stage int, roomNumber int) string {
+	return fmt.Sprintf(RDT_FILE, playerNum, stage, roomNumber, playerNum)
+}
+
+func PlayerModelFile(playerNum int) string {
+	return fmt.Sprintf(PLAYER_MODEL_FILE, playerNum, playerNum)
+}
+
+func PlayerEnemyFile(playerNum int, enemyType int) string {
+	return fmt.Sprintf(ENEMY_FILE, playerNum, playerNum, playerNum, enemyType)
+}
@@ -0,0 +1,25 @@
+package resource
+
+import "testing"
+
+func TestPlayerPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   string
+		expected string
+	}{
+		{name: "Leon_RDT", actual: PlayerRDTFile(0, 1, 0x0a), expected: "data/Pl0/RDP/ROOM10a0.RDT"},
+		{name: "Claire_RDT", actual: PlayerRDTFile(1, 2, 0x10), expected: "data/Pl1/RDP/ROOM2101.RDT"},
+		{name: "Leon_model", actual: PlayerModelFile(0), expected: "data/Pl0/PLD/PL00.PLD"},
+		{name: "Claire_model", actual: PlayerModelFile(1), expected: "data/Pl1/PLD/PL10.PLD"},
+		{name: "Leon_enemy", actual: PlayerEnemyFile(0, 0x10), expected: "data/Pl0/Emd0/EM010.EMD"},
+		{name: "Claire_enemy", actual: PlayerEnemyFile(1, 0x10), expected: "data/Pl1/Emd1/EM110.EMD"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, test.actual)
+			}
+		})
+	}
+}
@@ -1,9 +1,10 @@
 package resource
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"path"
 )
 
 // ValidationResult contains the results of file validation
@@ -14,10 +15,10 @@ type ValidationResult struct {
 	Error      error
 }
 
-// ValidateFilesExist validates that all required game data folders and files exist
-func ValidateFilesExist() error {
+// ValidateFilesExist validates that all required game data folders and files exist in the asset source
+func ValidateFilesExist(playerNum int) error {
 	// Validate core folders
-	coreFolders := []string{BASE_FOLDER, COMMON_FOLDER, COMMON_BIN_FOLDER, COMMON_DOOR_FOLDER, PL_FOLDER}
+	coreFolders := []string{BASE_FOLDER, COMMON_FOLDER, COMMON_BIN_FOLDER, COMMON_DOOR_FOLDER, PlayerFolder(playerNum)}
 	for _, folder := range coreFolders {
 		if err := validateFolder(folder, "core"); err != nil {
 			return err
@@ -26,7 +27,7 @@ func ValidateFilesExist() error {
 
 	// Validate region-specific folders
 	regionFolders := map[string]string{
-		"RDT_FOLDER":         RDT_FOLDER,
+		"RDT_FOLDER":         PlayerRDTFolder(playerNum),
 		"COMMON_DATA_FOLDER": COMMON_DATA_FOLDER,
 	}
 	for _, folder := range regionFolders {
@@ -41,7 +42,7 @@ func ValidateFilesExist() error {
 		ITEMDATA_FILE,
 		ESPDATA1_FILE,
 		ESPDATA2_FILE,
-		LEON_MODEL_FILE,
+		PlayerModelFile(playerNum),
 		CORE_SPRITE_FILE,
 		INVENTORY_FILE,
 		MENU_IMAGE_FILE,
@@ -67,7 +68,7 @@ func validateFolder(folderPath, folderType string) error {
 		return fmt.Errorf("missing folder %s", folderPath)
 	}
 
-	files, err := os.ReadDir(folderPath)
+	files, err := fs.ReadDir(assetFS, assetPath(folderPath))
 	if err != nil {
 		return fmt.Errorf("failed to read %s folder %s: %w", folderType, folderPath, err)
 	}
@@ -84,7 +85,7 @@ func validateFile(filePath string) error {
 	}
 
 	// Check if file is readable by getting file info
-	info, err := os.Stat(filePath)
+	info, err := fs.Stat(assetFS, filePath)
 	if err != nil {
 		return fmt.Errorf("failed to access file %s: %w", filePath, err)
 	}
@@ -93,17 +94,23 @@ func validateFile(filePath string) error {
 		return fmt.Errorf("critical file is empty: %s", filePath)
 	}
 
-	fmt.Printf("[FILE] Critical file '%s' validated (%d bytes)\n", filepath.Base(filePath), info.Size())
+	fmt.Printf("[FILE] Critical file '%s' validated (%d bytes)\n", path.Base(filePath), info.Size())
 	return nil
 }
 
-func PathExists(path string) (bool, error) {
-	_, err := os.Stat(path)
+// PathExists checks if a file or folder exists in the asset source
+func PathExists(name string) (bool, error) {
+	_, err := fs.Stat(assetFS, assetPath(name))
 	if err == nil {
 		return true, nil
 	}
-	if os.IsNotExist(err) {
+	if errors.Is(err, fs.ErrNotExist) {
 		return false, nil
 	}
 	return false, err
 }
+
+// assetPath removes the trailing slash from folder constants, which io/fs doesn't accept
+func assetPath(name string) string {
+	return path.Clean(name)
+}
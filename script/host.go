@@ -0,0 +1,67 @@
+package script
+
+import (
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// The opcodes change the rest of the game only through these interfaces.
+// The game and the renderer implement them, and tests can record the calls instead.
+
+type PlayerHost interface {
+	SetPlayerPosition(position mgl32.Vec3)
+	SetPlayerRotation(rotationAngle float32) // in degrees
+}
+
+// Objects are the item models placed in the room
+type ObjectHost interface {
+	SetObjectModel(instruction fileio.ScriptInstrObjModelSet)
+	SetObjectRotation(objectIndex int, rotationAngle float32) // in degrees
+}
+
+type EnemyHost interface {
+	AddEnemy(instruction fileio.ScriptInstrSceEmSet)
+}
+
+type SpriteHost interface {
+	AddSprite(sprite fileio.ScriptInstrSceEsprOn)
+	RemoveSprite(instruction fileio.ScriptInstrSceEsprKill)
+}
+
+// AOTs are the areas the player can interact with, such as doors, items and events
+type AotHost interface {
+	AddAotTrigger(instruction fileio.ScriptInstrAotSet)
+	AddAotTrigger4p(instruction fileio.ScriptInstrAotSet4p)
+	AddDoorAot(instruction fileio.ScriptInstrDoorAotSet)
+	AddDoorAot4p(instruction fileio.ScriptInstrDoorAotSet4p)
+	AddItemAot(instruction fileio.ScriptInstrItemAotSet)
+	AddItemAot4p(instruction fileio.ScriptInstrItemAotSet4p)
+	ResetAotTrigger(instruction fileio.ScriptInstrAotReset)
+	RemoveCollisionEntity(entityId int)
+}
+
+type CameraHost interface {
+	ChangeCamera(cameraId int)
+}
+
+type SoundHost interface {
+	ControlBgm(instruction fileio.ScriptInstrSceBgmControl)
+	PlaySoundEffect(instruction fileio.ScriptInstrSeOn)
+	PlayVoice(instruction fileio.ScriptInstrXaOn)
+}
+
+type MessageHost interface {
+	ShowMessage(instruction fileio.ScriptInstrMessageOn)
+}
+
+// Host is everything the scripts need from outside the interpreter
+type Host interface {
+	PlayerHost
+	ObjectHost
+	EnemyHost
+	SpriteHost
+	AotHost
+	CameraHost
+	SoundHost
+	MessageHost
+}
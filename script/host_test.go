@@ -0,0 +1,233 @@
+package script
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// recordingHost records every call the opcodes make
+type recordingHost struct {
+	calls []string
+}
+
+func (host *recordingHost) record(format string, args ...any) {
+	host.calls = append(host.calls, fmt.Sprintf(format, args...))
+}
+
+func (host *recordingHost) SetPlayerPosition(position mgl32.Vec3) {
+	host.record("SetPlayerPosition %v", position)
+}
+
+func (host *recordingHost) SetPlayerRotation(rotationAngle float32) {
+	host.record("SetPlayerRotation %v", rotationAngle)
+}
+
+func (host *recordingHost) SetObjectModel(instruction fileio.ScriptInstrObjModelSet) {
+	host.record("SetObjectModel %d", instruction.ObjectIndex)
+}
+
+func (host *recordingHost) SetObjectRotation(objectIndex int, rotationAngle float32) {
+	host.record("SetObjectRotation %d %v", objectIndex, rotationAngle)
+}
+
+func (host *recordingHost) AddEnemy(instruction fileio.ScriptInstrSceEmSet) {
+	host.record("AddEnemy 0x%X", instruction.Type)
+}
+
+func (host *recordingHost) AddSprite(sprite fileio.ScriptInstrSceEsprOn) {
+	host.record("AddSprite %d", sprite.Id)
+}
+
+func (host *recordingHost) RemoveSprite(instruction fileio.ScriptInstrSceEsprKill) {
+	host.record("RemoveSprite %d", instruction.Id)
+}
+
+func (host *recordingHost) AddAotTrigger(instruction fileio.ScriptInstrAotSet) {
+	host.record("AddAotTrigger %d", instruction.Aot)
+}
+
+func (host *recordingHost) AddAotTrigger4p(instruction fileio.ScriptInstrAotSet4p) {
+	host.record("AddAotTrigger4p %d", instruction.Aot)
+}
+
+func (host *recordingHost) AddDoorAot(instruction fileio.ScriptInstrDoorAotSet) {
+	host.record("AddDoorAot %d", instruction.Aot)
+}
+
+func (host *recordingHost) AddDoorAot4p(instruction fileio.ScriptInstrDoorAotSet4p) {
+	host.record("AddDoorAot4p %d", instruction.Aot)
+}
+
+func (host *recordingHost) AddItemAot(instruction fileio.ScriptInstrItemAotSet) {
+	host.record("AddItemAot %d", instruction.Aot)
+}
+
+func (host *recordingHost) AddItemAot4p(instruction fileio.ScriptInstrItemAotSet4p) {
+	host.record("AddItemAot4p %d", instruction.Aot)
+}
+
+func (host *recordingHost) ResetAotTrigger(instruction fileio.ScriptInstrAotReset) {
+	host.record("ResetAotTrigger %d", instruction.Aot)
+}
+
+func (host *recordingHost) RemoveCollisionEntity(entityId int) {
+	host.record("RemoveCollisionEntity %d", entityId)
+}
+
+func (host *recordingHost) ChangeCamera(cameraId int) {
+	host.record("ChangeCamera %d", cameraId)
+}
+
+func (host *recordingHost) ControlBgm(instruction fileio.ScriptInstrSceBgmControl) {
+	host.record("ControlBgm %d %d", instruction.Id, instruction.Operation)
+}
+
+func (host *recordingHost) PlaySoundEffect(instruction fileio.ScriptInstrSeOn) {
+	host.record("PlaySoundEffect %d %d", instruction.VabId, instruction.EdtId)
+}
+
+func (host *recordingHost) PlayVoice(instruction fileio.ScriptInstrXaOn) {
+	host.record("PlayVoice %d", instruction.Id)
+}
+
+func (host *recordingHost) ShowMessage(instruction fileio.ScriptInstrMessageOn) {
+	host.record("ShowMessage %d", instruction.MessageId)
+}
+
+func encodeInstruction(t *testing.T, instruction any) []byte {
+	t.Helper()
+	buffer := new(bytes.Buffer)
+	if err := binary.Write(buffer, binary.LittleEndian, instruction); err != nil {
+		t.Fatalf("Failed to encode %T: %v", instruction, err)
+	}
+	return buffer.Bytes()
+}
+
+func TestExecuteSingleInstruction_Host(t *testing.T) {
+	tests := []struct {
+		name             string
+		workSetComponent int
+		workSetIndex     int
+		instruction      any
+		expected         []string
+	}{
+		{
+			name:        "camera change",
+			instruction: fileio.ScriptInstrCutChg{Opcode: fileio.OP_CUT_CHG, CameraId: 3},
+			expected:    []string{"ChangeCamera 3"},
+		},
+		{
+			name:             "player position",
+			workSetComponent: WORKSET_PLAYER,
+			instruction:      fileio.ScriptInstrPosSet{Opcode: fileio.OP_POS_SET, X: 100, Y: -200, Z: 300},
+			expected:         []string{"SetPlayerPosition [100 -200 300]"},
+		},
+		{
+			name:             "object position is not applied yet",
+			workSetComponent: WORKSET_OBJECT,
+			instruction:      fileio.ScriptInstrPosSet{Opcode: fileio.OP_POS_SET, X: 100},
+			expected:         nil,
+		},
+		{
+			name:             "player rotation",
+			workSetComponent: WORKSET_PLAYER,
+			instruction:      fileio.ScriptInstrMemberSet{Opcode: fileio.OP_MEMBER_SET, MemberIndex: 15, Value: 1024},
+			expected:         []string{"SetPlayerRotation 90"},
+		},
+		{
+			name:             "object rotation",
+			workSetComponent: WORKSET_OBJECT,
+			workSetIndex:     2,
+			instruction:      fileio.ScriptInstrMemberSet{Opcode: fileio.OP_MEMBER_SET, MemberIndex: 15, Value: 2048},
+			expected:         []string{"SetObjectRotation 2 180"},
+		},
+		{
+			name:        "object model",
+			instruction: fileio.ScriptInstrObjModelSet{Opcode: fileio.OP_OBJ_MODEL_SET, ObjectIndex: 4},
+			expected:    []string{"SetObjectModel 4"},
+		},
+		{
+			name:        "enemy",
+			instruction: fileio.ScriptInstrSceEmSet{Opcode: fileio.OP_SCE_EM_SET, Type: 0x10, ModelType: 1},
+			expected:    []string{"AddEnemy 0x10"},
+		},
+		{
+			name:        "empty enemy slot",
+			instruction: fileio.ScriptInstrSceEmSet{Opcode: fileio.OP_SCE_EM_SET},
+			expected:    nil,
+		},
+		{
+			name:        "sprite on",
+			instruction: fileio.ScriptInstrSceEsprOn{Opcode: fileio.OP_SCE_ESPR_ON, Id: 5},
+			expected:    []string{"AddSprite 5"},
+		},
+		{
+			name:        "sprite kill",
+			instruction: fileio.ScriptInstrSceEsprKill{Opcode: fileio.OP_SCE_ESPR_KILL, Id: 5},
+			expected:    []string{"RemoveSprite 5"},
+		},
+		{
+			name:        "door",
+			instruction: fileio.ScriptInstrDoorAotSet{Opcode: fileio.OP_DOOR_AOT_SET, Aot: 7, Id: 1},
+			expected:    []string{"AddDoorAot 7"},
+		},
+		{
+			name:        "remove collision",
+			instruction: fileio.ScriptInstrScaIdSet{Opcode: fileio.OP_SCA_ID_SET, Id: 9, Flag: 0},
+			expected:    []string{"RemoveCollisionEntity 9"},
+		},
+		{
+			name:        "keep collision",
+			instruction: fileio.ScriptInstrScaIdSet{Opcode: fileio.OP_SCA_ID_SET, Id: 9, Flag: 1},
+			expected:    nil,
+		},
+		{
+			name:        "background music",
+			instruction: fileio.ScriptInstrSceBgmControl{Opcode: fileio.OP_SCE_BGM_CONTROL, Id: 1, Operation: 2},
+			expected:    []string{"ControlBgm 1 2"},
+		},
+		{
+			name:        "sound effect",
+			instruction: fileio.ScriptInstrSeOn{Opcode: fileio.OP_SE_ON, VabId: 2, EdtId: 6},
+			expected:    []string{"PlaySoundEffect 2 6"},
+		},
+		{
+			name:        "voice",
+			instruction: fileio.ScriptInstrXaOn{Opcode: fileio.OP_XA_ON, Id: 12},
+			expected:    []string{"PlayVoice 12"},
+		},
+		{
+			name:        "message",
+			instruction: fileio.ScriptInstrMessageOn{Opcode: fileio.OP_MESSAGE_ON, MessageId: 8},
+			expected:    []string{"ShowMessage 8"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef := NewScriptDef()
+			thread := scriptDef.ScriptThreads[0]
+			thread.WorkSetComponent = test.workSetComponent
+			thread.WorkSetIndex = test.workSetIndex
+			host := &recordingHost{}
+
+			lineData := encodeInstruction(t, test.instruction)
+			if size := fileio.InstructionSize[lineData[0]]; size != len(lineData) {
+				t.Fatalf("Encoded %d bytes, opcode 0x%02X is %d bytes", len(lineData), lineData[0], size)
+			}
+			returnValue := scriptDef.ExecuteSingleInstruction(0, thread, lineData, fileio.ScriptFunction{}, host)
+			if returnValue != INSTRUCTION_NORMAL {
+				t.Errorf("Expected return value %d, got %d", INSTRUCTION_NORMAL, returnValue)
+			}
+			if !reflect.DeepEqual(host.calls, test.expected) {
+				t.Errorf("Expected calls %v, got %v", test.expected, host.calls)
+			}
+		})
+	}
+}
@@ -5,48 +5,52 @@ import (
 	"encoding/binary"
 	"fmt"
 	"log"
+	"math"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
-	"github.com/OpenBiohazard2/OpenBiohazard2/game"
-	"github.com/OpenBiohazard2/OpenBiohazard2/render"
-	"github.com/OpenBiohazard2/OpenBiohazard2/world"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 const (
 	SCRIPT_FRAMES_PER_SECOND = 30.0
+	SCRIPT_TICK_SECONDS      = 1.0 / SCRIPT_FRAMES_PER_SECOND
+	// After a long frame, only this many ticks are run to catch up and the rest of the time is dropped
+	SCRIPT_MAX_CATCH_UP_TICKS = 4
 
 	INSTRUCTION_BREAK_FLOW = 0
 	INSTRUCTION_NORMAL     = 1
 	INSTRUCTION_THREAD_END = 2
+	INSTRUCTION_PAUSED     = 3 // stopped by the debugger before running the instruction
 
 	WORKSET_PLAYER = 1
 	WORKSET_ENEMY  = 3
 	WORKSET_OBJECT = 4
 )
 
-var (
-	scriptDeltaTime = 0.0
-)
-
 type ScriptDef struct {
-	ScriptThreads  []*ScriptThread
-	ScriptBitArray map[int]map[int]int
-	ScriptVariable map[int]int
-	DebugEnabled   bool
+	ScriptThreads        []*ScriptThread
+	ScriptBitArray       map[int]map[int]int
+	ScriptVariable       map[int]int
+	DebugEnabled         bool
+	TickAccumulator      float64 // seconds not yet run by a tick
+	MaxCatchUpTicks      int
+	UnimplementedOpcodes map[byte]int    // times each opcode without an implementation was skipped
+	debugger             *scriptDebugger // nil until a breakpoint, watch or pause is set
 }
 
 func NewScriptDef() *ScriptDef {
-	scriptThreads := make([]*ScriptThread, 20)
+	scriptThreads := make([]*ScriptThread, SCRIPT_THREAD_COUNT)
 	for i := 0; i < len(scriptThreads); i++ {
 		scriptThreads[i] = NewScriptThread()
 	}
 
 	return &ScriptDef{
-		ScriptThreads:  scriptThreads,
-		ScriptBitArray: make(map[int]map[int]int),
-		ScriptVariable: make(map[int]int),
-		DebugEnabled:   false,
+		ScriptThreads:        scriptThreads,
+		ScriptBitArray:       make(map[int]map[int]int),
+		ScriptVariable:       make(map[int]int),
+		DebugEnabled:         false,
+		MaxCatchUpTicks:      SCRIPT_MAX_CATCH_UP_TICKS,
+		UnimplementedOpcodes: make(map[byte]int),
 	}
 }
 
@@ -54,6 +58,10 @@ func (scriptDef *ScriptDef) Reset() {
 	for i := 0; i < len(scriptDef.ScriptThreads); i++ {
 		scriptDef.ScriptThreads[i].Reset()
 	}
+	scriptDef.TickAccumulator = 0
+	if scriptDef.debugger != nil {
+		scriptDef.debugger.resetThreads()
+	}
 }
 
 func (scriptDef *ScriptDef) InitScript(
@@ -66,21 +74,56 @@ func (scriptDef *ScriptDef) InitScript(
 	scriptDef.ScriptThreads[threadNum].FunctionIds = []int{startFunction}
 }
 
+// RunScript runs as many ticks as the elapsed time requires and returns the number of ticks run
 func (scriptDef *ScriptDef) RunScript(
 	scriptData fileio.ScriptFunction,
 	timeElapsedSeconds float64,
-	gameDef *game.GameDef,
-	renderDef *render.RenderDef) {
-	for i := 0; i < len(scriptDef.ScriptThreads); i++ {
-		// Regulate frames per second
-		scriptDeltaTime += timeElapsedSeconds
-		if scriptDeltaTime > 1.0/SCRIPT_FRAMES_PER_SECOND {
-			scriptDeltaTime = 0.0
-		} else {
-			continue
+	host Host) int {
+	// Time doesn't pass for the scripts while the debugger has them paused
+	if scriptDef.IsPaused() {
+		return 0
+	}
+	scriptDef.TickAccumulator += timeElapsedSeconds
+
+	ticks := 0
+	for scriptDef.TickAccumulator >= SCRIPT_TICK_SECONDS {
+		if ticks == scriptDef.MaxCatchUpTicks {
+			// Drop the time that is too far behind
+			scriptDef.TickAccumulator = math.Mod(scriptDef.TickAccumulator, SCRIPT_TICK_SECONDS)
+			break
 		}
+		scriptDef.Tick(scriptData, host)
+		scriptDef.TickAccumulator -= SCRIPT_TICK_SECONDS
+		ticks++
+		if scriptDef.IsPaused() {
+			break
+		}
+	}
+	return ticks
+}
 
-		scriptDef.RunScriptThread(i, scriptDef.ScriptThreads[i], scriptData, gameDef, renderDef)
+// Tick runs every active thread once, in thread order.
+// If the debugger stops in the middle of a tick, the next tick continues from the stopped thread.
+func (scriptDef *ScriptDef) Tick(scriptData fileio.ScriptFunction, host Host) {
+	firstThread := 0
+	if scriptDef.debugger != nil {
+		if scriptDef.debugger.paused {
+			return
+		}
+		firstThread = scriptDef.debugger.resumeThread
+		scriptDef.debugger.resumeThread = 0
+		scriptDef.debugger.scriptData = scriptData
+	}
+
+	for i := firstThread; i < len(scriptDef.ScriptThreads); i++ {
+		scriptDef.RunScriptThread(i, scriptDef.ScriptThreads[i], scriptData, host)
+		if scriptDef.IsPaused() {
+			scriptDef.debugger.resumeThread = i
+			return
+		}
+	}
+	if scriptDef.debugger != nil {
+		scriptDef.debugger.currentThread = -1
 	}
 }
 
@@ -88,8 +131,7 @@ func (scriptDef *ScriptDef) RunScriptThread(
 	threadNum int,
 	curScriptThread *ScriptThread,
 	scriptData fileio.ScriptFunction,
-	gameDef *game.GameDef,
-	renderDef *render.RenderDef) {
+	host Host) {
 
 	// Thread should not run
 	if curScriptThread.RunStatus == false {
@@ -97,7 +139,12 @@ func (scriptDef *ScriptDef) RunScriptThread(
 	}
 
 	for true {
-		sectionReturnValue := scriptDef.RunScriptUntilBreakControlFlow(threadNum, curScriptThread, scriptData, gameDef, renderDef)
+		sectionReturnValue := scriptDef.RunScriptUntilBreakControlFlow(threadNum, curScriptThread, scriptData, host)
+
+		// Continue from the same instruction when the debugger resumes
+		if sectionReturnValue == INSTRUCTION_PAUSED {
+			break
+		}
 
 		// End thread
 		if curScriptThread.ShouldTerminate(sectionReturnValue) {
@@ -112,8 +159,7 @@ func (scriptDef *ScriptDef) RunScriptUntilBreakControlFlow(
 	threadNum int,
 	curScriptThread *ScriptThread,
 	scriptData fileio.ScriptFunction,
-	gameDef *game.GameDef,
-	renderDef *render.RenderDef) int {
+	host Host) int {
 	scriptReturnValue := 0
 	for true {
 		lineData := scriptData.Instructions[curScriptThread.ProgramCounter]
@@ -125,10 +171,15 @@ func (scriptDef *ScriptDef) RunScriptUntilBreakControlFlow(
 
 		opcode := lineData[0]
 
+		if scriptDef.debugger != nil && scriptDef.debugger.shouldStop(threadNum, curScriptThread, lineData) {
+			scriptReturnValue = INSTRUCTION_PAUSED
+			break
+		}
+
 		// Override can be modified during execution
 		curScriptThread.OverrideProgramCounter = false
 
-		instructionReturnValue := scriptDef.ExecuteSingleInstruction(threadNum, curScriptThread, lineData, scriptData, gameDef, renderDef)
+		instructionReturnValue := scriptDef.ExecuteSingleInstruction(threadNum, curScriptThread, lineData, scriptData, host)
 
 		if !curScriptThread.OverrideProgramCounter {
 			curScriptThread.IncrementProgramCounter(opcode)
@@ -144,13 +195,67 @@ func (scriptDef *ScriptDef) RunScriptUntilBreakControlFlow(
 	return scriptReturnValue
 }
 
+// ImplementedOpcodes are the opcodes ExecuteSingleInstruction runs.
+// Every other opcode is skipped or only read, and recorded in UnimplementedOpcodes.
+// A test runs every opcode to check this matches what is recorded.
+var ImplementedOpcodes = map[byte]bool{
+	fileio.OP_EVT_END:         true,
+	fileio.OP_EVT_NEXT:        true,
+	fileio.OP_EVT_CHAIN:       true,
+	fileio.OP_EVT_EXEC:        true,
+	fileio.OP_EVT_KILL:        true,
+	fileio.OP_IF_START:        true,
+	fileio.OP_ELSE_START:      true,
+	fileio.OP_END_IF:          true,
+	fileio.OP_SLEEP:           true,
+	fileio.OP_SLEEPING:        true,
+	fileio.OP_FOR:             true,
+	fileio.OP_FOR_END:         true,
+	fileio.OP_WHILE_START:     true,
+	fileio.OP_WHILE_END:       true,
+	fileio.OP_DO_START:        true,
+	fileio.OP_DO_END:          true,
+	fileio.OP_SWITCH:          true,
+	fileio.OP_CASE:            true,
+	fileio.OP_DEFAULT:         true,
+	fileio.OP_END_SWITCH:      true,
+	fileio.OP_GOSUB:           true,
+	fileio.OP_BREAK:           true,
+	fileio.OP_CHECK:           true,
+	fileio.OP_SET_BIT:         true,
+	fileio.OP_COMPARE:         true,
+	fileio.OP_SAVE:            true,
+	fileio.OP_COPY:            true,
+	fileio.OP_CALC:            true,
+	fileio.OP_CALC2:           true,
+	fileio.OP_MESSAGE_ON:      true,
+	fileio.OP_CUT_CHG:         true,
+	fileio.OP_AOT_SET:         true,
+	fileio.OP_OBJ_MODEL_SET:   true,
+	fileio.OP_WORK_SET:        true,
+	fileio.OP_POS_SET:         true,
+	fileio.OP_MEMBER_SET:      true,
+	fileio.OP_SE_ON:           true,
+	fileio.OP_SCA_ID_SET:      true,
+	fileio.OP_SCE_ESPR_ON:     true,
+	fileio.OP_DOOR_AOT_SET:    true,
+	fileio.OP_SCE_EM_SET:      true,
+	fileio.OP_AOT_RESET:       true,
+	fileio.OP_SCE_ESPR_KILL:   true,
+	fileio.OP_ITEM_AOT_SET:    true,
+	fileio.OP_SCE_BGM_CONTROL: true,
+	fileio.OP_XA_ON:           true,
+	fileio.OP_AOT_SET_4P:      true,
+	fileio.OP_DOOR_AOT_SET_4P: true,
+	fileio.OP_ITEM_AOT_SET_4P: true,
+}
+
 func (scriptDef *ScriptDef) ExecuteSingleInstruction(
 	threadNum int,
 	curScriptThread *ScriptThread,
 	lineData []byte,
 	scriptData fileio.ScriptFunction,
-	gameDef *game.GameDef,
-	renderDef *render.RenderDef) int {
+	host Host) int {
 	var returnValue int
 
 	opcode := lineData[0]
@@ -160,8 +265,14 @@ func (scriptDef *ScriptDef) ExecuteSingleInstruction(
 	switch opcode {
 	case fileio.OP_EVT_END:
 		returnValue = scriptDef.ScriptEvtEnd(curScriptThread, lineData, threadNum)
+	case fileio.OP_EVT_NEXT:
+		returnValue = scriptDef.ScriptEvtNext(curScriptThread, lineData)
+	case fileio.OP_EVT_CHAIN:
+		returnValue = scriptDef.ScriptEvtChain(curScriptThread, lineData, scriptData, threadNum)
 	case fileio.OP_EVT_EXEC:
 		returnValue = scriptDef.ScriptEvtExec(lineData, scriptData)
+	case fileio.OP_EVT_KILL:
+		returnValue = scriptDef.ScriptEvtKill(curScriptThread, lineData, threadNum)
 	case fileio.OP_IF_START:
 		returnValue = scriptDef.ScriptIfBlockStart(curScriptThread, lineData)
 	case fileio.OP_ELSE_START:
@@ -172,10 +283,22 @@ func (scriptDef *ScriptDef) ExecuteSingleInstruction(
 		returnValue = scriptDef.ScriptSleep(curScriptThread, lineData)
 	case fileio.OP_SLEEPING:
 		returnValue = scriptDef.ScriptSleeping(curScriptThread, lineData)
+	case fileio.OP_WSLEEP:
+		returnValue = scriptDef.ScriptWsleep(curScriptThread)
+	case fileio.OP_WSLEEPING:
+		returnValue = scriptDef.ScriptWsleeping(curScriptThread, lineData)
 	case fileio.OP_FOR:
 		returnValue = scriptDef.ScriptForLoopBegin(curScriptThread, lineData)
 	case fileio.OP_FOR_END:
 		returnValue = scriptDef.ScriptForLoopEnd(curScriptThread, lineData)
+	case fileio.OP_WHILE_START:
+		returnValue = scriptDef.ScriptWhileLoopBegin(threadNum, curScriptThread, lineData, scriptData, host)
+	case fileio.OP_WHILE_END:
+		returnValue = scriptDef.ScriptWhileLoopEnd(curScriptThread)
+	case fileio.OP_DO_START:
+		returnValue = scriptDef.ScriptDoLoopBegin(curScriptThread, lineData)
+	case fileio.OP_DO_END:
+		returnValue = scriptDef.ScriptDoLoopEnd(threadNum, curScriptThread, lineData, scriptData, host)
 	case fileio.OP_SWITCH:
 		returnValue = scriptDef.ScriptSwitchBegin(curScriptThread, lineData, scriptData.Instructions)
 	case fileio.OP_CASE:
@@ -204,24 +327,28 @@ func (scriptDef *ScriptDef) ExecuteSingleInstruction(
 		returnValue = scriptDef.ScriptCalc(lineData)
 	case fileio.OP_CALC2: // 0x27
 		returnValue = scriptDef.ScriptCalc(lineData)
+	case fileio.OP_MESSAGE_ON: // 0x2b
+		returnValue = scriptDef.ScriptMessageOn(lineData, host)
 	case fileio.OP_CUT_CHG:
-		returnValue = scriptDef.ScriptCameraChange(lineData, gameDef)
+		returnValue = scriptDef.ScriptCameraChange(lineData, host)
 	case fileio.OP_AOT_SET:
-		returnValue = scriptDef.ScriptAotSet(lineData, gameDef)
+		returnValue = scriptDef.ScriptAotSet(lineData, host)
 	case fileio.OP_OBJ_MODEL_SET:
-		returnValue = scriptDef.ScriptObjectModelSet(lineData, renderDef)
+		returnValue = scriptDef.ScriptObjectModelSet(lineData, host)
 	case fileio.OP_WORK_SET:
 		returnValue = scriptDef.ScriptWorkSet(curScriptThread, lineData)
 	case fileio.OP_POS_SET:
-		returnValue = scriptDef.ScriptPositionSet(curScriptThread, lineData, gameDef)
+		returnValue = scriptDef.ScriptPositionSet(curScriptThread, lineData, host)
 	case fileio.OP_MEMBER_SET:
-		returnValue = scriptDef.ScriptMemberSet(curScriptThread, lineData, gameDef, renderDef)
+		returnValue = scriptDef.ScriptMemberSet(curScriptThread, lineData, host)
+	case fileio.OP_SE_ON: // 0x36
+		returnValue = scriptDef.ScriptSeOn(lineData, host)
 	case fileio.OP_SCA_ID_SET:
-		returnValue = scriptDef.ScriptScaIdSet(lineData, gameDef)
+		returnValue = scriptDef.ScriptScaIdSet(lineData, host)
 	case fileio.OP_SCE_ESPR_ON:
-		returnValue = scriptDef.ScriptSceEsprOn(lineData, gameDef, renderDef)
+		returnValue = scriptDef.ScriptSceEsprOn(lineData, host)
 	case fileio.OP_DOOR_AOT_SET:
-		returnValue = scriptDef.ScriptDoorAotSet(lineData, gameDef)
+		returnValue = scriptDef.ScriptDoorAotSet(lineData, host)
 	case fileio.OP_MEMBER_CMP:
 		returnValue = scriptDef.ScriptMemberCompare(lineData)
 	case fileio.OP_PLC_MOTION: // 0x3f
@@ -231,28 +358,50 @@ func (scriptDef *ScriptDef) ExecuteSingleInstruction(
 	case fileio.OP_PLC_NECK: // 0x41
 		returnValue = scriptDef.ScriptPlcNeck(lineData)
 	case fileio.OP_SCE_EM_SET: // 0x44
-		returnValue = scriptDef.ScriptSceEmSet(lineData, renderDef)
+		returnValue = scriptDef.ScriptSceEmSet(lineData, host)
 	case fileio.OP_AOT_RESET: // 0x46
-		returnValue = scriptDef.ScriptAotReset(lineData, gameDef)
+		returnValue = scriptDef.ScriptAotReset(lineData, host)
 	case fileio.OP_SCE_ESPR_KILL: // 0x4c
-		returnValue = scriptDef.ScriptSceEsprKill(lineData)
+		returnValue = scriptDef.ScriptSceEsprKill(lineData, host)
 	case fileio.OP_ITEM_AOT_SET: // 0x4e
-		returnValue = scriptDef.ScriptItemAotSet(lineData, gameDef)
+		returnValue = scriptDef.ScriptItemAotSet(lineData, host)
 	case fileio.OP_SCE_BGM_CONTROL: // 0x51
-		returnValue = scriptDef.ScriptSceBgmControl(lineData)
+		returnValue = scriptDef.ScriptSceBgmControl(lineData, host)
+	case fileio.OP_XA_ON: // 0x59
+		returnValue = scriptDef.ScriptXaOn(lineData, host)
 	case fileio.OP_AOT_SET_4P:
-		returnValue = scriptDef.ScriptAotSet4p(lineData, gameDef)
+		returnValue = scriptDef.ScriptAotSet4p(lineData, host)
 	case fileio.OP_DOOR_AOT_SET_4P:
-		returnValue = scriptDef.ScriptDoorAotSet4p(lineData, gameDef)
+		returnValue = scriptDef.ScriptDoorAotSet4p(lineData, host)
 	case fileio.OP_ITEM_AOT_SET_4P:
-		returnValue = scriptDef.ScriptItemAotSet4p(lineData, gameDef)
+		returnValue = scriptDef.ScriptItemAotSet4p(lineData, host)
 	default:
+		scriptDef.recordUnimplementedOpcode(opcode)
 		returnValue = 1
 	}
 
 	return returnValue
 }
 
+// Only the first time an opcode is skipped is logged, the rest are counted
+func (scriptDef *ScriptDef) recordUnimplementedOpcode(opcode byte) {
+	if scriptDef.UnimplementedOpcodes == nil {
+		scriptDef.UnimplementedOpcodes = make(map[byte]int)
+	}
+	if scriptDef.UnimplementedOpcodes[opcode] == 0 {
+		log.Printf("SCRIPT: Skipped unimplemented opcode %s (0x%02x)", getFunctionNameFromOpcode(opcode), opcode)
+	}
+	scriptDef.UnimplementedOpcodes[opcode]++
+}
+
+// stopThread ends a thread that can't continue from the current instruction
+func (scriptDef *ScriptDef) stopThread(thread *ScriptThread, err error) int {
+	log.Printf("Warning: terminate script thread at program counter %d: %v", thread.ProgramCounter, err)
+	thread.RunStatus = false
+	thread.OverrideProgramCounter = true
+	return INSTRUCTION_THREAD_END
+}
+
 func (scriptDef *ScriptDef) ScriptSleep(thread *ScriptThread, lineData []byte) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrSleep{}
@@ -261,15 +410,36 @@ func (scriptDef *ScriptDef) ScriptSleep(thread *ScriptThread, lineData []byte) i
 	// goes to sleeping instruction (0xa)
 	curLevelState := thread.LevelState[thread.SubLevel]
 
+	newLoopState, err := curLevelState.PushLoop()
+	if err != nil {
+		return scriptDef.stopThread(thread, err)
+	}
+	newLoopState.Counter = int(instruction.Count)
+
 	thread.ProgramCounter = thread.ProgramCounter + 1
 	thread.OverrideProgramCounter = true
+	return 1
+}
 
-	curLevelState.LoopLevel++
-	newLoopLevel := curLevelState.LoopLevel
-	curLevelState.LoopState[newLoopLevel].Counter = int(instruction.Count)
+// Wsleep waits for the work set object to finish moving.
+// Nothing moves on its own yet, so it waits for a single frame and is counted as unimplemented.
+func (scriptDef *ScriptDef) ScriptWsleep(thread *ScriptThread) int {
+	scriptDef.recordUnimplementedOpcode(fileio.OP_WSLEEP)
+	// goes to wsleeping instruction (0xc)
+	curLevelState := thread.LevelState[thread.SubLevel]
+	newLoopState, err := curLevelState.PushLoop()
+	if err != nil {
+		return scriptDef.stopThread(thread, err)
+	}
+	newLoopState.Counter = 1
 	return 1
 }
 
+func (scriptDef *ScriptDef) ScriptWsleeping(thread *ScriptThread, lineData []byte) int {
+	scriptDef.recordUnimplementedOpcode(lineData[0])
+	return scriptDef.ScriptSleeping(thread, lineData)
+}
+
 func (scriptDef *ScriptDef) ScriptSleeping(thread *ScriptThread, lineData []byte) int {
 	opcode := lineData[0]
 	curLevelState := thread.LevelState[thread.SubLevel]
@@ -286,23 +456,31 @@ func (scriptDef *ScriptDef) ScriptSleeping(thread *ScriptThread, lineData []byte
 	return INSTRUCTION_THREAD_END
 }
 
-func (scriptDef *ScriptDef) ScriptCameraChange(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptMessageOn(lineData []byte, host Host) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrMessageOn{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	host.ShowMessage(instruction)
+	return 1
+}
+
+func (scriptDef *ScriptDef) ScriptCameraChange(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrCutChg{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
-	gameDef.ChangeCamera(int(instruction.CameraId))
+	host.ChangeCamera(int(instruction.CameraId))
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptObjectModelSet(lineData []byte,
-	renderDef *render.RenderDef) int {
+func (scriptDef *ScriptDef) ScriptObjectModelSet(lineData []byte, host Host) int {
 
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrObjModelSet{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
-	renderDef.SetItemEntity(instruction)
+	host.SetObjectModel(instruction)
 	return 1
 }
 
@@ -316,13 +494,13 @@ func (scriptDef *ScriptDef) ScriptWorkSet(thread *ScriptThread, lineData []byte)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptPositionSet(thread *ScriptThread, lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptPositionSet(thread *ScriptThread, lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrPosSet{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	if thread.WorkSetComponent == WORKSET_PLAYER {
-		gameDef.Player.Position = mgl32.Vec3{float32(instruction.X), float32(instruction.Y), float32(instruction.Z)}
+		host.SetPlayerPosition(mgl32.Vec3{float32(instruction.X), float32(instruction.Y), float32(instruction.Z)})
 	} else {
 		// TODO: set position of object
 	}
@@ -330,7 +508,7 @@ func (scriptDef *ScriptDef) ScriptPositionSet(thread *ScriptThread, lineData []b
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptMemberSet(thread *ScriptThread, lineData []byte, gameDef *game.GameDef, renderDef *render.RenderDef) int {
+func (scriptDef *ScriptDef) ScriptMemberSet(thread *ScriptThread, lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrMemberSet{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
@@ -339,14 +517,13 @@ func (scriptDef *ScriptDef) ScriptMemberSet(thread *ScriptThread, lineData []byt
 		switch int(instruction.MemberIndex) {
 		case 15:
 			// convert to angle in degrees
-			gameDef.Player.RotationAngle = (float32(instruction.Value) / 4096.0) * 360.0
+			host.SetPlayerRotation((float32(instruction.Value) / 4096.0) * 360.0)
 		}
 	} else if thread.WorkSetComponent == WORKSET_OBJECT {
-		modelObject := renderDef.SceneSystem.ItemGroupEntity.ModelObjectData[int(thread.WorkSetIndex)]
 		switch int(instruction.MemberIndex) {
 		case 15:
 			// convert to angle in degrees
-			modelObject.RotationAngle = (float32(instruction.Value) / 4096.0) * 360.0
+			host.SetObjectRotation(thread.WorkSetIndex, (float32(instruction.Value)/4096.0)*360.0)
 		}
 	} else {
 		// TODO: set attribute of object
@@ -354,13 +531,22 @@ func (scriptDef *ScriptDef) ScriptMemberSet(thread *ScriptThread, lineData []byt
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptScaIdSet(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptSeOn(lineData []byte, host Host) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrSeOn{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	host.PlaySoundEffect(instruction)
+	return 1
+}
+
+func (scriptDef *ScriptDef) ScriptScaIdSet(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrScaIdSet{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	if instruction.Flag == 0 {
-		world.RemoveCollisionEntity(gameDef.GameWorld.GameRoom.CollisionEntities, int(instruction.Id))
+		host.RemoveCollisionEntity(int(instruction.Id))
 	}
 	return 1
 }
@@ -370,45 +556,38 @@ func (scriptDef *ScriptDef) ScriptMemberCompare(lineData []byte) int {
 	instruction := fileio.ScriptInstrMemberCompare{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
+	// TODO: compare the member, the condition always passes until then
+	scriptDef.recordUnimplementedOpcode(instruction.Opcode)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptSceEmSet(lineData []byte, renderDef *render.RenderDef) int {
+func (scriptDef *ScriptDef) ScriptSceEmSet(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrSceEmSet{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	// Create enemy entity if we have valid data
 	if instruction.Type != 0 && instruction.ModelType != 0 {
-		// Load the EMD file based on the enemy type (3-digit hexadecimal)
-		enemyEMDPath := fmt.Sprintf("data/PL0/EMD0/EM%03X.EMD", instruction.Type)
-		
-		// Load the enemy model data
-		emdOutput := fileio.LoadEMDFile(enemyEMDPath)
-		if emdOutput != nil {
-			// Create enemy entity
-			enemyEntity := render.NewEnemyEntity(emdOutput)
-			enemyEntity.SetEnemyData(instruction)
-			
-			// Add to scene system
-			renderDef.SceneSystem.EnemyGroupEntity.AddEnemy(enemyEntity)
-			
-			// Log enemy creation since there won't be too many enemies
-			fmt.Printf("Created enemy type 0x%03X at position (%d, %d, %d)\n", 
-				instruction.Type, instruction.X, instruction.Y, instruction.Z)
-		} else {
-			// Only log failures for debugging purposes
-			fmt.Printf("Failed to load enemy model for type 0x%03X\n", instruction.Type)
-		}
+		host.AddEnemy(instruction)
 	}
 
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptSceBgmControl(lineData []byte) int {
+func (scriptDef *ScriptDef) ScriptSceBgmControl(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrSceBgmControl{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
+	host.ControlBgm(instruction)
+	return 1
+}
+
+func (scriptDef *ScriptDef) ScriptXaOn(lineData []byte, host Host) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrXaOn{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	host.PlayVoice(instruction)
 	return 1
 }
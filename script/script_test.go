@@ -0,0 +1,119 @@
+package script
+
+import (
+	"testing"
+)
+
+// Two threads that count the ticks they run in variables 1 and 2
+const tickCounterScript = `
+function main
+  EvtExec(ThreadNum=1, ExOpcode=24, Event=1);
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=1000);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    EvtNext();
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+
+function counter
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=2, Operation=3, Value=1000);
+    Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+    EvtNext();
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`
+
+func TestRunScript_Ticks(t *testing.T) {
+	tests := []struct {
+		name          string
+		frameSeconds  []float64
+		expectedTicks []int
+	}{
+		{
+			name:          "one tick per frame at 30 fps",
+			frameSeconds:  []float64{1.0 / 30, 1.0 / 30, 1.0 / 30},
+			expectedTicks: []int{1, 1, 1},
+		},
+		{
+			name:          "one tick every other frame at 60 fps",
+			frameSeconds:  []float64{1.0 / 60, 1.0 / 60, 1.0 / 60, 1.0 / 60},
+			expectedTicks: []int{0, 1, 0, 1},
+		},
+		{
+			name:          "catch up after a slow frame",
+			frameSeconds:  []float64{3.0 / 30, 1.0 / 30},
+			expectedTicks: []int{3, 1},
+		},
+		{
+			name:          "catch up is capped after a long frame",
+			frameSeconds:  []float64{10.0, 1.0 / 30},
+			expectedTicks: []int{SCRIPT_MAX_CATCH_UP_TICKS, 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef, scriptData := loadTestScript(t, tickCounterScript)
+			host := &recordingHost{}
+
+			totalTicks := 0
+			for frame, frameSeconds := range test.frameSeconds {
+				ticks := scriptDef.RunScript(scriptData, frameSeconds, host)
+				if ticks != test.expectedTicks[frame] {
+					t.Errorf("Frame %d: expected %d ticks, got %d", frame, test.expectedTicks[frame], ticks)
+				}
+				totalTicks += ticks
+			}
+
+			// Every thread runs once per tick, including thread 0
+			if value := scriptDef.GetScriptVariable(1); value != totalTicks {
+				t.Errorf("Expected thread 0 to run %d times, got %d", totalTicks, value)
+			}
+			// Thread 1 is started by thread 0 in the first tick and runs later in the same tick
+			if value := scriptDef.GetScriptVariable(2); value != totalTicks {
+				t.Errorf("Expected thread 1 to run %d times, got %d", totalTicks, value)
+			}
+			if scriptDef.TickAccumulator < 0 || scriptDef.TickAccumulator >= SCRIPT_TICK_SECONDS {
+				t.Errorf("Expected less than one tick of time left, got %v seconds", scriptDef.TickAccumulator)
+			}
+		})
+	}
+}
+
+func TestTick(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, tickCounterScript)
+	host := &recordingHost{}
+
+	for i := 0; i < 5; i++ {
+		scriptDef.Tick(scriptData, host)
+	}
+	if scriptDef.GetScriptVariable(1) != 5 || scriptDef.GetScriptVariable(2) != 5 {
+		t.Errorf("Expected both threads to run 5 times, got %d and %d",
+			scriptDef.GetScriptVariable(1), scriptDef.GetScriptVariable(2))
+	}
+	if scriptDef.TickAccumulator != 0 {
+		t.Errorf("Expected Tick not to use the accumulator, got %v", scriptDef.TickAccumulator)
+	}
+}
+
+func TestReset_ClearsAccumulator(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, tickCounterScript)
+	scriptDef.RunScript(scriptData, 1.0/60, &recordingHost{})
+	scriptDef.Reset()
+	if scriptDef.TickAccumulator != 0 {
+		t.Errorf("Expected accumulator to be 0 after reset, got %v", scriptDef.TickAccumulator)
+	}
+}
+
+func TestScriptVariableCalculator_Shift(t *testing.T) {
+	scriptDef := NewScriptDef()
+	if result := scriptDef.ScriptVariableCalculator(10, -8, 1); result != -4 {
+		t.Errorf("Expected signed shift to give -4, got %d", result)
+	}
+	if result := scriptDef.ScriptVariableCalculator(11, -8, 1); result != 0x7FFC {
+		t.Errorf("Expected unsigned shift to give 0x7ffc, got 0x%x", result)
+	}
+}
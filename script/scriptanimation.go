@@ -15,7 +15,7 @@ func (scriptDef *ScriptDef) ScriptPlcMotion(lineData []byte) int {
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	// TODO: implement
-
+	scriptDef.recordUnimplementedOpcode(instruction.Opcode)
 	return 1
 }
 
@@ -25,7 +25,7 @@ func (scriptDef *ScriptDef) ScriptPlcDest(lineData []byte) int {
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	// TODO: implement
-
+	scriptDef.recordUnimplementedOpcode(instruction.Opcode)
 	return 1
 }
 
@@ -35,6 +35,6 @@ func (scriptDef *ScriptDef) ScriptPlcNeck(lineData []byte) int {
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	// TODO: implement
-
+	scriptDef.recordUnimplementedOpcode(instruction.Opcode)
 	return 1
 }
@@ -6,20 +6,19 @@ import (
 	"log"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
-	"github.com/OpenBiohazard2/OpenBiohazard2/game"
 	"github.com/OpenBiohazard2/OpenBiohazard2/world"
 )
 
-func (scriptDef *ScriptDef) ScriptAotSet(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptAotSet(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrAotSet{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
-	gameDef.GameWorld.AotManager.AddAotTrigger(instruction)
+	host.AddAotTrigger(instruction)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptDoorAotSet(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptDoorAotSet(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	door := fileio.ScriptInstrDoorAotSet{}
 	err := binary.Read(byteArr, binary.LittleEndian, &door)
@@ -31,11 +30,11 @@ func (scriptDef *ScriptDef) ScriptDoorAotSet(lineData []byte, gameDef *game.Game
 		log.Fatal("Door has incorrect aot type ", door.Id)
 	}
 
-	gameDef.GameWorld.AotManager.AddDoorAot(door)
+	host.AddDoorAot(door)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptItemAotSet(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptItemAotSet(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	item := fileio.ScriptInstrItemAotSet{}
 	binary.Read(byteArr, binary.LittleEndian, &item)
@@ -44,29 +43,29 @@ func (scriptDef *ScriptDef) ScriptItemAotSet(lineData []byte, gameDef *game.Game
 		log.Fatal("Item has incorrect aot type ", item.Id)
 	}
 
-	gameDef.GameWorld.AotManager.AddItemAot(item)
+	host.AddItemAot(item)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptAotReset(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptAotReset(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrAotReset{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
-	gameDef.GameWorld.AotManager.ResetAotTrigger(instruction)
+	host.ResetAotTrigger(instruction)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptAotSet4p(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptAotSet4p(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrAotSet4p{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
-	gameDef.GameWorld.AotManager.AddAotTrigger4p(instruction)
+	host.AddAotTrigger4p(instruction)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptDoorAotSet4p(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptDoorAotSet4p(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	door := fileio.ScriptInstrDoorAotSet4p{}
 	err := binary.Read(byteArr, binary.LittleEndian, &door)
@@ -78,11 +77,11 @@ func (scriptDef *ScriptDef) ScriptDoorAotSet4p(lineData []byte, gameDef *game.Ga
 		log.Fatal("Door has incorrect aot type ", door.Id)
 	}
 
-	gameDef.GameWorld.AotManager.AddDoorAot4p(door)
+	host.AddDoorAot4p(door)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptItemAotSet4p(lineData []byte, gameDef *game.GameDef) int {
+func (scriptDef *ScriptDef) ScriptItemAotSet4p(lineData []byte, host Host) int {
 
 	byteArr := bytes.NewBuffer(lineData)
 	item := fileio.ScriptInstrItemAotSet4p{}
@@ -92,6 +91,6 @@ func (scriptDef *ScriptDef) ScriptItemAotSet4p(lineData []byte, gameDef *game.Ga
 		log.Fatal("Item has incorrect aot type ", item.Id)
 	}
 
-	gameDef.GameWorld.AotManager.AddItemAot4p(item)
+	host.AddItemAot4p(item)
 	return 1
 }
@@ -0,0 +1,482 @@
+package script
+
+// Assembler for script text in the form written by FunctionName and GetOpcodeSignature
+//
+//	function main
+//	  CheckBit(BitArray=1, BitNumber=2, Value=1);
+//	  IfStart(Dummy=0, BlockLength=@skip);
+//	  SetBit(BitArray=1, BitNumber=3, Operation=1);
+//	  EndIf();
+//	skip:
+//	  Gosub(Event=@sub);
+//	  EvtEnd();
+//
+//	function sub
+//	  EvtEnd();
+//
+// A value of @name is a label in the same function, converted to the block length or offset
+// used by the opcode, or the index of a function.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+// Struct types for opcodes with named parameters
+var OpcodeInstructionTypes = map[byte]reflect.Type{
+	fileio.OP_EVT_EXEC:         reflect.TypeOf(fileio.ScriptInstrEventExec{}),
+	fileio.OP_IF_START:         reflect.TypeOf(fileio.ScriptInstrIfElseStart{}),
+	fileio.OP_ELSE_START:       reflect.TypeOf(fileio.ScriptInstrElseStart{}),
+	fileio.OP_SLEEP:            reflect.TypeOf(fileio.ScriptInstrSleep{}),
+	fileio.OP_FOR:              reflect.TypeOf(fileio.ScriptInstrForStart{}),
+	fileio.OP_WHILE_START:      reflect.TypeOf(fileio.ScriptInstrWhileStart{}),
+	fileio.OP_DO_START:         reflect.TypeOf(fileio.ScriptInstrDoStart{}),
+	fileio.OP_SWITCH:           reflect.TypeOf(fileio.ScriptInstrSwitch{}),
+	fileio.OP_CASE:             reflect.TypeOf(fileio.ScriptInstrSwitchCase{}),
+	fileio.OP_GOTO:             reflect.TypeOf(fileio.ScriptInstrGoto{}),
+	fileio.OP_GOSUB:            reflect.TypeOf(fileio.ScriptInstrGoSub{}),
+	fileio.OP_CHECK:            reflect.TypeOf(fileio.ScriptInstrCheckBitTest{}),
+	fileio.OP_SET_BIT:          reflect.TypeOf(fileio.ScriptInstrSetBit{}),
+	fileio.OP_COMPARE:          reflect.TypeOf(fileio.ScriptInstrCompare{}),
+	fileio.OP_SAVE:             reflect.TypeOf(fileio.ScriptInstrSave{}),
+	fileio.OP_COPY:             reflect.TypeOf(fileio.ScriptInstrCopy{}),
+	fileio.OP_CALC:             reflect.TypeOf(fileio.ScriptInstrCalc{}),
+	fileio.OP_CALC2:            reflect.TypeOf(fileio.ScriptInstrCalc2{}),
+	fileio.OP_CUT_CHG:          reflect.TypeOf(fileio.ScriptInstrCutChg{}),
+	fileio.OP_CUT_AUTO:         reflect.TypeOf(fileio.ScriptInstrCutAuto{}),
+	fileio.OP_AOT_SET:          reflect.TypeOf(fileio.ScriptInstrAotSet{}),
+	fileio.OP_AOT_RESET:        reflect.TypeOf(fileio.ScriptInstrAotReset{}),
+	fileio.OP_AOT_SET_4P:       reflect.TypeOf(fileio.ScriptInstrAotSet4p{}),
+	fileio.OP_OBJ_MODEL_SET:    reflect.TypeOf(fileio.ScriptInstrObjModelSet{}),
+	fileio.OP_DOOR_MODEL_SET:   reflect.TypeOf(fileio.ScriptInstrDoorModelSet{}),
+	fileio.OP_WORK_SET:         reflect.TypeOf(fileio.ScriptInstrWorkSet{}),
+	fileio.OP_MEMBER_SET:       reflect.TypeOf(fileio.ScriptInstrMemberSet{}),
+	fileio.OP_MEMBER_CMP:       reflect.TypeOf(fileio.ScriptInstrMemberCompare{}),
+	fileio.OP_POS_SET:          reflect.TypeOf(fileio.ScriptInstrPosSet{}),
+	fileio.OP_SCA_ID_SET:       reflect.TypeOf(fileio.ScriptInstrScaIdSet{}),
+	fileio.OP_SCE_ESPR_ON:      reflect.TypeOf(fileio.ScriptInstrSceEsprOn{}),
+	fileio.OP_SCE_ESPR_KILL:    reflect.TypeOf(fileio.ScriptInstrSceEsprKill{}),
+	fileio.OP_SCE_ESPR_CONTROL: reflect.TypeOf(fileio.ScriptInstrSceEsprControl{}),
+	fileio.OP_SCE_ESPR3D_ON:    reflect.TypeOf(fileio.ScriptInstrSceEspr3DOn{}),
+	fileio.OP_DOOR_AOT_SET:     reflect.TypeOf(fileio.ScriptInstrDoorAotSet{}),
+	fileio.OP_DOOR_AOT_SET_4P:  reflect.TypeOf(fileio.ScriptInstrDoorAotSet4p{}),
+	fileio.OP_PLC_MOTION:       reflect.TypeOf(fileio.ScriptInstrPlcMotion{}),
+	fileio.OP_PLC_DEST:         reflect.TypeOf(fileio.ScriptInstrPlcDest{}),
+	fileio.OP_PLC_NECK:         reflect.TypeOf(fileio.ScriptInstrPlcNeck{}),
+	fileio.OP_PLC_FLAG:         reflect.TypeOf(fileio.ScriptInstrPlcFlag{}),
+	fileio.OP_PLC_ROT:          reflect.TypeOf(fileio.ScriptInstrPlcRot{}),
+	fileio.OP_SCE_EM_SET:       reflect.TypeOf(fileio.ScriptInstrSceEmSet{}),
+	fileio.OP_ITEM_AOT_SET:     reflect.TypeOf(fileio.ScriptInstrItemAotSet{}),
+	fileio.OP_ITEM_AOT_SET_4P:  reflect.TypeOf(fileio.ScriptInstrItemAotSet4p{}),
+	fileio.OP_SCE_BGM_CONTROL:  reflect.TypeOf(fileio.ScriptInstrSceBgmControl{}),
+	fileio.OP_XA_ON:            reflect.TypeOf(fileio.ScriptInstrXaOn{}),
+	fileio.OP_KAGE_SET:         reflect.TypeOf(fileio.ScriptInstrKageSet{}),
+	fileio.OP_MIZU_DIV_SET:     reflect.TypeOf(fileio.ScriptInstrMizuDivSet{}),
+}
+
+// Opcodes with a block length or offset, and the position it is relative to
+// The value is added to the position of the instruction
+var opcodeLabelBase = map[byte]int{
+	fileio.OP_IF_START:    fileio.InstructionSize[fileio.OP_IF_START],
+	fileio.OP_ELSE_START:  0,
+	fileio.OP_FOR:         fileio.InstructionSize[fileio.OP_FOR],
+	fileio.OP_WHILE_START: fileio.InstructionSize[fileio.OP_WHILE_START],
+	fileio.OP_DO_START:    fileio.InstructionSize[fileio.OP_DO_START],
+	fileio.OP_SWITCH:      fileio.InstructionSize[fileio.OP_SWITCH],
+	fileio.OP_CASE:        fileio.InstructionSize[fileio.OP_CASE],
+	fileio.OP_GOTO:        0,
+}
+
+type assemblerParam struct {
+	key   string // empty for positional values
+	value string
+}
+
+type assemblerInstruction struct {
+	lineNumber int
+	opcode     byte
+	params     []assemblerParam
+	position   int // offset from the start of the function
+}
+
+type assemblerFunction struct {
+	name         string
+	instructions []assemblerInstruction
+	labels       map[string]int
+	size         int
+}
+
+// AssembleScript converts script text into SCD data with a function offset table
+func AssembleScript(text string) ([]byte, error) {
+	functions, err := parseScriptText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	functionIndices := make(map[string]int)
+	for i, function := range functions {
+		if function.name != "" {
+			functionIndices[function.name] = i
+		}
+	}
+
+	offsetTableSize := 2 * len(functions)
+	functionOffsets := make([]uint16, len(functions))
+	functionData := new(bytes.Buffer)
+	for i, function := range functions {
+		offset := offsetTableSize + functionData.Len()
+		if offset > 0xFFFF {
+			return nil, fmt.Errorf("function %d starts at offset %d, past the end of the offset table range", i, offset)
+		}
+		functionOffsets[i] = uint16(offset)
+
+		for _, instruction := range function.instructions {
+			lineBytes, err := encodeScriptInstruction(instruction, function, functionIndices)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", instruction.lineNumber, err)
+			}
+			functionData.Write(lineBytes)
+		}
+	}
+
+	output := new(bytes.Buffer)
+	binary.Write(output, binary.LittleEndian, functionOffsets)
+	output.Write(functionData.Bytes())
+	return output.Bytes(), nil
+}
+
+// AssembleInstruction converts one line such as "SetBit(BitArray=1, BitNumber=2, Operation=1);" into bytes
+func AssembleInstruction(line string) ([]byte, error) {
+	instruction, err := parseScriptInstruction(line)
+	if err != nil {
+		return nil, err
+	}
+	return encodeScriptInstruction(instruction, &assemblerFunction{labels: map[string]int{}}, map[string]int{})
+}
+
+// GetOpcodeFromFunctionName finds the opcode with the name used in FunctionName, ignoring case
+func GetOpcodeFromFunctionName(name string) (byte, bool) {
+	for opcode, functionName := range FunctionName {
+		if strings.EqualFold(functionName, name) {
+			return opcode, true
+		}
+	}
+	return 0, false
+}
+
+func parseScriptText(text string) ([]*assemblerFunction, error) {
+	functions := make([]*assemblerFunction, 0)
+	var currentFunction *assemblerFunction
+	startFunction := func(name string) {
+		currentFunction = &assemblerFunction{name: name, labels: make(map[string]int)}
+		functions = append(functions, currentFunction)
+	}
+
+	for i, line := range strings.Split(text, "\n") {
+		lineNumber := i + 1
+		line = stripScriptComment(line)
+		if line == "" {
+			continue
+		}
+
+		if fields := strings.Fields(line); fields[0] == "function" {
+			if len(fields) > 2 {
+				return nil, fmt.Errorf("line %d: expected \"function [name]\"", lineNumber)
+			}
+			name := ""
+			if len(fields) == 2 {
+				name = fields[1]
+				for _, function := range functions {
+					if function.name == name {
+						return nil, fmt.Errorf("line %d: function %s is already defined", lineNumber, name)
+					}
+				}
+			}
+			startFunction(name)
+			continue
+		}
+
+		if currentFunction == nil {
+			startFunction("")
+		}
+
+		if strings.HasSuffix(line, ":") && !strings.Contains(line, "(") {
+			label := strings.TrimSpace(strings.TrimSuffix(line, ":"))
+			if !isScriptIdentifier(label) {
+				return nil, fmt.Errorf("line %d: invalid label %q", lineNumber, label)
+			}
+			if _, exists := currentFunction.labels[label]; exists {
+				return nil, fmt.Errorf("line %d: label %s is already defined", lineNumber, label)
+			}
+			currentFunction.labels[label] = currentFunction.size
+			continue
+		}
+
+		instruction, err := parseScriptInstruction(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		instruction.lineNumber = lineNumber
+		instruction.position = currentFunction.size
+		currentFunction.instructions = append(currentFunction.instructions, instruction)
+		currentFunction.size += fileio.InstructionSize[instruction.opcode]
+	}
+
+	if len(functions) == 0 {
+		return nil, fmt.Errorf("script has no functions")
+	}
+	return functions, nil
+}
+
+func stripScriptComment(line string) string {
+	if index := strings.Index(line, "//"); index >= 0 {
+		line = line[:index]
+	}
+	if index := strings.Index(line, "#"); index >= 0 {
+		line = line[:index]
+	}
+	return strings.TrimSpace(line)
+}
+
+func isScriptIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && (!isDigit || i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseScriptInstruction(line string) (assemblerInstruction, error) {
+	line = strings.TrimSuffix(strings.TrimSpace(line), ";")
+	openIndex := strings.Index(line, "(")
+	if openIndex < 0 || !strings.HasSuffix(line, ")") {
+		return assemblerInstruction{}, fmt.Errorf("expected Name(parameters), got %q", line)
+	}
+
+	name := strings.TrimSpace(line[:openIndex])
+	opcode, exists := GetOpcodeFromFunctionName(name)
+	if !exists {
+		return assemblerInstruction{}, fmt.Errorf("unknown opcode %s", name)
+	}
+	if _, exists := fileio.InstructionSize[opcode]; !exists {
+		return assemblerInstruction{}, fmt.Errorf("opcode %s has no known size", name)
+	}
+
+	params, err := splitScriptParams(line[openIndex+1 : len(line)-1])
+	if err != nil {
+		return assemblerInstruction{}, err
+	}
+	return assemblerInstruction{opcode: opcode, params: params}, nil
+}
+
+// splitScriptParams splits "A=1, B=[1, 2]" on the commas outside of brackets
+func splitScriptParams(text string) ([]assemblerParam, error) {
+	params := make([]assemblerParam, 0)
+	if strings.TrimSpace(text) == "" {
+		return params, nil
+	}
+
+	depth := 0
+	start := 0
+	parts := make([]string, 0)
+	for i, c := range text {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unmatched ] in %q", text)
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unmatched [ in %q", text)
+	}
+	parts = append(parts, text[start:])
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty parameter in %q", text)
+		}
+		key, value, hasKey := strings.Cut(part, "=")
+		if !hasKey {
+			params = append(params, assemblerParam{value: part})
+			continue
+		}
+		params = append(params, assemblerParam{key: strings.TrimSpace(key), value: strings.TrimSpace(value)})
+	}
+	return params, nil
+}
+
+func encodeScriptInstruction(instruction assemblerInstruction, function *assemblerFunction, functionIndices map[string]int) ([]byte, error) {
+	opcode := instruction.opcode
+	size := fileio.InstructionSize[opcode]
+	resolve := func(value string) (int64, error) {
+		return resolveScriptValue(value, instruction, function, functionIndices)
+	}
+
+	var lineBytes []byte
+	if instructionType, exists := OpcodeInstructionTypes[opcode]; exists {
+		instructionValue := reflect.New(instructionType).Elem()
+		instructionValue.FieldByName("Opcode").SetUint(uint64(opcode))
+		for _, param := range instruction.params {
+			if param.key == "" {
+				return nil, fmt.Errorf("%s expects named parameters, got %q", FunctionName[opcode], param.value)
+			}
+			field, exists := findInstructionField(instructionValue, param.key)
+			if !exists {
+				return nil, fmt.Errorf("%s has no parameter %s", FunctionName[opcode], param.key)
+			}
+			if err := setInstructionField(field, param.value, resolve); err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", FunctionName[opcode], param.key, err)
+			}
+		}
+
+		buffer := new(bytes.Buffer)
+		if err := binary.Write(buffer, binary.LittleEndian, instructionValue.Interface()); err != nil {
+			return nil, err
+		}
+		lineBytes = buffer.Bytes()
+	} else {
+		lineBytes = []byte{opcode}
+		for i, param := range instruction.params {
+			if param.key != "" && param.key != fmt.Sprintf("param%d", i+1) {
+				return nil, fmt.Errorf("%s parameter %d should be param%d, got %s", FunctionName[opcode], i+1, i+1, param.key)
+			}
+			value, err := resolve(param.value)
+			if err != nil {
+				return nil, fmt.Errorf("%s parameter %d: %w", FunctionName[opcode], i+1, err)
+			}
+			paramBytes, err := encodeScriptInteger(value, 1)
+			if err != nil {
+				return nil, fmt.Errorf("%s parameter %d: %w", FunctionName[opcode], i+1, err)
+			}
+			lineBytes = append(lineBytes, paramBytes...)
+		}
+	}
+
+	if len(lineBytes) > size {
+		return nil, fmt.Errorf("%s is %d bytes, got %d bytes of parameters", FunctionName[opcode], size, len(lineBytes))
+	}
+	// Parameters that aren't part of the struct are zero
+	for len(lineBytes) < size {
+		lineBytes = append(lineBytes, 0)
+	}
+	return lineBytes, nil
+}
+
+func findInstructionField(instructionValue reflect.Value, name string) (reflect.Value, bool) {
+	if strings.EqualFold(name, "Opcode") {
+		return reflect.Value{}, false
+	}
+	instructionType := instructionValue.Type()
+	for i := 0; i < instructionType.NumField(); i++ {
+		if strings.EqualFold(instructionType.Field(i).Name, name) {
+			return instructionValue.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setInstructionField(field reflect.Value, value string, resolve func(string) (int64, error)) error {
+	if field.Kind() == reflect.Array {
+		if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
+			return fmt.Errorf("expected an array, got %q", value)
+		}
+		elements := strings.Split(value[1:len(value)-1], ",")
+		if len(elements) != field.Len() {
+			return fmt.Errorf("expected %d values, got %d", field.Len(), len(elements))
+		}
+		for i, element := range elements {
+			if err := setInstructionField(field.Index(i), strings.TrimSpace(element), resolve); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	number, err := resolve(value)
+	if err != nil {
+		return err
+	}
+	numBits := int(field.Type().Size()) * 8
+	if err := checkScriptIntegerRange(number, numBits); err != nil {
+		return err
+	}
+	unsignedValue := uint64(number) & (1<<uint(numBits) - 1)
+	switch field.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Sign extend so values written as unsigned keep their bits
+		field.SetInt(int64(unsignedValue<<uint(64-numBits)) >> uint(64-numBits))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(unsignedValue)
+	default:
+		return fmt.Errorf("unsupported field type %v", field.Type())
+	}
+	return nil
+}
+
+// resolveScriptValue parses a number or a @label
+func resolveScriptValue(value string, instruction assemblerInstruction, function *assemblerFunction, functionIndices map[string]int) (int64, error) {
+	if !strings.HasPrefix(value, "@") {
+		number, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", value)
+		}
+		return number, nil
+	}
+
+	name := value[1:]
+	if labelPosition, exists := function.labels[name]; exists {
+		base, hasLabel := opcodeLabelBase[instruction.opcode]
+		if !hasLabel {
+			return 0, fmt.Errorf("%s can't jump to label %s", FunctionName[instruction.opcode], name)
+		}
+		return int64(labelPosition - (instruction.position + base)), nil
+	}
+	if functionIndex, exists := functionIndices[name]; exists {
+		return int64(functionIndex), nil
+	}
+	return 0, fmt.Errorf("undefined label %s", name)
+}
+
+func encodeScriptInteger(value int64, numBytes int) ([]byte, error) {
+	if err := checkScriptIntegerRange(value, numBytes*8); err != nil {
+		return nil, err
+	}
+	output := make([]byte, numBytes)
+	for i := range output {
+		output[i] = byte(value >> uint(8*i))
+	}
+	return output, nil
+}
+
+// Values can be written as either signed or unsigned
+func checkScriptIntegerRange(value int64, numBits int) error {
+	if numBits >= 64 {
+		return nil
+	}
+	if value < -(1<<uint(numBits-1)) || value > (1<<uint(numBits))-1 {
+		return fmt.Errorf("value %d doesn't fit in %d bits", value, numBits)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package script
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+func TestAssembleInstruction_RoundTrip(t *testing.T) {
+	for opcode, size := range fileio.InstructionSize {
+		lineBytes := make([]byte, size)
+		lineBytes[0] = opcode
+		for i := 1; i < size; i++ {
+			lineBytes[i] = byte(i*37) + opcode
+		}
+		// The last byte of Calc isn't part of the struct
+		if opcode == fileio.OP_CALC {
+			lineBytes[size-1] = 0
+		}
+
+		line := getFunctionNameFromOpcode(opcode) + GetOpcodeSignature(lineBytes)
+		assembled, err := AssembleInstruction(line)
+		if err != nil {
+			t.Errorf("AssembleInstruction(%q) error: %v", line, err)
+			continue
+		}
+		if !bytes.Equal(assembled, lineBytes) {
+			t.Errorf("AssembleInstruction(%q): expected %v, got %v", line, lineBytes, assembled)
+		}
+	}
+}
+
+func TestAssembleScript_Labels(t *testing.T) {
+	text := `
+# Set a bit if another bit is set
+function main
+  CheckBit(BitArray=1, BitNumber=2, Value=1);
+  IfStart(Dummy=0, BlockLength=@else);
+    SetBit(BitArray=1, BitNumber=3, Operation=1);
+  ElseStart(Dummy=0, BlockLength=@end);
+else:
+    Gosub(Event=@sub);
+  EndIf();
+end:
+  EvtEnd();
+
+function sub
+  Sleep(Dummy=0, Count=30);
+  EvtEnd();
+`
+	data, err := AssembleScript(text)
+	if err != nil {
+		t.Fatalf("AssembleScript() error: %v", err)
+	}
+
+	expected := []byte{
+		4, 0, // main
+		24, 0, // sub
+		fileio.OP_CHECK, 1, 2, 1,
+		fileio.OP_IF_START, 0, 8, 0, // skip SetBit and ElseStart
+		fileio.OP_SET_BIT, 1, 3, 1,
+		fileio.OP_ELSE_START, 0, 7, 0, // from ElseStart to EvtEnd
+		fileio.OP_GOSUB, 1,
+		fileio.OP_END_IF,
+		fileio.OP_EVT_END,
+		fileio.OP_SLEEP, 0, 30, 0,
+		fileio.OP_EVT_END,
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("Expected\n%v\ngot\n%v", expected, data)
+	}
+
+	scdOutput, err := fileio.LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadRDT_SCDStream() error: %v", err)
+	}
+	if len(scdOutput.ScriptData.StartProgramCounter) != 2 {
+		t.Fatalf("Expected 2 functions, got %d", len(scdOutput.ScriptData.StartProgramCounter))
+	}
+	subStart := scdOutput.ScriptData.StartProgramCounter[1]
+	if scdOutput.ScriptData.Instructions[subStart][0] != fileio.OP_SLEEP {
+		t.Errorf("Expected second function to start with Sleep, got %v", scdOutput.ScriptData.Instructions[subStart])
+	}
+}
+
+func TestAssembleScript_ParamForms(t *testing.T) {
+	text := `
+  WhileStart(Dummy=0, BlockLength=@loop_end);
+    SuperSet(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15);
+  WhileEnd(param1=0);
+loop_end:
+  AotSet(Aot=1, Id=2, Type=3, X=-100, Data=[1, 2, 3, 4, 5, 6]);
+  PlcNeck(Operation=1, Unknown=[255, 1]);
+  EvtEnd();
+`
+	data, err := AssembleScript(text)
+	if err != nil {
+		t.Fatalf("AssembleScript() error: %v", err)
+	}
+
+	// Block length counts from the end of WhileStart
+	whileBlockLength := binary.LittleEndian.Uint16(data[4:6])
+	expectedLength := fileio.InstructionSize[fileio.OP_SUPER_SET] + fileio.InstructionSize[fileio.OP_WHILE_END]
+	if int(whileBlockLength) != expectedLength {
+		t.Errorf("Expected while block length %d, got %d", expectedLength, whileBlockLength)
+	}
+
+	aotStart := 2 + 4 + 16 + 2
+	aotSet := data[aotStart : aotStart+fileio.InstructionSize[fileio.OP_AOT_SET]]
+	if int16(binary.LittleEndian.Uint16(aotSet[6:8])) != -100 {
+		t.Errorf("Expected X=-100, got %v", aotSet[6:8])
+	}
+	if !bytes.Equal(aotSet[14:], []byte{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("Expected data array, got %v", aotSet[14:])
+	}
+
+	plcNeck := data[aotStart+len(aotSet):]
+	if plcNeck[8] != 255 || plcNeck[9] != 1 {
+		t.Errorf("Expected neck unknown bytes [255, 1], got %v", plcNeck[8:10])
+	}
+}
+
+func TestAssembleScript_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"unknown opcode", "NotAnOpcode();"},
+		{"unknown parameter", "SetBit(Bits=1);"},
+		{"undefined label", "IfStart(Dummy=0, BlockLength=@missing);"},
+		{"value out of range", "CutChg(CameraId=256);"},
+		{"label on opcode without offset", "target:\nCutChg(CameraId=@target);"},
+		{"too many parameters", "EvtKill(param1=1, param2=2);"},
+		{"wrong array size", "AotReset(Data=[1, 2]);"},
+		{"duplicate label", "a:\na:\nEvtEnd();"},
+		{"empty script", "# nothing"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := AssembleScript(test.text); err == nil {
+				t.Errorf("Expected error for %q", test.text)
+			}
+		})
+	}
+}
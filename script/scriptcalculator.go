@@ -28,7 +28,12 @@ func (scriptDef *ScriptDef) SetBitArray(bitArrayIndex int, bitNumber int, value
 	if !exists {
 		scriptDef.ScriptBitArray[bitArrayIndex] = make(map[int]int)
 	}
+	oldValue := scriptDef.ScriptBitArray[bitArrayIndex][bitNumber]
 	scriptDef.ScriptBitArray[bitArrayIndex][bitNumber] = value
+
+	if scriptDef.debugger != nil && oldValue != value {
+		scriptDef.debugger.bitChanged(bitArrayIndex, bitNumber, oldValue, value)
+	}
 }
 
 func (scriptDef *ScriptDef) GetScriptVariable(id int) int {
@@ -36,7 +41,12 @@ func (scriptDef *ScriptDef) GetScriptVariable(id int) int {
 }
 
 func (scriptDef *ScriptDef) SetScriptVariable(id int, value int) {
+	oldValue := scriptDef.ScriptVariable[id]
 	scriptDef.ScriptVariable[id] = value
+
+	if scriptDef.debugger != nil && oldValue != value {
+		scriptDef.debugger.variableChanged(id, oldValue, value)
+	}
 }
 
 func (scriptDef *ScriptDef) ScriptCheckBit(lineData []byte) int {
@@ -202,7 +212,8 @@ func (scriptDef *ScriptDef) ScriptVariableCalculator(operation int, leftValue in
 	case 10:
 		return leftValue >> (rightValue % 32)
 	case 11:
-		return leftValue >> (rightValue % 32)
+		// Shifts the 16 bit variable without keeping the sign
+		return int(uint16(leftValue) >> (rightValue % 32))
 	default:
 		log.Fatalf("SCRIPT: Invalid calculator operation %d", operation)
 	}
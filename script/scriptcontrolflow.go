@@ -54,8 +54,10 @@ func (scriptDef *ScriptDef) ScriptForLoopBegin(thread *ScriptThread, lineData []
 		newProgramCounter := thread.ProgramCounter + fileio.InstructionSize[opcode]
 		curLevelState := thread.LevelState[thread.SubLevel]
 
-		curLevelState.LoopLevel++
-		newLoopState := curLevelState.LoopState[curLevelState.LoopLevel]
+		newLoopState, err := curLevelState.PushLoop()
+		if err != nil {
+			return scriptDef.stopThread(thread, err)
+		}
 		newLoopState.Counter = int(instruction.Count)
 		newLoopState.Break = newProgramCounter + int(instruction.BlockLength)
 		newLoopState.StackValue = newProgramCounter
@@ -94,6 +96,124 @@ func (scriptDef *ScriptDef) ScriptForLoopEnd(thread *ScriptThread, lineData []by
 	return 1
 }
 
+// The conditions of a while loop come right after the while instruction
+// and are checked again each time the loop repeats
+func (scriptDef *ScriptDef) ScriptWhileLoopBegin(
+	threadNum int,
+	thread *ScriptThread,
+	lineData []byte,
+	scriptData fileio.ScriptFunction,
+	host Host,
+) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrWhileStart{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	opcode := lineData[0]
+	curLevelState := thread.LevelState[thread.SubLevel]
+	conditionsStart := thread.ProgramCounter + fileio.InstructionSize[opcode]
+
+	newLoopState, err := curLevelState.PushLoop()
+	if err != nil {
+		return scriptDef.stopThread(thread, err)
+	}
+	newLoopState.Break = conditionsStart + int(instruction.BlockLength)
+	newLoopState.StackValue = thread.ProgramCounter
+	newLoopState.LevelIfCounter = curLevelState.IfElseCounter
+
+	conditionsPassed, bodyStart := scriptDef.RunConditions(threadNum, thread, conditionsStart, scriptData, host)
+	if conditionsPassed {
+		thread.ProgramCounter = bodyStart
+	} else {
+		// Exit while loop block
+		thread.ProgramCounter = newLoopState.Break
+		curLevelState.LoopLevel--
+	}
+	thread.OverrideProgramCounter = true
+	return 1
+}
+
+func (scriptDef *ScriptDef) ScriptWhileLoopEnd(thread *ScriptThread) int {
+	curLevelState := thread.LevelState[thread.SubLevel]
+	curLoopState := curLevelState.LoopState[curLevelState.LoopLevel]
+
+	// Go back to the while instruction to check the conditions again
+	curLevelState.LoopLevel--
+	thread.ProgramCounter = curLoopState.StackValue
+	thread.OverrideProgramCounter = true
+	return 1
+}
+
+func (scriptDef *ScriptDef) ScriptDoLoopBegin(thread *ScriptThread, lineData []byte) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrDoStart{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	opcode := lineData[0]
+	newProgramCounter := thread.ProgramCounter + fileio.InstructionSize[opcode]
+	curLevelState := thread.LevelState[thread.SubLevel]
+
+	newLoopState, err := curLevelState.PushLoop()
+	if err != nil {
+		return scriptDef.stopThread(thread, err)
+	}
+	newLoopState.Break = newProgramCounter + int(instruction.BlockLength)
+	newLoopState.StackValue = newProgramCounter
+	newLoopState.LevelIfCounter = curLevelState.IfElseCounter
+	return 1
+}
+
+// The conditions of a do while loop come right after the do end instruction
+func (scriptDef *ScriptDef) ScriptDoLoopEnd(
+	threadNum int,
+	thread *ScriptThread,
+	lineData []byte,
+	scriptData fileio.ScriptFunction,
+	host Host,
+) int {
+	opcode := lineData[0]
+	curLevelState := thread.LevelState[thread.SubLevel]
+	curLoopState := curLevelState.LoopState[curLevelState.LoopLevel]
+
+	conditionsStart := thread.ProgramCounter + fileio.InstructionSize[opcode]
+	conditionsPassed, conditionsEnd := scriptDef.RunConditions(threadNum, thread, conditionsStart, scriptData, host)
+	if conditionsPassed {
+		// Go back to beginning of do loop
+		thread.ProgramCounter = curLoopState.StackValue
+	} else {
+		// Exit do loop block
+		thread.ProgramCounter = conditionsEnd
+		curLevelState.LoopLevel--
+	}
+	thread.OverrideProgramCounter = true
+	return 1
+}
+
+// RunConditions checks the condition instructions starting at a position.
+// It returns whether all of them passed and the position after the last one.
+func (scriptDef *ScriptDef) RunConditions(
+	threadNum int,
+	thread *ScriptThread,
+	position int,
+	scriptData fileio.ScriptFunction,
+	host Host,
+) (bool, int) {
+	conditionsPassed := true
+	for {
+		lineData := scriptData.Instructions[position]
+		if len(lineData) == 0 || !conditionOpcodes[lineData[0]] {
+			return conditionsPassed, position
+		}
+
+		// Skip the rest once a condition fails
+		if conditionsPassed {
+			returnValue := scriptDef.ExecuteSingleInstruction(threadNum, thread, lineData, scriptData, host)
+			conditionsPassed = returnValue != INSTRUCTION_BREAK_FLOW
+		}
+		position += fileio.InstructionSize[lineData[0]]
+	}
+}
+
 func (scriptDef *ScriptDef) ScriptSwitchBegin(
 	thread *ScriptThread,
 	lineData []byte,
@@ -107,11 +227,13 @@ func (scriptDef *ScriptDef) ScriptSwitchBegin(
 	opcode := lineData[0]
 	curLevelState := thread.LevelState[thread.SubLevel]
 
-	curLevelState.LoopLevel++
-	newLoopLevel := curLevelState.LoopLevel
+	newLoopState, err := curLevelState.PushLoop()
+	if err != nil {
+		return scriptDef.stopThread(thread, err)
+	}
 	newProgramCounter := thread.ProgramCounter + fileio.InstructionSize[opcode]
-	curLevelState.LoopState[newLoopLevel].Break = newProgramCounter + int(switchConditional.BlockLength)
-	curLevelState.LoopState[newLoopLevel].LevelIfCounter = curLevelState.IfElseCounter
+	newLoopState.Break = newProgramCounter + int(switchConditional.BlockLength)
+	newLoopState.LevelIfCounter = curLevelState.IfElseCounter
 
 	for true {
 		newLineData := instructions[newProgramCounter]
@@ -160,6 +282,7 @@ func (scriptDef *ScriptDef) ScriptGoto(thread *ScriptThread, lineData []byte) in
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
 	// Disable due to infinite loop
+	scriptDef.recordUnimplementedOpcode(instruction.Opcode)
 	/*thread.LevelState[thread.SubLevel].IfElseCounter = int(instruction.IfElseCounter)
 	thread.StackIndex = int(instruction.IfElseCounter) + 1
 	thread.LevelState[thread.SubLevel].LoopLevel = int(instruction.LoopLevel)
@@ -194,7 +317,9 @@ func (scriptDef *ScriptDef) ScriptBreak(thread *ScriptThread, lineData []byte) i
 
 	thread.OverrideProgramCounter = true
 	thread.ProgramCounter = curLoopState.Break
+	// Leave the if blocks inside the loop
 	curLevelState.IfElseCounter = curLoopState.LevelIfCounter
+	thread.StackIndex = curLoopState.LevelIfCounter + 1
 	curLevelState.LoopLevel--
 	return 1
 }
@@ -1,6 +1,7 @@
 package script
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
@@ -304,3 +305,264 @@ func TestScriptBreakWithThread(t *testing.T) {
 		t.Error("Expected OverrideProgramCounter to be true")
 	}
 }
+
+// loadTestScript assembles script text and starts its first function on thread 0
+func loadTestScript(t *testing.T, text string) (*ScriptDef, fileio.ScriptFunction) {
+	t.Helper()
+	data, err := AssembleScript(text)
+	if err != nil {
+		t.Fatalf("AssembleScript() error: %v", err)
+	}
+	scdOutput, err := fileio.LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadRDT_SCDStream() error: %v", err)
+	}
+
+	scriptDef := NewScriptDef()
+	scriptDef.InitScript(scdOutput.ScriptData, 0, 0)
+	return scriptDef, scdOutput.ScriptData
+}
+
+// runTestFrames runs one tick per frame, like the game loop at 30 frames per second
+func runTestFrames(scriptDef *ScriptDef, scriptData fileio.ScriptFunction, frames int) {
+	host := &recordingHost{}
+	for frame := 0; frame < frames; frame++ {
+		scriptDef.Tick(scriptData, host)
+	}
+}
+
+func TestScriptLoops(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected map[int]int // script variables after the thread ends
+	}{
+		{
+			name: "while counts up",
+			script: `
+function main
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=3);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			expected: map[int]int{1: 3},
+		},
+		{
+			name: "while skips the body when the condition is false",
+			script: `
+function main
+  Save(VarId=1, Value=5);
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=3);
+    Save(VarId=2, Value=1);
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			expected: map[int]int{1: 5, 2: 0},
+		},
+		{
+			name: "while checks every condition",
+			script: `
+function main
+  Save(VarId=2, Value=1);
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=5);
+    Compare(Dummy=0, VarId=2, Operation=0, Value=1);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    IfStart(Dummy=0, BlockLength=@endif);
+      Compare(Dummy=0, VarId=1, Operation=0, Value=2);
+      Save(VarId=2, Value=0);
+    EndIf();
+endif:
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			expected: map[int]int{1: 2, 2: 0},
+		},
+		{
+			name: "while inside for",
+			script: `
+function main
+  ForStart(Dummy=0, BlockLength=@endfor, Count=3);
+    Save(VarId=2, Value=0);
+    WhileStart(Dummy=0, BlockLength=@endwhile);
+      Compare(Dummy=0, VarId=2, Operation=3, Value=2);
+      Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+      Calc(Dummy=0, Operation=0, VarId=3, Value=1);
+    WhileEnd(param1=0);
+endwhile:
+  ForEnd(param1=0);
+endfor:
+  EvtEnd();
+`,
+			expected: map[int]int{2: 2, 3: 6},
+		},
+		{
+			name: "while inside while",
+			script: `
+function main
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=3);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    Save(VarId=2, Value=0);
+    WhileStart(Dummy=0, BlockLength=@endinner);
+      Compare(Dummy=0, VarId=2, Operation=3, Value=4);
+      Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+      Calc(Dummy=0, Operation=0, VarId=3, Value=1);
+    WhileEnd(param1=0);
+endinner:
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			expected: map[int]int{1: 3, 2: 4, 3: 12},
+		},
+		{
+			name: "break inside while",
+			script: `
+function main
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=10);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    IfStart(Dummy=0, BlockLength=@endif);
+      Compare(Dummy=0, VarId=1, Operation=0, Value=4);
+      Break(param1=0);
+    EndIf();
+endif:
+  WhileEnd(param1=0);
+end:
+  Save(VarId=2, Value=1);
+  EvtEnd();
+`,
+			expected: map[int]int{1: 4, 2: 1},
+		},
+		{
+			name: "break leaves only the inner loop",
+			script: `
+function main
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=3);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    ForStart(Dummy=0, BlockLength=@endfor, Count=5);
+      Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+      Break(param1=0);
+    ForEnd(param1=0);
+endfor:
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			expected: map[int]int{1: 3, 2: 3},
+		},
+		{
+			name: "do while runs the body before checking",
+			script: `
+function main
+  Save(VarId=1, Value=5);
+  DoStart(Dummy=0, BlockLength=@end);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+  DoEnd(param1=0);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=3);
+end:
+  EvtEnd();
+`,
+			expected: map[int]int{1: 6},
+		},
+		{
+			name: "do while counts up",
+			script: `
+function main
+  DoStart(Dummy=0, BlockLength=@end);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+  DoEnd(param1=0);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=4);
+end:
+  Save(VarId=2, Value=1);
+  EvtEnd();
+`,
+			expected: map[int]int{1: 4, 2: 1},
+		},
+		{
+			name: "break inside do while",
+			script: `
+function main
+  DoStart(Dummy=0, BlockLength=@end);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    IfStart(Dummy=0, BlockLength=@endif);
+      Compare(Dummy=0, VarId=1, Operation=0, Value=2);
+      Break(param1=0);
+    EndIf();
+endif:
+  DoEnd(param1=0);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=10);
+end:
+  Save(VarId=2, Value=1);
+  EvtEnd();
+`,
+			expected: map[int]int{1: 2, 2: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef, scriptData := loadTestScript(t, test.script)
+			runTestFrames(scriptDef, scriptData, 1)
+
+			thread := scriptDef.ScriptThreads[0]
+			if thread.RunStatus {
+				t.Fatalf("Expected thread to end in one frame, stopped at program counter %d", thread.ProgramCounter)
+			}
+			for varId, expectedValue := range test.expected {
+				if value := scriptDef.GetScriptVariable(varId); value != expectedValue {
+					t.Errorf("Expected variable %d to be %d, got %d", varId, expectedValue, value)
+				}
+			}
+
+			// Every loop and if block was left
+			levelState := thread.LevelState[0]
+			if levelState.LoopLevel != -1 || levelState.IfElseCounter != -1 || thread.StackIndex != 0 {
+				t.Errorf("Expected LoopLevel -1, IfElseCounter -1 and StackIndex 0, got %d, %d and %d",
+					levelState.LoopLevel, levelState.IfElseCounter, thread.StackIndex)
+			}
+		})
+	}
+}
+
+func TestScriptLoops_TooDeep(t *testing.T) {
+	// Each thread level has 4 loop levels, so the sleep can't start
+	scriptDef, scriptData := loadTestScript(t, `
+function main
+  ForStart(Dummy=0, BlockLength=@end1, Count=2);
+    ForStart(Dummy=0, BlockLength=@end2, Count=2);
+      WhileStart(Dummy=0, BlockLength=@end3);
+        DoStart(Dummy=0, BlockLength=@end4);
+          Sleep(Dummy=0, Count=1);
+          Save(VarId=1, Value=1);
+        DoEnd(param1=0);
+end4:
+      WhileEnd(param1=0);
+end3:
+    ForEnd(param1=0);
+end2:
+  ForEnd(param1=0);
+end1:
+  EvtEnd();
+`)
+	runTestFrames(scriptDef, scriptData, 1)
+
+	thread := scriptDef.ScriptThreads[0]
+	if thread.RunStatus {
+		t.Errorf("Expected thread to stop, still running at program counter %d", thread.ProgramCounter)
+	}
+	if levelState := thread.LevelState[0]; levelState.LoopLevel != len(levelState.LoopState)-1 {
+		t.Errorf("Expected loop level %d, got %d", len(levelState.LoopState)-1, levelState.LoopLevel)
+	}
+	if value := scriptDef.GetScriptVariable(1); value != 0 {
+		t.Errorf("Expected the loop body to not run, got variable 1 = %d", value)
+	}
+}
@@ -0,0 +1,195 @@
+package script
+
+// Counts how often each opcode is used by the room scripts, to find which missing opcodes matter most
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+)
+
+type OpcodeUsage struct {
+	Opcode      int             `json:"opcode"`
+	Name        string          `json:"name"`
+	Implemented bool            `json:"implemented"`
+	Count       int             `json:"count"`
+	Rooms       []world.RoomKey `json:"rooms"`
+}
+
+type OpcodeCoverage struct {
+	RoomCount   int                   `json:"roomCount"`
+	Opcodes     []OpcodeUsage         `json:"opcodes"`
+	FailedRooms []world.RoomLoadError `json:"failedRooms"`
+
+	usage map[byte]*OpcodeUsage
+	rooms map[world.RoomKey]bool
+}
+
+func NewOpcodeCoverage() *OpcodeCoverage {
+	return &OpcodeCoverage{
+		Opcodes:     make([]OpcodeUsage, 0),
+		FailedRooms: make([]world.RoomLoadError, 0),
+		usage:       make(map[byte]*OpcodeUsage),
+		rooms:       make(map[world.RoomKey]bool),
+	}
+}
+
+// LoadOpcodeCoverage scans the scripts of every room of a player in fsys.
+// Rooms that fail to load are listed in FailedRooms instead of stopping the scan.
+// The opcodes of a room that stops at an unknown opcode are counted up to that opcode.
+func LoadOpcodeCoverage(fsys fs.FS, playerNum int) (*OpcodeCoverage, error) {
+	coverage := NewOpcodeCoverage()
+	failedRooms, err := world.ScanRooms(fsys, playerNum, func(roomKey world.RoomKey, rdtOutput *fileio.RDTOutput) {
+		if rdtOutput.InitScriptData != nil {
+			coverage.AddScript(roomKey, rdtOutput.InitScriptData.ScriptData)
+		}
+		if rdtOutput.RoomScriptData != nil {
+			coverage.AddScript(roomKey, rdtOutput.RoomScriptData.ScriptData)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	coverage.FailedRooms = failedRooms
+	return coverage, nil
+}
+
+// AddScript counts every instruction in a script, including the ones in branches that are never taken
+func (coverage *OpcodeCoverage) AddScript(roomKey world.RoomKey, scriptData fileio.ScriptFunction) {
+	if !coverage.rooms[roomKey] {
+		coverage.rooms[roomKey] = true
+		coverage.RoomCount++
+	}
+
+	for _, lineData := range scriptData.Instructions {
+		if len(lineData) == 0 {
+			continue
+		}
+		opcode := lineData[0]
+		usage, exists := coverage.usage[opcode]
+		if !exists {
+			usage = &OpcodeUsage{
+				Opcode:      int(opcode),
+				Name:        getFunctionNameFromOpcode(opcode),
+				Implemented: ImplementedOpcodes[opcode],
+				Rooms:       make([]world.RoomKey, 0),
+			}
+			coverage.usage[opcode] = usage
+		}
+		usage.Count++
+		if !containsRoom(usage.Rooms, roomKey) {
+			usage.Rooms = append(usage.Rooms, roomKey)
+		}
+	}
+	coverage.updateOpcodes()
+}
+
+func containsRoom(rooms []world.RoomKey, roomKey world.RoomKey) bool {
+	for _, room := range rooms {
+		if room == roomKey {
+			return true
+		}
+	}
+	return false
+}
+
+// The opcodes used in the most rooms come first
+func (coverage *OpcodeCoverage) updateOpcodes() {
+	coverage.Opcodes = coverage.Opcodes[:0]
+	for _, usage := range coverage.usage {
+		sort.Slice(usage.Rooms, func(i, j int) bool { return usage.Rooms[i].String() < usage.Rooms[j].String() })
+		coverage.Opcodes = append(coverage.Opcodes, *usage)
+	}
+	sort.Slice(coverage.Opcodes, func(i, j int) bool {
+		a, b := coverage.Opcodes[i], coverage.Opcodes[j]
+		if len(a.Rooms) != len(b.Rooms) {
+			return len(a.Rooms) > len(b.Rooms)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Opcode < b.Opcode
+	})
+}
+
+// Unimplemented returns the opcodes used by the scripts that the interpreter skips
+func (coverage *OpcodeCoverage) Unimplemented() []OpcodeUsage {
+	unimplemented := make([]OpcodeUsage, 0)
+	for _, usage := range coverage.Opcodes {
+		if !usage.Implemented {
+			unimplemented = append(unimplemented, usage)
+		}
+	}
+	return unimplemented
+}
+
+// RoomsFullyImplemented counts the rooms where every opcode is implemented
+func (coverage *OpcodeCoverage) RoomsFullyImplemented() int {
+	blocked := make(map[world.RoomKey]bool)
+	for _, usage := range coverage.Unimplemented() {
+		for _, room := range usage.Rooms {
+			blocked[room] = true
+		}
+	}
+	return coverage.RoomCount - len(blocked)
+}
+
+// WriteJSON writes the usage of every opcode as JSON
+func (coverage *OpcodeCoverage) WriteJSON(w io.Writer) error {
+	output, err := json.MarshalIndent(coverage, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
+
+// WriteText writes a summary and a table of the opcodes, the unimplemented ones first
+func (coverage *OpcodeCoverage) WriteText(w io.Writer) error {
+	unimplemented := coverage.Unimplemented()
+	fmt.Fprintf(w, "%d rooms scanned, %d failed to load\n", coverage.RoomCount, len(coverage.FailedRooms))
+	fmt.Fprintf(w, "%d of %d opcodes used are implemented\n", len(coverage.Opcodes)-len(unimplemented), len(coverage.Opcodes))
+	fmt.Fprintf(w, "%d of %d rooms only use implemented opcodes\n", coverage.RoomsFullyImplemented(), coverage.RoomCount)
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	writeRows := func(title string, implemented bool) {
+		fmt.Fprintf(table, "\n%s\nopcode\tname\trooms\tcount\texample rooms\n", title)
+		for _, usage := range coverage.Opcodes {
+			if usage.Implemented == implemented {
+				fmt.Fprintf(table, "0x%02x\t%s\t%d\t%d\t%s\n", usage.Opcode, usage.Name, len(usage.Rooms), usage.Count, formatRoomList(usage.Rooms, 8))
+			}
+		}
+	}
+	writeRows("Unimplemented", false)
+	writeRows("Implemented", true)
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	if len(coverage.FailedRooms) > 0 {
+		fmt.Fprintln(w, "\nFailed to load, only the opcodes before the error are counted")
+		for _, failed := range coverage.FailedRooms {
+			fmt.Fprintf(w, "%s: %s\n", failed.Room, failed.Error)
+		}
+	}
+	return nil
+}
+
+func formatRoomList(rooms []world.RoomKey, limit int) string {
+	names := make([]string, 0, limit)
+	for i, room := range rooms {
+		if i == limit {
+			names = append(names, fmt.Sprintf("and %d more", len(rooms)-limit))
+			break
+		}
+		names = append(names, room.String())
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,124 @@
+package script
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+)
+
+func TestOpcodeCoverage_AddScript(t *testing.T) {
+	_, firstScript := loadTestScript(t, `
+function main
+  Save(VarId=1, Value=1);
+  Save(VarId=2, Value=1);
+  NoOp();
+  EvtEnd();
+`)
+	_, secondScript := loadTestScript(t, `
+function main
+  NoOp();
+  EvtEnd();
+`)
+	coverage := NewOpcodeCoverage()
+	coverage.AddScript(world.RoomKey{Stage: 1, Room: 0x00}, firstScript)
+	coverage.AddScript(world.RoomKey{Stage: 1, Room: 0x01}, secondScript)
+
+	if coverage.RoomCount != 2 {
+		t.Errorf("Expected 2 rooms, got %d", coverage.RoomCount)
+	}
+	expected := []string{"NoOp 2 2 false", "EvtEnd 2 2 true", "Save 1 2 true"}
+	found := make([]string, len(coverage.Opcodes))
+	for i, usage := range coverage.Opcodes {
+		found[i] = fmt.Sprintf("%s %d %d %v", usage.Name, len(usage.Rooms), usage.Count, usage.Implemented)
+	}
+	if strings.Join(found, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("Expected %v, got %v", expected, found)
+	}
+
+	unimplemented := coverage.Unimplemented()
+	if len(unimplemented) != 1 || unimplemented[0].Opcode != fileio.OP_NO_OP {
+		t.Errorf("Expected only NoOp to be unimplemented, got %v", unimplemented)
+	}
+	if rooms := coverage.RoomsFullyImplemented(); rooms != 0 {
+		t.Errorf("Expected no room to be fully implemented, got %d", rooms)
+	}
+
+	var output bytes.Buffer
+	if err := coverage.WriteText(&output); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"2 of 3 opcodes used are implemented", "Unimplemented", "0x00    NoOp"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in\n%s", expected, output.String())
+		}
+	}
+}
+
+// Opcodes with a handler that only partly runs them, so they must still be counted as unimplemented
+var knownStubOpcodes = map[byte]bool{
+	fileio.OP_GOTO:       true,
+	fileio.OP_WSLEEP:     true,
+	fileio.OP_WSLEEPING:  true,
+	fileio.OP_MEMBER_CMP: true,
+	fileio.OP_PLC_MOTION: true,
+	fileio.OP_PLC_DEST:   true,
+	fileio.OP_PLC_NECK:   true,
+}
+
+// Runs every opcode, to check ImplementedOpcodes matches the opcodes that are recorded as skipped
+func TestImplementedOpcodes_MatchExecution(t *testing.T) {
+	for opcode := range knownStubOpcodes {
+		if ImplementedOpcodes[opcode] {
+			t.Errorf("Opcode 0x%02x %s is a stub but is in ImplementedOpcodes", opcode, FunctionName[opcode])
+		}
+	}
+	for opcode, size := range fileio.InstructionSize {
+		if size == 0 {
+			continue
+		}
+		lineData := make([]byte, size)
+		lineData[0] = opcode
+		switch opcode {
+		case fileio.OP_DOOR_AOT_SET, fileio.OP_DOOR_AOT_SET_4P:
+			lineData[2] = world.AOT_DOOR
+		case fileio.OP_ITEM_AOT_SET, fileio.OP_ITEM_AOT_SET_4P:
+			lineData[2] = world.AOT_ITEM
+		}
+		// A switch needs its end, and the loop and if block ends need a block to leave
+		scriptData := fileio.ScriptFunction{
+			StartProgramCounter: []int{0},
+			Instructions:        map[int][]byte{0: lineData, size: {fileio.OP_END_SWITCH, 0}},
+		}
+		scriptDef := NewScriptDef()
+		thread := scriptDef.ScriptThreads[0]
+		thread.RunStatus = true
+		thread.LevelState[0].LoopLevel = 0
+		thread.LevelState[0].IfElseCounter = 0
+		thread.StackIndex = 1
+
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Opcode 0x%02x panicked: %v", opcode, r)
+				}
+			}()
+			returnValue := scriptDef.ExecuteSingleInstruction(0, thread, lineData, scriptData, &recordingHost{})
+			// Stubs run what they can, so only skipped opcodes must carry on to the next instruction
+			if !ImplementedOpcodes[opcode] && !knownStubOpcodes[opcode] && returnValue != INSTRUCTION_NORMAL {
+				t.Errorf("Opcode 0x%02x: expected skipping to return %d, got %d", opcode, INSTRUCTION_NORMAL, returnValue)
+			}
+		}()
+
+		recorded := scriptDef.UnimplementedOpcodes[opcode] > 0
+		if ImplementedOpcodes[opcode] && recorded {
+			t.Errorf("Opcode 0x%02x %s is in ImplementedOpcodes but was recorded as skipped", opcode, FunctionName[opcode])
+		}
+		if !ImplementedOpcodes[opcode] && !recorded {
+			t.Errorf("Opcode 0x%02x %s is not in ImplementedOpcodes but was not recorded as skipped", opcode, FunctionName[opcode])
+		}
+	}
+}
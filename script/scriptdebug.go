@@ -36,7 +36,11 @@ var (
 		fileio.OP_GOSUB:            "Gosub",
 		fileio.OP_GOSUB_RETURN:     "GosubReturn",
 		fileio.OP_BREAK:            "Break",
+		fileio.OP_FOR2:             "For2",
+		fileio.OP_BREAK_POINT:      "BreakPoint",
 		fileio.OP_WORK_COPY:        "WorkCopy",
+		fileio.OP_NO_OP_1E:         "NoOp1E",
+		fileio.OP_NO_OP_1F:         "NoOp1F",
 		fileio.OP_NO_OP2:           "NoOp2",
 		fileio.OP_CHECK:            "CheckBit",
 		fileio.OP_SET_BIT:          "SetBit",
@@ -61,6 +65,7 @@ var (
 		fileio.OP_MEMBER_SET2:      "MemberSet2",
 		fileio.OP_SE_ON:            "SeOn",
 		fileio.OP_SCA_ID_SET:       "ScaIdSet",
+		fileio.OP_FLR_SET:          "FlrSet",
 		fileio.OP_DIR_CK:           "DirCk",
 		fileio.OP_SCE_ESPR_ON:      "SceEsprOn",
 		fileio.OP_DOOR_AOT_SET:     "DoorAotSet",
@@ -73,18 +78,24 @@ var (
 		fileio.OP_PLC_RET:          "PlcRet",
 		fileio.OP_PLC_FLAG:         "PlcFlag",
 		fileio.OP_SCE_EM_SET:       "SceEmSet",
+		fileio.OP_COL_CHG_SET:      "ColChgSet",
 		fileio.OP_AOT_RESET:        "AotReset",
 		fileio.OP_AOT_ON:           "AotOn",
 		fileio.OP_SUPER_SET:        "SuperSet",
+		fileio.OP_SUPER_RESET:      "SuperReset",
+		fileio.OP_PLC_GUN:          "PlcGun",
 		fileio.OP_CUT_REPLACE:      "CutReplace",
 		fileio.OP_SCE_ESPR_KILL:    "SceEsprKill",
 		fileio.OP_DOOR_MODEL_SET:   "DoorModelSet",
 		fileio.OP_ITEM_AOT_SET:     "ItemAotSet",
+		fileio.OP_SCE_KEY_CK:       "SceKeyCk",
 		fileio.OP_SCE_TRG_CK:       "SceTrgCk",
 		fileio.OP_SCE_BGM_CONTROL:  "SceBgmControl",
 		fileio.OP_SCE_ESPR_CONTROL: "SceEsprControl",
 		fileio.OP_SCE_FADE_SET:     "SceFadeSet",
 		fileio.OP_SCE_ESPR3D_ON:    "SceEspr3dOn",
+		fileio.OP_MEMBER_CALC:      "MemberCalc",
+		fileio.OP_MEMBER_CALC2:     "MemberCalc2",
 		fileio.OP_SCE_BGMTBL_SET:   "SceBgmTblSet",
 		fileio.OP_PLC_ROT:          "PlcRot",
 		fileio.OP_XA_ON:            "XaOn",
@@ -110,6 +121,16 @@ var (
 		fileio.OP_SCE_SCR_MOVE:     "SceScrMove",
 		fileio.OP_PARTS_SET:        "PartsSet",
 		fileio.OP_MOVIE_ON:         "MovieOn",
+		fileio.OP_SPLC_RET:         "SplcRet",
+		fileio.OP_SPLC_SCE:         "SplcSce",
+		fileio.OP_SUPER_ON:         "SuperOn",
+		fileio.OP_MIRROR_SET:       "MirrorSet",
+		fileio.OP_SCE_FADE_ADJUST:  "SceFadeAdjust",
+		fileio.OP_SCE_ESPR3D_ON2:   "SceEspr3dOn2",
+		fileio.OP_SCE_ITEM_GET:     "SceItemGet",
+		fileio.OP_SCE_LINE_START:   "SceLineStart",
+		fileio.OP_SCE_LINE_MAIN:    "SceLineMain",
+		fileio.OP_SCE_LINE_END:     "SceLineEnd",
 		fileio.OP_SCE_PARTS_BOMB:   "ScePartsBomb",
 		fileio.OP_SCE_PARTS_DOWN:   "ScePartsDown",
 	}
@@ -0,0 +1,355 @@
+package script
+
+import (
+	"fmt"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+// Debugger for the room scripts
+// Breakpoints stop every thread before an instruction runs, watches stop after a flag or variable changes
+
+const (
+	BREAKPOINT_PROGRAM_COUNTER = "pc"
+	BREAKPOINT_OPCODE          = "opcode"
+	BREAKPOINT_BIT             = "bit"
+	BREAKPOINT_VARIABLE        = "variable"
+
+	DEBUG_STOP_BREAKPOINT = "breakpoint"
+	DEBUG_STOP_WATCH      = "watch"
+	DEBUG_STOP_STEP       = "step"
+	DEBUG_STOP_PAUSE      = "pause"
+
+	ANY_FUNCTION = -1
+)
+
+type ScriptBreakpoint struct {
+	Id             int
+	Kind           string // BREAKPOINT_PROGRAM_COUNTER, BREAKPOINT_OPCODE, BREAKPOINT_BIT or BREAKPOINT_VARIABLE
+	FunctionId     int    // ANY_FUNCTION to stop in every function
+	ProgramCounter int
+	Opcode         byte
+	BitArray       int
+	BitNumber      int
+	VariableId     int
+}
+
+// ScriptDebugStop describes where and why the scripts stopped
+type ScriptDebugStop struct {
+	Reason         string
+	BreakpointId   int // 0 when not stopped by a breakpoint or watch
+	ThreadNum      int // -1 when stopped between ticks
+	FunctionId     int
+	ProgramCounter int
+	Instruction    string
+	Change         string // the flag or variable change that triggered a watch
+}
+
+// ScriptThreadState is a copy of a thread for the debugger
+type ScriptThreadState struct {
+	ThreadNum        int
+	RunStatus        bool
+	ProgramCounter   int
+	SubLevel         int
+	StackIndex       int
+	WorkSetComponent int
+	WorkSetIndex     int
+	FunctionIds      []int
+	Levels           []ScriptLevelState // one for each sub level up to the current one
+	Instruction      string
+}
+
+type ScriptLevelState struct {
+	IfElseCounter int
+	ReturnAddress int
+	Loops         []LoopState // active loops, innermost last
+}
+
+// ScriptDebugOptions changes how the debugger shows the scripts
+type ScriptDebugOptions struct {
+	FlagNames *FlagNames // names for bits and variables, can be nil
+}
+
+type scriptDebugger struct {
+	options      ScriptDebugOptions
+	breakpoints  []ScriptBreakpoint
+	nextId       int
+	paused       bool
+	resumeThread int  // thread to continue from in the next tick
+	skipNext     bool // the instruction stopped at runs when resuming
+	stepping     bool
+	stepThread   int // -1 to stop in any thread
+	stepSubLevel int // -1 to step into sub functions
+	pendingStop  *ScriptDebugStop
+	lastStop     ScriptDebugStop
+	stopHandler  func(stop ScriptDebugStop)
+	scriptData   fileio.ScriptFunction // from the last tick, to show instructions
+
+	// The instruction running now, for watches
+	currentThread         int
+	currentProgramCounter int
+}
+
+func (scriptDef *ScriptDef) getDebugger() *scriptDebugger {
+	if scriptDef.debugger == nil {
+		scriptDef.debugger = &scriptDebugger{nextId: 1, currentThread: -1}
+	}
+	return scriptDef.debugger
+}
+
+// AddBreakpoint stops before the instruction at the program counter runs in a function
+func (scriptDef *ScriptDef) AddBreakpoint(functionId int, programCounter int) int {
+	return scriptDef.getDebugger().add(ScriptBreakpoint{
+		Kind:           BREAKPOINT_PROGRAM_COUNTER,
+		FunctionId:     functionId,
+		ProgramCounter: programCounter,
+	})
+}
+
+// AddOpcodeBreakpoint stops before any instruction with the opcode runs
+func (scriptDef *ScriptDef) AddOpcodeBreakpoint(opcode byte) int {
+	return scriptDef.getDebugger().add(ScriptBreakpoint{Kind: BREAKPOINT_OPCODE, Opcode: opcode})
+}
+
+// WatchBit stops after a bit in a bit array changes
+func (scriptDef *ScriptDef) WatchBit(bitArray int, bitNumber int) int {
+	return scriptDef.getDebugger().add(ScriptBreakpoint{Kind: BREAKPOINT_BIT, BitArray: bitArray, BitNumber: bitNumber})
+}
+
+// WatchVariable stops after a script variable changes
+func (scriptDef *ScriptDef) WatchVariable(variableId int) int {
+	return scriptDef.getDebugger().add(ScriptBreakpoint{Kind: BREAKPOINT_VARIABLE, VariableId: variableId})
+}
+
+// RemoveBreakpoint removes a breakpoint or watch by id
+func (scriptDef *ScriptDef) RemoveBreakpoint(id int) bool {
+	debugger := scriptDef.getDebugger()
+	for i, breakpoint := range debugger.breakpoints {
+		if breakpoint.Id == id {
+			debugger.breakpoints = append(debugger.breakpoints[:i], debugger.breakpoints[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (scriptDef *ScriptDef) Breakpoints() []ScriptBreakpoint {
+	return append([]ScriptBreakpoint(nil), scriptDef.getDebugger().breakpoints...)
+}
+
+func (scriptDef *ScriptDef) SetDebugOptions(options ScriptDebugOptions) {
+	scriptDef.getDebugger().options = options
+}
+
+// SetDebugStopHandler is called every time the scripts stop
+func (scriptDef *ScriptDef) SetDebugStopHandler(handler func(stop ScriptDebugStop)) {
+	scriptDef.getDebugger().stopHandler = handler
+}
+
+func (scriptDef *ScriptDef) IsPaused() bool {
+	return scriptDef.debugger != nil && scriptDef.debugger.paused
+}
+
+// LastDebugStop returns where the scripts stopped the last time
+func (scriptDef *ScriptDef) LastDebugStop() ScriptDebugStop {
+	return scriptDef.getDebugger().lastStop
+}
+
+// Pause stops all threads before the next tick
+func (scriptDef *ScriptDef) Pause() {
+	debugger := scriptDef.getDebugger()
+	if debugger.paused {
+		return
+	}
+	debugger.stepping = false
+	debugger.stop(ScriptDebugStop{Reason: DEBUG_STOP_PAUSE, ThreadNum: -1})
+}
+
+// Continue runs the threads until the next breakpoint or watch
+func (scriptDef *ScriptDef) Continue() {
+	debugger := scriptDef.getDebugger()
+	if !debugger.paused {
+		return
+	}
+	debugger.stepping = false
+	debugger.resume()
+}
+
+// StepInstruction runs one instruction in the stopped thread
+func (scriptDef *ScriptDef) StepInstruction() {
+	scriptDef.step(-1)
+}
+
+// StepOver runs one instruction in the stopped thread, and the whole sub function if it is a gosub
+func (scriptDef *ScriptDef) StepOver() {
+	debugger := scriptDef.getDebugger()
+	subLevel := -1
+	if debugger.lastStop.ThreadNum >= 0 {
+		subLevel = scriptDef.ScriptThreads[debugger.lastStop.ThreadNum].SubLevel
+	}
+	scriptDef.step(subLevel)
+}
+
+func (scriptDef *ScriptDef) step(stepSubLevel int) {
+	debugger := scriptDef.getDebugger()
+	if !debugger.paused {
+		return
+	}
+	debugger.stepping = true
+	debugger.stepThread = debugger.lastStop.ThreadNum
+	debugger.stepSubLevel = stepSubLevel
+	debugger.resume()
+}
+
+// ThreadState copies the state of a thread, with its loop stack
+func (scriptDef *ScriptDef) ThreadState(threadNum int) ScriptThreadState {
+	thread := scriptDef.ScriptThreads[threadNum]
+	state := ScriptThreadState{
+		ThreadNum:        threadNum,
+		RunStatus:        thread.RunStatus,
+		ProgramCounter:   thread.ProgramCounter,
+		SubLevel:         thread.SubLevel,
+		StackIndex:       thread.StackIndex,
+		WorkSetComponent: thread.WorkSetComponent,
+		WorkSetIndex:     thread.WorkSetIndex,
+		FunctionIds:      append([]int(nil), thread.FunctionIds...),
+		Levels:           make([]ScriptLevelState, 0),
+	}
+	for level := 0; level <= thread.SubLevel && level < len(thread.LevelState); level++ {
+		levelState := thread.LevelState[level]
+		levelCopy := ScriptLevelState{
+			IfElseCounter: levelState.IfElseCounter,
+			ReturnAddress: levelState.ReturnAddress,
+			Loops:         make([]LoopState, 0),
+		}
+		for loop := 0; loop <= levelState.LoopLevel && loop < len(levelState.LoopState); loop++ {
+			levelCopy.Loops = append(levelCopy.Loops, *levelState.LoopState[loop])
+		}
+		state.Levels = append(state.Levels, levelCopy)
+	}
+	if scriptDef.debugger != nil && thread.RunStatus {
+		state.Instruction = scriptDef.debugger.instructionAt(thread.ProgramCounter)
+	}
+	return state
+}
+
+func (debugger *scriptDebugger) add(breakpoint ScriptBreakpoint) int {
+	breakpoint.Id = debugger.nextId
+	debugger.nextId++
+	debugger.breakpoints = append(debugger.breakpoints, breakpoint)
+	return breakpoint.Id
+}
+
+func (debugger *scriptDebugger) stop(stop ScriptDebugStop) {
+	debugger.paused = true
+	debugger.stepping = false
+	debugger.pendingStop = nil
+	debugger.lastStop = stop
+	if debugger.stopHandler != nil {
+		debugger.stopHandler(stop)
+	}
+}
+
+func (debugger *scriptDebugger) resume() {
+	debugger.paused = false
+	// Stopped before an instruction ran, so let it run this time
+	debugger.skipNext = debugger.lastStop.ThreadNum >= 0
+}
+
+// The threads were reset by a room change
+func (debugger *scriptDebugger) resetThreads() {
+	debugger.resumeThread = 0
+	debugger.skipNext = false
+	debugger.stepping = false
+}
+
+// shouldStop is checked before each instruction runs
+func (debugger *scriptDebugger) shouldStop(threadNum int, thread *ScriptThread, lineData []byte) bool {
+	debugger.currentThread = threadNum
+	debugger.currentProgramCounter = thread.ProgramCounter
+	if debugger.skipNext {
+		debugger.skipNext = false
+		return false
+	}
+
+	var stop *ScriptDebugStop
+	if debugger.pendingStop != nil {
+		stop = debugger.pendingStop
+	} else if debugger.stepping && (debugger.stepThread < 0 || debugger.stepThread == threadNum) &&
+		(debugger.stepSubLevel < 0 || thread.SubLevel <= debugger.stepSubLevel) {
+		stop = &ScriptDebugStop{Reason: DEBUG_STOP_STEP}
+	} else {
+		stop = debugger.findBreakpoint(thread, lineData)
+	}
+	if stop == nil {
+		return false
+	}
+
+	stop.ThreadNum = threadNum
+	stop.FunctionId = thread.FunctionIds[len(thread.FunctionIds)-1]
+	stop.ProgramCounter = thread.ProgramCounter
+	stop.Instruction = formatInstruction(lineData)
+	debugger.stop(*stop)
+	return true
+}
+
+func (debugger *scriptDebugger) findBreakpoint(thread *ScriptThread, lineData []byte) *ScriptDebugStop {
+	functionId := thread.FunctionIds[len(thread.FunctionIds)-1]
+	for _, breakpoint := range debugger.breakpoints {
+		switch breakpoint.Kind {
+		case BREAKPOINT_PROGRAM_COUNTER:
+			if breakpoint.ProgramCounter != thread.ProgramCounter {
+				continue
+			}
+			if breakpoint.FunctionId != ANY_FUNCTION && breakpoint.FunctionId != functionId {
+				continue
+			}
+		case BREAKPOINT_OPCODE:
+			if breakpoint.Opcode != lineData[0] {
+				continue
+			}
+		default:
+			continue
+		}
+		return &ScriptDebugStop{Reason: DEBUG_STOP_BREAKPOINT, BreakpointId: breakpoint.Id}
+	}
+	return nil
+}
+
+// Watches stop before the next instruction, after the one that made the change
+func (debugger *scriptDebugger) bitChanged(bitArray int, bitNumber int, oldValue int, newValue int) {
+	for _, breakpoint := range debugger.breakpoints {
+		if breakpoint.Kind == BREAKPOINT_BIT && breakpoint.BitArray == bitArray && breakpoint.BitNumber == bitNumber {
+			debugger.watchHit(breakpoint.Id, fmt.Sprintf("%s changed from %d to %d", debugger.options.FlagNames.label(BitFlag(bitArray, bitNumber)), oldValue, newValue))
+			return
+		}
+	}
+}
+
+func (debugger *scriptDebugger) variableChanged(variableId int, oldValue int, newValue int) {
+	for _, breakpoint := range debugger.breakpoints {
+		if breakpoint.Kind == BREAKPOINT_VARIABLE && breakpoint.VariableId == variableId {
+			debugger.watchHit(breakpoint.Id, fmt.Sprintf("%s changed from %d to %d", debugger.options.FlagNames.label(VariableFlag(variableId)), oldValue, newValue))
+			return
+		}
+	}
+}
+
+func (debugger *scriptDebugger) watchHit(breakpointId int, change string) {
+	if debugger.currentThread >= 0 {
+		change += fmt.Sprintf(" by thread %d at pc %d", debugger.currentThread, debugger.currentProgramCounter)
+	}
+	debugger.pendingStop = &ScriptDebugStop{Reason: DEBUG_STOP_WATCH, BreakpointId: breakpointId, Change: change}
+}
+
+func (debugger *scriptDebugger) instructionAt(programCounter int) string {
+	lineData := debugger.scriptData.Instructions[programCounter]
+	if len(lineData) == 0 {
+		return ""
+	}
+	return formatInstruction(lineData)
+}
+
+func formatInstruction(lineData []byte) string {
+	return getFunctionNameFromOpcode(lineData[0]) + GetOpcodeSignature(lineData)
+}
@@ -0,0 +1,376 @@
+package script
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+// instructionOffsets returns the program counters of a function's instructions in order
+func instructionOffsets(scriptData fileio.ScriptFunction, functionId int) []int {
+	start := scriptData.StartProgramCounter[functionId]
+	end := -1
+	if functionId+1 < len(scriptData.StartProgramCounter) {
+		end = scriptData.StartProgramCounter[functionId+1]
+	}
+	offsets := make([]int, 0)
+	for programCounter := range scriptData.Instructions {
+		if programCounter >= start && (end < 0 || programCounter < end) {
+			offsets = append(offsets, programCounter)
+		}
+	}
+	sort.Ints(offsets)
+	return offsets
+}
+
+const debuggerTestScript = `
+function main
+  Save(VarId=1, Value=1);
+  Gosub(Event=@sub);
+  Save(VarId=1, Value=2);
+  SetBit(BitArray=1, BitNumber=3, Operation=1);
+  Save(VarId=1, Value=3);
+  EvtEnd();
+
+function sub
+  Save(VarId=2, Value=1);
+  Save(VarId=2, Value=2);
+  EvtEnd();
+`
+
+func TestDebugger_Breakpoint(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	offsets := instructionOffsets(scriptData, 0)
+	stops := make([]ScriptDebugStop, 0)
+	scriptDef.SetDebugStopHandler(func(stop ScriptDebugStop) { stops = append(stops, stop) })
+	id := scriptDef.AddBreakpoint(0, offsets[2])
+
+	runTestFrames(scriptDef, scriptData, 1)
+	if !scriptDef.IsPaused() {
+		t.Fatal("Expected the scripts to pause at the breakpoint")
+	}
+	if len(stops) != 1 || stops[0].Reason != DEBUG_STOP_BREAKPOINT || stops[0].BreakpointId != id ||
+		stops[0].ThreadNum != 0 || stops[0].ProgramCounter != offsets[2] {
+		t.Fatalf("Expected a stop at breakpoint %d, got %+v", id, stops)
+	}
+	if value := scriptDef.GetScriptVariable(1); value != 1 {
+		t.Errorf("Expected the breakpoint to stop before the instruction, variable 1 is %d", value)
+	}
+
+	// Paused scripts do not run
+	runTestFrames(scriptDef, scriptData, 3)
+	if value := scriptDef.GetScriptVariable(1); value != 1 {
+		t.Errorf("Expected paused scripts not to run, variable 1 is %d", value)
+	}
+
+	scriptDef.RemoveBreakpoint(id)
+	scriptDef.Continue()
+	runTestFrames(scriptDef, scriptData, 1)
+	if value := scriptDef.GetScriptVariable(1); value != 3 {
+		t.Errorf("Expected the thread to finish after continue, variable 1 is %d", value)
+	}
+}
+
+func TestDebugger_ContinueRunsTheStoppedInstruction(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	offsets := instructionOffsets(scriptData, 0)
+	scriptDef.AddBreakpoint(ANY_FUNCTION, offsets[0])
+
+	runTestFrames(scriptDef, scriptData, 1)
+	scriptDef.Continue()
+	runTestFrames(scriptDef, scriptData, 1)
+	if scriptDef.IsPaused() || scriptDef.GetScriptVariable(1) != 3 {
+		t.Errorf("Expected continue to run past the breakpoint, variable 1 is %d", scriptDef.GetScriptVariable(1))
+	}
+}
+
+func TestDebugger_OpcodeBreakpoint(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	scriptDef.AddOpcodeBreakpoint(fileio.OP_SET_BIT)
+
+	runTestFrames(scriptDef, scriptData, 1)
+	stop := scriptDef.LastDebugStop()
+	if !scriptDef.IsPaused() || stop.Reason != DEBUG_STOP_BREAKPOINT || !strings.HasPrefix(stop.Instruction, "SetBit") {
+		t.Fatalf("Expected a stop at SetBit, got %+v", stop)
+	}
+	if value := scriptDef.GetScriptVariable(1); value != 2 {
+		t.Errorf("Expected variable 1 to be 2, got %d", value)
+	}
+}
+
+func TestDebugger_ResumesTheTickFromTheStoppedThread(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, `
+function main
+  EvtExec(ThreadNum=2, ExOpcode=24, Event=1);
+  Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+  Sleep(Dummy=10, Count=30);
+  EvtEnd();
+
+function other
+  Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+  Calc(Dummy=0, Operation=0, VarId=3, Value=1);
+  EvtEnd();
+`)
+	offsets := instructionOffsets(scriptData, 1)
+	scriptDef.AddBreakpoint(1, offsets[1])
+
+	runTestFrames(scriptDef, scriptData, 1)
+	if !scriptDef.IsPaused() || scriptDef.LastDebugStop().ThreadNum != 2 {
+		t.Fatalf("Expected a stop in thread 2, got %+v", scriptDef.LastDebugStop())
+	}
+
+	scriptDef.Continue()
+	runTestFrames(scriptDef, scriptData, 1)
+	// The first tick finishes without running thread 0 again
+	expected := map[int]int{1: 1, 2: 1, 3: 1}
+	for varId, expectedValue := range expected {
+		if value := scriptDef.GetScriptVariable(varId); value != expectedValue {
+			t.Errorf("Expected variable %d to be %d, got %d", varId, expectedValue, value)
+		}
+	}
+}
+
+func TestDebugger_Step(t *testing.T) {
+	tests := []struct {
+		name             string
+		stepOver         bool
+		expectedSubLevel int
+		expectedVar2     int
+	}{
+		{name: "step into a gosub", stepOver: false, expectedSubLevel: 1, expectedVar2: 0},
+		{name: "step over a gosub", stepOver: true, expectedSubLevel: 0, expectedVar2: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+			offsets := instructionOffsets(scriptData, 0)
+			scriptDef.AddBreakpoint(0, offsets[1])
+
+			runTestFrames(scriptDef, scriptData, 1)
+			if scriptDef.LastDebugStop().ProgramCounter != offsets[1] {
+				t.Fatalf("Expected a stop at the gosub, got %+v", scriptDef.LastDebugStop())
+			}
+
+			if test.stepOver {
+				scriptDef.StepOver()
+			} else {
+				scriptDef.StepInstruction()
+			}
+			runTestFrames(scriptDef, scriptData, 1)
+
+			stop := scriptDef.LastDebugStop()
+			if !scriptDef.IsPaused() || stop.Reason != DEBUG_STOP_STEP {
+				t.Fatalf("Expected a step stop, got %+v", stop)
+			}
+			if subLevel := scriptDef.ScriptThreads[0].SubLevel; subLevel != test.expectedSubLevel {
+				t.Errorf("Expected sub level %d, got %d", test.expectedSubLevel, subLevel)
+			}
+			if value := scriptDef.GetScriptVariable(2); value != test.expectedVar2 {
+				t.Errorf("Expected variable 2 to be %d, got %d", test.expectedVar2, value)
+			}
+		})
+	}
+}
+
+func TestDebugger_StepInstruction(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	offsets := instructionOffsets(scriptData, 0)
+	scriptDef.AddBreakpoint(0, offsets[2])
+
+	runTestFrames(scriptDef, scriptData, 1)
+	scriptDef.StepInstruction()
+	runTestFrames(scriptDef, scriptData, 1)
+
+	stop := scriptDef.LastDebugStop()
+	if stop.ProgramCounter != offsets[3] {
+		t.Errorf("Expected a stop at program counter %d, got %d", offsets[3], stop.ProgramCounter)
+	}
+	if value := scriptDef.GetScriptVariable(1); value != 2 {
+		t.Errorf("Expected one instruction to run, variable 1 is %d", value)
+	}
+}
+
+func TestDebugger_Watches(t *testing.T) {
+	tests := []struct {
+		name           string
+		watch          func(scriptDef *ScriptDef) int
+		expectedChange string
+	}{
+		{
+			name:           "bit",
+			watch:          func(scriptDef *ScriptDef) int { return scriptDef.WatchBit(1, 3) },
+			expectedChange: "bit 1:3 changed from 0 to 1",
+		},
+		{
+			name:           "variable",
+			watch:          func(scriptDef *ScriptDef) int { return scriptDef.WatchVariable(2) },
+			expectedChange: "var 2 changed from 0 to 1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+			id := test.watch(scriptDef)
+
+			runTestFrames(scriptDef, scriptData, 1)
+			stop := scriptDef.LastDebugStop()
+			if !scriptDef.IsPaused() || stop.Reason != DEBUG_STOP_WATCH || stop.BreakpointId != id {
+				t.Fatalf("Expected a stop at watch %d, got %+v", id, stop)
+			}
+			if !strings.HasPrefix(stop.Change, test.expectedChange) {
+				t.Errorf("Expected change %q, got %q", test.expectedChange, stop.Change)
+			}
+		})
+	}
+}
+
+func TestDebugger_UnchangedValueDoesNotStop(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, `
+function main
+  Save(VarId=1, Value=0);
+  EvtEnd();
+`)
+	scriptDef.WatchVariable(1)
+	runTestFrames(scriptDef, scriptData, 1)
+	if scriptDef.IsPaused() {
+		t.Errorf("Expected no stop, got %+v", scriptDef.LastDebugStop())
+	}
+}
+
+func TestDebugger_PauseBetweenTicks(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	scriptDef.Pause()
+	runTestFrames(scriptDef, scriptData, 1)
+	if value := scriptDef.GetScriptVariable(1); value != 0 {
+		t.Errorf("Expected paused scripts not to run, variable 1 is %d", value)
+	}
+
+	scriptDef.Continue()
+	runTestFrames(scriptDef, scriptData, 1)
+	if value := scriptDef.GetScriptVariable(1); value != 3 {
+		t.Errorf("Expected the thread to finish after continue, variable 1 is %d", value)
+	}
+}
+
+func TestDebugger_ThreadStateShowsLoops(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, `
+function main
+  ForStart(Dummy=0, BlockLength=@endfor, Count=3);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+  ForEnd(param1=0);
+endfor:
+  EvtEnd();
+`)
+	offsets := instructionOffsets(scriptData, 0)
+	scriptDef.AddBreakpoint(0, offsets[1])
+	runTestFrames(scriptDef, scriptData, 1)
+
+	state := scriptDef.ThreadState(0)
+	if len(state.Levels) != 1 || len(state.Levels[0].Loops) != 1 {
+		t.Fatalf("Expected one level with one loop, got %+v", state.Levels)
+	}
+	if counter := state.Levels[0].Loops[0].Counter; counter != 3 {
+		t.Errorf("Expected loop counter 3, got %d", counter)
+	}
+	if !strings.HasPrefix(state.Instruction, "Calc") {
+		t.Errorf("Expected the next instruction to be Calc, got %q", state.Instruction)
+	}
+}
+
+func TestDebugServer_Execute(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	server := NewDebugServer(scriptDef, ScriptDebugOptions{})
+
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"status", "running\nok"},
+		{"break op SetBit", "breakpoint 1 on SetBit\nok"},
+		{"break * 0x10", "breakpoint 2 at any function pc 16\nok"},
+		{"watch var 2", "watch 3 on var 2\nok"},
+		{"delete 2", "ok"},
+		{"delete 2", "error: no breakpoint 2"},
+		{"continue", "error: not paused"},
+		{"break op Nothing", "error: unknown opcode 'Nothing'"},
+		{"watch", "error: expected bit <array> <bit>, var <id> or a flag name"},
+		{"watch var x", "error: invalid number 'x'"},
+		{"jump", "error: unknown command 'jump', try help"},
+	}
+	for _, test := range tests {
+		if output := server.Execute(test.command); output != test.expected {
+			t.Errorf("Execute(%q): expected %q, got %q", test.command, test.expected, output)
+		}
+	}
+
+	runTestFrames(scriptDef, scriptData, 1)
+	if output := server.Execute("status"); !strings.HasPrefix(output, "paused watch 3, var 2 changed from 0 to 1") {
+		t.Errorf("Expected a stop at the watch, got %q", output)
+	}
+	if output := server.Execute("var 2"); output != "var 2 = 1\nok" {
+		t.Errorf("Expected variable 2 to be 1, got %q", output)
+	}
+	if output := server.Execute("threads"); !strings.HasPrefix(output, "thread 0 function 1") {
+		t.Errorf("Expected thread 0 in function 1, got %q", output)
+	}
+	if output := server.Execute("c"); output != "ok" {
+		t.Errorf("Expected continue to succeed, got %q", output)
+	}
+}
+
+// blockedWriter holds every write until it is released
+type blockedWriter struct {
+	writing chan struct{}
+	release chan struct{}
+	output  strings.Builder
+}
+
+func (writer *blockedWriter) Write(data []byte) (int, error) {
+	writer.writing <- struct{}{}
+	<-writer.release
+	return writer.output.Write(data)
+}
+
+func TestDebugServer_ServeSlowClient(t *testing.T) {
+	scriptDef, _ := loadTestScript(t, debuggerTestScript)
+	server := NewDebugServer(scriptDef, ScriptDebugOptions{})
+	reader, input := io.Pipe()
+	writer := &blockedWriter{writing: make(chan struct{}, 8), release: make(chan struct{})}
+	served := make(chan struct{})
+	go func() {
+		server.Serve(reader, writer)
+		close(served)
+	}()
+
+	// Commands still run while the client isn't reading its output
+	fmt.Fprintln(input, "status")
+	fmt.Fprintln(input, "pause")
+	deadline := time.After(5 * time.Second)
+	for !scriptDef.IsPaused() {
+		server.ProcessCommands()
+		select {
+		case <-deadline:
+			t.Fatal("Timed out waiting for the commands to run")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	select {
+	case <-writer.writing:
+	case <-deadline:
+		t.Fatal("Timed out waiting for the output to be written")
+	}
+
+	input.Close()
+	close(writer.release)
+	<-served
+	if output := writer.output.String(); output != "running\nok\nstopped pause between ticks\nok\n" {
+		t.Errorf("Expected the output of both commands and the stop, got %q", output)
+	}
+}
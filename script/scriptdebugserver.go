@@ -0,0 +1,441 @@
+package script
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Line protocol for the script debugger, over TCP or stdin
+// Each command gets its output followed by "ok" or "error: <message>"
+// Stops are sent to every client as "stopped ..." lines
+
+const debugServerHelp = `commands:
+  break <function|*> <pc>      stop before the instruction at the program counter
+  break op <name|number>       stop before every instruction with the opcode
+  watch bit <array> <bit>      stop after a bit changes
+  watch var <id>               stop after a variable changes
+  watch <name>                 stop after a named bit or variable changes
+  delete <id>                  remove a breakpoint or watch
+  list                         show breakpoints and watches
+  pause                        stop all threads
+  continue | c                 run until the next stop
+  step | s                     run one instruction in the stopped thread
+  next | n                     like step, but run a whole gosub
+  threads                      show the running threads
+  thread <n>                   show a thread with its loop stack
+  bit <array> <bit>            show a bit
+  var <id>                     show a variable
+  status                       show whether the scripts are running
+bits and variables can also be given by their name from the flag names file`
+
+type debugCommand struct {
+	line   string
+	client *debugClient
+}
+
+// Output for a client is queued and written by its own goroutine, so a slow client never blocks the game loop
+type debugClient struct {
+	writer io.Writer
+	ready  chan struct{} // signaled when there is output to write or the client is closed
+
+	mutex   sync.Mutex
+	pending []string
+	closed  bool
+}
+
+// DebugServer reads debugger commands from its clients.
+// The commands are queued and only run by ProcessCommands, so they run between frames of the game loop.
+type DebugServer struct {
+	scriptDef *ScriptDef
+	names     *FlagNames
+	commands  chan debugCommand
+
+	mutex   sync.Mutex // only guards the client list
+	clients []*debugClient
+}
+
+func newDebugClient(writer io.Writer) *debugClient {
+	return &debugClient{
+		writer: writer,
+		ready:  make(chan struct{}, 1),
+	}
+}
+
+// send queues a line of output without waiting for it to be written
+func (client *debugClient) send(line string) {
+	client.mutex.Lock()
+	if !client.closed {
+		client.pending = append(client.pending, line)
+	}
+	client.mutex.Unlock()
+	client.signal()
+}
+
+// close stops the writer once the queued output is written
+func (client *debugClient) close() {
+	client.mutex.Lock()
+	client.closed = true
+	client.mutex.Unlock()
+	client.signal()
+}
+
+func (client *debugClient) signal() {
+	select {
+	case client.ready <- struct{}{}:
+	default:
+	}
+}
+
+// writeOutput writes the queued output until the client is closed.
+// After a failed write the rest of the output is dropped.
+func (client *debugClient) writeOutput() {
+	failed := false
+	for range client.ready {
+		client.mutex.Lock()
+		lines, closed := client.pending, client.closed
+		client.pending = nil
+		client.mutex.Unlock()
+
+		for _, line := range lines {
+			if failed {
+				break
+			}
+			if _, err := fmt.Fprintln(client.writer, line); err != nil {
+				failed = true
+			}
+		}
+		if closed {
+			return
+		}
+	}
+}
+
+func NewDebugServer(scriptDef *ScriptDef, options ScriptDebugOptions) *DebugServer {
+	server := &DebugServer{
+		scriptDef: scriptDef,
+		names:     options.FlagNames,
+		commands:  make(chan debugCommand, 64),
+	}
+	scriptDef.SetDebugOptions(options)
+	scriptDef.SetDebugStopHandler(server.broadcastStop)
+	return server
+}
+
+// Listen accepts clients on a TCP address, such as localhost:4000
+func (server *DebugServer) Listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				server.Serve(conn, conn)
+			}()
+		}
+	}()
+	return nil
+}
+
+// Serve reads commands from one client until the reader is closed.
+// It returns after the output queued for the client is written.
+func (server *DebugServer) Serve(reader io.Reader, writer io.Writer) {
+	client := newDebugClient(writer)
+	server.mutex.Lock()
+	server.clients = append(server.clients, client)
+	server.mutex.Unlock()
+
+	written := make(chan struct{})
+	go func() {
+		client.writeOutput()
+		close(written)
+	}()
+
+	defer func() {
+		server.mutex.Lock()
+		for i, other := range server.clients {
+			if other == client {
+				server.clients = append(server.clients[:i], server.clients[i+1:]...)
+				break
+			}
+		}
+		server.mutex.Unlock()
+		client.close()
+		<-written
+	}()
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		server.commands <- debugCommand{line: line, client: client}
+	}
+}
+
+// ProcessCommands runs the commands received since the last call, without blocking
+func (server *DebugServer) ProcessCommands() {
+	for {
+		select {
+		case command := <-server.commands:
+			command.client.send(server.Execute(command.line))
+		default:
+			return
+		}
+	}
+}
+
+// Execute runs one command and returns its output
+func (server *DebugServer) Execute(line string) string {
+	output, err := server.execute(strings.Fields(line))
+	if err != nil {
+		return output + "error: " + err.Error()
+	}
+	return output + "ok"
+}
+
+func (server *DebugServer) broadcastStop(stop ScriptDebugStop) {
+	line := "stopped " + formatDebugStop(stop)
+	server.mutex.Lock()
+	clients := append([]*debugClient(nil), server.clients...)
+	server.mutex.Unlock()
+	for _, client := range clients {
+		client.send(line)
+	}
+}
+
+func (server *DebugServer) execute(fields []string) (string, error) {
+	scriptDef := server.scriptDef
+	output := &strings.Builder{}
+	command, args := fields[0], fields[1:]
+
+	switch command {
+	case "help":
+		fmt.Fprintln(output, debugServerHelp)
+	case "break":
+		if len(args) == 2 && args[0] == "op" {
+			opcode, err := parseOpcode(args[1])
+			if err != nil {
+				return "", err
+			}
+			id := scriptDef.AddOpcodeBreakpoint(opcode)
+			fmt.Fprintf(output, "breakpoint %d on %s\n", id, getFunctionNameFromOpcode(opcode))
+			return output.String(), nil
+		}
+		if len(args) != 2 {
+			return "", fmt.Errorf("expected break <function|*> <pc> or break op <opcode>")
+		}
+		functionId := ANY_FUNCTION
+		if args[0] != "*" {
+			var err error
+			if functionId, err = parseNumber(args[0]); err != nil {
+				return "", err
+			}
+		}
+		programCounter, err := parseNumber(args[1])
+		if err != nil {
+			return "", err
+		}
+		id := scriptDef.AddBreakpoint(functionId, programCounter)
+		fmt.Fprintf(output, "breakpoint %d at %s\n", id, formatBreakpoint(ScriptBreakpoint{
+			Kind: BREAKPOINT_PROGRAM_COUNTER, FunctionId: functionId, ProgramCounter: programCounter}, server.names))
+	case "watch":
+		flag, err := parseFlag(args, server.names)
+		if err != nil {
+			return "", err
+		}
+		var id int
+		if flag.Kind == FLAG_KIND_BIT {
+			id = scriptDef.WatchBit(flag.Array, flag.Number)
+		} else {
+			id = scriptDef.WatchVariable(flag.Number)
+		}
+		fmt.Fprintf(output, "watch %d on %s\n", id, server.names.label(flag))
+	case "delete":
+		numbers, err := parseNumbers(args)
+		if err != nil || len(numbers) != 1 {
+			return "", fmt.Errorf("expected delete <id>")
+		}
+		if !scriptDef.RemoveBreakpoint(numbers[0]) {
+			return "", fmt.Errorf("no breakpoint %d", numbers[0])
+		}
+	case "list":
+		for _, breakpoint := range scriptDef.Breakpoints() {
+			fmt.Fprintf(output, "%d %s\n", breakpoint.Id, formatBreakpoint(breakpoint, server.names))
+		}
+	case "pause":
+		scriptDef.Pause()
+	case "continue", "c":
+		if !scriptDef.IsPaused() {
+			return "", fmt.Errorf("not paused")
+		}
+		scriptDef.Continue()
+	case "step", "s", "next", "n":
+		if !scriptDef.IsPaused() {
+			return "", fmt.Errorf("not paused")
+		}
+		if command == "next" || command == "n" {
+			scriptDef.StepOver()
+		} else {
+			scriptDef.StepInstruction()
+		}
+	case "threads":
+		for threadNum := range scriptDef.ScriptThreads {
+			state := scriptDef.ThreadState(threadNum)
+			if state.RunStatus {
+				fmt.Fprintln(output, formatThreadSummary(state))
+			}
+		}
+	case "thread":
+		numbers, err := parseNumbers(args)
+		if err != nil || len(numbers) != 1 || numbers[0] < 0 || numbers[0] >= len(scriptDef.ScriptThreads) {
+			return "", fmt.Errorf("expected thread <0-%d>", len(scriptDef.ScriptThreads)-1)
+		}
+		writeThreadState(output, scriptDef.ThreadState(numbers[0]))
+	case "bit", "var":
+		flag, err := parseFlag(fields, server.names)
+		if err != nil {
+			return "", err
+		}
+		if flag.Kind == FLAG_KIND_BIT {
+			fmt.Fprintf(output, "%s = %d\n", server.names.label(flag), scriptDef.ScriptBitArray[flag.Array][flag.Number])
+		} else {
+			fmt.Fprintf(output, "%s = %d\n", server.names.label(flag), scriptDef.GetScriptVariable(flag.Number))
+		}
+	case "status":
+		if scriptDef.IsPaused() {
+			fmt.Fprintf(output, "paused %s\n", formatDebugStop(scriptDef.LastDebugStop()))
+		} else {
+			fmt.Fprintln(output, "running")
+		}
+	default:
+		return "", fmt.Errorf("unknown command '%s', try help", command)
+	}
+	return output.String(), nil
+}
+
+func parseNumber(value string) (int, error) {
+	number, err := strconv.ParseInt(value, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s'", value)
+	}
+	return int(number), nil
+}
+
+func parseNumbers(values []string) ([]int, error) {
+	numbers := make([]int, len(values))
+	for i, value := range values {
+		number, err := parseNumber(value)
+		if err != nil {
+			return nil, err
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// parseFlag accepts bit <array> <bit>, var <id> or a name from the flag names file
+func parseFlag(fields []string, names *FlagNames) (FlagId, error) {
+	usage := fmt.Errorf("expected bit <array> <bit>, var <id> or a flag name")
+	if len(fields) == 1 || len(fields) == 2 {
+		name := fields[len(fields)-1]
+		if flag, exists := names.Lookup(name); exists {
+			if len(fields) == 2 && fields[0] != flag.Kind {
+				return FlagId{}, fmt.Errorf("'%s' is %s", name, flag)
+			}
+			return flag, nil
+		}
+	}
+	if len(fields) == 0 {
+		return FlagId{}, usage
+	}
+	numbers, err := parseNumbers(fields[1:])
+	switch {
+	case fields[0] == FLAG_KIND_BIT && len(fields) == 3 && err == nil:
+		return BitFlag(numbers[0], numbers[1]), nil
+	case fields[0] == FLAG_KIND_VARIABLE && len(fields) == 2 && err == nil:
+		return VariableFlag(numbers[0]), nil
+	case err != nil && (fields[0] == FLAG_KIND_BIT || fields[0] == FLAG_KIND_VARIABLE):
+		return FlagId{}, err
+	}
+	if len(fields) == 1 {
+		return FlagId{}, fmt.Errorf("unknown flag name '%s'", fields[0])
+	}
+	return FlagId{}, usage
+}
+
+// parseOpcode accepts an opcode name such as SetBit or a number such as 0x22
+func parseOpcode(value string) (byte, error) {
+	if number, err := strconv.ParseUint(value, 0, 8); err == nil {
+		return byte(number), nil
+	}
+	if opcode, exists := GetOpcodeFromFunctionName(value); exists {
+		return opcode, nil
+	}
+	return 0, fmt.Errorf("unknown opcode '%s'", value)
+}
+
+func formatBreakpoint(breakpoint ScriptBreakpoint, names *FlagNames) string {
+	switch breakpoint.Kind {
+	case BREAKPOINT_PROGRAM_COUNTER:
+		function := "any function"
+		if breakpoint.FunctionId != ANY_FUNCTION {
+			function = fmt.Sprintf("function %d", breakpoint.FunctionId)
+		}
+		return fmt.Sprintf("%s pc %d", function, breakpoint.ProgramCounter)
+	case BREAKPOINT_OPCODE:
+		return fmt.Sprintf("opcode %s (0x%02x)", getFunctionNameFromOpcode(breakpoint.Opcode), breakpoint.Opcode)
+	case BREAKPOINT_BIT:
+		return "watch " + names.label(BitFlag(breakpoint.BitArray, breakpoint.BitNumber))
+	case BREAKPOINT_VARIABLE:
+		return "watch " + names.label(VariableFlag(breakpoint.VariableId))
+	}
+	return breakpoint.Kind
+}
+
+func formatDebugStop(stop ScriptDebugStop) string {
+	text := stop.Reason
+	if stop.BreakpointId != 0 {
+		text += fmt.Sprintf(" %d", stop.BreakpointId)
+	}
+	if stop.Change != "" {
+		text += ", " + stop.Change + ","
+	}
+	if stop.ThreadNum < 0 {
+		return text + " between ticks"
+	}
+	return text + fmt.Sprintf(" thread %d function %d pc %d: %s",
+		stop.ThreadNum, stop.FunctionId, stop.ProgramCounter, stop.Instruction)
+}
+
+func formatThreadSummary(state ScriptThreadState) string {
+	return fmt.Sprintf("thread %d function %d pc %d sublevel %d: %s",
+		state.ThreadNum, state.FunctionIds[len(state.FunctionIds)-1], state.ProgramCounter, state.SubLevel, state.Instruction)
+}
+
+func writeThreadState(output io.Writer, state ScriptThreadState) {
+	fmt.Fprintf(output, "thread %d running %v\n", state.ThreadNum, state.RunStatus)
+	fmt.Fprintf(output, "  pc %d: %s\n", state.ProgramCounter, state.Instruction)
+	fmt.Fprintf(output, "  functions %v\n", state.FunctionIds)
+	fmt.Fprintf(output, "  sublevel %d stack index %d work set %d:%d\n",
+		state.SubLevel, state.StackIndex, state.WorkSetComponent, state.WorkSetIndex)
+	for level, levelState := range state.Levels {
+		fmt.Fprintf(output, "  level %d if/else counter %d return address %d\n",
+			level, levelState.IfElseCounter, levelState.ReturnAddress)
+		for loop, loopState := range levelState.Loops {
+			fmt.Fprintf(output, "    loop %d counter %d start %d break %d\n",
+				loop, loopState.Counter, loopState.StackValue, loopState.Break)
+		}
+	}
+}
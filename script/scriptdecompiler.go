@@ -0,0 +1,490 @@
+package script
+
+// Rebuilds nested control flow from the block lengths in script functions
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+const (
+	SCRIPT_BLOCK_IF      = "if"
+	SCRIPT_BLOCK_FOR     = "for"
+	SCRIPT_BLOCK_WHILE   = "while"
+	SCRIPT_BLOCK_DO      = "do"
+	SCRIPT_BLOCK_SWITCH  = "switch"
+	SCRIPT_BLOCK_CASE    = "case"
+	SCRIPT_BLOCK_DEFAULT = "default"
+)
+
+// Opcodes that can stop an if or while block
+var conditionOpcodes = map[byte]bool{
+	fileio.OP_CHECK:        true,
+	fileio.OP_COMPARE:      true,
+	fileio.OP_MEMBER_CMP:   true,
+	fileio.OP_DIR_CK:       true,
+	fileio.OP_KEEP_ITEM_CK: true,
+	fileio.OP_SCE_TRG_CK:   true,
+}
+
+var compareOperators = []string{"==", ">", ">=", "<", "<=", "!=", "&"}
+
+var calcOperators = []string{"+=", "-=", "*=", "/=", "%=", "|=", "&=", "^=", "~", "<<=", ">>=", ">>>="}
+
+// ScriptNode is an instruction, or a block with the instructions inside it
+type ScriptNode struct {
+	Position   int           `json:"position"` // program counter
+	Opcode     int           `json:"opcode"`
+	Name       string        `json:"name"`
+	Params     string        `json:"params,omitempty"`
+	Annotation string        `json:"annotation,omitempty"`
+	Block      string        `json:"block,omitempty"`
+	Conditions []*ScriptNode `json:"conditions,omitempty"`
+	Children   []*ScriptNode `json:"children,omitempty"`
+	Else       []*ScriptNode `json:"else,omitempty"`
+	Target     *int          `json:"target,omitempty"` // function for gosub, program counter for goto
+
+	lineBytes []byte
+}
+
+type DecompiledFunction struct {
+	Index int           `json:"index"`
+	Start int           `json:"start"`
+	Nodes []*ScriptNode `json:"nodes"`
+}
+
+type scriptLine struct {
+	position  int
+	lineBytes []byte
+}
+
+// DecompileScript splits the script into functions and rebuilds the blocks in each function.
+// Flags and variables in the annotations are shown by their names, if names is not nil.
+func DecompileScript(scriptData fileio.ScriptFunction, names *FlagNames) []DecompiledFunction {
+	functions := make([]DecompiledFunction, len(scriptData.StartProgramCounter))
+	for i, start := range scriptData.StartProgramCounter {
+		end := -1
+		if i+1 < len(scriptData.StartProgramCounter) {
+			end = scriptData.StartProgramCounter[i+1]
+		}
+		lines := readScriptLines(scriptData.Instructions, start, end)
+
+		lineEnd := start
+		if len(lines) > 0 {
+			lastLine := lines[len(lines)-1]
+			lineEnd = lastLine.position + len(lastLine.lineBytes)
+		}
+		decompiler := &scriptDecompiler{lines: lines, lineIndex: make(map[int]int), names: names}
+		for index, line := range lines {
+			decompiler.lineIndex[line.position] = index
+		}
+
+		functions[i] = DecompiledFunction{
+			Index: i,
+			Start: start,
+			Nodes: decompiler.parseBlock(start, lineEnd),
+		}
+	}
+	return functions
+}
+
+// readScriptLines follows the instruction sizes, skipping the sleeping instruction inside sleep
+func readScriptLines(instructions map[int][]byte, start int, end int) []scriptLine {
+	lines := make([]scriptLine, 0)
+	position := start
+	for end < 0 || position < end {
+		lineBytes, exists := instructions[position]
+		if !exists || len(lineBytes) == 0 {
+			break
+		}
+		lines = append(lines, scriptLine{position: position, lineBytes: lineBytes})
+		size := fileio.InstructionSize[lineBytes[0]]
+		if size == 0 || lineBytes[0] == fileio.OP_EVT_END {
+			break
+		}
+		position += size
+	}
+	return lines
+}
+
+type scriptDecompiler struct {
+	lines     []scriptLine
+	lineIndex map[int]int // position to index in lines
+	names     *FlagNames
+}
+
+func (d *scriptDecompiler) lineAt(position int) (scriptLine, bool) {
+	index, exists := d.lineIndex[position]
+	if !exists {
+		return scriptLine{}, false
+	}
+	return d.lines[index], true
+}
+
+// parseBlock converts the instructions from start up to end
+func (d *scriptDecompiler) parseBlock(start int, end int) []*ScriptNode {
+	nodes := make([]*ScriptNode, 0)
+	position := start
+	for position < end {
+		line, exists := d.lineAt(position)
+		if !exists {
+			break
+		}
+		node, next := d.parseLine(line, end)
+		nodes = append(nodes, node)
+		position = next
+	}
+	return nodes
+}
+
+// parseLine returns the node and the position after it
+func (d *scriptDecompiler) parseLine(line scriptLine, end int) (*ScriptNode, int) {
+	node := NewScriptNode(line.position, line.lineBytes, d.names)
+	lineBytes := line.lineBytes
+	opcode := lineBytes[0]
+	next := line.position + len(lineBytes)
+	blockLength := func(offset int) int {
+		return int(binary.LittleEndian.Uint16(lineBytes[offset : offset+2]))
+	}
+
+	switch opcode {
+	case fileio.OP_IF_START:
+		blockEnd := next + blockLength(2)
+		if blockEnd > end {
+			return node, next
+		}
+		node.Block = SCRIPT_BLOCK_IF
+		bodyStart := d.parseConditions(node, next, blockEnd)
+
+		// The else instruction is at the end of the if block
+		elseLine, hasElse := d.lineAt(blockEnd - fileio.InstructionSize[fileio.OP_ELSE_START])
+		if hasElse && elseLine.lineBytes[0] == fileio.OP_ELSE_START && elseLine.position >= bodyStart {
+			elseEnd := elseLine.position + int(binary.LittleEndian.Uint16(elseLine.lineBytes[2:4]))
+			if elseEnd >= blockEnd && elseEnd <= end {
+				node.Children = trimBlockEnd(d.parseBlock(bodyStart, elseLine.position), fileio.OP_END_IF)
+				node.Else = trimBlockEnd(d.parseBlock(blockEnd, elseEnd), fileio.OP_END_IF)
+				return node, elseEnd
+			}
+		}
+		node.Children = trimBlockEnd(d.parseBlock(bodyStart, blockEnd), fileio.OP_END_IF)
+		return node, blockEnd
+	case fileio.OP_FOR:
+		blockEnd := next + blockLength(2)
+		if blockEnd > end {
+			return node, next
+		}
+		node.Block = SCRIPT_BLOCK_FOR
+		node.Children = trimBlockEnd(d.parseBlock(next, blockEnd), fileio.OP_FOR_END)
+		return node, blockEnd
+	case fileio.OP_WHILE_START:
+		blockEnd := next + blockLength(2)
+		if blockEnd > end {
+			return node, next
+		}
+		node.Block = SCRIPT_BLOCK_WHILE
+		bodyStart := d.parseConditions(node, next, blockEnd)
+		node.Children = trimBlockEnd(d.parseBlock(bodyStart, blockEnd), fileio.OP_WHILE_END)
+		return node, blockEnd
+	case fileio.OP_DO_START:
+		blockEnd := next + blockLength(2)
+		if blockEnd > end {
+			return node, next
+		}
+		node.Block = SCRIPT_BLOCK_DO
+		node.Children, node.Conditions = splitDoConditions(d.parseBlock(next, blockEnd))
+		return node, blockEnd
+	case fileio.OP_SWITCH:
+		blockEnd := next + blockLength(2)
+		if blockEnd > end {
+			return node, next
+		}
+		node.Block = SCRIPT_BLOCK_SWITCH
+		node.Children = d.parseSwitchCases(next, blockEnd)
+		return node, blockEnd
+	}
+	return node, next
+}
+
+// parseConditions moves the condition instructions at the start of a block into the node
+func (d *scriptDecompiler) parseConditions(node *ScriptNode, start int, end int) int {
+	position := start
+	for position < end {
+		line, exists := d.lineAt(position)
+		if !exists || !conditionOpcodes[line.lineBytes[0]] {
+			break
+		}
+		node.Conditions = append(node.Conditions, NewScriptNode(line.position, line.lineBytes, d.names))
+		position += len(line.lineBytes)
+	}
+	return position
+}
+
+func (d *scriptDecompiler) parseSwitchCases(start int, end int) []*ScriptNode {
+	nodes := make([]*ScriptNode, 0)
+	position := start
+	for position < end {
+		line, exists := d.lineAt(position)
+		if !exists {
+			break
+		}
+
+		switch line.lineBytes[0] {
+		case fileio.OP_CASE:
+			node := NewScriptNode(line.position, line.lineBytes, d.names)
+			caseEnd := position + len(line.lineBytes) + int(binary.LittleEndian.Uint16(line.lineBytes[2:4]))
+			if caseEnd > end || caseEnd <= position {
+				caseEnd = end
+			}
+			node.Block = SCRIPT_BLOCK_CASE
+			node.Children = d.parseBlock(position+len(line.lineBytes), caseEnd)
+			nodes = append(nodes, node)
+			position = caseEnd
+		case fileio.OP_DEFAULT:
+			node := NewScriptNode(line.position, line.lineBytes, d.names)
+			node.Block = SCRIPT_BLOCK_DEFAULT
+			node.Children = trimBlockEnd(d.parseBlock(position+len(line.lineBytes), end), fileio.OP_END_SWITCH)
+			nodes = append(nodes, node)
+			position = end
+		case fileio.OP_END_SWITCH:
+			position += len(line.lineBytes)
+		default:
+			node, next := d.parseLine(line, end)
+			nodes = append(nodes, node)
+			position = next
+		}
+	}
+	return nodes
+}
+
+// trimBlockEnd removes the instruction that closes a block, which is shown as the end of the block
+// The conditions of a do block come after the do end instruction at the end of the block
+func splitDoConditions(nodes []*ScriptNode) ([]*ScriptNode, []*ScriptNode) {
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i].Opcode == int(fileio.OP_DO_END) && nodes[i].Block == "" {
+			return nodes[:i], nodes[i+1:]
+		}
+		if !conditionOpcodes[byte(nodes[i].Opcode)] || nodes[i].Block != "" {
+			break
+		}
+	}
+	return nodes, nil
+}
+
+func trimBlockEnd(nodes []*ScriptNode, opcode byte) []*ScriptNode {
+	if len(nodes) > 0 && nodes[len(nodes)-1].Opcode == int(opcode) && nodes[len(nodes)-1].Block == "" {
+		return nodes[:len(nodes)-1]
+	}
+	return nodes
+}
+
+func NewScriptNode(position int, lineBytes []byte, names *FlagNames) *ScriptNode {
+	signature := GetOpcodeSignature(lineBytes)
+	node := &ScriptNode{
+		Position:   position,
+		Opcode:     int(lineBytes[0]),
+		Name:       getFunctionNameFromOpcode(lineBytes[0]),
+		Params:     strings.TrimSuffix(strings.TrimPrefix(signature, "("), ");"),
+		Annotation: AnnotateInstruction(lineBytes, names),
+		lineBytes:  lineBytes,
+	}
+	if node.Name == "" {
+		node.Name = fmt.Sprintf("Unknown%02X", lineBytes[0])
+	}
+
+	switch lineBytes[0] {
+	case fileio.OP_GOSUB:
+		target := int(readInstruction[fileio.ScriptInstrGoSub](lineBytes).Event)
+		node.Target = &target
+	case fileio.OP_GOTO:
+		target := position + int(readInstruction[fileio.ScriptInstrGoto](lineBytes).Offset)
+		node.Target = &target
+	}
+	return node
+}
+
+// AnnotateInstruction describes flags, variables, triggers and doors used by an instruction
+func AnnotateInstruction(lineBytes []byte, names *FlagNames) string {
+	if len(lineBytes) < fileio.InstructionSize[lineBytes[0]] {
+		return ""
+	}
+
+	switch lineBytes[0] {
+	case fileio.OP_EVT_EXEC:
+		instruction := readInstruction[fileio.ScriptInstrEventExec](lineBytes)
+		return fmt.Sprintf("run function %d on thread %d", instruction.Event, instruction.ThreadNum)
+	case fileio.OP_GOSUB:
+		instruction := readInstruction[fileio.ScriptInstrGoSub](lineBytes)
+		return fmt.Sprintf("call function %d", instruction.Event)
+	case fileio.OP_CHECK:
+		instruction := readInstruction[fileio.ScriptInstrCheckBitTest](lineBytes)
+		return fmt.Sprintf("%s == %d", names.bitExpression(instruction.BitArray, instruction.BitNumber), instruction.Value)
+	case fileio.OP_SET_BIT:
+		instruction := readInstruction[fileio.ScriptInstrSetBit](lineBytes)
+		bit := names.bitExpression(instruction.BitArray, instruction.BitNumber)
+		switch instruction.Operation {
+		case 0:
+			return bit + " = 0"
+		case 1:
+			return bit + " = 1"
+		case 7:
+			return bit + " ^= 1"
+		}
+		return fmt.Sprintf("%s invalid operation %d", bit, instruction.Operation)
+	case fileio.OP_COMPARE:
+		instruction := readInstruction[fileio.ScriptInstrCompare](lineBytes)
+		return fmt.Sprintf("%s %s %d", names.variableExpression(instruction.VarId), lookupOperator(compareOperators, int(instruction.Operation)), instruction.Value)
+	case fileio.OP_SAVE:
+		instruction := readInstruction[fileio.ScriptInstrSave](lineBytes)
+		return fmt.Sprintf("%s = %d", names.variableExpression(instruction.VarId), instruction.Value)
+	case fileio.OP_COPY:
+		instruction := readInstruction[fileio.ScriptInstrCopy](lineBytes)
+		return fmt.Sprintf("%s = %s", names.variableExpression(instruction.DestVarId), names.variableExpression(instruction.SourceVarId))
+	case fileio.OP_CALC:
+		instruction := readInstruction[fileio.ScriptInstrCalc](lineBytes)
+		return fmt.Sprintf("%s %s %d", names.variableExpression(instruction.VarId), lookupOperator(calcOperators, int(instruction.Operation)), instruction.Value)
+	case fileio.OP_CALC2:
+		instruction := readInstruction[fileio.ScriptInstrCalc2](lineBytes)
+		return fmt.Sprintf("%s %s %s", names.variableExpression(instruction.VarId), lookupOperator(calcOperators, int(instruction.Operation)), names.variableExpression(instruction.SourceVarId))
+	case fileio.OP_MEMBER_CMP:
+		instruction := readInstruction[fileio.ScriptInstrMemberCompare](lineBytes)
+		return fmt.Sprintf("member[%d] %s %d", instruction.MemberIndex, lookupOperator(compareOperators, int(instruction.CompareOperation)), instruction.Value)
+	case fileio.OP_CUT_CHG:
+		instruction := readInstruction[fileio.ScriptInstrCutChg](lineBytes)
+		return fmt.Sprintf("camera %d", instruction.CameraId)
+	case fileio.OP_AOT_SET:
+		instruction := readInstruction[fileio.ScriptInstrAotSet](lineBytes)
+		return fmt.Sprintf("aot %d type %d %s", instruction.Aot, instruction.Type,
+			formatRectangle(instruction.X, instruction.Z, instruction.Width, instruction.Depth))
+	case fileio.OP_AOT_SET_4P:
+		instruction := readInstruction[fileio.ScriptInstrAotSet4p](lineBytes)
+		return fmt.Sprintf("aot %d type %d %s", instruction.Aot, instruction.Type,
+			formatQuad(instruction.X1, instruction.Z1, instruction.X2, instruction.Z2, instruction.X3, instruction.Z3, instruction.X4, instruction.Z4))
+	case fileio.OP_ITEM_AOT_SET:
+		instruction := readInstruction[fileio.ScriptInstrItemAotSet](lineBytes)
+		return fmt.Sprintf("aot %d item %d x%d %s, picked flag %d", instruction.Aot, instruction.ItemId, instruction.Amount,
+			formatRectangle(instruction.X, instruction.Z, instruction.Width, instruction.Depth), instruction.ItemPickedIndex)
+	case fileio.OP_ITEM_AOT_SET_4P:
+		instruction := readInstruction[fileio.ScriptInstrItemAotSet4p](lineBytes)
+		return fmt.Sprintf("aot %d item %d x%d %s, picked flag %d", instruction.Aot, instruction.ItemId, instruction.Amount,
+			formatQuad(instruction.X1, instruction.Z1, instruction.X2, instruction.Z2, instruction.X3, instruction.Z3, instruction.X4, instruction.Z4),
+			instruction.ItemPickedIndex)
+	case fileio.OP_DOOR_AOT_SET:
+		instruction := readInstruction[fileio.ScriptInstrDoorAotSet](lineBytes)
+		return fmt.Sprintf("aot %d door %s to %s", instruction.Aot,
+			formatRectangle(instruction.X, instruction.Z, instruction.Width, instruction.Depth),
+			formatDoorTarget(instruction.Stage, instruction.Room, instruction.Camera, instruction.NextX, instruction.NextY, instruction.NextZ, instruction.NextDir))
+	case fileio.OP_DOOR_AOT_SET_4P:
+		instruction := readInstruction[fileio.ScriptInstrDoorAotSet4p](lineBytes)
+		return fmt.Sprintf("aot %d door %s to %s", instruction.Aot,
+			formatQuad(instruction.X1, instruction.Z1, instruction.X2, instruction.Z2, instruction.X3, instruction.Z3, instruction.X4, instruction.Z4),
+			formatDoorTarget(instruction.Stage, instruction.Room, instruction.Camera, instruction.NextX, instruction.NextY, instruction.NextZ, instruction.NextDir))
+	case fileio.OP_AOT_RESET:
+		instruction := readInstruction[fileio.ScriptInstrAotReset](lineBytes)
+		return fmt.Sprintf("reset aot %d type %d", instruction.Aot, instruction.Type)
+	case fileio.OP_POS_SET:
+		instruction := readInstruction[fileio.ScriptInstrPosSet](lineBytes)
+		return fmt.Sprintf("position %s", formatCoords3D(instruction.X, instruction.Y, instruction.Z))
+	case fileio.OP_SCE_EM_SET:
+		instruction := readInstruction[fileio.ScriptInstrSceEmSet](lineBytes)
+		return fmt.Sprintf("enemy %d type %d at %s", instruction.Id, instruction.Type, formatCoords3D(instruction.X, instruction.Y, instruction.Z))
+	}
+	return ""
+}
+
+func lookupOperator(operators []string, operation int) string {
+	if operation < 0 || operation >= len(operators) {
+		return fmt.Sprintf("op%d", operation)
+	}
+	return operators[operation]
+}
+
+func formatRectangle(x, z, width, depth int16) string {
+	return fmt.Sprintf("(%d, %d)-(%d, %d)", x, z, int(x)+int(width), int(z)+int(depth))
+}
+
+func formatQuad(x1, z1, x2, z2, x3, z3, x4, z4 int16) string {
+	return fmt.Sprintf("(%d, %d) (%d, %d) (%d, %d) (%d, %d)", x1, z1, x2, z2, x3, z3, x4, z4)
+}
+
+// The door stage starts from 0, the room file starts from 1
+func formatDoorTarget(stage, room, camera uint8, x, y, z, dir int16) string {
+	return fmt.Sprintf("room %d%02X camera %d at %s dir %d", int(stage)+1, room, camera, formatCoords3D(x, y, z), dir)
+}
+
+// FormatDecompiledScript writes each function as indented pseudocode
+func FormatDecompiledScript(functions []DecompiledFunction, names *FlagNames) string {
+	var builder strings.Builder
+	for i, function := range functions {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		fmt.Fprintf(&builder, "function %d {\n", function.Index)
+		writeScriptNodes(&builder, function.Nodes, 1, names)
+		builder.WriteString("}\n")
+	}
+	return builder.String()
+}
+
+func writeScriptNodes(builder *strings.Builder, nodes []*ScriptNode, depth int, names *FlagNames) {
+	for _, node := range nodes {
+		writeScriptNode(builder, node, depth, names)
+	}
+}
+
+func writeScriptNode(builder *strings.Builder, node *ScriptNode, depth int, names *FlagNames) {
+	indent := strings.Repeat("    ", depth)
+	switch node.Block {
+	case SCRIPT_BLOCK_IF, SCRIPT_BLOCK_WHILE:
+		fmt.Fprintf(builder, "%s%s (%s) {\n", indent, node.Block, formatConditions(node.Conditions))
+	case SCRIPT_BLOCK_FOR:
+		fmt.Fprintf(builder, "%sfor (%d times) {\n", indent, readInstruction[fileio.ScriptInstrForStart](node.lineBytes).Count)
+	case SCRIPT_BLOCK_DO:
+		fmt.Fprintf(builder, "%sdo {\n", indent)
+	case SCRIPT_BLOCK_SWITCH:
+		fmt.Fprintf(builder, "%sswitch (%s) {\n", indent, names.variableExpression(readInstruction[fileio.ScriptInstrSwitch](node.lineBytes).VarId))
+	case SCRIPT_BLOCK_CASE:
+		fmt.Fprintf(builder, "%scase %d:\n", indent, readInstruction[fileio.ScriptInstrSwitchCase](node.lineBytes).Value)
+		writeScriptNodes(builder, node.Children, depth+1, names)
+		return
+	case SCRIPT_BLOCK_DEFAULT:
+		fmt.Fprintf(builder, "%sdefault:\n", indent)
+		writeScriptNodes(builder, node.Children, depth+1, names)
+		return
+	default:
+		fmt.Fprintf(builder, "%s%s(%s);", indent, node.Name, node.Params)
+		if node.Annotation != "" {
+			fmt.Fprintf(builder, " // %s", node.Annotation)
+		}
+		builder.WriteString("\n")
+		return
+	}
+
+	writeScriptNodes(builder, node.Children, depth+1, names)
+	if node.Else != nil {
+		fmt.Fprintf(builder, "%s} else {\n", indent)
+		writeScriptNodes(builder, node.Else, depth+1, names)
+	}
+	if node.Block == SCRIPT_BLOCK_DO {
+		fmt.Fprintf(builder, "%s} while (%s);\n", indent, formatConditions(node.Conditions))
+		return
+	}
+	fmt.Fprintf(builder, "%s}\n", indent)
+}
+
+func formatConditions(conditions []*ScriptNode) string {
+	if len(conditions) == 0 {
+		return "true"
+	}
+	parts := make([]string, len(conditions))
+	for i, condition := range conditions {
+		if condition.Annotation != "" {
+			parts[i] = condition.Annotation
+		} else {
+			parts[i] = fmt.Sprintf("%s(%s)", condition.Name, condition.Params)
+		}
+	}
+	return strings.Join(parts, " && ")
+}
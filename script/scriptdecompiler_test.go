@@ -0,0 +1,174 @@
+package script
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+func decompileTestScript(t *testing.T, text string, names *FlagNames) []DecompiledFunction {
+	data, err := AssembleScript(text)
+	if err != nil {
+		t.Fatalf("AssembleScript() error: %v", err)
+	}
+	scdOutput, err := fileio.LoadRDT_SCDStream(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("LoadRDT_SCDStream() error: %v", err)
+	}
+	return DecompileScript(scdOutput.ScriptData, names)
+}
+
+func TestDecompileScript_Blocks(t *testing.T) {
+	functions := decompileTestScript(t, `
+function main
+  IfStart(Dummy=0, BlockLength=@else);
+    CheckBit(BitArray=1, BitNumber=2, Value=1);
+    SetBit(BitArray=1, BitNumber=3, Operation=1);
+  ElseStart(Dummy=0, BlockLength=@endif);
+else:
+    Gosub(Event=@sub);
+  EndIf();
+endif:
+  ForStart(Dummy=0, BlockLength=@forend, Count=3);
+    CutChg(CameraId=2);
+  ForEnd(param1=0);
+forend:
+  Switch(VarId=4, BlockLength=@switchend);
+  Case(Dummy=0, BlockLength=@case2, Value=1);
+    Save(VarId=5, Value=10);
+    Break(param1=0);
+case2:
+  Default(param1=0);
+    Save(VarId=5, Value=20);
+  EndSwitch(param1=0);
+switchend:
+  EvtEnd();
+
+function sub
+  Sleep(Dummy=0, Count=30);
+  EvtEnd();
+`, nil)
+	if len(functions) != 2 {
+		t.Fatalf("Expected 2 functions, got %d", len(functions))
+	}
+
+	nodes := functions[0].Nodes
+	if len(nodes) != 4 {
+		t.Fatalf("Expected if, for, switch and end, got %d nodes", len(nodes))
+	}
+
+	ifNode := nodes[0]
+	if ifNode.Block != SCRIPT_BLOCK_IF || len(ifNode.Conditions) != 1 || len(ifNode.Children) != 1 || len(ifNode.Else) != 1 {
+		t.Fatalf("Unexpected if block: %+v", ifNode)
+	}
+	if ifNode.Conditions[0].Annotation != "flag[1][2] == 1" {
+		t.Errorf("Unexpected condition annotation %q", ifNode.Conditions[0].Annotation)
+	}
+	if gosub := ifNode.Else[0]; gosub.Target == nil || *gosub.Target != 1 {
+		t.Errorf("Expected gosub to function 1, got %+v", gosub)
+	}
+
+	forNode := nodes[1]
+	if forNode.Block != SCRIPT_BLOCK_FOR || len(forNode.Children) != 1 || forNode.Children[0].Opcode != fileio.OP_CUT_CHG {
+		t.Errorf("Unexpected for block: %+v", forNode)
+	}
+
+	switchNode := nodes[2]
+	if switchNode.Block != SCRIPT_BLOCK_SWITCH || len(switchNode.Children) != 2 {
+		t.Fatalf("Unexpected switch block: %+v", switchNode)
+	}
+	if switchNode.Children[0].Block != SCRIPT_BLOCK_CASE || len(switchNode.Children[0].Children) != 2 {
+		t.Errorf("Unexpected case block: %+v", switchNode.Children[0])
+	}
+	if switchNode.Children[1].Block != SCRIPT_BLOCK_DEFAULT || len(switchNode.Children[1].Children) != 1 {
+		t.Errorf("Unexpected default block: %+v", switchNode.Children[1])
+	}
+
+	// The sleeping instruction inside sleep isn't shown
+	if len(functions[1].Nodes) != 2 || functions[1].Nodes[0].Opcode != fileio.OP_SLEEP {
+		t.Errorf("Unexpected sub function: %+v", functions[1].Nodes)
+	}
+
+	text := FormatDecompiledScript(functions, nil)
+	for _, expected := range []string{
+		"    if (flag[1][2] == 1) {\n        SetBit(",
+		"    } else {\n        Gosub(Event=1); // call function 1\n    }\n",
+		"    for (3 times) {\n",
+		"    switch (var[4]) {\n        case 1:\n            Save(",
+		"        default:\n",
+		"function 1 {\n    Sleep(",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected output to contain %q, got\n%s", expected, text)
+		}
+	}
+}
+
+func TestDecompileScript_DoWhile(t *testing.T) {
+	functions := decompileTestScript(t, `
+function main
+  DoStart(Dummy=0, BlockLength=@end);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+  DoEnd(param1=0);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=4);
+end:
+  EvtEnd();
+`, nil)
+	doNode := functions[0].Nodes[0]
+	if doNode.Block != SCRIPT_BLOCK_DO || len(doNode.Children) != 1 || len(doNode.Conditions) != 1 {
+		t.Fatalf("Unexpected do block: %+v", doNode)
+	}
+
+	text := FormatDecompiledScript(functions, nil)
+	expected := "    do {\n        Calc(Dummy=0, Operation=0, VarId=1, Value=1); // var[1] += 1\n    } while (var[1] < 4);\n"
+	if !strings.Contains(text, expected) {
+		t.Errorf("Expected output to contain %q, got\n%s", expected, text)
+	}
+}
+
+func TestDecompileScript_InvalidBlockLength(t *testing.T) {
+	functions := decompileTestScript(t, `
+  IfStart(Dummy=0, BlockLength=200);
+  SetBit(BitArray=1, BitNumber=3, Operation=1);
+  EvtEnd();
+`, nil)
+	// A block past the end of the function is shown as plain instructions
+	nodes := functions[0].Nodes
+	if len(nodes) != 3 || nodes[0].Block != "" {
+		t.Errorf("Expected 3 plain instructions, got %+v", nodes)
+	}
+}
+
+func TestAnnotateInstruction_Calc(t *testing.T) {
+	tests := []struct {
+		instruction string
+		expected    string
+	}{
+		{"Calc(Dummy=0, Operation=9, VarId=3, Value=2);", "var[3] <<= 2"},
+		{"Calc(Dummy=0, Operation=10, VarId=3, Value=2);", "var[3] >>= 2"},
+		{"Calc(Dummy=0, Operation=11, VarId=3, Value=2);", "var[3] >>>= 2"},
+		{"Calc2(Operation=11, VarId=3, SourceVarId=4);", "var[3] >>>= var[4]"},
+	}
+	for _, tt := range tests {
+		lineBytes, err := AssembleInstruction(tt.instruction)
+		if err != nil {
+			t.Fatalf("AssembleInstruction() error: %v", err)
+		}
+		if annotation := AnnotateInstruction(lineBytes, nil); annotation != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.instruction, tt.expected, annotation)
+		}
+	}
+}
+
+func TestAnnotateInstruction_Door(t *testing.T) {
+	lineBytes, err := AssembleInstruction("DoorAotSet(Aot=2, X=-1000, Z=500, Width=1200, Depth=800, NextX=100, NextY=0, NextZ=-200, NextDir=1024, Stage=0, Room=5, Camera=3);")
+	if err != nil {
+		t.Fatalf("AssembleInstruction() error: %v", err)
+	}
+	expected := "aot 2 door (-1000, 500)-(200, 1300) to room 105 camera 3 at [100, 0, -200] dir 1024"
+	if annotation := AnnotateInstruction(lineBytes, nil); annotation != expected {
+		t.Errorf("Expected %q, got %q", expected, annotation)
+	}
+}
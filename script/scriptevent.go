@@ -58,3 +58,52 @@ func (scriptDef *ScriptDef) ScriptEvtExec(lineData []byte, scriptData fileio.Scr
 	scriptDef.ScriptThreads[nextThreadNum].FunctionIds = []int{int(instruction.Event)}
 	return INSTRUCTION_NORMAL
 }
+
+// ScriptEvtNext lets the other threads run and continues from the next instruction on the next frame
+func (scriptDef *ScriptDef) ScriptEvtNext(thread *ScriptThread, lineData []byte) int {
+	thread.ProgramCounter += fileio.InstructionSize[lineData[0]]
+	thread.OverrideProgramCounter = true
+	return INSTRUCTION_THREAD_END
+}
+
+// ScriptEvtChain replaces the function running in the thread with another one
+func (scriptDef *ScriptDef) ScriptEvtChain(thread *ScriptThread, lineData []byte, scriptData fileio.ScriptFunction, threadNum int) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrEvtChain{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	if int(instruction.Event) >= len(scriptData.StartProgramCounter) {
+		return scriptDef.stopThread(thread, fmt.Errorf("evt chain to function %d, the script has %d functions", instruction.Event, len(scriptData.StartProgramCounter)))
+	}
+
+	scriptDef.ScriptDebugLine(fmt.Sprintf("(EvtChain) Thread %v continues with function %v", threadNum, instruction.Event))
+
+	thread.Reset()
+	thread.RunStatus = true
+	thread.ProgramCounter = scriptData.StartProgramCounter[instruction.Event]
+	thread.OverrideProgramCounter = true
+	thread.FunctionIds = []int{int(instruction.Event)}
+	return INSTRUCTION_NORMAL
+}
+
+// ScriptEvtKill stops another thread, even in the middle of a loop or sleep
+func (scriptDef *ScriptDef) ScriptEvtKill(thread *ScriptThread, lineData []byte, threadNum int) int {
+	byteArr := bytes.NewBuffer(lineData)
+	instruction := fileio.ScriptInstrEvtKill{}
+	binary.Read(byteArr, binary.LittleEndian, &instruction)
+
+	killThreadNum := int(instruction.ThreadNum)
+	if killThreadNum >= len(scriptDef.ScriptThreads) {
+		return INSTRUCTION_NORMAL
+	}
+
+	scriptDef.ScriptDebugLine(fmt.Sprintf("(EvtKill) Thread %v stops script thread %v", threadNum, killThreadNum))
+	scriptDef.ScriptThreads[killThreadNum].Reset()
+
+	// The thread stopped itself
+	if killThreadNum == threadNum {
+		thread.OverrideProgramCounter = true
+		return INSTRUCTION_THREAD_END
+	}
+	return INSTRUCTION_NORMAL
+}
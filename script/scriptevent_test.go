@@ -0,0 +1,223 @@
+package script
+
+import (
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+func TestScriptEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		frames   int
+		expected map[int]int // script variables after the frames
+		running  []int       // threads still running after the frames
+	}{
+		{
+			name: "evt next continues on the next frame",
+			script: `
+function main
+  Save(VarId=1, Value=1);
+  EvtNext();
+  Save(VarId=2, Value=1);
+  EvtEnd();
+`,
+			frames:   1,
+			expected: map[int]int{1: 1, 2: 0},
+			running:  []int{0},
+		},
+		{
+			name: "evt next inside a while loop",
+			script: `
+function main
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=10);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    EvtNext();
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			frames:   3,
+			expected: map[int]int{1: 3},
+			running:  []int{0},
+		},
+		{
+			name: "evt chain replaces the function",
+			script: `
+function main
+  Save(VarId=1, Value=1);
+  EvtChain(param1=0, param2=0, param3=1);
+  Save(VarId=1, Value=99);
+  EvtEnd();
+
+function chained
+  Save(VarId=2, Value=7);
+  EvtEnd();
+`,
+			frames:   1,
+			expected: map[int]int{1: 1, 2: 7},
+		},
+		{
+			name: "evt chain leaves the loops of the old function",
+			script: `
+function main
+  ForStart(Dummy=0, BlockLength=@endfor, Count=3);
+    EvtChain(param1=0, param2=0, param3=1);
+  ForEnd(param1=0);
+endfor:
+  EvtEnd();
+
+function chained
+  Sleep(Dummy=10, Count=30);
+  EvtEnd();
+`,
+			frames:  2,
+			running: []int{0},
+		},
+		{
+			name: "evt chain to a missing function stops the thread",
+			script: `
+function main
+  Save(VarId=1, Value=1);
+  EvtChain(param1=0, param2=0, param3=5);
+  Save(VarId=1, Value=99);
+  EvtEnd();
+`,
+			frames:   1,
+			expected: map[int]int{1: 1},
+		},
+		{
+			name: "evt kill stops a sleeping thread",
+			script: `
+function main
+  EvtExec(ThreadNum=1, ExOpcode=24, Event=1);
+  EvtNext();
+  EvtKill(param1=1);
+  EvtEnd();
+
+function sleeper
+  Sleep(Dummy=10, Count=30);
+  Save(VarId=1, Value=1);
+  EvtEnd();
+`,
+			frames:   40,
+			expected: map[int]int{1: 0},
+		},
+		{
+			name: "evt kill stops a thread inside a loop",
+			script: `
+function main
+  EvtExec(ThreadNum=2, ExOpcode=24, Event=1);
+  Sleep(Dummy=10, Count=3);
+  EvtKill(param1=2);
+  EvtEnd();
+
+function counter
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=1, Operation=3, Value=100);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    EvtNext();
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`,
+			frames:   10,
+			expected: map[int]int{1: 3},
+		},
+		{
+			name: "evt kill stops its own thread",
+			script: `
+function main
+  Save(VarId=1, Value=1);
+  EvtKill(param1=0);
+  Save(VarId=1, Value=2);
+  EvtEnd();
+`,
+			frames:   1,
+			expected: map[int]int{1: 1},
+		},
+		{
+			name: "wsleep waits for one frame",
+			script: `
+function main
+  Wsleep();
+  Wsleeping();
+  Save(VarId=1, Value=1);
+  EvtEnd();
+`,
+			frames:   1,
+			expected: map[int]int{1: 0},
+			running:  []int{0},
+		},
+		{
+			name: "wsleep continues after one frame",
+			script: `
+function main
+  Wsleep();
+  Wsleeping();
+  Save(VarId=1, Value=1);
+  EvtEnd();
+`,
+			frames:   2,
+			expected: map[int]int{1: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef, scriptData := loadTestScript(t, test.script)
+			runTestFrames(scriptDef, scriptData, test.frames)
+
+			for varId, expectedValue := range test.expected {
+				if value := scriptDef.GetScriptVariable(varId); value != expectedValue {
+					t.Errorf("Expected variable %d to be %d, got %d", varId, expectedValue, value)
+				}
+			}
+
+			running := make([]int, 0)
+			for threadNum, thread := range scriptDef.ScriptThreads {
+				if thread.RunStatus {
+					running = append(running, threadNum)
+				}
+			}
+			if len(running) != len(test.running) {
+				t.Fatalf("Expected threads %v to be running, got %v", test.running, running)
+			}
+			for i := range running {
+				if running[i] != test.running[i] {
+					t.Fatalf("Expected threads %v to be running, got %v", test.running, running)
+				}
+			}
+		})
+	}
+}
+
+func TestScriptEvtKill_ResetsSleep(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, `
+function main
+  Sleep(Dummy=10, Count=30);
+  EvtEnd();
+`)
+	runTestFrames(scriptDef, scriptData, 1)
+
+	thread := scriptDef.ScriptThreads[0]
+	if thread.LevelState[0].LoopLevel != 0 || thread.LevelState[0].LoopState[0].Counter != 29 {
+		t.Fatalf("Expected thread to be sleeping, got loop level %d counter %d",
+			thread.LevelState[0].LoopLevel, thread.LevelState[0].LoopState[0].Counter)
+	}
+
+	lineData := []byte{fileio.OP_EVT_KILL, 0} // Kill thread 0 from thread 1
+	returnValue := scriptDef.ScriptEvtKill(scriptDef.ScriptThreads[1], lineData, 1)
+	if returnValue != INSTRUCTION_NORMAL {
+		t.Errorf("Expected return value %d, got %d", INSTRUCTION_NORMAL, returnValue)
+	}
+	if thread.RunStatus {
+		t.Error("Expected killed thread to stop")
+	}
+	if thread.LevelState[0].LoopLevel != -1 || thread.LevelState[0].LoopState[0].Counter != 0 {
+		t.Errorf("Expected sleep to be cleared, got loop level %d counter %d",
+			thread.LevelState[0].LoopLevel, thread.LevelState[0].LoopState[0].Counter)
+	}
+}
@@ -0,0 +1,210 @@
+package script
+
+// Names for the flags and variables used by the room scripts, read from a user-editable text file
+//
+// Each line names a bit or a variable, and # starts a comment:
+//
+//	bit 1 47 FLAG_LICKER_BROKE_WINDOW
+//	var 12 DOOR_KNOCKS
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	FLAG_KIND_BIT      = "bit"
+	FLAG_KIND_VARIABLE = "var"
+)
+
+var flagNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// FlagId is a bit in a bit array, or a script variable
+type FlagId struct {
+	Kind   string `json:"kind"`
+	Array  int    `json:"array,omitempty"` // bit array, only for bits
+	Number int    `json:"number"`          // bit number or variable id
+}
+
+func BitFlag(bitArray int, bitNumber int) FlagId {
+	return FlagId{Kind: FLAG_KIND_BIT, Array: bitArray, Number: bitNumber}
+}
+
+func VariableFlag(variableId int) FlagId {
+	return FlagId{Kind: FLAG_KIND_VARIABLE, Number: variableId}
+}
+
+// Same format as the debugger, e.g. bit 1:47 or var 12
+func (id FlagId) String() string {
+	if id.Kind == FLAG_KIND_BIT {
+		return fmt.Sprintf("bit %d:%d", id.Array, id.Number)
+	}
+	return fmt.Sprintf("var %d", id.Number)
+}
+
+func flagIdLess(a FlagId, b FlagId) bool {
+	if a.Kind != b.Kind {
+		return a.Kind == FLAG_KIND_BIT
+	}
+	if a.Array != b.Array {
+		return a.Array < b.Array
+	}
+	return a.Number < b.Number
+}
+
+type FlagNames struct {
+	names map[FlagId]string
+	ids   map[string]FlagId
+}
+
+func NewFlagNames() *FlagNames {
+	return &FlagNames{
+		names: make(map[FlagId]string),
+		ids:   make(map[string]FlagId),
+	}
+}
+
+func LoadFlagNamesFile(filename string) (*FlagNames, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open flag names file %s: %w", filename, err)
+	}
+	defer file.Close()
+	return LoadFlagNames(file)
+}
+
+func LoadFlagNames(reader io.Reader) (*FlagNames, error) {
+	names := NewFlagNames()
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if index := strings.Index(line, "#"); index >= 0 {
+			line = line[:index]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := names.parseLine(fields); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+func (names *FlagNames) parseLine(fields []string) error {
+	var id FlagId
+	switch {
+	case fields[0] == FLAG_KIND_BIT && len(fields) == 4:
+		bitArray, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid bit array '%s'", fields[1])
+		}
+		bitNumber, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return fmt.Errorf("invalid bit number '%s'", fields[2])
+		}
+		id = BitFlag(bitArray, bitNumber)
+	case fields[0] == FLAG_KIND_VARIABLE && len(fields) == 3:
+		variableId, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid variable id '%s'", fields[1])
+		}
+		id = VariableFlag(variableId)
+	default:
+		return fmt.Errorf("expected 'bit <array> <bit> <name>' or 'var <id> <name>'")
+	}
+	return names.Set(id, fields[len(fields)-1])
+}
+
+// Set names a flag. Names must be identifiers and can only be used once.
+func (names *FlagNames) Set(id FlagId, name string) error {
+	if !flagNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid name '%s', expected letters, digits and underscores", name)
+	}
+	if otherId, exists := names.ids[name]; exists && otherId != id {
+		return fmt.Errorf("name '%s' is already used by %s", name, otherId)
+	}
+	if oldName, exists := names.names[id]; exists {
+		delete(names.ids, oldName)
+	}
+	names.names[id] = name
+	names.ids[name] = id
+	return nil
+}
+
+// Name and Lookup can be called on nil names, which have no flags
+func (names *FlagNames) Name(id FlagId) (string, bool) {
+	if names == nil {
+		return "", false
+	}
+	name, exists := names.names[id]
+	return name, exists
+}
+
+func (names *FlagNames) Lookup(name string) (FlagId, bool) {
+	if names == nil {
+		return FlagId{}, false
+	}
+	id, exists := names.ids[name]
+	return id, exists
+}
+
+// Write saves the names in the same format they are loaded from
+func (names *FlagNames) Write(writer io.Writer) error {
+	ids := make([]FlagId, 0, len(names.names))
+	for id := range names.names {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return flagIdLess(ids[i], ids[j]) })
+
+	for _, id := range ids {
+		var err error
+		if id.Kind == FLAG_KIND_BIT {
+			_, err = fmt.Fprintf(writer, "bit %d %d %s\n", id.Array, id.Number, names.names[id])
+		} else {
+			_, err = fmt.Fprintf(writer, "var %d %s\n", id.Number, names.names[id])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Labels use the name if there is one
+
+// For the debugger, e.g. bit 1:47
+func (names *FlagNames) label(id FlagId) string {
+	if name, exists := names.Name(id); exists {
+		return name
+	}
+	return id.String()
+}
+
+// For the decompiler, e.g. flag[1][47]
+func (names *FlagNames) bitExpression(bitArray uint8, bitNumber uint8) string {
+	if name, exists := names.Name(BitFlag(int(bitArray), int(bitNumber))); exists {
+		return name
+	}
+	return fmt.Sprintf("flag[%d][%d]", bitArray, bitNumber)
+}
+
+// For the decompiler, e.g. var[12]
+func (names *FlagNames) variableExpression(variableId uint8) string {
+	if name, exists := names.Name(VariableFlag(int(variableId))); exists {
+		return name
+	}
+	return fmt.Sprintf("var[%d]", variableId)
+}
@@ -0,0 +1,122 @@
+package script
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testFlagNames = `
+# Story flags
+bit 1 47 FLAG_LICKER_BROKE_WINDOW
+bit 1 3  FLAG_DOOR_OPEN   # set by the main script
+var 2 KNOCKS
+`
+
+func loadTestFlagNames(t *testing.T) *FlagNames {
+	t.Helper()
+	names, err := LoadFlagNames(strings.NewReader(testFlagNames))
+	if err != nil {
+		t.Fatalf("LoadFlagNames() error: %v", err)
+	}
+	return names
+}
+
+func TestLoadFlagNames(t *testing.T) {
+	names := loadTestFlagNames(t)
+
+	if name, exists := names.Name(BitFlag(1, 47)); !exists || name != "FLAG_LICKER_BROKE_WINDOW" {
+		t.Errorf("Expected bit 1:47 to be named, got %q", name)
+	}
+	if id, exists := names.Lookup("KNOCKS"); !exists || id != VariableFlag(2) {
+		t.Errorf("Expected KNOCKS to be var 2, got %v", id)
+	}
+	if _, exists := names.Name(BitFlag(2, 47)); exists {
+		t.Errorf("Expected bit 2:47 to have no name")
+	}
+
+	var output bytes.Buffer
+	if err := names.Write(&output); err != nil {
+		t.Fatal(err)
+	}
+	expected := "bit 1 3 FLAG_DOOR_OPEN\nbit 1 47 FLAG_LICKER_BROKE_WINDOW\nvar 2 KNOCKS\n"
+	if output.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, output.String())
+	}
+}
+
+func TestLoadFlagNames_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"unknown kind", "flag 1 2 NAME", "line 1: expected"},
+		{"missing name", "bit 1 2", "line 1: expected"},
+		{"bit array", "bit a 2 NAME", "line 1: invalid bit array 'a'"},
+		{"variable", "\nvar x NAME", "line 2: invalid variable id 'x'"},
+		{"invalid name", "var 1 my-name", "invalid name 'my-name'"},
+		{"duplicate name", "var 1 NAME\nvar 2 NAME", "line 2: name 'NAME' is already used by var 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := LoadFlagNames(strings.NewReader(test.text))
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestFlagNames_Decompiler(t *testing.T) {
+	names := loadTestFlagNames(t)
+	functions := decompileTestScript(t, `
+function main
+  IfStart(Dummy=0, BlockLength=@end);
+    CheckBit(BitArray=1, BitNumber=47, Value=1);
+    SetBit(BitArray=1, BitNumber=3, Operation=1);
+    Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+    Save(VarId=4, Value=1);
+  EndIf();
+end:
+  EvtEnd();
+`, names)
+	output := FormatDecompiledScript(functions, names)
+	for _, expected := range []string{
+		"if (FLAG_LICKER_BROKE_WINDOW == 1) {",
+		"// FLAG_DOOR_OPEN = 1",
+		"// KNOCKS += 1",
+		"// var[4] = 1",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in\n%s", expected, output)
+		}
+	}
+}
+
+func TestFlagNames_Debugger(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, debuggerTestScript)
+	server := NewDebugServer(scriptDef, ScriptDebugOptions{FlagNames: loadTestFlagNames(t)})
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"watch FLAG_DOOR_OPEN", "watch 1 on FLAG_DOOR_OPEN\nok"},
+		{"watch var KNOCKS", "watch 2 on KNOCKS\nok"},
+		{"watch bit KNOCKS", "error: 'KNOCKS' is var 2"},
+		{"watch NOTHING", "error: unknown flag name 'NOTHING'"},
+		{"list", "1 watch FLAG_DOOR_OPEN\n2 watch KNOCKS\nok"},
+		{"bit 1 47", "FLAG_LICKER_BROKE_WINDOW = 0\nok"},
+		{"delete 2", "ok"},
+	}
+	for _, test := range tests {
+		if output := server.Execute(test.command); output != test.expected {
+			t.Errorf("Execute(%q): expected %q, got %q", test.command, test.expected, output)
+		}
+	}
+
+	runTestFrames(scriptDef, scriptData, 1)
+	if change := scriptDef.LastDebugStop().Change; !strings.HasPrefix(change, "FLAG_DOOR_OPEN changed from 0 to 1") {
+		t.Errorf("Expected the watch to show the name, got %q", change)
+	}
+}
@@ -0,0 +1,233 @@
+package script
+
+// Cross reference of where the room scripts read and write each flag and variable
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+)
+
+const (
+	FLAG_ACCESS_READ  = "read"
+	FLAG_ACCESS_WRITE = "write"
+
+	SCRIPT_NAME_INIT = "init"
+	SCRIPT_NAME_ROOM = "main"
+)
+
+// FlagReference is one instruction that reads or writes a flag
+type FlagReference struct {
+	Flag           FlagId        `json:"flag"`
+	Name           string        `json:"name,omitempty"`
+	Access         string        `json:"access"`
+	Room           world.RoomKey `json:"room"`
+	Script         string        `json:"script"` // init or main
+	Function       int           `json:"function"`
+	ProgramCounter int           `json:"pc"`
+	Instruction    string        `json:"instruction"`
+}
+
+type FlagXref struct {
+	References  []FlagReference       `json:"references"`
+	FailedRooms []world.RoomLoadError `json:"failedRooms,omitempty"` // only the functions read before the error are included
+}
+
+func NewFlagXref() *FlagXref {
+	return &FlagXref{
+		References:  make([]FlagReference, 0),
+		FailedRooms: make([]world.RoomLoadError, 0),
+	}
+}
+
+// LoadFlagXref scans the scripts of every room of a player in fsys.
+// Rooms that fail to load are listed in FailedRooms.
+func LoadFlagXref(fsys fs.FS, playerNum int) (*FlagXref, error) {
+	xref := NewFlagXref()
+	failedRooms, err := world.ScanRooms(fsys, playerNum, func(roomKey world.RoomKey, rdtOutput *fileio.RDTOutput) {
+		if rdtOutput.InitScriptData != nil {
+			xref.AddScript(roomKey, SCRIPT_NAME_INIT, rdtOutput.InitScriptData.ScriptData)
+		}
+		if rdtOutput.RoomScriptData != nil {
+			xref.AddScript(roomKey, SCRIPT_NAME_ROOM, rdtOutput.RoomScriptData.ScriptData)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	xref.FailedRooms = failedRooms
+	xref.sort()
+	return xref, nil
+}
+
+// AddScript adds every flag and variable used by the instructions of a script.
+// The instructions are scanned without running them, so branches that are never taken are included.
+func (xref *FlagXref) AddScript(roomKey world.RoomKey, scriptName string, scriptData fileio.ScriptFunction) {
+	for functionId, start := range scriptData.StartProgramCounter {
+		end := -1
+		if functionId+1 < len(scriptData.StartProgramCounter) {
+			end = scriptData.StartProgramCounter[functionId+1]
+		}
+		for _, programCounter := range functionProgramCounters(scriptData, start, end) {
+			lineData := scriptData.Instructions[programCounter]
+			for _, access := range instructionFlagAccesses(lineData) {
+				xref.References = append(xref.References, FlagReference{
+					Flag:           access.flag,
+					Access:         access.access,
+					Room:           roomKey,
+					Script:         scriptName,
+					Function:       functionId,
+					ProgramCounter: programCounter,
+					Instruction:    formatInstruction(lineData),
+				})
+			}
+		}
+	}
+}
+
+func functionProgramCounters(scriptData fileio.ScriptFunction, start int, end int) []int {
+	programCounters := make([]int, 0)
+	for programCounter, lineData := range scriptData.Instructions {
+		if programCounter >= start && (end < 0 || programCounter < end) && len(lineData) > 0 {
+			programCounters = append(programCounters, programCounter)
+		}
+	}
+	sort.Ints(programCounters)
+	return programCounters
+}
+
+type flagAccess struct {
+	flag   FlagId
+	access string
+}
+
+func instructionFlagAccesses(lineData []byte) []flagAccess {
+	if len(lineData) < fileio.InstructionSize[lineData[0]] {
+		return nil
+	}
+
+	switch lineData[0] {
+	case fileio.OP_CHECK:
+		instruction := readInstruction[fileio.ScriptInstrCheckBitTest](lineData)
+		return []flagAccess{{BitFlag(int(instruction.BitArray), int(instruction.BitNumber)), FLAG_ACCESS_READ}}
+	case fileio.OP_SET_BIT:
+		instruction := readInstruction[fileio.ScriptInstrSetBit](lineData)
+		return []flagAccess{{BitFlag(int(instruction.BitArray), int(instruction.BitNumber)), FLAG_ACCESS_WRITE}}
+	case fileio.OP_COMPARE:
+		instruction := readInstruction[fileio.ScriptInstrCompare](lineData)
+		return []flagAccess{{VariableFlag(int(instruction.VarId)), FLAG_ACCESS_READ}}
+	case fileio.OP_SWITCH:
+		instruction := readInstruction[fileio.ScriptInstrSwitch](lineData)
+		return []flagAccess{{VariableFlag(int(instruction.VarId)), FLAG_ACCESS_READ}}
+	case fileio.OP_SAVE:
+		instruction := readInstruction[fileio.ScriptInstrSave](lineData)
+		return []flagAccess{{VariableFlag(int(instruction.VarId)), FLAG_ACCESS_WRITE}}
+	case fileio.OP_COPY:
+		instruction := readInstruction[fileio.ScriptInstrCopy](lineData)
+		return []flagAccess{
+			{VariableFlag(int(instruction.SourceVarId)), FLAG_ACCESS_READ},
+			{VariableFlag(int(instruction.DestVarId)), FLAG_ACCESS_WRITE},
+		}
+	case fileio.OP_CALC:
+		instruction := readInstruction[fileio.ScriptInstrCalc](lineData)
+		return []flagAccess{{VariableFlag(int(instruction.VarId)), FLAG_ACCESS_WRITE}}
+	case fileio.OP_CALC2:
+		instruction := readInstruction[fileio.ScriptInstrCalc2](lineData)
+		return []flagAccess{
+			{VariableFlag(int(instruction.SourceVarId)), FLAG_ACCESS_READ},
+			{VariableFlag(int(instruction.VarId)), FLAG_ACCESS_WRITE},
+		}
+	}
+	return nil
+}
+
+func (xref *FlagXref) sort() {
+	sort.SliceStable(xref.References, func(i, j int) bool {
+		a, b := xref.References[i], xref.References[j]
+		if a.Flag != b.Flag {
+			return flagIdLess(a.Flag, b.Flag)
+		}
+		if a.Room != b.Room {
+			return a.Room.String() < b.Room.String()
+		}
+		if a.Script != b.Script {
+			return a.Script == SCRIPT_NAME_INIT
+		}
+		return a.ProgramCounter < b.ProgramCounter
+	})
+}
+
+// Lookup returns where a flag is used
+func (xref *FlagXref) Lookup(flag FlagId) []FlagReference {
+	references := make([]FlagReference, 0)
+	for _, reference := range xref.References {
+		if reference.Flag == flag {
+			references = append(references, reference)
+		}
+	}
+	return references
+}
+
+// Flags returns every flag used, in order
+func (xref *FlagXref) Flags() []FlagId {
+	seen := make(map[FlagId]bool)
+	flags := make([]FlagId, 0)
+	for _, reference := range xref.References {
+		if !seen[reference.Flag] {
+			seen[reference.Flag] = true
+			flags = append(flags, reference.Flag)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool { return flagIdLess(flags[i], flags[j]) })
+	return flags
+}
+
+// WithNames returns the references with the names of their flags filled in
+func (xref *FlagXref) WithNames(names *FlagNames) *FlagXref {
+	named := &FlagXref{
+		References:  make([]FlagReference, len(xref.References)),
+		FailedRooms: xref.FailedRooms,
+	}
+	for i, reference := range xref.References {
+		reference.Name, _ = names.Name(reference.Flag)
+		named.References[i] = reference
+	}
+	return named
+}
+
+// WriteJSON writes the references as JSON
+func (xref *FlagXref) WriteJSON(w io.Writer) error {
+	output, err := json.MarshalIndent(xref, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
+
+// WriteCSV writes one reference per row
+func (xref *FlagXref) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"flag", "name", "access", "room", "script", "function", "pc", "instruction"})
+	for _, reference := range xref.References {
+		writer.Write([]string{
+			reference.Flag.String(),
+			reference.Name,
+			reference.Access,
+			reference.Room.String(),
+			reference.Script,
+			strconv.Itoa(reference.Function),
+			strconv.Itoa(reference.ProgramCounter),
+			reference.Instruction,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,122 @@
+package script
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+)
+
+func testFlagXref(t *testing.T) *FlagXref {
+	t.Helper()
+	_, scriptData := loadTestScript(t, `
+function main
+  IfStart(Dummy=0, BlockLength=@end);
+    CheckBit(BitArray=1, BitNumber=47, Value=0);
+    SetBit(BitArray=1, BitNumber=47, Operation=1);
+    Copy(DestVarId=3, SourceVarId=2);
+  EndIf();
+end:
+  EvtEnd();
+
+function other
+  Compare(Dummy=0, VarId=2, Operation=0, Value=1);
+  Calc2(Operation=0, VarId=2, SourceVarId=5);
+  EvtEnd();
+`)
+	xref := NewFlagXref()
+	xref.AddScript(world.RoomKey{Stage: 1, Room: 0x0a}, SCRIPT_NAME_ROOM, scriptData)
+	xref.sort()
+	return xref
+}
+
+func TestFlagXref_AddScript(t *testing.T) {
+	xref := testFlagXref(t)
+
+	expectedFlags := []FlagId{BitFlag(1, 47), VariableFlag(2), VariableFlag(3), VariableFlag(5)}
+	flags := xref.Flags()
+	if len(flags) != len(expectedFlags) {
+		t.Fatalf("Expected flags %v, got %v", expectedFlags, flags)
+	}
+	for i := range flags {
+		if flags[i] != expectedFlags[i] {
+			t.Errorf("Expected flags %v, got %v", expectedFlags, flags)
+		}
+	}
+
+	tests := []struct {
+		flag     FlagId
+		expected []string // access, function and instruction name
+	}{
+		{BitFlag(1, 47), []string{"read 0 CheckBit", "write 0 SetBit"}},
+		{VariableFlag(2), []string{"read 0 Copy", "read 1 Compare", "write 1 Calc2"}},
+		{VariableFlag(3), []string{"write 0 Copy"}},
+		{VariableFlag(5), []string{"read 1 Calc2"}},
+	}
+	for _, test := range tests {
+		references := xref.Lookup(test.flag)
+		found := make([]string, len(references))
+		for i, reference := range references {
+			name := strings.SplitN(reference.Instruction, "(", 2)[0]
+			found[i] = reference.Access + " " + string(rune('0'+reference.Function)) + " " + name
+			if reference.Room != (world.RoomKey{Stage: 1, Room: 0x0a}) || reference.Script != SCRIPT_NAME_ROOM {
+				t.Errorf("%s: expected room 10A main script, got %v", test.flag, reference)
+			}
+		}
+		if strings.Join(found, ", ") != strings.Join(test.expected, ", ") {
+			t.Errorf("%s: expected %v, got %v", test.flag, test.expected, found)
+		}
+	}
+}
+
+func TestFlagXref_Export(t *testing.T) {
+	names, err := LoadFlagNames(strings.NewReader("bit 1 47 FLAG_LICKER_BROKE_WINDOW\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xref := testFlagXref(t).WithNames(names)
+
+	var csvOutput bytes.Buffer
+	if err := xref.WriteCSV(&csvOutput); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+	if len(lines) != len(xref.References)+1 {
+		t.Fatalf("Expected a header and %d rows, got %d lines", len(xref.References), len(lines))
+	}
+	if lines[0] != "flag,name,access,room,script,function,pc,instruction" {
+		t.Errorf("Unexpected header %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "bit 1:47,FLAG_LICKER_BROKE_WINDOW,read,10A,main,0,") {
+		t.Errorf("Unexpected first row %q", lines[1])
+	}
+
+	var jsonOutput bytes.Buffer
+	if err := xref.WriteJSON(&jsonOutput); err != nil {
+		t.Fatal(err)
+	}
+	var decoded FlagXref
+	if err := json.Unmarshal(jsonOutput.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.References) != len(xref.References) || decoded.References[0] != xref.References[0] {
+		t.Errorf("Expected the JSON to round trip, got %v", decoded.References)
+	}
+}
+
+func TestLoadFlagXref_FailedRooms(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/Pl0/RDP/ROOM1000.RDT": {Data: []byte{0xFF}},
+	}
+	xref, err := LoadFlagXref(fsys, 0)
+	if err != nil {
+		t.Fatalf("LoadFlagXref() error: %v", err)
+	}
+	xref = xref.WithNames(nil)
+	if len(xref.FailedRooms) != 1 || xref.FailedRooms[0].Room != (world.RoomKey{Stage: 1, Room: 0x00}) {
+		t.Errorf("Expected room 100 to fail, got %v", xref.FailedRooms)
+	}
+}
@@ -386,7 +386,8 @@ func formatForEndParams(lineBytes []byte) string {
 }
 
 func formatWhileStartParams(lineBytes []byte) string {
-	return fmt.Sprintf("param1=%d, param2=%d", lineBytes[1], binary.LittleEndian.Uint16(lineBytes[2:4]))
+	instruction := readInstruction[fileio.ScriptInstrWhileStart](lineBytes)
+	return fmt.Sprintf("Dummy=%d, BlockLength=%d", instruction.Dummy, instruction.BlockLength)
 }
 
 func formatWhileEndParams(lineBytes []byte) string {
@@ -394,7 +395,8 @@ func formatWhileEndParams(lineBytes []byte) string {
 }
 
 func formatDoStartParams(lineBytes []byte) string {
-	return fmt.Sprintf("param1=%d, param2=%d", lineBytes[1], binary.LittleEndian.Uint16(lineBytes[2:4]))
+	instruction := readInstruction[fileio.ScriptInstrDoStart](lineBytes)
+	return fmt.Sprintf("Dummy=%d, BlockLength=%d", instruction.Dummy, instruction.BlockLength)
 }
 
 func formatDoEndParams(lineBytes []byte) string {
@@ -405,12 +407,13 @@ func formatEndSwitchParams(lineBytes []byte) string {
 	return fmt.Sprintf("param1=%d", lineBytes[1])
 }
 
-func formatDefaultCaseParams(lineBytes []byte) string {
-	return fmt.Sprintf("param1=%d, param2=%d, param3=%d", lineBytes[1], binary.LittleEndian.Uint16(lineBytes[2:4]), binary.LittleEndian.Uint16(lineBytes[4:6]))
-}
-
-func formatGosubReturnParams(lineBytes []byte) string {
-	return ""
+// formatByteParams shows every byte after the opcode
+func formatByteParams(lineBytes []byte) string {
+	var params []string
+	for i := 1; i < len(lineBytes); i++ {
+		params = append(params, fmt.Sprintf("param%d=%d", i, lineBytes[i]))
+	}
+	return strings.Join(params, ", ")
 }
 
 func formatBreakParams(lineBytes []byte) string {
@@ -626,8 +629,8 @@ var OpcodeSignatures = map[byte]OpcodeSignature{
 	fileio.OP_DO_START:       formatDoStartParams,
 	fileio.OP_DO_END:         formatDoEndParams,
 	fileio.OP_END_SWITCH:     formatEndSwitchParams,
-	fileio.OP_DEFAULT:        formatDefaultCaseParams,
-	fileio.OP_GOSUB_RETURN:   formatGosubReturnParams,
+	fileio.OP_DEFAULT:        formatByteParams,
+	fileio.OP_GOSUB_RETURN:   formatByteParams,
 	fileio.OP_BREAK:          formatBreakParams,
 	fileio.OP_WORK_COPY:      formatWorkCopyParams,
 	fileio.OP_SCE_RND:        formatSceRndParams,
@@ -651,14 +654,14 @@ var OpcodeSignatures = map[byte]OpcodeSignature{
 	fileio.OP_KEEP_ITEM_CK:   formatSceItemLostParams,
 	fileio.OP_SCE_ITEM_LOST:  formatSceItemLostParams,
 	fileio.OP_SCE_ESPR_ON2:   formatSceEsprOn2Params,
-	fileio.OP_SCE_ESPR_KILL2: formatSceEsprOn2Params,
+	fileio.OP_SCE_ESPR_KILL2: formatByteParams,
 	fileio.OP_PLC_STOP:       formatPlcStopParams,
 	fileio.OP_LIGHT_POS_SET:  formatLightPosSetParams,
 	fileio.OP_LIGHT_KIDO_SET: formatLightKidoSetParams,
-	fileio.OP_RBJ_RESET:      formatPartsSetParams,
-	fileio.OP_SCE_SCR_MOVE:   formatPartsSetParams,
+	fileio.OP_RBJ_RESET:      formatByteParams,
+	fileio.OP_SCE_SCR_MOVE:   formatByteParams,
 	fileio.OP_PARTS_SET:      formatPartsSetParams,
-	fileio.OP_MOVIE_ON:       formatPartsSetParams,
+	fileio.OP_MOVIE_ON:       formatByteParams,
 	fileio.OP_SCE_PARTS_BOMB: formatScePartsBombParams,
 	fileio.OP_SCE_PARTS_DOWN: formatScePartsDownParams,
 }
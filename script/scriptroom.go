@@ -0,0 +1,57 @@
+package script
+
+import (
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/world"
+)
+
+// InitGameFlags sets the flags that the room scripts expect at the start of a new game
+func (scriptDef *ScriptDef) InitGameFlags(playerId int) {
+	// Set game difficulty (0 is easy, 1 is normal)
+	scriptDef.SetBitArray(0, game.SYSTEM_FLAG_DIFFICULTY, game.DIFFICULTY_EASY)
+	// Room scripts check this flag to know which character is playing
+	scriptDef.SetBitArray(0, game.SYSTEM_FLAG_PLAYER, playerId)
+	// Set camera id
+	scriptDef.SetScriptVariable(26, 0)
+}
+
+// InitRoomScripts runs the init script once when a room loads,
+// then starts the threads of the room script that run in the game loop
+func (scriptDef *ScriptDef) InitRoomScripts(gameRoom game.RoomScript, host Host) {
+	// Reset all state
+	scriptDef.Reset()
+
+	// Run initial script once when the room loads
+	threadNum := 0
+	functionNum := 0
+	initScriptData := gameRoom.InitScriptData
+	scriptDef.InitScript(initScriptData, threadNum, functionNum)
+	// The init script has to finish before the room starts, so it can't stop in the debugger
+	debugger := scriptDef.debugger
+	scriptDef.debugger = nil
+	scriptDef.Tick(initScriptData, host)
+	scriptDef.debugger = debugger
+
+	// Initialize the room script to be run in the game loop
+	threadNum = 0
+	functionNum = 0
+	roomScriptData := gameRoom.RoomScriptData
+	scriptDef.InitScript(roomScriptData, threadNum, functionNum)
+	threadNum = 1
+	functionNum = 1
+	scriptDef.InitScript(roomScriptData, threadNum, functionNum)
+}
+
+// RunEventTrigger starts the event of the AOT trigger the player is standing in, like a cutscene
+func (scriptDef *ScriptDef) RunEventTrigger(gameDef *game.GameDef) {
+	aot := gameDef.GameWorld.AotManager.GetAotTriggerNearPlayer(gameDef.Player.Position)
+	if aot != nil {
+		if aot.Header.Id == world.AOT_EVENT {
+			threadNum := aot.Data[0]
+			eventNum := aot.Data[3]
+			lineData := []byte{fileio.OP_EVT_EXEC, threadNum, 0, eventNum}
+			scriptDef.ScriptEvtExec(lineData, gameDef.RoomScript.RoomScriptData)
+		}
+	}
+}
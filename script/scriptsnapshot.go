@@ -0,0 +1,412 @@
+package script
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Snapshots of the interpreter state, for save games, rewinding and bug reports
+// A snapshot holds everything a tick reads, so a restored ScriptDef runs the same way as the one it was taken from
+
+const (
+	SCRIPT_SNAPSHOT_VERSION = 1
+	scriptSnapshotMagic     = "SCRS"
+)
+
+type ScriptSnapshot struct {
+	Version         int                 `json:"version"`
+	Threads         []ThreadSnapshot    `json:"threads"`
+	BitArrays       map[int]map[int]int `json:"bitArrays"`
+	Variables       map[int]int         `json:"variables"`
+	TickAccumulator float64             `json:"tickAccumulator"`
+	ResumeThread    int                 `json:"resumeThread"` // not 0 if the debugger stopped in the middle of a tick
+}
+
+type ThreadSnapshot struct {
+	RunStatus              bool            `json:"runStatus"`
+	WorkSetComponent       int             `json:"workSetComponent"`
+	WorkSetIndex           int             `json:"workSetIndex"`
+	ProgramCounter         int             `json:"programCounter"`
+	StackIndex             int             `json:"stackIndex"`
+	SubLevel               int             `json:"subLevel"`
+	OverrideProgramCounter bool            `json:"overrideProgramCounter"`
+	FunctionIds            []int           `json:"functionIds"`
+	Levels                 []LevelSnapshot `json:"levels"`
+}
+
+type LevelSnapshot struct {
+	IfElseCounter int         `json:"ifElseCounter"`
+	LoopLevel     int         `json:"loopLevel"`
+	ReturnAddress int         `json:"returnAddress"`
+	Stack         []int       `json:"stack"`
+	Loops         []LoopState `json:"loops"`
+}
+
+// Snapshot copies the interpreter state
+func (scriptDef *ScriptDef) Snapshot() *ScriptSnapshot {
+	snapshot := &ScriptSnapshot{
+		Version:         SCRIPT_SNAPSHOT_VERSION,
+		Threads:         make([]ThreadSnapshot, len(scriptDef.ScriptThreads)),
+		BitArrays:       make(map[int]map[int]int),
+		Variables:       make(map[int]int),
+		TickAccumulator: scriptDef.TickAccumulator,
+	}
+	if scriptDef.debugger != nil {
+		snapshot.ResumeThread = scriptDef.debugger.resumeThread
+	}
+
+	for i, thread := range scriptDef.ScriptThreads {
+		threadSnapshot := ThreadSnapshot{
+			RunStatus:              thread.RunStatus,
+			WorkSetComponent:       thread.WorkSetComponent,
+			WorkSetIndex:           thread.WorkSetIndex,
+			ProgramCounter:         thread.ProgramCounter,
+			StackIndex:             thread.StackIndex,
+			SubLevel:               thread.SubLevel,
+			OverrideProgramCounter: thread.OverrideProgramCounter,
+			FunctionIds:            append([]int(nil), thread.FunctionIds...),
+			Levels:                 make([]LevelSnapshot, len(thread.LevelState)),
+		}
+		for j, levelState := range thread.LevelState {
+			levelSnapshot := LevelSnapshot{
+				IfElseCounter: levelState.IfElseCounter,
+				LoopLevel:     levelState.LoopLevel,
+				ReturnAddress: levelState.ReturnAddress,
+				Stack:         append([]int(nil), levelState.Stack...),
+				Loops:         make([]LoopState, len(levelState.LoopState)),
+			}
+			for k, loopState := range levelState.LoopState {
+				levelSnapshot.Loops[k] = *loopState
+			}
+			threadSnapshot.Levels[j] = levelSnapshot
+		}
+		snapshot.Threads[i] = threadSnapshot
+	}
+
+	for bitArray, bits := range scriptDef.ScriptBitArray {
+		snapshot.BitArrays[bitArray] = make(map[int]int)
+		for bitNumber, value := range bits {
+			snapshot.BitArrays[bitArray][bitNumber] = value
+		}
+	}
+	for id, value := range scriptDef.ScriptVariable {
+		snapshot.Variables[id] = value
+	}
+	return snapshot
+}
+
+// Restore replaces the interpreter state with a snapshot.
+// Breakpoints and watches are kept.
+func (scriptDef *ScriptDef) Restore(snapshot *ScriptSnapshot) error {
+	if err := snapshot.validate(); err != nil {
+		return err
+	}
+
+	scriptThreads := make([]*ScriptThread, len(snapshot.Threads))
+	for i, threadSnapshot := range snapshot.Threads {
+		thread := &ScriptThread{
+			RunStatus:              threadSnapshot.RunStatus,
+			WorkSetComponent:       threadSnapshot.WorkSetComponent,
+			WorkSetIndex:           threadSnapshot.WorkSetIndex,
+			ProgramCounter:         threadSnapshot.ProgramCounter,
+			StackIndex:             threadSnapshot.StackIndex,
+			SubLevel:               threadSnapshot.SubLevel,
+			OverrideProgramCounter: threadSnapshot.OverrideProgramCounter,
+			FunctionIds:            append([]int(nil), threadSnapshot.FunctionIds...),
+			LevelState:             make([]*LevelState, len(threadSnapshot.Levels)),
+		}
+		for j, levelSnapshot := range threadSnapshot.Levels {
+			levelState := &LevelState{
+				IfElseCounter: levelSnapshot.IfElseCounter,
+				LoopLevel:     levelSnapshot.LoopLevel,
+				ReturnAddress: levelSnapshot.ReturnAddress,
+				Stack:         append([]int(nil), levelSnapshot.Stack...),
+				LoopState:     make([]*LoopState, len(levelSnapshot.Loops)),
+			}
+			for k := range levelSnapshot.Loops {
+				loopState := levelSnapshot.Loops[k]
+				levelState.LoopState[k] = &loopState
+			}
+			thread.LevelState[j] = levelState
+		}
+		scriptThreads[i] = thread
+	}
+
+	scriptDef.ScriptThreads = scriptThreads
+	scriptDef.ScriptBitArray = make(map[int]map[int]int)
+	for bitArray, bits := range snapshot.BitArrays {
+		scriptDef.ScriptBitArray[bitArray] = make(map[int]int)
+		for bitNumber, value := range bits {
+			scriptDef.ScriptBitArray[bitArray][bitNumber] = value
+		}
+	}
+	scriptDef.ScriptVariable = make(map[int]int)
+	for id, value := range snapshot.Variables {
+		scriptDef.ScriptVariable[id] = value
+	}
+	scriptDef.TickAccumulator = snapshot.TickAccumulator
+
+	if scriptDef.debugger != nil || snapshot.ResumeThread != 0 {
+		debugger := scriptDef.getDebugger()
+		debugger.resetThreads()
+		debugger.resumeThread = snapshot.ResumeThread
+	}
+	return nil
+}
+
+// validate checks a loaded snapshot has the same thread, stack and loop sizes as NewScriptDef,
+// so restoring it won't index outside them
+func (snapshot *ScriptSnapshot) validate() error {
+	if snapshot.Version != SCRIPT_SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported script snapshot version %d, expected %d", snapshot.Version, SCRIPT_SNAPSHOT_VERSION)
+	}
+	if len(snapshot.Threads) != SCRIPT_THREAD_COUNT {
+		return fmt.Errorf("script snapshot has %d threads, expected %d", len(snapshot.Threads), SCRIPT_THREAD_COUNT)
+	}
+	if snapshot.ResumeThread < 0 || snapshot.ResumeThread >= len(snapshot.Threads) {
+		return fmt.Errorf("script snapshot resumes from thread %d of %d", snapshot.ResumeThread, len(snapshot.Threads))
+	}
+	for i, thread := range snapshot.Threads {
+		if len(thread.Levels) != SCRIPT_THREAD_LEVELS {
+			return fmt.Errorf("thread %d: %d levels, expected %d", i, len(thread.Levels), SCRIPT_THREAD_LEVELS)
+		}
+		for j, level := range thread.Levels {
+			if len(level.Stack) != SCRIPT_STACK_SIZE {
+				return fmt.Errorf("thread %d level %d: stack of %d, expected %d", i, j, len(level.Stack), SCRIPT_STACK_SIZE)
+			}
+			if len(level.Loops) != SCRIPT_LOOP_LEVELS {
+				return fmt.Errorf("thread %d level %d: %d loops, expected %d", i, j, len(level.Loops), SCRIPT_LOOP_LEVELS)
+			}
+		}
+		if thread.SubLevel < 0 || thread.SubLevel >= len(thread.Levels) {
+			return fmt.Errorf("thread %d: sub level %d outside %d levels", i, thread.SubLevel, len(thread.Levels))
+		}
+		if len(thread.FunctionIds) == 0 {
+			return fmt.Errorf("thread %d: no function ids", i)
+		}
+		if thread.StackIndex < 0 || thread.StackIndex > len(thread.Levels[thread.SubLevel].Stack) {
+			return fmt.Errorf("thread %d: stack index %d outside the stack", i, thread.StackIndex)
+		}
+		for j, level := range thread.Levels {
+			if level.LoopLevel < -1 || level.LoopLevel >= len(level.Loops) {
+				return fmt.Errorf("thread %d level %d: loop level %d outside %d loops", i, j, level.LoopLevel, len(level.Loops))
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalBinary writes the snapshot as little endian int32 values after a magic and version.
+// Maps are written sorted by key, so the same state always gives the same bytes.
+func (snapshot *ScriptSnapshot) MarshalBinary() ([]byte, error) {
+	writer := &snapshotWriter{}
+	writer.buffer.WriteString(scriptSnapshotMagic)
+	writer.writeInt(snapshot.Version)
+	writer.writeUint64(math.Float64bits(snapshot.TickAccumulator))
+	writer.writeInt(snapshot.ResumeThread)
+
+	writer.writeInt(len(snapshot.Threads))
+	for _, thread := range snapshot.Threads {
+		writer.writeBool(thread.RunStatus)
+		writer.writeInt(thread.WorkSetComponent)
+		writer.writeInt(thread.WorkSetIndex)
+		writer.writeInt(thread.ProgramCounter)
+		writer.writeInt(thread.StackIndex)
+		writer.writeInt(thread.SubLevel)
+		writer.writeBool(thread.OverrideProgramCounter)
+		writer.writeInts(thread.FunctionIds)
+		writer.writeInt(len(thread.Levels))
+		for _, level := range thread.Levels {
+			writer.writeInt(level.IfElseCounter)
+			writer.writeInt(level.LoopLevel)
+			writer.writeInt(level.ReturnAddress)
+			writer.writeInts(level.Stack)
+			writer.writeInt(len(level.Loops))
+			for _, loop := range level.Loops {
+				writer.writeInt(loop.Counter)
+				writer.writeInt(loop.Break)
+				writer.writeInt(loop.LevelIfCounter)
+				writer.writeInt(loop.StackValue)
+			}
+		}
+	}
+
+	bitArrays := sortedKeys(snapshot.BitArrays)
+	writer.writeInt(len(bitArrays))
+	for _, bitArray := range bitArrays {
+		writer.writeInt(bitArray)
+		writer.writeIntMap(snapshot.BitArrays[bitArray])
+	}
+	writer.writeIntMap(snapshot.Variables)
+	return writer.buffer.Bytes(), nil
+}
+
+func (snapshot *ScriptSnapshot) UnmarshalBinary(data []byte) error {
+	if !bytes.HasPrefix(data, []byte(scriptSnapshotMagic)) {
+		return fmt.Errorf("not a script snapshot")
+	}
+	reader := &snapshotReader{reader: bytes.NewReader(data[len(scriptSnapshotMagic):])}
+	result := ScriptSnapshot{}
+	result.Version = reader.readInt()
+	if reader.err == nil && result.Version != SCRIPT_SNAPSHOT_VERSION {
+		return fmt.Errorf("unsupported script snapshot version %d, expected %d", result.Version, SCRIPT_SNAPSHOT_VERSION)
+	}
+	result.TickAccumulator = math.Float64frombits(reader.readUint64())
+	result.ResumeThread = reader.readInt()
+
+	result.Threads = make([]ThreadSnapshot, reader.readLength())
+	for i := range result.Threads {
+		thread := &result.Threads[i]
+		thread.RunStatus = reader.readBool()
+		thread.WorkSetComponent = reader.readInt()
+		thread.WorkSetIndex = reader.readInt()
+		thread.ProgramCounter = reader.readInt()
+		thread.StackIndex = reader.readInt()
+		thread.SubLevel = reader.readInt()
+		thread.OverrideProgramCounter = reader.readBool()
+		thread.FunctionIds = reader.readInts()
+		thread.Levels = make([]LevelSnapshot, reader.readLength())
+		for j := range thread.Levels {
+			level := &thread.Levels[j]
+			level.IfElseCounter = reader.readInt()
+			level.LoopLevel = reader.readInt()
+			level.ReturnAddress = reader.readInt()
+			level.Stack = reader.readInts()
+			level.Loops = make([]LoopState, reader.readLength())
+			for k := range level.Loops {
+				level.Loops[k] = LoopState{
+					Counter:        reader.readInt(),
+					Break:          reader.readInt(),
+					LevelIfCounter: reader.readInt(),
+					StackValue:     reader.readInt(),
+				}
+			}
+		}
+	}
+
+	result.BitArrays = make(map[int]map[int]int)
+	numBitArrays := reader.readLength()
+	for i := 0; i < numBitArrays; i++ {
+		bitArray := reader.readInt()
+		result.BitArrays[bitArray] = reader.readIntMap()
+	}
+	result.Variables = reader.readIntMap()
+
+	if reader.err != nil {
+		return fmt.Errorf("failed to read script snapshot: %w", reader.err)
+	}
+	if reader.reader.Len() != 0 {
+		return fmt.Errorf("script snapshot has %d bytes left over", reader.reader.Len())
+	}
+	*snapshot = result
+	return nil
+}
+
+type snapshotWriter struct {
+	buffer bytes.Buffer
+}
+
+func (writer *snapshotWriter) writeInt(value int) {
+	binary.Write(&writer.buffer, binary.LittleEndian, int32(value))
+}
+
+func (writer *snapshotWriter) writeUint64(value uint64) {
+	binary.Write(&writer.buffer, binary.LittleEndian, value)
+}
+
+func (writer *snapshotWriter) writeBool(value bool) {
+	if value {
+		writer.buffer.WriteByte(1)
+	} else {
+		writer.buffer.WriteByte(0)
+	}
+}
+
+func (writer *snapshotWriter) writeInts(values []int) {
+	writer.writeInt(len(values))
+	for _, value := range values {
+		writer.writeInt(value)
+	}
+}
+
+func (writer *snapshotWriter) writeIntMap(values map[int]int) {
+	keys := sortedKeys(values)
+	writer.writeInt(len(keys))
+	for _, key := range keys {
+		writer.writeInt(key)
+		writer.writeInt(values[key])
+	}
+}
+
+// snapshotReader keeps the first error, so the fields can be read without checking each one
+type snapshotReader struct {
+	reader *bytes.Reader
+	err    error
+}
+
+func (reader *snapshotReader) readInt() int {
+	var value int32
+	if reader.err == nil {
+		reader.err = binary.Read(reader.reader, binary.LittleEndian, &value)
+	}
+	return int(value)
+}
+
+func (reader *snapshotReader) readUint64() uint64 {
+	var value uint64
+	if reader.err == nil {
+		reader.err = binary.Read(reader.reader, binary.LittleEndian, &value)
+	}
+	return value
+}
+
+func (reader *snapshotReader) readBool() bool {
+	if reader.err != nil {
+		return false
+	}
+	value, err := reader.reader.ReadByte()
+	reader.err = err
+	return value != 0
+}
+
+// readLength reads a count, which can't be more than the bytes left
+func (reader *snapshotReader) readLength() int {
+	length := reader.readInt()
+	if reader.err == nil && (length < 0 || length > reader.reader.Len()) {
+		reader.err = fmt.Errorf("invalid length %d", length)
+	}
+	if reader.err != nil {
+		return 0
+	}
+	return length
+}
+
+func (reader *snapshotReader) readInts() []int {
+	values := make([]int, reader.readLength())
+	for i := range values {
+		values[i] = reader.readInt()
+	}
+	return values
+}
+
+func (reader *snapshotReader) readIntMap() map[int]int {
+	values := make(map[int]int)
+	length := reader.readLength()
+	for i := 0; i < length; i++ {
+		key := reader.readInt()
+		values[key] = reader.readInt()
+	}
+	return values
+}
+
+func sortedKeys[V any](values map[int]V) []int {
+	keys := make([]int, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	return keys
+}
@@ -0,0 +1,196 @@
+package script
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const snapshotTestScript = `
+function main
+  EvtExec(ThreadNum=2, ExOpcode=24, Event=1);
+  ForStart(Dummy=0, BlockLength=@endfor, Count=4);
+    Calc(Dummy=0, Operation=0, VarId=1, Value=1);
+    Sleep(Dummy=10, Count=5);
+  ForEnd(param1=0);
+endfor:
+  SetBit(BitArray=1, BitNumber=3, Operation=1);
+  EvtEnd();
+
+function counter
+  WhileStart(Dummy=0, BlockLength=@end);
+    Compare(Dummy=0, VarId=2, Operation=3, Value=40);
+    Calc(Dummy=0, Operation=0, VarId=2, Value=1);
+    EvtNext();
+  WhileEnd(param1=0);
+end:
+  EvtEnd();
+`
+
+func TestSnapshot_ResumesIdentically(t *testing.T) {
+	encodings := []struct {
+		name      string
+		roundTrip func(t *testing.T, snapshot *ScriptSnapshot) *ScriptSnapshot
+	}{
+		{
+			name: "json",
+			roundTrip: func(t *testing.T, snapshot *ScriptSnapshot) *ScriptSnapshot {
+				data, err := json.Marshal(snapshot)
+				if err != nil {
+					t.Fatalf("json.Marshal() error: %v", err)
+				}
+				loaded := &ScriptSnapshot{}
+				if err := json.Unmarshal(data, loaded); err != nil {
+					t.Fatalf("json.Unmarshal() error: %v", err)
+				}
+				return loaded
+			},
+		},
+		{
+			name: "binary",
+			roundTrip: func(t *testing.T, snapshot *ScriptSnapshot) *ScriptSnapshot {
+				data, err := snapshot.MarshalBinary()
+				if err != nil {
+					t.Fatalf("MarshalBinary() error: %v", err)
+				}
+				loaded := &ScriptSnapshot{}
+				if err := loaded.UnmarshalBinary(data); err != nil {
+					t.Fatalf("UnmarshalBinary() error: %v", err)
+				}
+				return loaded
+			},
+		},
+	}
+
+	for _, encoding := range encodings {
+		for _, frames := range []int{3, 8, 13} {
+			t.Run(fmt.Sprintf("%s after %d frames", encoding.name, frames), func(t *testing.T) {
+				original, scriptData := loadTestScript(t, snapshotTestScript)
+				host := &recordingHost{}
+				// A partial tick is left in the accumulator
+				for frame := 0; frame < frames; frame++ {
+					original.RunScript(scriptData, SCRIPT_TICK_SECONDS*1.5, host)
+				}
+
+				snapshot := encoding.roundTrip(t, original.Snapshot())
+				restored := NewScriptDef()
+				if err := restored.Restore(snapshot); err != nil {
+					t.Fatalf("Restore() error: %v", err)
+				}
+				if !reflect.DeepEqual(restored.Snapshot(), original.Snapshot()) {
+					t.Fatalf("Expected the restored state to match after %d frames", frames)
+				}
+
+				// Both run the same from here on
+				for frame := 0; frame < 60; frame++ {
+					originalTicks := original.RunScript(scriptData, SCRIPT_TICK_SECONDS*1.5, host)
+					restoredTicks := restored.RunScript(scriptData, SCRIPT_TICK_SECONDS*1.5, host)
+					if originalTicks != restoredTicks {
+						t.Fatalf("Frame %d: expected %d ticks, got %d", frame, originalTicks, restoredTicks)
+					}
+					if !reflect.DeepEqual(restored.Snapshot(), original.Snapshot()) {
+						t.Fatalf("Frame %d: expected the restored state to match", frame)
+					}
+				}
+				if restored.GetScriptVariable(1) != 4 || restored.GetScriptVariable(2) != 40 || restored.GetBitArray(1, 3) != 1 {
+					t.Errorf("Expected the scripts to finish, got variables %v and bits %v",
+						restored.ScriptVariable, restored.ScriptBitArray)
+				}
+			})
+		}
+	}
+}
+
+func TestSnapshot_DoesNotShareState(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, snapshotTestScript)
+	runTestFrames(scriptDef, scriptData, 2)
+	snapshot := scriptDef.Snapshot()
+
+	runTestFrames(scriptDef, scriptData, 10)
+	if snapshot.Variables[1] != 1 || snapshot.Threads[0].Levels[0].Loops[0].Counter != 4 {
+		t.Errorf("Expected the snapshot not to change, got variables %v", snapshot.Variables)
+	}
+
+	if err := scriptDef.Restore(snapshot); err != nil {
+		t.Fatalf("Restore() error: %v", err)
+	}
+	scriptDef.SetScriptVariable(1, 10)
+	if snapshot.Variables[1] != 1 {
+		t.Errorf("Expected the restored state not to change the snapshot")
+	}
+}
+
+func TestSnapshot_BinaryIsStable(t *testing.T) {
+	scriptDef, scriptData := loadTestScript(t, snapshotTestScript)
+	runTestFrames(scriptDef, scriptData, 6)
+
+	first, _ := scriptDef.Snapshot().MarshalBinary()
+	second, _ := scriptDef.Snapshot().MarshalBinary()
+	if string(first) != string(second) {
+		t.Errorf("Expected the same state to give the same bytes")
+	}
+}
+
+func TestSnapshot_Errors(t *testing.T) {
+	scriptDef, _ := loadTestScript(t, snapshotTestScript)
+	data, _ := scriptDef.Snapshot().MarshalBinary()
+
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"not a snapshot", []byte("hello"), "not a script snapshot"},
+		{"truncated", data[:len(data)-3], "failed to read script snapshot"},
+		{"left over bytes", append(append([]byte(nil), data...), 0), "bytes left over"},
+		{"version", append([]byte(scriptSnapshotMagic), 2, 0, 0, 0), "unsupported script snapshot version 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := (&ScriptSnapshot{}).UnmarshalBinary(test.data)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Expected error containing %q, got %v", test.expected, err)
+			}
+		})
+	}
+
+	snapshot := scriptDef.Snapshot()
+	snapshot.Threads[0].SubLevel = 9
+	if err := scriptDef.Restore(snapshot); err == nil {
+		t.Errorf("Expected an invalid sub level to fail")
+	}
+	snapshot = scriptDef.Snapshot()
+	snapshot.Version = 0
+	if err := scriptDef.Restore(snapshot); err == nil {
+		t.Errorf("Expected an old version to fail")
+	}
+}
+
+func TestSnapshot_RestoreMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(snapshot *ScriptSnapshot)
+		expected string
+	}{
+		{"empty stack", func(snapshot *ScriptSnapshot) { snapshot.Threads[0].Levels[0].Stack = []int{} }, "stack of 0, expected 8"},
+		{"missing level", func(snapshot *ScriptSnapshot) { snapshot.Threads[1].Levels = snapshot.Threads[1].Levels[:2] }, "2 levels, expected 4"},
+		{"missing loops", func(snapshot *ScriptSnapshot) { snapshot.Threads[0].Levels[3].Loops = nil }, "0 loops, expected 4"},
+		{"missing thread", func(snapshot *ScriptSnapshot) { snapshot.Threads = snapshot.Threads[:1] }, "1 threads, expected 20"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scriptDef, scriptData := loadTestScript(t, snapshotTestScript)
+			snapshot := scriptDef.Snapshot()
+			test.change(snapshot)
+
+			err := scriptDef.Restore(snapshot)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Fatalf("Expected error containing %q, got %v", test.expected, err)
+			}
+			// The state from before is kept and still runs
+			runTestFrames(scriptDef, scriptData, 2)
+		})
+	}
+}
@@ -5,26 +5,22 @@ import (
 	"encoding/binary"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
-	"github.com/OpenBiohazard2/OpenBiohazard2/game"
-	"github.com/OpenBiohazard2/OpenBiohazard2/render"
 )
 
-func (scriptDef *ScriptDef) ScriptSceEsprOn(lineData []byte, gameDef *game.GameDef, renderDef *render.RenderDef) int {
+func (scriptDef *ScriptDef) ScriptSceEsprOn(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	scriptSprite := fileio.ScriptInstrSceEsprOn{}
 	binary.Read(byteArr, binary.LittleEndian, &scriptSprite)
 
-	gameDef.GameWorld.AotManager.AddScriptSprite(scriptSprite)
-	renderDef.AddSprite(scriptSprite)
+	host.AddSprite(scriptSprite)
 	return 1
 }
 
-func (scriptDef *ScriptDef) ScriptSceEsprKill(lineData []byte) int {
+func (scriptDef *ScriptDef) ScriptSceEsprKill(lineData []byte, host Host) int {
 	byteArr := bytes.NewBuffer(lineData)
 	instruction := fileio.ScriptInstrSceEsprKill{}
 	binary.Read(byteArr, binary.LittleEndian, &instruction)
 
-	// TODO: implement
-
+	host.RemoveSprite(instruction)
 	return 1
 }
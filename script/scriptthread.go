@@ -1,11 +1,23 @@
 package script
 
 import (
+	"errors"
+	"fmt"
 	"log"
 
 	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
 )
 
+// Sizes of the fixed arrays of the interpreter
+const (
+	SCRIPT_THREAD_COUNT  = 20
+	SCRIPT_THREAD_LEVELS = 4 // one for each gosub depth
+	SCRIPT_STACK_SIZE    = 8
+	SCRIPT_LOOP_LEVELS   = 4
+)
+
+var ErrLoopTooDeep = errors.New("loops nested too deep")
+
 type ScriptThread struct {
 	RunStatus              bool
 	WorkSetComponent       int
@@ -34,7 +46,7 @@ type LoopState struct {
 }
 
 func NewLevelState() *LevelState {
-	loopState := make([]*LoopState, 4)
+	loopState := make([]*LoopState, SCRIPT_LOOP_LEVELS)
 	for i := 0; i < len(loopState); i++ {
 		loopState[i] = NewLoopState()
 	}
@@ -43,11 +55,21 @@ func NewLevelState() *LevelState {
 		IfElseCounter: 0,
 		LoopLevel:     0,
 		ReturnAddress: 0,
-		Stack:         make([]int, 8),
+		Stack:         make([]int, SCRIPT_STACK_SIZE),
 		LoopState:     loopState,
 	}
 }
 
+// PushLoop moves to the next loop level and returns its state.
+// Sleep and switch blocks also use a loop level.
+func (levelState *LevelState) PushLoop() (*LoopState, error) {
+	if levelState.LoopLevel+1 >= len(levelState.LoopState) {
+		return nil, fmt.Errorf("%w, only %d loop levels", ErrLoopTooDeep, len(levelState.LoopState))
+	}
+	levelState.LoopLevel++
+	return levelState.LoopState[levelState.LoopLevel], nil
+}
+
 func NewLoopState() *LoopState {
 	return &LoopState{
 		Counter:        0,
@@ -65,7 +87,7 @@ func (loopState *LoopState) ResetLoopState() {
 }
 
 func NewScriptThread() *ScriptThread {
-	levelState := make([]*LevelState, 4)
+	levelState := make([]*LevelState, SCRIPT_THREAD_LEVELS)
 	for i := 0; i < len(levelState); i++ {
 		levelState[i] = NewLevelState()
 	}
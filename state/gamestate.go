@@ -3,6 +3,7 @@ package state
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"time"
@@ -21,12 +22,16 @@ var enableDebugDump = false // only enabled for development
 type MainGameStateInput struct {
 	GameDef        *game.GameDef
 	ScriptDef      *script.ScriptDef
+	ScriptHost     script.Host
 	MainGameRender *MainGameRender
+
+	ScriptDebugServer *script.DebugServer // nil unless the script debugger is enabled
 }
 
 type MainGameRender struct {
 	RenderDef               *render.RenderDef
 	RoomcutBinOutput        *fileio.BinOutput
+	RoomcutFile             *resource.Asset // kept open to read backgrounds on camera changes
 	RenderRoom              render.RenderRoom
 	PlayerEntity            *render.PlayerEntity
 	DebugEntities           []*render.DebugEntity
@@ -42,33 +47,37 @@ type DebugDumpJson struct {
 
 func NewMainGameStateInput(renderDef *render.RenderDef, gameDef *game.GameDef) *MainGameStateInput {
 	scriptDef := script.NewScriptDef()
-	// Set game difficulty (0 is easy, 1 is normal)
-	scriptDef.SetBitArray(0, 25, game.DIFFICULTY_EASY)
-	// Set camera id
-	scriptDef.SetScriptVariable(26, 0)
+	scriptDef.InitGameFlags(gameDef.PlayerId)
 
 	return &MainGameStateInput{
 		GameDef:        gameDef,
 		ScriptDef:      scriptDef,
-		MainGameRender: NewMainGameRender(renderDef),
+		ScriptHost:     newGameScriptHost(gameDef, renderDef),
+		MainGameRender: NewMainGameRender(renderDef, gameDef.PlayerId),
 	}
 }
 
-func NewMainGameRender(renderDef *render.RenderDef) *MainGameRender {
+func NewMainGameRender(renderDef *render.RenderDef, playerId int) *MainGameRender {
 	// Load player model
-	pldOutput, err := fileio.LoadPLDFile(resource.LEON_MODEL_FILE)
+	pldOutput, err := resource.LoadAsset(resource.PlayerModelFile(playerId), fileio.LoadPLDStream)
 	if err != nil {
 		log.Fatal("Error loading player model: ", err)
 	}
 
 	// Core sprite file has sprite ids 0-7
 	// All other sprites are loaded based on the room
-	_, err = fileio.LoadESPFile(resource.CORE_SPRITE_FILE)
+	_, err = resource.LoadAsset(resource.CORE_SPRITE_FILE, func(r io.ReaderAt, fileLength int64) (*fileio.ESPOutput, error) {
+		return fileio.LoadESPStream(r, fileLength, fileLength-4)
+	})
 	if err != nil {
 		log.Fatal("Error loading core sprite file: ", err)
 	}
 
-	roomcutBinOutput, err := fileio.LoadBINFile(resource.ROOMCUT_FILE)
+	roomcutFile, err := resource.OpenAsset(resource.ROOMCUT_FILE)
+	if err != nil {
+		log.Fatal("Error opening roomcut BIN file: ", err)
+	}
+	roomcutBinOutput, err := fileio.LoadBINStream(roomcutFile, roomcutFile.Size)
 	if err != nil {
 		log.Fatal("Error loading roomcut BIN file: ", err)
 	}
@@ -76,6 +85,7 @@ func NewMainGameRender(renderDef *render.RenderDef) *MainGameRender {
 	return &MainGameRender{
 		RenderDef:               renderDef,
 		RoomcutBinOutput:        roomcutBinOutput,
+		RoomcutFile:             roomcutFile,
 		PlayerEntity:            render.NewPlayerEntity(pldOutput),
 		DebugEntities:           make([]*render.DebugEntity, 0),
 		CameraSwitchDebugEntity: nil,
@@ -104,14 +114,11 @@ func loadRoomState(mainGameStateInput *MainGameStateInput) {
 	renderDef := mainGameRender.RenderDef
 
 	// Load room data from file
-	roomFilename := gameDef.GetRoomFilename(game.PLAYER_LEON)
-	rdtOutput, err := fileio.LoadRDTFile(roomFilename)
+	rdtOutput, err := gameDef.LoadRoom()
 	if err != nil {
 		log.Fatal("Error loading RDT file. ", err)
 	}
-	fmt.Println("Loaded", roomFilename)
-	gameDef.RoomScript = gameDef.NewRoomScript(rdtOutput)
-	gameDef.GameWorld.LoadNewRoom(rdtOutput)
+	fmt.Println("Loaded", gameDef.GetRoomFilename(gameDef.PlayerId))
 	mainGameRender.RenderRoom = render.NewRenderRoom(rdtOutput)
 
 	// Initialize room model objects
@@ -121,34 +128,11 @@ func loadRoomState(mainGameStateInput *MainGameStateInput) {
 	// Initialize sprite textures
 	renderDef.SceneSystem.SpriteGroupEntity = render.NewSpriteGroupEntity(mainGameRender.RenderRoom.SpriteData)
 
-	initScriptOnRoomLoad(scriptDef, gameDef, renderDef)
+	scriptDef.InitRoomScripts(gameDef.RoomScript, mainGameStateInput.ScriptHost)
 
 	mainGameRender.DebugEntities = render.BuildAllDebugEntities(gameDef.GameWorld)
 }
 
-func initScriptOnRoomLoad(scriptDef *script.ScriptDef, gameDef *game.GameDef, renderDef *render.RenderDef) {
-	// Reset all state
-	scriptDef.Reset()
-
-	gameRoom := gameDef.RoomScript
-
-	// Run initial script once when the room loads
-	threadNum := 0
-	functionNum := 0
-	initScriptData := gameRoom.InitScriptData
-	scriptDef.InitScript(initScriptData, threadNum, functionNum)
-	scriptDef.RunScript(initScriptData, 10.0, gameDef, renderDef)
-
-	// Initialize the room script to be run in the game loop
-	threadNum = 0
-	functionNum = 0
-	roomScriptData := gameRoom.RoomScriptData
-	scriptDef.InitScript(roomScriptData, threadNum, functionNum)
-	threadNum = 1
-	functionNum = 1
-	scriptDef.InitScript(roomScriptData, threadNum, functionNum)
-}
-
 func loadCameraState(mainGameStateInput *MainGameStateInput) {
 	gameDef := mainGameStateInput.GameDef
 	mainGameRender := mainGameStateInput.MainGameRender
@@ -172,7 +156,7 @@ func updateCameraView(mainGameRender *MainGameRender, gameDef *game.GameDef) {
 
 func updateRoomBackroundImage(mainGameRender *MainGameRender, gameDef *game.GameDef) {
 	// Update background image
-	roomOutput, err := fileio.ExtractRoomBackground(resource.ROOMCUT_FILE, mainGameRender.RoomcutBinOutput, gameDef.GetBackgroundImageNumber())
+	roomOutput, err := fileio.ExtractRoomBackgroundStream(mainGameRender.RoomcutFile, mainGameRender.RoomcutBinOutput, gameDef.GetBackgroundImageNumber())
 	if err != nil {
 		log.Printf("Warning: failed to load room background: %v", err)
 		return
@@ -240,20 +224,10 @@ func runGameLoop(mainGameStateInput *MainGameStateInput, gameStateManager *GameS
 	inputHandler.HandleAllInput(gameDef, timeElapsedSeconds, gameDef.GameWorld)
 	gameDef.HandleCameraSwitch(gameDef.Player.Position)
 	gameDef.HandleRoomSwitch(gameDef.Player.Position)
-	handleEventTrigger(scriptDef, gameDef)
-
-	scriptDef.RunScript(gameDef.RoomScript.RoomScriptData, timeElapsedSeconds, gameDef, renderDef)
-}
+	scriptDef.RunEventTrigger(gameDef)
 
-func handleEventTrigger(scriptDef *script.ScriptDef, gameDef *game.GameDef) {
-	// Handles events like cutscenes
-	aot := gameDef.GameWorld.AotManager.GetAotTriggerNearPlayer(gameDef.Player.Position)
-	if aot != nil {
-		if aot.Header.Id == world.AOT_EVENT {
-			threadNum := aot.Data[0]
-			eventNum := aot.Data[3]
-			lineData := []byte{fileio.OP_EVT_EXEC, threadNum, 0, eventNum}
-			scriptDef.ScriptEvtExec(lineData, gameDef.RoomScript.RoomScriptData)
-		}
+	if mainGameStateInput.ScriptDebugServer != nil {
+		mainGameStateInput.ScriptDebugServer.ProcessCommands()
 	}
+	scriptDef.RunScript(gameDef.RoomScript.RoomScriptData, timeElapsedSeconds, mainGameStateInput.ScriptHost)
 }
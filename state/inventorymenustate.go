@@ -16,9 +16,10 @@ type InventoryStateInput struct {
 	InventoryMenu       *ui.InventoryMenu
 	HealthDisplay       *ui.HealthDisplay
 	InventoryManager    *ui.InventoryManager
+	PlayerId            int
 }
 
-func NewInventoryStateInput(renderDef *render.RenderDef) *InventoryStateInput {
+func NewInventoryStateInput(renderDef *render.RenderDef, playerId int) *InventoryStateInput {
 	inventoryMenuImages := resource.LoadTIMImages(resource.INVENTORY_FILE)
 	inventoryItemImages := resource.LoadTIMImages(resource.ITEMALL_FILE)
 	
@@ -29,7 +30,8 @@ func NewInventoryStateInput(renderDef *render.RenderDef) *InventoryStateInput {
 		InventoryItemImages: inventoryItemImages,
 		InventoryMenu:       ui.NewInventoryMenu(),
 		HealthDisplay:       ui.NewHealthDisplay(),
-		InventoryManager:    ui.NewInventoryManager(),
+		InventoryManager:    ui.NewInventoryManager(playerId),
+		PlayerId:            playerId,
 	}
 }
 
@@ -73,6 +75,6 @@ func HandleInventory(inventoryStateInput *InventoryStateInput, gameStateManager
 	}
 
 	timeElapsedSeconds := windowHandler.GetTimeSinceLastFrame()
-	inventoryStateInput.UIRenderer.GenerateInventoryImage(inventoryMenuImages, inventoryItemImages, inventoryMenu, healthDisplay, inventoryManager, timeElapsedSeconds)
+	inventoryStateInput.UIRenderer.GenerateInventoryImage(inventoryMenuImages, inventoryItemImages, inventoryMenu, healthDisplay, inventoryManager, inventoryStateInput.PlayerId, timeElapsedSeconds)
 	renderDef.RenderSolidVideoBuffer()
 }
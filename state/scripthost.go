@@ -0,0 +1,26 @@
+package state
+
+import (
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+	"github.com/OpenBiohazard2/OpenBiohazard2/render"
+)
+
+// gameScriptHost runs the room scripts against the game and the renderer
+type gameScriptHost struct {
+	*game.GameDef
+	*render.ScriptScene
+}
+
+func newGameScriptHost(gameDef *game.GameDef, renderDef *render.RenderDef) *gameScriptHost {
+	return &gameScriptHost{
+		GameDef:     gameDef,
+		ScriptScene: render.NewScriptScene(renderDef, gameDef.PlayerId),
+	}
+}
+
+// Sprites are drawn and also kept in the AOT manager for the debug dump
+func (host *gameScriptHost) AddSprite(sprite fileio.ScriptInstrSceEsprOn) {
+	host.GameWorld.AotManager.AddScriptSprite(sprite)
+	host.ScriptScene.AddSprite(sprite)
+}
@@ -1,5 +1,9 @@
 package ui
 
+import (
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
+)
+
 // InventoryItem represents an item in the player's inventory
 type InventoryItem struct {
 	Id   int
@@ -14,18 +18,25 @@ type InventoryManager struct {
 	playerInventoryItems      []InventoryItem
 }
 
-// NewInventoryManager creates a new inventory manager
-func NewInventoryManager() *InventoryManager {
+// NewInventoryManager creates a new inventory manager with the player's starting items
+func NewInventoryManager(playerId int) *InventoryManager {
 	return &InventoryManager{
 		totalInventoryTime:        0,
 		updateInventoryCursorTime: 30, // milliseconds
-		playerInventoryItems:      initializeInventoryItems(),
+		playerInventoryItems:      initializeInventoryItems(playerId),
 	}
 }
 
 // initializeInventoryItems creates the initial inventory items
-func initializeInventoryItems() []InventoryItem {
+// Each player starts with their own hand gun and reserved item
+func initializeInventoryItems(playerId int) []InventoryItem {
 	items := make([]InventoryItem, 11)
+	if playerId == game.PLAYER_CLAIRE {
+		items[0] = InventoryItem{Id: 3, Num: 13, Size: 1}                  // hand gun (Browning)
+		items[1] = InventoryItem{Id: 1, Num: 1, Size: 0}                   // knife
+		items[RESERVED_ITEM_SLOT] = InventoryItem{Id: 48, Num: 1, Size: 0} // lockpick
+		return items
+	}
 	items[0] = InventoryItem{Id: 2, Num: 18, Size: 1}                  // hand gun
 	items[1] = InventoryItem{Id: 1, Num: 1, Size: 0}                   // knife
 	items[RESERVED_ITEM_SLOT] = InventoryItem{Id: 47, Num: 1, Size: 0} // lighter
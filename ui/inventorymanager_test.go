@@ -2,10 +2,12 @@ package ui
 
 import (
 	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
 )
 
 func TestNewInventoryManager(t *testing.T) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	if manager == nil {
 		t.Fatal("NewInventoryManager() returned nil")
@@ -31,8 +33,19 @@ func TestNewInventoryManager(t *testing.T) {
 	}
 }
 
+func TestNewInventoryManager_Claire(t *testing.T) {
+	items := NewInventoryManager(game.PLAYER_CLAIRE).GetPlayerInventoryItems()
+
+	if items[0].Id != 3 || items[0].Num != 13 {
+		t.Errorf("Expected first item to be Claire's hand gun (Id: 3, Num: 13), got (Id: %d, Num: %d)", items[0].Id, items[0].Num)
+	}
+	if items[RESERVED_ITEM_SLOT].Id != 48 {
+		t.Errorf("Expected reserved item to be the lockpick (Id: 48), got %d", items[RESERVED_ITEM_SLOT].Id)
+	}
+}
+
 func TestInventoryManager_UpdateInventoryTime(t *testing.T) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	// Test initial state
 	if manager.totalInventoryTime != 0 {
@@ -55,7 +68,7 @@ func TestInventoryManager_UpdateInventoryTime(t *testing.T) {
 }
 
 func TestInventoryManager_ShouldUpdateCursor(t *testing.T) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	// Test initial state - should not update cursor
 	if manager.ShouldUpdateCursor() {
@@ -70,7 +83,7 @@ func TestInventoryManager_ShouldUpdateCursor(t *testing.T) {
 }
 
 func TestInventoryManager_ResetInventoryTime(t *testing.T) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	// Add some time
 	manager.UpdateInventoryTime(0.1)
@@ -86,7 +99,7 @@ func TestInventoryManager_ResetInventoryTime(t *testing.T) {
 }
 
 func TestInventoryManager_GetPlayerInventoryItems(t *testing.T) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	items := manager.GetPlayerInventoryItems()
 
@@ -105,8 +118,8 @@ func TestInventoryManager_GetPlayerInventoryItems(t *testing.T) {
 
 func TestInventoryManager_Isolation(t *testing.T) {
 	// Test that multiple instances don't interfere
-	manager1 := NewInventoryManager()
-	manager2 := NewInventoryManager()
+	manager1 := NewInventoryManager(game.PLAYER_LEON)
+	manager2 := NewInventoryManager(game.PLAYER_LEON)
 
 	// Update them independently
 	manager1.UpdateInventoryTime(0.1)
@@ -133,7 +146,7 @@ func TestInventoryManager_Isolation(t *testing.T) {
 
 // Benchmark tests
 func BenchmarkUpdateInventoryTime(b *testing.B) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -142,7 +155,7 @@ func BenchmarkUpdateInventoryTime(b *testing.B) {
 }
 
 func BenchmarkGetPlayerInventoryItems(b *testing.B) {
-	manager := NewInventoryManager()
+	manager := NewInventoryManager(game.PLAYER_LEON)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
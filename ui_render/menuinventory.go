@@ -4,6 +4,7 @@ import (
 	"image"
 	"image/color"
 
+	"github.com/OpenBiohazard2/OpenBiohazard2/game"
 	"github.com/OpenBiohazard2/OpenBiohazard2/geometry"
 	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
 	"github.com/OpenBiohazard2/OpenBiohazard2/ui"
@@ -16,6 +17,17 @@ const (
 	HEALTH_POS_Y   = 29
 )
 
+// Where the name and portrait of each player are in the second inventory menu image
+type playerFaceImage struct {
+	name     image.Rectangle
+	portrait image.Rectangle
+}
+
+var playerFaceImages = map[int]playerFaceImage{
+	game.PLAYER_LEON:   {name: image.Rect(1, 73, 1+37, 73+8), portrait: image.Rect(0, 85, 38, 85+42)},
+	game.PLAYER_CLAIRE: {name: image.Rect(39, 73, 39+37, 73+8), portrait: image.Rect(38, 85, 38+38, 85+42)},
+}
+
 // GenerateInventoryImage renders the inventory menu
 func (r *UIRenderer) GenerateInventoryImage(
 	inventoryMenuImages []*resource.Image16Bit,
@@ -23,13 +35,14 @@ func (r *UIRenderer) GenerateInventoryImage(
 	inventoryMenu *ui.InventoryMenu,
 	healthDisplay *ui.HealthDisplay,
 	inventoryManager *ui.InventoryManager,
+	playerId int,
 	timeElapsedSeconds float64,
 ) {
 	r.ClearScreen()
 	screenImage := r.GetScreenImage()
 	inventoryManager.UpdateInventoryTime(timeElapsedSeconds)
 	healthDisplay.UpdateHealthDisplay(timeElapsedSeconds)
-	buildBackground(screenImage, inventoryMenuImages, inventoryMenu, healthDisplay)
+	buildBackground(screenImage, inventoryMenuImages, inventoryMenu, healthDisplay, playerId)
 	buildItems(screenImage, inventoryMenuImages, inventoryItemImages, inventoryMenu, inventoryManager)
 	r.UpdateVideoBuffer(screenImage)
 }
@@ -96,7 +109,7 @@ func displayInventoryMainCursor(screenImage *resource.Image16Bit, inventoryMenuI
 		inventoryMenuImages[3], image.Rect(0, 30, 44, 30+34), brightnessFactor)
 }
 
-func buildBackground(screenImage *resource.Image16Bit, inventoryMenuImages []*resource.Image16Bit, inventoryMenu *ui.InventoryMenu, healthDisplay *ui.HealthDisplay) {
+func buildBackground(screenImage *resource.Image16Bit, inventoryMenuImages []*resource.Image16Bit, inventoryMenu *ui.InventoryMenu, healthDisplay *ui.HealthDisplay, playerId int) {
 	// The inventory image is split up into many small components
 	// Combine them manually back into a single image
 	// source image is 256x256
@@ -104,7 +117,7 @@ func buildBackground(screenImage *resource.Image16Bit, inventoryMenuImages []*re
 	backgroundColor := color.RGBA{5, 5, 31, 255}
 	screenImage.FillPixels(image.Point{0, 0}, geometry.BACKGROUND_IMAGE_RECT, backgroundColor)
 
-	buildPlayerFace(screenImage, inventoryMenuImages)
+	buildPlayerFace(screenImage, inventoryMenuImages, playerId)
 	buildHealthECG(screenImage, healthDisplay, inventoryMenuImages, backgroundColor)
 
 	// Equipped item
@@ -131,14 +144,19 @@ func buildBackground(screenImage *resource.Image16Bit, inventoryMenuImages []*re
 	buildDescription(screenImage, inventoryMenuImages)
 }
 
-func buildPlayerFace(screenImage *resource.Image16Bit, inventoryMenuImages []*resource.Image16Bit) {
+func buildPlayerFace(screenImage *resource.Image16Bit, inventoryMenuImages []*resource.Image16Bit, playerId int) {
+	face, exists := playerFaceImages[playerId]
+	if !exists {
+		face = playerFaceImages[game.PLAYER_LEON]
+	}
+
 	// Player
 	screenImage.WriteSubImage(image.Point{7, 16}, inventoryMenuImages[0], image.Rect(106, 152, 106+4, 152+60))  // left
 	screenImage.WriteSubImage(image.Point{11, 16}, inventoryMenuImages[0], image.Rect(0, 140, 39, 140+4))       // top
 	screenImage.WriteSubImage(image.Point{49, 16}, inventoryMenuImages[0], image.Rect(109, 152, 109+4, 152+60)) // right
 	screenImage.WriteSubImage(image.Point{11, 72}, inventoryMenuImages[0], image.Rect(0, 140, 39, 140+4))       // bottom
-	screenImage.WriteSubImage(image.Point{11, 21}, inventoryMenuImages[1], image.Rect(1, 73, 1+37, 73+8))       // player name
-	screenImage.WriteSubImage(image.Point{11, 31}, inventoryMenuImages[1], image.Rect(0, 85, 38, 85+42))        // player image
+	screenImage.WriteSubImage(image.Point{11, 21}, inventoryMenuImages[1], face.name)                           // player name
+	screenImage.WriteSubImage(image.Point{11, 31}, inventoryMenuImages[1], face.portrait)                       // player image
 	screenImage.WriteSubImage(image.Point{11, 30}, inventoryMenuImages[0], image.Rect(56, 164, 56+38, 164+1))   // line between name and image
 
 	// Pipes to the left of player image
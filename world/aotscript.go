@@ -0,0 +1,78 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+// Objects that a room script places in the room
+type ScriptObjects struct {
+	AotManager *AotManager
+	ItemModels []fileio.ScriptInstrObjModelSet
+}
+
+// CollectScriptObjects goes through every instruction of a script without running it.
+// The conditions around each instruction are ignored, so the result has every door,
+// item and trigger that the room can have. Used by tools that don't run the game.
+func CollectScriptObjects(scriptData *fileio.SCDOutput) *ScriptObjects {
+	objects := &ScriptObjects{AotManager: NewAotManager()}
+	if scriptData == nil {
+		return objects
+	}
+
+	programCounters := make([]int, 0, len(scriptData.ScriptData.Instructions))
+	for programCounter := range scriptData.ScriptData.Instructions {
+		programCounters = append(programCounters, programCounter)
+	}
+	sort.Ints(programCounters)
+
+	aotManager := objects.AotManager
+	for _, programCounter := range programCounters {
+		lineData := scriptData.ScriptData.Instructions[programCounter]
+		if len(lineData) == 0 {
+			continue
+		}
+		byteArr := bytes.NewReader(lineData)
+		switch lineData[0] {
+		case fileio.OP_AOT_SET:
+			instruction := fileio.ScriptInstrAotSet{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				aotManager.AddAotTrigger(instruction)
+			}
+		case fileio.OP_AOT_SET_4P:
+			instruction := fileio.ScriptInstrAotSet4p{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				aotManager.AddAotTrigger4p(instruction)
+			}
+		case fileio.OP_DOOR_AOT_SET:
+			instruction := fileio.ScriptInstrDoorAotSet{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				aotManager.AddDoorAot(instruction)
+			}
+		case fileio.OP_DOOR_AOT_SET_4P:
+			instruction := fileio.ScriptInstrDoorAotSet4p{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				aotManager.AddDoorAot4p(instruction)
+			}
+		case fileio.OP_ITEM_AOT_SET:
+			instruction := fileio.ScriptInstrItemAotSet{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				aotManager.AddItemAot(instruction)
+			}
+		case fileio.OP_ITEM_AOT_SET_4P:
+			instruction := fileio.ScriptInstrItemAotSet4p{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				aotManager.AddItemAot4p(instruction)
+			}
+		case fileio.OP_OBJ_MODEL_SET:
+			instruction := fileio.ScriptInstrObjModelSet{}
+			if binary.Read(byteArr, binary.LittleEndian, &instruction) == nil {
+				objects.ItemModels = append(objects.ItemModels, instruction)
+			}
+		}
+	}
+	return objects
+}
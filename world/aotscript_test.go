@@ -0,0 +1,54 @@
+package world
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+func encodeInstruction(t *testing.T, instruction any) []byte {
+	t.Helper()
+	buffer := new(bytes.Buffer)
+	if err := binary.Write(buffer, binary.LittleEndian, instruction); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestCollectScriptObjects(t *testing.T) {
+	scriptData := &fileio.SCDOutput{ScriptData: fileio.ScriptFunction{Instructions: map[int][]byte{
+		// Both branches of a condition are collected
+		0:  {fileio.OP_IF_START, 0, 10, 0},
+		4:  encodeInstruction(t, fileio.ScriptInstrDoorAotSet{Opcode: fileio.OP_DOOR_AOT_SET, Aot: 1, Id: AOT_DOOR, X: 100, Z: 200, Width: 300, Depth: 400, Room: 1}),
+		36: encodeInstruction(t, fileio.ScriptInstrItemAotSet{Opcode: fileio.OP_ITEM_AOT_SET, Aot: 2, Id: AOT_ITEM, ItemId: 3}),
+		58: encodeInstruction(t, fileio.ScriptInstrAotSet{Opcode: fileio.OP_AOT_SET, Aot: 3, Id: AOT_EVENT}),
+		78: encodeInstruction(t, fileio.ScriptInstrObjModelSet{Opcode: fileio.OP_OBJ_MODEL_SET, ObjectIndex: 1}),
+	}}}
+
+	objects := CollectScriptObjects(scriptData)
+	aotManager := objects.AotManager
+	if len(aotManager.Doors) != 1 || aotManager.Doors[0].Room != 1 {
+		t.Fatalf("expected 1 door to room 1, got %+v", aotManager.Doors)
+	}
+	if corner := aotManager.Doors[0].Bounds.Vertices[2]; corner.X() != 400 || corner.Z() != 600 {
+		t.Errorf("expected the far door corner at (400, 600), got (%v, %v)", corner.X(), corner.Z())
+	}
+	if len(aotManager.Items) != 1 || aotManager.Items[0].ItemId != 3 {
+		t.Errorf("expected item 3, got %+v", aotManager.Items)
+	}
+	if len(aotManager.AotTriggers) != 1 {
+		t.Errorf("expected 1 trigger, got %d", len(aotManager.AotTriggers))
+	}
+	if len(objects.ItemModels) != 1 || objects.ItemModels[0].ObjectIndex != 1 {
+		t.Errorf("expected item model 1, got %+v", objects.ItemModels)
+	}
+}
+
+func TestCollectScriptObjects_NoScript(t *testing.T) {
+	objects := CollectScriptObjects(nil)
+	if len(objects.AotManager.Doors) != 0 || len(objects.ItemModels) != 0 {
+		t.Error("expected no objects without a script")
+	}
+}
@@ -7,6 +7,14 @@ import (
 	"github.com/go-gl/mathgl/mgl32"
 )
 
+// Camera switches and AOTs with this floor are on every floor
+const ALL_FLOORS = 255
+
+// IsOnFloor checks if a camera switch or AOT is on a floor
+func IsOnFloor(floor uint8, floorNum int) bool {
+	return floor == ALL_FLOORS || int(floor) == floorNum
+}
+
 type CameraSwitchHandler struct {
 	CameraSwitches          []fileio.RVDHeader
 	CameraSwitchTransitions map[int][]int
@@ -58,7 +66,7 @@ func (cameraSwitchHandler *CameraSwitchHandler) GetCameraSwitchNewRegion(positio
 		corner4 := mgl32.Vec3{float32(region.X4), 0, float32(region.Z4)}
 
 		// Check region floor for rooms with multiple floor heights
-		if region.Floor != 255 && int(region.Floor) != playerFloorNum {
+		if !IsOnFloor(region.Floor, playerFloorNum) {
 			continue
 		}
 
@@ -342,3 +342,24 @@ func BenchmarkGetCameraSwitchNewRegion(b *testing.B) {
 		handler.GetCameraSwitchNewRegion(position, 0)
 	}
 }
+
+func TestIsOnFloor(t *testing.T) {
+	tests := []struct {
+		floor    uint8
+		floorNum int
+		expected bool
+	}{
+		{0, 0, true},
+		{1, 0, false},
+		{1, 1, true},
+		{33, 1, false},
+		{ALL_FLOORS, 0, true},
+		{ALL_FLOORS, 5, true},
+	}
+
+	for _, test := range tests {
+		if result := IsOnFloor(test.floor, test.floorNum); result != test.expected {
+			t.Errorf("IsOnFloor(%d, %d): expected %v, got %v", test.floor, test.floorNum, test.expected, result)
+		}
+	}
+}
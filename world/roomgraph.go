@@ -0,0 +1,266 @@
+package world
+
+// Graph of the rooms in the game, connected by the doors in their scripts
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// Stage starts from 1, room is a hex from 0
+type RoomKey struct {
+	Stage int `json:"stage"`
+	Room  int `json:"room"`
+}
+
+// Same as the room part of the RDT file name, e.g. 10A for ROOM10A0.RDT
+func (key RoomKey) String() string {
+	return fmt.Sprintf("%d%02X", key.Stage, key.Room)
+}
+
+// ParseRoomKey reads a room in the format of RoomKey.String
+func ParseRoomKey(value string) (RoomKey, error) {
+	var key RoomKey
+	if len(value) != 3 {
+		return key, fmt.Errorf("invalid room %q, expected stage and room like 10A", value)
+	}
+	if _, err := fmt.Sscanf(value, "%1d%2x", &key.Stage, &key.Room); err != nil {
+		return key, fmt.Errorf("invalid room %q, expected stage and room like 10A", value)
+	}
+	return key, nil
+}
+
+// ParseRoomFilename reads the room and player from an RDT file name, e.g. ROOM10A0.RDT
+func ParseRoomFilename(filename string) (RoomKey, int, bool) {
+	var key RoomKey
+	var playerNum int
+	name := strings.ToUpper(path.Base(filename))
+	if _, err := fmt.Sscanf(name, "ROOM%1d%2x%1d.RDT", &key.Stage, &key.Room, &playerNum); err != nil {
+		return RoomKey{}, 0, false
+	}
+	return key, playerNum, true
+}
+
+// A door from one room to another
+type RoomDoor struct {
+	From      RoomKey `json:"from"`
+	To        RoomKey `json:"to"`
+	Aot       uint8   `json:"aot"`
+	Camera    uint8   `json:"camera"`
+	NextX     int16   `json:"nextX"`
+	NextY     int16   `json:"nextY"`
+	NextZ     int16   `json:"nextZ"`
+	NextDir   int16   `json:"nextDir"`
+	NextFloor uint8   `json:"nextFloor"`
+	KeyId     uint8   `json:"keyId"` // 0 if the door is not locked
+	KeyType   uint8   `json:"keyType"`
+}
+
+// SpawnPosition is where the player appears in the next room
+func (door RoomDoor) SpawnPosition() mgl32.Vec3 {
+	return mgl32.Vec3{float32(door.NextX), float32(door.NextY), float32(door.NextZ)}
+}
+
+type RoomGraph struct {
+	Rooms       []RoomKey       `json:"rooms"`
+	Doors       []RoomDoor      `json:"doors"`
+	FailedRooms []RoomLoadError `json:"failedRooms,omitempty"` // their doors may be missing
+	exits       map[RoomKey][]int
+}
+
+func NewRoomGraph() *RoomGraph {
+	return &RoomGraph{
+		Rooms:       make([]RoomKey, 0),
+		Doors:       make([]RoomDoor, 0),
+		FailedRooms: make([]RoomLoadError, 0),
+		exits:       make(map[RoomKey][]int),
+	}
+}
+
+// LoadRoomGraph scans the scripts of every room of a player in fsys.
+// The scripts are not run, so every door is found regardless of the game state.
+// Rooms that fail to load are listed in FailedRooms.
+func LoadRoomGraph(fsys fs.FS, playerNum int) (*RoomGraph, error) {
+	graph := NewRoomGraph()
+	failedRooms, err := ScanRooms(fsys, playerNum, func(roomKey RoomKey, rdtOutput *fileio.RDTOutput) {
+		graph.AddRoom(roomKey, rdtOutput.InitScriptData, rdtOutput.RoomScriptData)
+	})
+	if err != nil {
+		return nil, err
+	}
+	graph.FailedRooms = failedRooms
+	return graph, nil
+}
+
+// AddRoom adds a room and the doors from its scripts
+func (graph *RoomGraph) AddRoom(roomKey RoomKey, scripts ...*fileio.SCDOutput) {
+	graph.addRoomKey(roomKey)
+	for _, scriptData := range scripts {
+		for _, door := range CollectScriptObjects(scriptData).AotManager.Doors {
+			graph.AddDoor(RoomDoor{
+				From:      roomKey,
+				To:        RoomKey{Stage: int(door.Stage) + 1, Room: int(door.Room)},
+				Aot:       door.Header.Aot,
+				Camera:    door.Camera,
+				NextX:     door.NextX,
+				NextY:     door.NextY,
+				NextZ:     door.NextZ,
+				NextDir:   door.NextDir,
+				NextFloor: door.NextFloor,
+				KeyId:     door.KeyId,
+				KeyType:   door.KeyType,
+			})
+		}
+	}
+}
+
+// AddDoor adds a door and both of the rooms it connects
+func (graph *RoomGraph) AddDoor(door RoomDoor) {
+	graph.addRoomKey(door.From)
+	graph.addRoomKey(door.To)
+	graph.exits[door.From] = append(graph.exits[door.From], len(graph.Doors))
+	graph.Doors = append(graph.Doors, door)
+}
+
+func (graph *RoomGraph) addRoomKey(roomKey RoomKey) {
+	if _, exists := graph.exits[roomKey]; exists {
+		return
+	}
+	graph.exits[roomKey] = nil
+	index := sort.Search(len(graph.Rooms), func(i int) bool { return !roomKeyLess(graph.Rooms[i], roomKey) })
+	graph.Rooms = append(graph.Rooms, RoomKey{})
+	copy(graph.Rooms[index+1:], graph.Rooms[index:])
+	graph.Rooms[index] = roomKey
+}
+
+func roomKeyLess(a RoomKey, b RoomKey) bool {
+	if a.Stage != b.Stage {
+		return a.Stage < b.Stage
+	}
+	return a.Room < b.Room
+}
+
+// DoorsFrom returns the doors that lead out of a room
+func (graph *RoomGraph) DoorsFrom(roomKey RoomKey) []RoomDoor {
+	doors := make([]RoomDoor, 0, len(graph.exits[roomKey]))
+	for _, doorIndex := range graph.exits[roomKey] {
+		doors = append(doors, graph.Doors[doorIndex])
+	}
+	return doors
+}
+
+// DoorsTo returns the doors that lead into a room
+func (graph *RoomGraph) DoorsTo(roomKey RoomKey) []RoomDoor {
+	doors := make([]RoomDoor, 0)
+	for _, door := range graph.Doors {
+		if door.To == roomKey {
+			doors = append(doors, door)
+		}
+	}
+	return doors
+}
+
+// SpawnPosition is where the player appears after the first door leading into a room.
+// Rooms with no doors leading in have no spawn position.
+func (graph *RoomGraph) SpawnPosition(roomKey RoomKey) (mgl32.Vec3, bool) {
+	doors := graph.DoorsTo(roomKey)
+	if len(doors) == 0 {
+		return mgl32.Vec3{}, false
+	}
+	return doors[0].SpawnPosition(), true
+}
+
+// ShortestPath returns the fewest doors to go through to get from one room to another.
+// Locked doors are included, so the path may need keys.
+func (graph *RoomGraph) ShortestPath(from RoomKey, to RoomKey) ([]RoomDoor, bool) {
+	if _, exists := graph.exits[from]; !exists {
+		return nil, false
+	}
+	if from == to {
+		return []RoomDoor{}, true
+	}
+
+	// Breadth first search, remembering the door used to reach each room
+	enteredBy := map[RoomKey]int{from: -1}
+	queue := []RoomKey{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, doorIndex := range graph.exits[current] {
+			next := graph.Doors[doorIndex].To
+			if _, visited := enteredBy[next]; visited {
+				continue
+			}
+			enteredBy[next] = doorIndex
+			if next == to {
+				return graph.pathTo(to, enteredBy), true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil, false
+}
+
+func (graph *RoomGraph) pathTo(to RoomKey, enteredBy map[RoomKey]int) []RoomDoor {
+	path := make([]RoomDoor, 0)
+	for doorIndex := enteredBy[to]; doorIndex >= 0; {
+		door := graph.Doors[doorIndex]
+		path = append(path, door)
+		doorIndex = enteredBy[door.From]
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// WriteJSON writes the rooms and doors as JSON
+func (graph *RoomGraph) WriteJSON(w io.Writer) error {
+	output, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format, with one cluster per stage
+func (graph *RoomGraph) WriteDOT(w io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("digraph rooms {\n")
+	builder.WriteString("  node [shape=box];\n")
+	currentStage := -1
+	for _, roomKey := range graph.Rooms {
+		if roomKey.Stage != currentStage {
+			if currentStage != -1 {
+				builder.WriteString("  }\n")
+			}
+			currentStage = roomKey.Stage
+			fmt.Fprintf(&builder, "  subgraph cluster_stage%d {\n    label=\"Stage %d\";\n", currentStage, currentStage)
+		}
+		fmt.Fprintf(&builder, "    \"%s\";\n", roomKey)
+	}
+	if currentStage != -1 {
+		builder.WriteString("  }\n")
+	}
+	for _, door := range graph.Doors {
+		label := fmt.Sprintf("aot %d, cam %d", door.Aot, door.Camera)
+		style := ""
+		if door.KeyId != 0 {
+			label += fmt.Sprintf(", key %d (type %d)", door.KeyId, door.KeyType)
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&builder, "  \"%s\" -> \"%s\" [label=\"%s\"%s];\n", door.From, door.To, label, style)
+	}
+	builder.WriteString("}\n")
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
@@ -0,0 +1,154 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+func doorScript(t *testing.T, doors ...fileio.ScriptInstrDoorAotSet) *fileio.SCDOutput {
+	instructions := make(map[int][]byte)
+	for i, door := range doors {
+		door.Opcode = fileio.OP_DOOR_AOT_SET
+		door.Id = AOT_DOOR
+		instructions[i*32] = encodeInstruction(t, door)
+	}
+	return &fileio.SCDOutput{ScriptData: fileio.ScriptFunction{Instructions: instructions}}
+}
+
+func testRoomGraph(t *testing.T) *RoomGraph {
+	graph := NewRoomGraph()
+	graph.AddRoom(RoomKey{1, 0x00}, doorScript(t,
+		fileio.ScriptInstrDoorAotSet{Aot: 1, Stage: 0, Room: 0x01, NextX: 100, NextZ: 200, Camera: 3},
+		fileio.ScriptInstrDoorAotSet{Aot: 2, Stage: 0, Room: 0x02, KeyId: 5, KeyType: 1},
+	))
+	graph.AddRoom(RoomKey{1, 0x01}, doorScript(t,
+		fileio.ScriptInstrDoorAotSet{Aot: 0, Stage: 0, Room: 0x00},
+		fileio.ScriptInstrDoorAotSet{Aot: 1, Stage: 1, Room: 0x0a},
+	))
+	graph.AddRoom(RoomKey{1, 0x02}, doorScript(t))
+	return graph
+}
+
+func TestRoomGraph_AddRoom(t *testing.T) {
+	graph := testRoomGraph(t)
+
+	expectedRooms := []RoomKey{{1, 0x00}, {1, 0x01}, {1, 0x02}, {2, 0x0a}}
+	if len(graph.Rooms) != len(expectedRooms) {
+		t.Fatalf("expected rooms %v, got %v", expectedRooms, graph.Rooms)
+	}
+	for i, roomKey := range expectedRooms {
+		if graph.Rooms[i] != roomKey {
+			t.Errorf("expected room %d to be %v, got %v", i, roomKey, graph.Rooms[i])
+		}
+	}
+
+	doors := graph.DoorsFrom(RoomKey{1, 0x00})
+	if len(doors) != 2 {
+		t.Fatalf("expected 2 doors from room 100, got %d", len(doors))
+	}
+	if doors[0].To != (RoomKey{1, 0x01}) || doors[0].Camera != 3 {
+		t.Errorf("expected door to room 101 with camera 3, got %+v", doors[0])
+	}
+	if doors[1].KeyId != 5 || doors[1].KeyType != 1 {
+		t.Errorf("expected door locked with key 5, got %+v", doors[1])
+	}
+
+	position, ok := graph.SpawnPosition(RoomKey{1, 0x01})
+	if !ok || position.X() != 100 || position.Z() != 200 {
+		t.Errorf("expected spawn position (100, 200) in room 101, got %v %v", position, ok)
+	}
+	if _, ok := graph.SpawnPosition(RoomKey{3, 0x00}); ok {
+		t.Error("expected no spawn position in room 300")
+	}
+}
+
+func TestRoomGraph_ShortestPath(t *testing.T) {
+	graph := testRoomGraph(t)
+
+	tests := []struct {
+		name     string
+		from, to RoomKey
+		expected []RoomKey
+		found    bool
+	}{
+		{"same room", RoomKey{1, 0x00}, RoomKey{1, 0x00}, []RoomKey{}, true},
+		{"one door", RoomKey{1, 0x00}, RoomKey{1, 0x02}, []RoomKey{{1, 0x02}}, true},
+		{"two doors", RoomKey{1, 0x00}, RoomKey{2, 0x0a}, []RoomKey{{1, 0x01}, {2, 0x0a}}, true},
+		{"back through a room", RoomKey{1, 0x01}, RoomKey{1, 0x02}, []RoomKey{{1, 0x00}, {1, 0x02}}, true},
+		{"no way out", RoomKey{1, 0x02}, RoomKey{1, 0x00}, nil, false},
+		{"unknown room", RoomKey{7, 0x00}, RoomKey{1, 0x00}, nil, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, found := graph.ShortestPath(test.from, test.to)
+			if found != test.found {
+				t.Fatalf("expected found=%v, got %v", test.found, found)
+			}
+			if len(path) != len(test.expected) {
+				t.Fatalf("expected %d doors, got %+v", len(test.expected), path)
+			}
+			for i, door := range path {
+				if door.To != test.expected[i] {
+					t.Errorf("expected door %d to lead to %v, got %v", i, test.expected[i], door.To)
+				}
+			}
+		})
+	}
+}
+
+func TestRoomGraph_Export(t *testing.T) {
+	graph := testRoomGraph(t)
+
+	var dot bytes.Buffer
+	if err := graph.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"subgraph cluster_stage2",
+		"\"100\" -> \"101\" [label=\"aot 1, cam 3\"];",
+		"\"100\" -> \"102\" [label=\"aot 2, cam 0, key 5 (type 1)\", style=dashed];",
+	} {
+		if !strings.Contains(dot.String(), expected) {
+			t.Errorf("expected DOT output to contain %q, got:\n%s", expected, dot.String())
+		}
+	}
+
+	var jsonOutput bytes.Buffer
+	if err := graph.WriteJSON(&jsonOutput); err != nil {
+		t.Fatal(err)
+	}
+	var decoded RoomGraph
+	if err := json.Unmarshal(jsonOutput.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Rooms) != 4 || len(decoded.Doors) != 4 || decoded.Doors[1].KeyId != 5 {
+		t.Errorf("unexpected JSON output:\n%s", jsonOutput.String())
+	}
+}
+
+func TestParseRoomKey(t *testing.T) {
+	roomKey, err := ParseRoomKey("10A")
+	if err != nil || roomKey != (RoomKey{1, 0x0a}) {
+		t.Errorf("expected room 1 0x0a, got %v %v", roomKey, err)
+	}
+	if roomKey.String() != "10A" {
+		t.Errorf("expected 10A, got %s", roomKey.String())
+	}
+	for _, value := range []string{"", "1", "10A0", "X0A"} {
+		if _, err := ParseRoomKey(value); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+
+	roomKey, playerNum, ok := ParseRoomFilename("data/Pl1/RDP/room20f1.rdt")
+	if !ok || roomKey != (RoomKey{2, 0x0f}) || playerNum != 1 {
+		t.Errorf("expected room 20F of player 1, got %v %d %v", roomKey, playerNum, ok)
+	}
+	if _, _, ok := ParseRoomFilename("EM010.EMD"); ok {
+		t.Error("expected EM010.EMD not to be a room file")
+	}
+}
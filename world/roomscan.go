@@ -0,0 +1,47 @@
+package world
+
+// Loads every room of a player, for the tools that look at the whole game
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+	"github.com/OpenBiohazard2/OpenBiohazard2/resource"
+)
+
+// RoomLoadError is a room that could not be fully loaded
+type RoomLoadError struct {
+	Room  RoomKey `json:"room"`
+	Error string  `json:"error"`
+}
+
+// ScanRooms calls visit with every room of a player in fsys, in file name order.
+// Rooms that fail to load are returned instead of stopping the scan.
+// A room that could only be read in part, such as one with an unknown opcode, is visited and also returned.
+// The error is only set if the room folder can't be read.
+func ScanRooms(fsys fs.FS, playerNum int, visit func(roomKey RoomKey, rdtOutput *fileio.RDTOutput)) ([]RoomLoadError, error) {
+	rdtFolder := strings.TrimSuffix(resource.PlayerRDTFolder(playerNum), "/")
+	entries, err := fs.ReadDir(fsys, rdtFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read room folder %s: %w", rdtFolder, err)
+	}
+
+	failedRooms := make([]RoomLoadError, 0)
+	for _, entry := range entries {
+		roomKey, filePlayerNum, ok := ParseRoomFilename(entry.Name())
+		if !ok || filePlayerNum != playerNum || entry.IsDir() {
+			continue
+		}
+		rdtOutput, err := resource.LoadAssetFrom(fsys, path.Join(rdtFolder, entry.Name()), fileio.LoadRDT)
+		if err != nil {
+			failedRooms = append(failedRooms, RoomLoadError{Room: roomKey, Error: err.Error()})
+		}
+		if rdtOutput != nil {
+			visit(roomKey, rdtOutput)
+		}
+	}
+	return failedRooms, nil
+}
@@ -0,0 +1,41 @@
+package world
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/OpenBiohazard2/OpenBiohazard2/fileio"
+)
+
+func TestScanRooms(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/Pl0/RDP/ROOM1000.RDT": {Data: []byte{0xFF, 0xFF}},
+		"data/Pl0/RDP/ROOM10A0.RDT": {Data: []byte{}},
+		"data/Pl0/RDP/ROOM1001.RDT": {Data: []byte{0xFF}},
+		"data/Pl0/RDP/README.TXT":   {Data: []byte("not a room")},
+	}
+
+	visited := 0
+	failedRooms, err := ScanRooms(fsys, 0, func(roomKey RoomKey, rdtOutput *fileio.RDTOutput) {
+		visited++
+	})
+	if err != nil {
+		t.Fatalf("ScanRooms() error: %v", err)
+	}
+	if visited != 0 {
+		t.Errorf("Expected no room to be visited, got %d", visited)
+	}
+	expected := []RoomKey{{Stage: 1, Room: 0x00}, {Stage: 1, Room: 0x0A}}
+	if len(failedRooms) != len(expected) {
+		t.Fatalf("Expected %v to fail, got %v", expected, failedRooms)
+	}
+	for i, failed := range failedRooms {
+		if failed.Room != expected[i] || failed.Error == "" {
+			t.Errorf("Expected room %s to fail with an error, got %+v", expected[i], failed)
+		}
+	}
+
+	if _, err := ScanRooms(fsys, 1, func(RoomKey, *fileio.RDTOutput) {}); err == nil {
+		t.Error("Expected an error for a missing room folder")
+	}
+}